@@ -19,12 +19,15 @@ package main
 import (
 	"flag"
 	"fmt"
+	"net"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/spf13/pflag"
 	apiv1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/auth"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
 	"k8s.io/ingress-nginx/internal/ingress/controller"
 	ngx_config "k8s.io/ingress-nginx/internal/ingress/controller/config"
@@ -95,6 +98,11 @@ controller for servicing HTTP traffic.`)
 The key in the map indicates the external port to be used. The value is a
 reference to a Service in the form "namespace/name:port", where "port" can
 either be a port name or number.`)
+		hostPortsConfigMapName = flags.String("host-ports-configmap", "",
+			`Name of the ConfigMap containing custom listen ports for hostnames.
+The key in the map is the hostname. The value is the HTTP port and optionally
+the HTTPS port in the form "http-port[:https-port]", where either port can be
+empty to keep the default.`)
 
 		resyncPeriod = flags.Duration("sync-period", 0,
 			`Period at which the controller forces the repopulation of its local object stores. Disabled by default.`)
@@ -197,8 +205,81 @@ Takes the form "<host>:port". If not provided, no admission controller is starte
 		shutdownGracePeriod = flags.Int("shutdown-grace-period", 0, "Seconds to wait after receiving the shutdown signal, before stopping the nginx process.")
 
 		deepInspector = flags.Bool("deep-inspect", true, "Enables ingress object security deep inspector")
+
+		locationTiebreak = flags.String("location-tiebreak", controller.LocationTiebreakReverse,
+			`Order of locations with paths of equal length. Use "reverse" for reverse alphabetical order or "forward" for alphabetical order.
+NGINX always prefers the longest matching prefix, so this only changes the evaluation order of regular expression locations.`)
+
+		rejectUnwatchedNamespace = flags.Bool("reject-unwatched-namespace", false,
+			`Reject objects outside the namespace defined by --watch-namespace in the validating webhook instead of ignoring them.`)
+
+		maxLocationsPerServer = flags.Int("max-locations-per-server", 0,
+			`Maximum number of locations of a server. The validating webhook rejects objects that would exceed it. Use 0 for no limit.`)
+
+		defaultBackendConnectTimeout = flags.Int("default-backend-connect-timeout", 0,
+			`Timeout in seconds to establish a connection with the default backend. Use 0 to keep the proxy-connect-timeout of the configmap.`)
+
+		defaultBackendReadTimeout = flags.Int("default-backend-read-timeout", 0,
+			`Timeout in seconds to read a response from the default backend. Use 0 to keep the proxy-read-timeout of the configmap.`)
+
+		namespaceDefaultBackendSvc = flags.String("namespace-default-backend-service", "",
+			`Name of a Service used as the default backend of the objects without one in its namespace, instead of the
+default backend of the controller. Namespaces without this Service keep the default backend of the controller.`)
+
+		enableMisdirectedRequestCheck = flags.Bool("enable-misdirected-request-check", false,
+			`Return 421 Misdirected Request when the TLS SNI of the connection does not match the Host of the request, so HTTP/2 clients
+reusing a connection for another host covered by the same certificate open a new connection to the right server.`)
+
+		upstreamDNSResolver = flags.StringSlice("upstream-dns-resolver", []string{},
+			`Comma separated list of DNS servers, as IP or IP:port, used by NGINX to resolve the ExternalName Services and
+default-backend-url hosts at request time instead of once per configuration reload.`)
+
+		upstreamDNSValid = flags.Duration("upstream-dns-valid", 30*time.Second,
+			`Time NGINX caches the answers of the --upstream-dns-resolver servers.`)
+
+		validateCanaryPrimary = flags.Bool("validate-canary-primary", false,
+			`Reject in the validating webhook the canary objects defining a host and path no other non-canary object defines.
+Keep it disabled when the canary and its primary can be applied together, in any order.`)
+
+		addMCIDebugHeader = flags.Bool("add-mci-debug-header", false,
+			`Add to the responses the X-Served-By-MCI header, with the namespace and name of the MultiClusterIngress of the location.
+Meant for debugging, as it exposes the internal topology to the clients.`)
+
+		validateBackendServices = flags.Bool("validate-backend-services", false,
+			`Reject in the validating webhook the MultiClusterIngress objects referencing a service that does not exist.
+Keep it disabled when the services can be created after the MultiClusterIngress objects referencing them.`)
+
+		topologyZone = flags.String("topology-zone", "",
+			`Zone of the controller, usually the topology.kubernetes.io/zone label of its node.
+The upstreams of the MultiClusterIngress objects with the upstream-topology-aware annotation only use the endpoints hinted for this zone.`)
+
+		explodeAliases = flags.Bool("explode-aliases", false,
+			`Generate a distinct server block for each alias of the server-alias annotation, sharing the locations and the certificate of the server, instead of adding the aliases to its server_name.`)
+
+		admissionTimeout = flags.Duration("admission-timeout", 0,
+			`Maximum time the validating webhook spends rendering and testing the configuration of a MultiClusterIngress object before rejecting it.
+Use a value lower than the timeout of the webhook configuration. 0 waits until the test finishes.`)
+
+		emitAdmissionEvents = flags.Bool("emit-admission-events", false,
+			`Record an event in each MultiClusterIngress object checked by the validating webhook, Normal when it is accepted and Warning with the error when it is rejected.
+Keep it disabled to avoid an event for each change applied to the MultiClusterIngress objects.`)
+
+		useEndpointSlices = flags.Bool("use-endpointslices", true,
+			`Read the endpoints of the services from their EndpointSlices, keeping the topology hints and the conditions of the endpoints,
+instead of their Endpoints, limited to 1000 addresses. The Endpoints are still used for the services without EndpointSlice.`)
+
+		includeTerminatingServing = flags.Bool("include-terminating-serving", false,
+			`Keep in the upstreams the endpoints terminating but still serving, so they finish the in-flight requests during rollouts.
+The endpoints terminating and no longer serving are always excluded. Requires --use-endpointslices.`)
+
+		groupCanaryUpstreams = flags.Bool("group-canary-upstreams", false,
+			`Order the backends so each canary backend follows the backend it is an alternative for, instead of sorting all backends by name.`)
 	)
 
+	flags.StringVar(&auth.FileNaming, "auth-file-naming", auth.FileNamingUID,
+		`Naming of the password files generated for basic and digest authentication. Use "uid" to name them after the UIDs of the object and the secret,
+or "stable" to name them after the namespace and name of the object, so secret rotation only changes the content of the file.`)
+
 	flags.StringVar(&nginx.MaxmindMirror, "maxmind-mirror", "", `Maxmind mirror url (example: http://geoip.local/databases`)
 	flags.StringVar(&nginx.MaxmindLicenseKey, "maxmind-license-key", "", `Maxmind license key to download GeoLite2 Databases.
 https://blog.maxmind.com/2019/12/18/significant-changes-to-accessing-and-using-geolite2-databases`)
@@ -279,6 +360,27 @@ https://blog.maxmind.com/2019/12/18/significant-changes-to-accessing-and-using-g
 		return false, nil, fmt.Errorf("flags --watch-namespace and --watch-namespace-selector are mutually exclusive")
 	}
 
+	if *locationTiebreak != controller.LocationTiebreakReverse && *locationTiebreak != controller.LocationTiebreakForward {
+		return false, nil, fmt.Errorf("flag --location-tiebreak must be %q or %q", controller.LocationTiebreakReverse, controller.LocationTiebreakForward)
+	}
+
+	if *defaultBackendConnectTimeout < 0 || *defaultBackendReadTimeout < 0 {
+		return false, nil, fmt.Errorf("flags --default-backend-connect-timeout and --default-backend-read-timeout must not be negative")
+	}
+
+	resolvers, err := parseUpstreamDNSResolvers(*upstreamDNSResolver)
+	if err != nil {
+		return false, nil, err
+	}
+
+	if *upstreamDNSValid < time.Second {
+		return false, nil, fmt.Errorf("flag --upstream-dns-valid must be at least one second")
+	}
+
+	if auth.FileNaming != auth.FileNamingUID && auth.FileNaming != auth.FileNamingStable {
+		return false, nil, fmt.Errorf("flag --auth-file-naming must be %q or %q", auth.FileNamingUID, auth.FileNamingStable)
+	}
+
 	var namespaceSelector labels.Selector
 	if len(*watchNamespaceSelector) != 0 {
 		var err error
@@ -291,34 +393,54 @@ https://blog.maxmind.com/2019/12/18/significant-changes-to-accessing-and-using-g
 	ngx_config.EnableSSLChainCompletion = *enableSSLChainCompletion
 
 	config := &controller.Configuration{
-		APIServerHost:              *apiserverHost,
-		KubeConfigFile:             *kubeConfigFile,
-		KarmadaConfigFile:          *karmadaConfigFile,
-		UpdateStatus:               *updateStatus,
-		ElectionID:                 *electionID,
-		EnableProfiling:            *profiling,
-		EnableMetrics:              *enableMetrics,
-		MetricsPerHost:             *metricsPerHost,
-		MonitorMaxBatchSize:        *monitorMaxBatchSize,
-		DisableServiceExternalName: *disableServiceExternalName,
-		EnableSSLPassthrough:       *enableSSLPassthrough,
-		ResyncPeriod:               *resyncPeriod,
-		DefaultService:             *defaultSvc,
-		Namespace:                  *watchNamespace,
-		WatchNamespaceSelector:     namespaceSelector,
-		ConfigMapName:              *configMap,
-		TCPConfigMapName:           *tcpConfigMapName,
-		UDPConfigMapName:           *udpConfigMapName,
-		DisableFullValidationTest:  *disableFullValidationTest,
-		DefaultSSLCertificate:      *defSSLCertificate,
-		DeepInspector:              *deepInspector,
-		PublishService:             *publishSvc,
-		PublishStatusAddress:       *publishStatusAddress,
-		UpdateStatusOnShutdown:     *updateStatusOnShutdown,
-		ShutdownGracePeriod:        *shutdownGracePeriod,
-		UseNodeInternalIP:          *useNodeInternalIP,
-		SyncRateLimit:              *syncRateLimit,
-		HealthCheckHost:            *healthzHost,
+		APIServerHost:                  *apiserverHost,
+		KubeConfigFile:                 *kubeConfigFile,
+		KarmadaConfigFile:              *karmadaConfigFile,
+		UpdateStatus:                   *updateStatus,
+		ElectionID:                     *electionID,
+		EnableProfiling:                *profiling,
+		EnableMetrics:                  *enableMetrics,
+		MetricsPerHost:                 *metricsPerHost,
+		MonitorMaxBatchSize:            *monitorMaxBatchSize,
+		DisableServiceExternalName:     *disableServiceExternalName,
+		EnableSSLPassthrough:           *enableSSLPassthrough,
+		ResyncPeriod:                   *resyncPeriod,
+		DefaultService:                 *defaultSvc,
+		Namespace:                      *watchNamespace,
+		WatchNamespaceSelector:         namespaceSelector,
+		ConfigMapName:                  *configMap,
+		TCPConfigMapName:               *tcpConfigMapName,
+		HostPortsConfigMapName:         *hostPortsConfigMapName,
+		UDPConfigMapName:               *udpConfigMapName,
+		DisableFullValidationTest:      *disableFullValidationTest,
+		DefaultSSLCertificate:          *defSSLCertificate,
+		DeepInspector:                  *deepInspector,
+		LocationTiebreak:               *locationTiebreak,
+		RejectUnwatchedNamespace:       *rejectUnwatchedNamespace,
+		MaxLocationsPerServer:          *maxLocationsPerServer,
+		GroupCanaryUpstreams:           *groupCanaryUpstreams,
+		DefaultBackendConnectTimeout:   *defaultBackendConnectTimeout,
+		DefaultBackendReadTimeout:      *defaultBackendReadTimeout,
+		NamespaceDefaultBackendService: *namespaceDefaultBackendSvc,
+		EnableMisdirectedRequestCheck:  *enableMisdirectedRequestCheck,
+		UpstreamDNSResolver:            resolvers,
+		UpstreamDNSValid:               *upstreamDNSValid,
+		ValidateCanaryPrimary:          *validateCanaryPrimary,
+		AddMCIDebugHeader:              *addMCIDebugHeader,
+		ValidateBackendServices:        *validateBackendServices,
+		TopologyZone:                   *topologyZone,
+		ExplodeAliases:                 *explodeAliases,
+		AdmissionTimeout:               *admissionTimeout,
+		EmitAdmissionEvents:            *emitAdmissionEvents,
+		UseEndpointSlices:              *useEndpointSlices,
+		IncludeTerminatingServing:      *includeTerminatingServing,
+		PublishService:                 *publishSvc,
+		PublishStatusAddress:           *publishStatusAddress,
+		UpdateStatusOnShutdown:         *updateStatusOnShutdown,
+		ShutdownGracePeriod:            *shutdownGracePeriod,
+		UseNodeInternalIP:              *useNodeInternalIP,
+		SyncRateLimit:                  *syncRateLimit,
+		HealthCheckHost:                *healthzHost,
 		ListenPorts: &ngx_config.ListenPorts{
 			Default:  *defServerPort,
 			Health:   *healthzPort,
@@ -342,7 +464,6 @@ https://blog.maxmind.com/2019/12/18/significant-changes-to-accessing-and-using-g
 		config.RootCAFile = *rootCAFile
 	}
 
-	var err error
 	if nginx.MaxmindEditionIDs != "" {
 		if err = nginx.ValidateGeoLite2DBEditions(); err != nil {
 			return false, nil, err
@@ -358,3 +479,29 @@ https://blog.maxmind.com/2019/12/18/significant-changes-to-accessing-and-using-g
 
 	return false, config, err
 }
+
+// parseUpstreamDNSResolvers validates the addresses of the --upstream-dns-resolver
+// flag and returns them as expected by the NGINX resolver directive
+func parseUpstreamDNSResolvers(addrs []string) ([]string, error) {
+	resolvers := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		if ip := net.ParseIP(addr); ip != nil {
+			resolvers = append(resolvers, net.JoinHostPort(ip.String(), "53"))
+			continue
+		}
+
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil || net.ParseIP(host) == nil {
+			return nil, fmt.Errorf("flag --upstream-dns-resolver contains an invalid address %q", addr)
+		}
+
+		p, err := strconv.Atoi(port)
+		if err != nil || p <= 0 || p > 65535 {
+			return nil, fmt.Errorf("flag --upstream-dns-resolver contains an invalid port in %q", addr)
+		}
+
+		resolvers = append(resolvers, net.JoinHostPort(net.ParseIP(host).String(), port))
+	}
+
+	return resolvers, nil
+}
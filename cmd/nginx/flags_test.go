@@ -19,7 +19,9 @@ package main
 import (
 	"flag"
 	"os"
+	"reflect"
 	"testing"
+	"time"
 )
 
 // resetForTesting clears all flag state and sets the usage function as directed.
@@ -118,3 +120,45 @@ func TestMaxmindRetryDownload(t *testing.T) {
 		t.Fatalf("Expected an error parsing flags but none returned")
 	}
 }
+
+func TestUpstreamDNSResolver(t *testing.T) {
+	resetForTesting(func() { t.Fatal("Parsing failed") })
+
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+	os.Args = []string{"cmd", "--http-port", "0", "--https-port", "0", "--upstream-dns-resolver", "10.96.0.10,fd00::10,[fd00::11]:5353", "--upstream-dns-valid", "10s"}
+
+	_, conf, err := parseFlags()
+	if err != nil {
+		t.Fatalf("Unexpected error parsing flags: %v", err)
+	}
+
+	expected := []string{"10.96.0.10:53", "[fd00::10]:53", "[fd00::11]:5353"}
+	if !reflect.DeepEqual(conf.UpstreamDNSResolver, expected) {
+		t.Errorf("expected resolvers %v but got %v", expected, conf.UpstreamDNSResolver)
+	}
+
+	if conf.UpstreamDNSValid != 10*time.Second {
+		t.Errorf("expected valid 10s but got %v", conf.UpstreamDNSValid)
+	}
+}
+
+func TestUpstreamDNSResolverInvalid(t *testing.T) {
+	for _, args := range [][]string{
+		{"--upstream-dns-resolver", "kube-dns.kube-system"},
+		{"--upstream-dns-resolver", "10.96.0.10:0"},
+		{"--upstream-dns-resolver", "10.96.0.10:dns"},
+		{"--upstream-dns-valid", "0s"},
+	} {
+		resetForTesting(func() { t.Fatal("Parsing failed") })
+
+		oldArgs := os.Args
+		os.Args = append([]string{"cmd", "--http-port", "0", "--https-port", "0"}, args...)
+
+		_, _, err := parseFlags()
+		os.Args = oldArgs
+		if err == nil {
+			t.Errorf("Expected an error parsing flags %v but none returned", args)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package absoluteredirect
+
+import (
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	networking "k8s.io/api/networking/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+type absoluteRedirect struct {
+	r resolver.Resolver
+}
+
+// Config contains the absolute_redirect configuration of a location.
+// An empty AbsoluteRedirect means the NGINX default, absolute redirects, applies.
+type Config struct {
+	AbsoluteRedirect string `json:"absoluteRedirect,omitempty"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+
+	return c1.AbsoluteRedirect == c2.AbsoluteRedirect
+}
+
+// NewParser creates a new absolute-redirect annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return absoluteRedirect{r}
+}
+
+// Parse parses the annotations contained in the ingress rule used to
+// choose between absolute and relative redirects issued by NGINX
+func (a absoluteRedirect) Parse(ing *networking.Ingress) (interface{}, error) {
+	enabled, err := parser.GetBoolAnnotation("absolute-redirect", ing)
+	if err != nil {
+		return &Config{}, nil
+	}
+
+	return newConfig(enabled), nil
+}
+
+// ParseByMCI parses the annotations contained in the multiclusteringress rule
+// used to choose between absolute and relative redirects issued by NGINX
+func (a absoluteRedirect) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interface{}, error) {
+	enabled, err := parser.GetBoolAnnotationFromMCI("absolute-redirect", mci)
+	if err != nil {
+		return &Config{}, nil
+	}
+
+	return newConfig(enabled), nil
+}
+
+func newConfig(enabled bool) *Config {
+	if enabled {
+		return &Config{AbsoluteRedirect: "on"}
+	}
+
+	return &Config{AbsoluteRedirect: "off"}
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package absoluteredirect
+
+import (
+	"testing"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	api "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+func TestParseByMCI(t *testing.T) {
+	annotation := parser.GetAnnotationWithPrefix("absolute-redirect")
+
+	ap := NewParser(&resolver.Mock{})
+	if ap == nil {
+		t.Fatalf("expected a parser.IngressAnnotation but returned nil")
+	}
+
+	testCases := []struct {
+		annotations map[string]string
+		expected    *Config
+	}{
+		{map[string]string{annotation: "true"}, &Config{AbsoluteRedirect: "on"}},
+		{map[string]string{annotation: "false"}, &Config{AbsoluteRedirect: "off"}},
+		{map[string]string{annotation: "invalid"}, &Config{}},
+		{map[string]string{}, &Config{}},
+		{nil, &Config{}},
+	}
+
+	mci := &karmadanetworking.MultiClusterIngress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+	}
+
+	for _, testCase := range testCases {
+		mci.SetAnnotations(testCase.annotations)
+		i, err := ap.ParseByMCI(mci)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		p, _ := i.(*Config)
+		if !p.Equal(testCase.expected) {
+			t.Errorf("expected %v but returned %v, annotations: %s", testCase.expected, p, testCase.annotations)
+		}
+	}
+}
+
+func TestEqual(t *testing.T) {
+	if !(&Config{AbsoluteRedirect: "on"}).Equal(&Config{AbsoluteRedirect: "on"}) {
+		t.Errorf("expected equal configurations")
+	}
+
+	if (&Config{AbsoluteRedirect: "on"}).Equal(&Config{}) {
+		t.Errorf("expected different configurations")
+	}
+
+	if (&Config{}).Equal(nil) {
+		t.Errorf("expected different configurations")
+	}
+}
@@ -24,44 +24,76 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/klog/v2"
 
+	"k8s.io/ingress-nginx/internal/ingress/annotations/absoluteredirect"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/alias"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/auth"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/authreq"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/authreqglobal"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/authtls"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/backendprotocol"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/backendproxyprotocol"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/backendscheme"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/brotli"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/canary"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/charset"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/clientbodybuffersize"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/clientbodyinfileonly"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/clientcert"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/clientkeepalive"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/connection"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/cors"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/customhttperrors"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/defaultbackend"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/defaultsslcertificate"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/disabled"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/disablepathredirect"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/emptyupstream"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/ewmadecay"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/fastcgi"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/geoipaccess"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/globalratelimit"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/grpctimeout"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/gzip"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/healthcheck"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/http2pushpreload"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/influxdb"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/internalredirect"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/ipwhitelist"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/loadbalancing"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/log"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/maintenancemode"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/mirror"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/modsecurity"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/opentracing"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/passivehealthcheck"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/portinredirect"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/proxy"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/proxycache"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/proxysetheaders"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/proxyssl"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/ratelimit"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/redirect"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/requestid"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/rewrite"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/routebyheader"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/satisfy"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/secureupstream"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/securityheaders"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/serversnippet"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/servertokens"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/serviceportname"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/serviceupstream"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/sessionaffinity"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/snippet"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/sse"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/sslcipher"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/sslpassthrough"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/sslprotocols"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/streamservices"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/streamsnippet"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/subfilter"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/topologyaware"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/upstreamhashby"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/upstreamvhost"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/xforwardedprefix"
@@ -75,12 +107,20 @@ const DeniedKeyName = "Denied"
 // Ingress defines the valid annotations present in one NGINX Ingress rule
 type Ingress struct {
 	metav1.ObjectMeta
+	AbsoluteRedirect     absoluteredirect.Config
 	BackendProtocol      string
+	BackendScheme        string
+	BackendProxyProtocol bool
 	Aliases              []string
 	BasicDigestAuth      auth.Config
+	Brotli               brotli.Config
 	Canary               canary.Config
 	CertificateAuth      authtls.Config
+	Charset              charset.Config
+	ClientCert           clientcert.Config
 	ClientBodyBufferSize string
+	ClientBodyInFileOnly string
+	ClientKeepalive      clientkeepalive.Config
 	ConfigurationSnippet string
 	Connection           connection.Config
 	CorsConfig           cors.Config
@@ -91,32 +131,71 @@ type Ingress struct {
 	Denied             *string
 	ExternalAuth       authreq.Config
 	EnableGlobalAuth   bool
+	GRPCTimeout        grpctimeout.Config
+	Gzip               gzip.Config
 	HTTP2PushPreload   bool
+	PreloadLinks       []string
+	InternalRedirect   internalredirect.Config
 	Opentracing        opentracing.Config
 	Proxy              proxy.Config
+	ProxyCache         proxycache.Config
+	ProxySetHeaders    proxysetheaders.Config
 	ProxySSL           proxyssl.Config
 	RateLimit          ratelimit.Config
 	GlobalRateLimit    globalratelimit.Config
+	HealthCheck        healthcheck.Config
+	PassiveHealthCheck passivehealthcheck.Config
 	Redirect           redirect.Config
+	RequestID          requestid.Config
 	Rewrite            rewrite.Config
+	RouteByHeader      routebyheader.Config
 	Satisfy            string
 	SecureUpstream     secureupstream.Config
+	SecurityHeaders    securityheaders.Config
 	ServerSnippet      string
+	ServerTokens       servertokens.Config
 	ServiceUpstream    bool
+	ServicePortName    string
 	SessionAffinity    sessionaffinity.Config
+	SSE                bool
 	SSLPassthrough     bool
 	UsePortInRedirects bool
 	UpstreamHashBy     upstreamhashby.Config
 	LoadBalancing      string
+	EWMADecay          float64
 	UpstreamVhost      string
 	Whitelist          ipwhitelist.SourceRange
 	XForwardedPrefix   string
 	SSLCipher          sslcipher.Config
+	SSLProtocols       sslprotocols.Config
+	SubFilter          subfilter.Config
+	GeoIPAccess        geoipaccess.Config
 	Logs               log.Config
 	InfluxDB           influxdb.Config
 	ModSecurity        modsecurity.Config
 	Mirror             mirror.Config
 	StreamSnippet      string
+	// StreamServices are the TCP and UDP services exposed
+	// next to the ones of the tcp-services and udp-services ConfigMaps
+	StreamServices streamservices.Config
+	// TopologyAware restricts the endpoints of the upstreams to
+	// the ones hinted for the zone of the controller
+	TopologyAware bool
+	// Disabled takes the multiclusteringress out of the configuration
+	// as if it did not exist
+	Disabled bool
+	// ServiceUnavailableOnEmptyUpstream returns 503 for locations
+	// whose upstream has no endpoints instead of using a default backend
+	ServiceUnavailableOnEmptyUpstream bool
+	// MaintenanceMode returns 503, or uses the custom default backend,
+	// for every location instead of their upstreams
+	MaintenanceMode bool
+	// DisablePathRedirect adds an exact location without the trailing
+	// slash for the locations ending with one
+	DisablePathRedirect bool
+	// DefaultSSLCertificate replaces the global default certificate for the
+	// hosts without a matching TLS certificate
+	DefaultSSLCertificate defaultsslcertificate.Config
 }
 
 // Extractor defines the annotation parsers to be used in the extraction of annotations
@@ -128,46 +207,79 @@ type Extractor struct {
 func NewAnnotationExtractor(cfg resolver.Resolver) Extractor {
 	return Extractor{
 		map[string]parser.IngressAnnotation{
-			"Aliases":              alias.NewParser(cfg),
-			"BasicDigestAuth":      auth.NewParser(auth.AuthDirectory, cfg),
-			"Canary":               canary.NewParser(cfg),
-			"CertificateAuth":      authtls.NewParser(cfg),
-			"ClientBodyBufferSize": clientbodybuffersize.NewParser(cfg),
-			"ConfigurationSnippet": snippet.NewParser(cfg),
-			"Connection":           connection.NewParser(cfg),
-			"CorsConfig":           cors.NewParser(cfg),
-			"CustomHTTPErrors":     customhttperrors.NewParser(cfg),
-			"DefaultBackend":       defaultbackend.NewParser(cfg),
-			"FastCGI":              fastcgi.NewParser(cfg),
-			"ExternalAuth":         authreq.NewParser(cfg),
-			"EnableGlobalAuth":     authreqglobal.NewParser(cfg),
-			"HTTP2PushPreload":     http2pushpreload.NewParser(cfg),
-			"Opentracing":          opentracing.NewParser(cfg),
-			"Proxy":                proxy.NewParser(cfg),
-			"ProxySSL":             proxyssl.NewParser(cfg),
-			"RateLimit":            ratelimit.NewParser(cfg),
-			"GlobalRateLimit":      globalratelimit.NewParser(cfg),
-			"Redirect":             redirect.NewParser(cfg),
-			"Rewrite":              rewrite.NewParser(cfg),
-			"Satisfy":              satisfy.NewParser(cfg),
-			"SecureUpstream":       secureupstream.NewParser(cfg),
-			"ServerSnippet":        serversnippet.NewParser(cfg),
-			"ServiceUpstream":      serviceupstream.NewParser(cfg),
-			"SessionAffinity":      sessionaffinity.NewParser(cfg),
-			"SSLPassthrough":       sslpassthrough.NewParser(cfg),
-			"UsePortInRedirects":   portinredirect.NewParser(cfg),
-			"UpstreamHashBy":       upstreamhashby.NewParser(cfg),
-			"LoadBalancing":        loadbalancing.NewParser(cfg),
-			"UpstreamVhost":        upstreamvhost.NewParser(cfg),
-			"Whitelist":            ipwhitelist.NewParser(cfg),
-			"XForwardedPrefix":     xforwardedprefix.NewParser(cfg),
-			"SSLCipher":            sslcipher.NewParser(cfg),
-			"Logs":                 log.NewParser(cfg),
-			"InfluxDB":             influxdb.NewParser(cfg),
-			"BackendProtocol":      backendprotocol.NewParser(cfg),
-			"ModSecurity":          modsecurity.NewParser(cfg),
-			"Mirror":               mirror.NewParser(cfg),
-			"StreamSnippet":        streamsnippet.NewParser(cfg),
+			"AbsoluteRedirect":                  absoluteredirect.NewParser(cfg),
+			"Aliases":                           alias.NewParser(cfg),
+			"BasicDigestAuth":                   auth.NewParser(auth.AuthDirectory, cfg),
+			"Brotli":                            brotli.NewParser(cfg),
+			"Charset":                           charset.NewParser(cfg),
+			"ClientCert":                        clientcert.NewParser(cfg),
+			"Canary":                            canary.NewParser(cfg),
+			"CertificateAuth":                   authtls.NewParser(cfg),
+			"ClientBodyBufferSize":              clientbodybuffersize.NewParser(cfg),
+			"ClientBodyInFileOnly":              clientbodyinfileonly.NewParser(cfg),
+			"ClientKeepalive":                   clientkeepalive.NewParser(cfg),
+			"ConfigurationSnippet":              snippet.NewParser(cfg),
+			"Connection":                        connection.NewParser(cfg),
+			"CorsConfig":                        cors.NewParser(cfg),
+			"CustomHTTPErrors":                  customhttperrors.NewParser(cfg),
+			"DefaultBackend":                    defaultbackend.NewParser(cfg),
+			"DefaultSSLCertificate":             defaultsslcertificate.NewParser(cfg),
+			"FastCGI":                           fastcgi.NewParser(cfg),
+			"ExternalAuth":                      authreq.NewParser(cfg),
+			"EnableGlobalAuth":                  authreqglobal.NewParser(cfg),
+			"GeoIPAccess":                       geoipaccess.NewParser(cfg),
+			"GRPCTimeout":                       grpctimeout.NewParser(cfg),
+			"Gzip":                              gzip.NewParser(cfg),
+			"HTTP2PushPreload":                  http2pushpreload.NewParser(cfg),
+			"PreloadLinks":                      http2pushpreload.NewPreloadLinksParser(cfg),
+			"InternalRedirect":                  internalredirect.NewParser(cfg),
+			"Opentracing":                       opentracing.NewParser(cfg),
+			"Proxy":                             proxy.NewParser(cfg),
+			"ProxyCache":                        proxycache.NewParser(cfg),
+			"ProxySetHeaders":                   proxysetheaders.NewParser(cfg),
+			"ProxySSL":                          proxyssl.NewParser(cfg),
+			"RateLimit":                         ratelimit.NewParser(cfg),
+			"GlobalRateLimit":                   globalratelimit.NewParser(cfg),
+			"HealthCheck":                       healthcheck.NewParser(cfg),
+			"PassiveHealthCheck":                passivehealthcheck.NewParser(cfg),
+			"Redirect":                          redirect.NewParser(cfg),
+			"RequestID":                         requestid.NewParser(cfg),
+			"Rewrite":                           rewrite.NewParser(cfg),
+			"RouteByHeader":                     routebyheader.NewParser(cfg),
+			"Satisfy":                           satisfy.NewParser(cfg),
+			"SecureUpstream":                    secureupstream.NewParser(cfg),
+			"ServerSnippet":                     serversnippet.NewParser(cfg),
+			"ServiceUpstream":                   serviceupstream.NewParser(cfg),
+			"ServicePortName":                   serviceportname.NewParser(cfg),
+			"ServiceUnavailableOnEmptyUpstream": emptyupstream.NewParser(cfg),
+			"MaintenanceMode":                   maintenancemode.NewParser(cfg),
+			"DisablePathRedirect":               disablepathredirect.NewParser(cfg),
+			"SessionAffinity":                   sessionaffinity.NewParser(cfg),
+			"SSE":                               sse.NewParser(cfg),
+			"SSLPassthrough":                    sslpassthrough.NewParser(cfg),
+			"SubFilter":                         subfilter.NewParser(cfg),
+			"UsePortInRedirects":                portinredirect.NewParser(cfg),
+			"UpstreamHashBy":                    upstreamhashby.NewParser(cfg),
+			"TopologyAware":                     topologyaware.NewParser(cfg),
+			"LoadBalancing":                     loadbalancing.NewParser(cfg),
+			"EWMADecay":                         ewmadecay.NewParser(cfg),
+			"UpstreamVhost":                     upstreamvhost.NewParser(cfg),
+			"Whitelist":                         ipwhitelist.NewParser(cfg),
+			"XForwardedPrefix":                  xforwardedprefix.NewParser(cfg),
+			"SSLCipher":                         sslcipher.NewParser(cfg),
+			"SSLProtocols":                      sslprotocols.NewParser(cfg),
+			"ServerTokens":                      servertokens.NewParser(cfg),
+			"SecurityHeaders":                   securityheaders.NewParser(cfg),
+			"Disabled":                          disabled.NewParser(cfg),
+			"Logs":                              log.NewParser(cfg),
+			"InfluxDB":                          influxdb.NewParser(cfg),
+			"BackendProtocol":                   backendprotocol.NewParser(cfg),
+			"BackendScheme":                     backendscheme.NewParser(cfg),
+			"BackendProxyProtocol":              backendproxyprotocol.NewParser(cfg),
+			"ModSecurity":                       modsecurity.NewParser(cfg),
+			"Mirror":                            mirror.NewParser(cfg),
+			"StreamSnippet":                     streamsnippet.NewParser(cfg),
+			"StreamServices":                    streamservices.NewParser(cfg),
 		},
 	}
 }
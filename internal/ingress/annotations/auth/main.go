@@ -17,15 +17,20 @@ limitations under the License.
 package auth
 
 import (
+	"crypto/sha1" // #nosec
+	"encoding/hex"
 	"fmt"
 	"os"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 
 	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
 	api "k8s.io/api/core/v1"
 	networking "k8s.io/api/networking/v1"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
 
 	"k8s.io/ingress-nginx/internal/file"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
@@ -38,11 +43,43 @@ var (
 	// AuthDirectory default directory used to store files
 	// to authenticate request
 	AuthDirectory = "/etc/ingress-controller/auth"
+	// FileNaming defines how the password files are named
+	FileNaming = FileNamingUID
+
+	// bcryptHashRegex matches a bcrypt hash, capturing its cost
+	bcryptHashRegex = regexp.MustCompile(`^\$2[abxy]?\$(\d{2})\$[./A-Za-z0-9]{53}$`)
+	// cryptHashRegexes match the other hashes of the password files,
+	// produced by crypt and by htpasswd
+	cryptHashRegexes = []*regexp.Regexp{
+		// DES crypt of htpasswd -d and openssl passwd -crypt
+		regexp.MustCompile(`^[./A-Za-z0-9]{13}$`),
+		// apr1 and MD5 crypt
+		regexp.MustCompile(`^\$(apr1|1)\$[^$]{1,8}\$[./A-Za-z0-9]{22}$`),
+		// SHA-256 and SHA-512 crypt
+		regexp.MustCompile(`^\$5\$(rounds=\d+\$)?[^$]{1,16}\$[./A-Za-z0-9]{43}$`),
+		regexp.MustCompile(`^\$6\$(rounds=\d+\$)?[^$]{1,16}\$[./A-Za-z0-9]{86}$`),
+		// SHA-1 of htpasswd -s
+		regexp.MustCompile(`^\{S?SHA\}[A-Za-z0-9+/]+={0,2}$`),
+	}
+	// digestEntryRegex matches an entry of an htdigest file, user:realm:hash
+	digestEntryRegex = regexp.MustCompile(`^[^:]+:[^:]*:[0-9a-fA-F]{32}$`)
+)
+
+const (
+	bcryptMinCost = 4
+	bcryptMaxCost = 31
 )
 
 const (
 	fileAuth = "auth-file"
 	mapAuth  = "auth-map"
+
+	// FileNamingUID names the password files after the UIDs of the
+	// object and the secrets, so the path changes when a secret is replaced
+	FileNamingUID = "uid"
+	// FileNamingStable names the password files after the namespace and
+	// name of the object, so the path does not change when a secret is replaced
+	FileNamingStable = "stable"
 )
 
 // Config returns authentication configuration for an Ingress rule
@@ -54,6 +91,27 @@ type Config struct {
 	FileSHA    string `json:"fileSha"`
 	Secret     string `json:"secret"`
 	SecretType string `json:"secretType"`
+	// Secrets contains all the secrets merged in the password file,
+	// in the order of precedence
+	Secrets []string `json:"secrets,omitempty"`
+	// RealmSecretKey is the key of the auth secret containing the realm.
+	// Empty when the realm comes from the auth-realm annotation.
+	RealmSecretKey string `json:"realmSecretKey,omitempty"`
+	// Checksum covers the type, the realm and FileSHA, which only
+	// changes with the content of the password file
+	Checksum string `json:"checksum,omitempty"`
+}
+
+// ComputeChecksum returns the checksum of the type, the realm and the
+// file checksum of the configuration
+func (bd *Config) ComputeChecksum() string {
+	hasher := sha1.New() // #nosec
+	for _, value := range []string{bd.Type, bd.Realm, bd.FileSHA} {
+		hasher.Write([]byte(value))
+		hasher.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil))
 }
 
 // Equal tests for equality between two Config types
@@ -70,6 +128,9 @@ func (bd1 *Config) Equal(bd2 *Config) bool {
 	if bd1.Realm != bd2.Realm {
 		return false
 	}
+	if bd1.RealmSecretKey != bd2.RealmSecretKey {
+		return false
+	}
 	if bd1.File != bd2.File {
 		return false
 	}
@@ -79,20 +140,44 @@ func (bd1 *Config) Equal(bd2 *Config) bool {
 	if bd1.FileSHA != bd2.FileSHA {
 		return false
 	}
+	if bd1.Checksum != bd2.Checksum {
+		return false
+	}
 	if bd1.Secret != bd2.Secret {
 		return false
 	}
+	if len(bd1.Secrets) != len(bd2.Secrets) {
+		return false
+	}
+	for i := range bd1.Secrets {
+		if bd1.Secrets[i] != bd2.Secrets[i] {
+			return false
+		}
+	}
 	return true
 }
 
+// FilenameBuilder returns the full path of the password file of an Ingress or
+// MultiClusterIngress from its namespace, its UID and the UID of its secrets,
+// joined with a dash when several secrets are merged
+type FilenameBuilder func(namespace, uid, secretUID string) string
+
 type auth struct {
-	r             resolver.Resolver
-	authDirectory string
+	r               resolver.Resolver
+	authDirectory   string
+	filenameBuilder FilenameBuilder
 }
 
-// NewParser creates a new authentication annotation parser
-func NewParser(authDirectory string, r resolver.Resolver) parser.IngressAnnotation {
-	return auth{r, authDirectory}
+// NewParser creates a new authentication annotation parser. An optional
+// FilenameBuilder replaces the default naming of the password files, for
+// instance to prefix them with the cluster when several controllers share
+// the authentication directory.
+func NewParser(authDirectory string, r resolver.Resolver, filenameBuilder ...FilenameBuilder) parser.IngressAnnotation {
+	a := auth{r: r, authDirectory: authDirectory}
+	if len(filenameBuilder) > 0 {
+		a.filenameBuilder = filenameBuilder[0]
+	}
+	return a
 }
 
 // Parse parses the annotations contained in the ingress
@@ -143,7 +228,7 @@ func (a auth) Parse(ing *networking.Ingress) (interface{}, error) {
 
 	realm, _ := parser.GetStringAnnotation("auth-realm", ing)
 
-	passFilename := fmt.Sprintf("%v/%v-%v-%v.passwd", a.authDirectory, ing.GetNamespace(), ing.UID, secret.UID)
+	passFilename := a.passwdFilename(ing.GetNamespace(), ing.GetName(), string(ing.UID), string(secret.UID))
 
 	switch secretType {
 	case fileAuth:
@@ -162,7 +247,7 @@ func (a auth) Parse(ing *networking.Ingress) (interface{}, error) {
 		}
 	}
 
-	return &Config{
+	cfg := &Config{
 		Type:       at,
 		Realm:      realm,
 		File:       passFilename,
@@ -170,7 +255,10 @@ func (a auth) Parse(ing *networking.Ingress) (interface{}, error) {
 		FileSHA:    file.SHA1(passFilename),
 		Secret:     name,
 		SecretType: secretType,
-	}, nil
+	}
+	cfg.Checksum = cfg.ComputeChecksum()
+
+	return cfg, nil
 }
 
 // ParseByMCI parses the annotations contained in the multiclusteringress
@@ -200,58 +288,239 @@ func (a auth) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interface{
 		}
 	}
 
-	sns, sname, err := cache.SplitMetaNamespaceKey(s)
-	if err != nil {
+	if secretType != fileAuth && secretType != mapAuth {
 		return nil, ing_errors.LocationDenied{
-			Reason: fmt.Errorf("error reading secret name from annotation: %w", err),
+			Reason: fmt.Errorf("invalid auth-secret-type in annotation, must be 'auth-file' or 'auth-map'"),
 		}
 	}
 
-	if sns == "" {
-		sns = mci.Namespace
-	}
+	var names []string
+	var secrets []*api.Secret
+	for _, key := range strings.Split(s, ",") {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
 
-	name := fmt.Sprintf("%v/%v", sns, sname)
-	secret, err := a.r.GetSecret(name)
-	if err != nil {
-		return nil, ing_errors.LocationDenied{
-			Reason: fmt.Errorf("unexpected error reading secret %s: %w", name, err),
+		sns, sname, err := cache.SplitMetaNamespaceKey(key)
+		if err != nil {
+			return nil, ing_errors.LocationDenied{
+				Reason: fmt.Errorf("error reading secret name from annotation: %w", err),
+			}
+		}
+
+		if sns == "" {
+			sns = mci.Namespace
+		}
+
+		name := fmt.Sprintf("%v/%v", sns, sname)
+		secret, err := a.r.GetSecret(name)
+		if err != nil {
+			return nil, ing_errors.LocationDenied{
+				Reason: fmt.Errorf("unexpected error reading secret %s: %w", name, err),
+			}
 		}
+
+		names = append(names, name)
+		secrets = append(secrets, secret)
 	}
 
-	realm, _ := parser.GetStringAnnotationFromMCI("auth-realm", mci)
+	if len(secrets) == 0 {
+		return nil, ing_errors.NewLocationDenied("error reading secret name from annotation: no secret found")
+	}
 
-	passFilename := fmt.Sprintf("%v/%v-%v-%v.passwd", a.authDirectory, mci.GetNamespace(), mci.UID, secret.UID)
+	realm, _ := parser.GetStringAnnotationFromMCI("auth-realm", mci)
 
-	switch secretType {
-	case fileAuth:
-		err = dumpSecretAuthFile(passFilename, secret)
+	realmKey, err := parser.GetStringAnnotationFromMCI("auth-realm-secret-key", mci)
+	if err != nil && !ing_errors.IsMissingAnnotations(err) {
+		return nil, err
+	}
+	if realmKey != "" {
+		realm, err = secretRealm(secrets[0], realmKey)
 		if err != nil {
 			return nil, err
 		}
-	case mapAuth:
-		err = dumpSecretAuthMap(passFilename, secret)
-		if err != nil {
-			return nil, err
+
+		// the realm is not a user of the password file
+		if secretType == mapAuth {
+			secrets[0] = secretWithoutKey(secrets[0], realmKey)
 		}
+	}
+
+	uids := make([]string, 0, len(secrets))
+	for _, secret := range secrets {
+		uids = append(uids, string(secret.UID))
+	}
+	passFilename := a.passwdFilename(mci.GetNamespace(), mci.GetName(), string(mci.UID), strings.Join(uids, "-"))
+
+	switch {
+	case len(secrets) > 1:
+		err = dumpMergedSecretsAuth(passFilename, secrets, secretType)
+	case secretType == fileAuth:
+		err = dumpSecretAuthFile(passFilename, secrets[0])
 	default:
-		return nil, ing_errors.LocationDenied{
-			Reason: fmt.Errorf("invalid auth-secret-type in annotation, must be 'auth-file' or 'auth-map': %w", err),
-		}
+		err = dumpSecretAuthMap(passFilename, secrets[0])
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	return &Config{
+	cfg := &Config{
 		Type:       at,
 		Realm:      realm,
 		File:       passFilename,
 		Secured:    true,
-		FileSHA:    file.SHA1(passFilename),
-		Secret:     name,
+		FileSHA:    authFileSHA(passFilename, names),
+		Secret:     names[0],
 		SecretType: secretType,
-	}, nil
+		Secrets:    names,
+
+		RealmSecretKey: realmKey,
+	}
+	cfg.Checksum = cfg.ComputeChecksum()
+
+	return cfg, nil
+}
+
+// secretRealm returns the realm contained in a key of the auth secret
+func secretRealm(secret *api.Secret, key string) (string, error) {
+	val, ok := secret.Data[key]
+	if !ok {
+		return "", ing_errors.LocationDenied{
+			Reason: fmt.Errorf("the secret %s/%s does not contain the key %s of auth-realm-secret-key", secret.Namespace, secret.Name, key),
+		}
+	}
+
+	realm := strings.TrimSpace(string(val))
+	if strings.ContainsAny(realm, "\r\n") {
+		return "", ing_errors.LocationDenied{
+			Reason: fmt.Errorf("the realm in the key %s of the secret %s/%s contains line breaks", key, secret.Namespace, secret.Name),
+		}
+	}
+
+	return realm, nil
+}
+
+// secretWithoutKey returns a copy of the secret without the specified key
+func secretWithoutKey(secret *api.Secret, key string) *api.Secret {
+	cp := secret.DeepCopy()
+	delete(cp.Data, key)
+	return cp
+}
+
+// passwdFilename returns the path of the password file built by the
+// FilenameBuilder of the parser, or according to FileNaming without one
+func (a auth) passwdFilename(namespace, name, uid, secretUID string) string {
+	if a.filenameBuilder != nil {
+		return a.filenameBuilder(namespace, uid, secretUID)
+	}
+
+	if FileNaming == FileNamingStable {
+		return fmt.Sprintf("%v/%v-%v.passwd", a.authDirectory, namespace, name)
+	}
+
+	return fmt.Sprintf("%v/%v-%v-%v.passwd", a.authDirectory, namespace, uid, secretUID)
+}
+
+// authFileSHA returns the checksum of the password file and the names of
+// the secrets merged into it
+func authFileSHA(filename string, secrets []string) string {
+	hasher := sha1.New() // #nosec
+	hasher.Write([]byte(file.SHA1(filename)))
+	for _, secret := range secrets {
+		hasher.Write([]byte(secret))
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
+// secretAuthEntries returns the htpasswd entries contained in a secret
+// of the specified type, indexed by username
+func secretAuthEntries(secret *api.Secret, secretType string) (map[string]string, error) {
+	entries := map[string]string{}
+
+	if secretType == mapAuth {
+		for user, pass := range secret.Data {
+			if len(pass) == 0 {
+				warnEmptyPassword(user, secret)
+				continue
+			}
+			entries[user] = fmt.Sprintf("%v:%v", user, string(pass))
+		}
+		if len(entries) == 0 {
+			return nil, emptyAuthMapError(secret)
+		}
+		return entries, nil
+	}
+
+	val, ok := secret.Data["auth"]
+	if !ok {
+		return nil, ing_errors.LocationDenied{
+			Reason: fmt.Errorf("the secret %s does not contain a key with value auth", secret.Name),
+		}
+	}
+
+	if err := validateAuthFile(secret.Name, val); err != nil {
+		return nil, err
+	}
+
+	for _, line := range strings.Split(string(val), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		user := strings.SplitN(line, ":", 2)[0]
+		entries[user] = line
+	}
+
+	return entries, nil
+}
+
+// dumpMergedSecretsAuth merges the entries of several secrets into a single
+// password file sorted by username. When a username is defined in more than
+// one secret the entry of the first secret in the list is used.
+func dumpMergedSecretsAuth(filename string, secrets []*api.Secret, secretType string) error {
+	merged := map[string]string{}
+	for _, secret := range secrets {
+		entries, err := secretAuthEntries(secret, secretType)
+		if err != nil {
+			return err
+		}
+
+		for user, entry := range entries {
+			if _, ok := merged[user]; ok {
+				klog.Warningf("user %q from secret %s/%s is already defined in a previous auth secret, ignoring",
+					user, secret.Namespace, secret.Name)
+				continue
+			}
+			merged[user] = entry
+		}
+	}
+
+	users := make([]string, 0, len(merged))
+	for user := range merged {
+		users = append(users, user)
+	}
+	sort.Strings(users)
+
+	builder := &strings.Builder{}
+	for _, user := range users {
+		builder.WriteString(merged[user])
+		builder.WriteString("\n")
+	}
+
+	err := os.WriteFile(filename, []byte(builder.String()), file.ReadWriteByUser)
+	if err != nil {
+		return ing_errors.LocationDenied{
+			Reason: fmt.Errorf("unexpected error creating password file: %w", err),
+		}
+	}
+
+	return nil
 }
 
-// dumpSecret dumps the content of a secret into a file
+// dumpSecretAuthFile dumps the content of a secret into a file
 // in the expected format for the specified authorization
 func dumpSecretAuthFile(filename string, secret *api.Secret) error {
 	val, ok := secret.Data["auth"]
@@ -261,6 +530,10 @@ func dumpSecretAuthFile(filename string, secret *api.Secret) error {
 		}
 	}
 
+	if err := validateAuthFile(secret.Name, val); err != nil {
+		return err
+	}
+
 	err := os.WriteFile(filename, val, file.ReadWriteByUser)
 	if err != nil {
 		return ing_errors.LocationDenied{
@@ -271,15 +544,88 @@ func dumpSecretAuthFile(filename string, secret *api.Secret) error {
 	return nil
 }
 
+// validateAuthFile checks every entry of the auth key of a secret is a user:hash
+// pair with a hash NGINX can verify, or a user:realm:hash entry of an htdigest
+// file, so plaintext passwords do not silently fail every login
+func validateAuthFile(secretName string, content []byte) error {
+	for i, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if digestEntryRegex.MatchString(line) {
+			continue
+		}
+
+		entry := strings.SplitN(line, ":", 2)
+		if len(entry) != 2 || entry[0] == "" {
+			return ing_errors.LocationDenied{
+				Reason: fmt.Errorf("the line %d of the key auth of the secret %s is not a user:hash entry", i+1, secretName),
+			}
+		}
+
+		if err := validatePasswordHash(entry[1]); err != nil {
+			return ing_errors.LocationDenied{
+				Reason: fmt.Errorf("the line %d of the key auth of the secret %s %w", i+1, secretName, err),
+			}
+		}
+	}
+
+	return nil
+}
+
+// validatePasswordHash returns an error describing why the hash is not a
+// crypt, bcrypt or apr1 hash, or a bcrypt hash with an invalid cost
+func validatePasswordHash(hash string) error {
+	// an empty hash matches no password, NGINX rejects every login of the user
+	if hash == "" {
+		return nil
+	}
+
+	if match := bcryptHashRegex.FindStringSubmatch(hash); match != nil {
+		cost, _ := strconv.Atoi(match[1])
+		if cost < bcryptMinCost || cost > bcryptMaxCost {
+			return fmt.Errorf("has a bcrypt cost of %d, outside of %d-%d", cost, bcryptMinCost, bcryptMaxCost)
+		}
+		return nil
+	}
+
+	for _, regex := range cryptHashRegexes {
+		if regex.MatchString(hash) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("does not contain a crypt, bcrypt or apr1 hash, plaintext passwords are not supported")
+}
+
 func dumpSecretAuthMap(filename string, secret *api.Secret) error {
+	// sort the users so the file, and its checksum, do not change
+	// with the iteration order of the map
+	users := make([]string, 0, len(secret.Data))
+	for user := range secret.Data {
+		users = append(users, user)
+	}
+	sort.Strings(users)
+
 	builder := &strings.Builder{}
-	for user, pass := range secret.Data {
+	for _, user := range users {
+		pass := secret.Data[user]
+		if len(pass) == 0 {
+			warnEmptyPassword(user, secret)
+			continue
+		}
 		builder.WriteString(user)
 		builder.WriteString(":")
 		builder.WriteString(string(pass))
 		builder.WriteString("\n")
 	}
 
+	if builder.Len() == 0 {
+		return emptyAuthMapError(secret)
+	}
+
 	err := os.WriteFile(filename, []byte(builder.String()), file.ReadWriteByUser)
 	if err != nil {
 		return ing_errors.LocationDenied{
@@ -289,3 +635,17 @@ func dumpSecretAuthMap(filename string, secret *api.Secret) error {
 
 	return nil
 }
+
+// warnEmptyPassword logs the users of an auth-map secret ignored because
+// an empty password would let them log in without one
+func warnEmptyPassword(user string, secret *api.Secret) {
+	klog.Warningf("user %q of the auth secret %s/%s has an empty password, ignoring", user, secret.Namespace, secret.Name)
+}
+
+// emptyAuthMapError denies the location when no user of an auth-map secret has
+// a password, rather than writing an empty password file
+func emptyAuthMapError(secret *api.Secret) error {
+	return ing_errors.LocationDenied{
+		Reason: fmt.Errorf("the secret %s does not contain any user with a password", secret.Name),
+	}
+}
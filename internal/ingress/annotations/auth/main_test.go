@@ -17,16 +17,22 @@ limitations under the License.
 package auth
 
 import (
+	"bytes"
 	"fmt"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"errors"
 
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
 	api "k8s.io/api/core/v1"
 	networking "k8s.io/api/networking/v1"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/ingress-nginx/internal/file"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
 	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
@@ -241,6 +247,84 @@ func TestDumpSecretAuthFile(t *testing.T) {
 	}
 }
 
+func TestDumpSecretAuthFileValidation(t *testing.T) {
+	testCases := []struct {
+		name      string
+		content   string
+		expectErr string
+	}{
+		{"apr1", "foo:$apr1$foo$.lPtau/qFW01QB33YrKbV1", ""},
+		{"md5 crypt", "foo:$1$abc$iCQ2D3nhptRYi27fDYv2s1", ""},
+		{"sha256 crypt", "foo:$5$abc$qsg6EHbUzHQzF1POlD7zUwBINSELQxypPaeDcZe6vH0", ""},
+		{"sha512 crypt", "foo:$6$abc$IdWKNKTJEb8LxY7CGg8YBXlvtfZzFw7Mp/r6niK9YB2mdvgY..TKjv1T..8RadRt2qvUHYRLr/TsVArtr91iR1", ""},
+		{"bcrypt", "foo:$2y$12$R9h/cIPz0gi.URNNX3kh2OPST9/PgBkqquzi.Ss7KIUgO2t0jWMUW", ""},
+		{"sha1", "foo:{SHA}5en6G6MezRroT3XKqkdPOmY/BfQ=", ""},
+		{"digest", "foo:realm:5ebe2294ecd0e0f08eab7690d2a6ee69", ""},
+		{"comments and blank lines", "# users\n\nfoo:$apr1$foo$.lPtau/qFW01QB33YrKbV1\n", ""},
+		{"plaintext password", "foo:$apr1$foo$.lPtau/qFW01QB33YrKbV1\nbar:secret", "the line 2 of the key auth of the secret demo-secret does not contain a crypt, bcrypt or apr1 hash"},
+		{"des crypt", "foo:abNANd1rDfiNc", ""},
+		{"empty hash", "foo:", ""},
+		{"plaintext password with a symbol", "foo:pass-word", "the line 1 of the key auth of the secret demo-secret does not contain a crypt, bcrypt or apr1 hash"},
+		{"truncated apr1", "foo:$apr1$foo", "the line 1 of the key auth of the secret demo-secret does not contain a crypt, bcrypt or apr1 hash"},
+		{"bcrypt cost too low", "foo:$2y$03$R9h/cIPz0gi.URNNX3kh2OPST9/PgBkqquzi.Ss7KIUgO2t0jWMUW", "the line 1 of the key auth of the secret demo-secret has a bcrypt cost of 3, outside of 4-31"},
+		{"bcrypt cost too high", "foo:$2y$32$R9h/cIPz0gi.URNNX3kh2OPST9/PgBkqquzi.Ss7KIUgO2t0jWMUW", "the line 1 of the key auth of the secret demo-secret has a bcrypt cost of 32, outside of 4-31"},
+		{"missing hash", "\nfoo", "the line 2 of the key auth of the secret demo-secret is not a user:hash entry"},
+		{"missing user", ":$apr1$foo$.lPtau/qFW01QB33YrKbV1", "the line 1 of the key auth of the secret demo-secret is not a user:hash entry"},
+	}
+
+	tmpfile, dir, s := dummySecretContent(t)
+	defer os.RemoveAll(dir)
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s.Data = map[string][]byte{"auth": []byte(tc.content)}
+
+			err := dumpSecretAuthFile(tmpfile, s)
+			if tc.expectErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			if !ing_errors.IsLocationDenied(err) {
+				t.Fatalf("expected a LocationDenied error but returned %v", err)
+			}
+			if !strings.Contains(err.Error(), tc.expectErr) {
+				t.Errorf("expected error %q but returned %q", tc.expectErr, err.Error())
+			}
+		})
+	}
+}
+
+func TestDumpMergedSecretsAuthFileValidation(t *testing.T) {
+	tmpfile, dir, _ := dummySecretContent(t)
+	defer os.RemoveAll(dir)
+
+	secret := func(name, content string) *api.Secret {
+		return &api.Secret{
+			ObjectMeta: meta_v1.ObjectMeta{Namespace: api.NamespaceDefault, Name: name},
+			Data:       map[string][]byte{"auth": []byte(content)},
+		}
+	}
+
+	valid := secret("valid", "foo:$apr1$foo$.lPtau/qFW01QB33YrKbV1")
+	plaintext := secret("plaintext", "# users\nbar:password")
+
+	err := dumpMergedSecretsAuth(tmpfile, []*api.Secret{valid, plaintext}, fileAuth)
+	if !ing_errors.IsLocationDenied(err) {
+		t.Fatalf("expected a LocationDenied error but returned %v", err)
+	}
+	expected := "the line 2 of the key auth of the secret plaintext does not contain a crypt, bcrypt or apr1 hash"
+	if !strings.Contains(err.Error(), expected) {
+		t.Errorf("expected error %q but returned %q", expected, err.Error())
+	}
+
+	if err := dumpMergedSecretsAuth(tmpfile, []*api.Secret{valid}, fileAuth); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
 func TestDumpSecretAuthMap(t *testing.T) {
 	tmpfile, dir, s := dummySecretContent(t)
 	defer os.RemoveAll(dir)
@@ -250,3 +334,638 @@ func TestDumpSecretAuthMap(t *testing.T) {
 		t.Errorf("Unexpected error creating htpasswd file %v: %v", tmpfile, err)
 	}
 }
+
+func TestAuthMapEmptyPasswords(t *testing.T) {
+	_, dir, _ := dummySecretContent(t)
+	defer os.RemoveAll(dir)
+
+	secret := func(name string, data map[string]string) *api.Secret {
+		s := &api.Secret{
+			ObjectMeta: meta_v1.ObjectMeta{Namespace: api.NamespaceDefault, Name: name, UID: types.UID(name + "-uid")},
+			Data:       map[string][]byte{},
+		}
+		for user, pass := range data {
+			s.Data[user] = []byte(pass)
+		}
+		return s
+	}
+
+	r := mockSecrets{
+		secrets: map[string]*api.Secret{
+			"default/partial": secret("partial", map[string]string{"alice": "$apr1$bob$nPhmj9PatrwHt4BKLMXMx1", "bob": ""}),
+			"default/empty":   secret("empty", map[string]string{"bob": "", "carol": ""}),
+			"default/other":   secret("other", map[string]string{"dave": "$apr1$bob$nPhmj9PatrwHt4BKLMXMx1"}),
+		},
+	}
+
+	parse := map[string]func(secrets string) (interface{}, error){
+		"ingress": func(secrets string) (interface{}, error) {
+			ing := buildIngress()
+			ing.SetAnnotations(map[string]string{
+				parser.GetAnnotationWithPrefix("auth-type"):        "basic",
+				parser.GetAnnotationWithPrefix("auth-secret"):      secrets,
+				parser.GetAnnotationWithPrefix("auth-secret-type"): "auth-map",
+			})
+			return NewParser(dir, r).Parse(ing)
+		},
+		"multiclusteringress": func(secrets string) (interface{}, error) {
+			mci := &karmadanetworking.MultiClusterIngress{
+				ObjectMeta: meta_v1.ObjectMeta{Name: "foo", Namespace: api.NamespaceDefault, UID: "mci-uid"},
+			}
+			mci.SetAnnotations(map[string]string{
+				parser.GetAnnotationWithPrefix("auth-type"):        "basic",
+				parser.GetAnnotationWithPrefix("auth-secret"):      secrets,
+				parser.GetAnnotationWithPrefix("auth-secret-type"): "auth-map",
+			})
+			return NewParser(dir, r).ParseByMCI(mci)
+		},
+	}
+
+	testCases := []struct {
+		title     string
+		secrets   string
+		expected  string
+		expectErr bool
+		mciOnly   bool
+	}{
+		{"users without password are skipped", "partial", "alice:$apr1$bob$nPhmj9PatrwHt4BKLMXMx1\n", false, false},
+		{"no user with a password is denied", "empty", "", true, false},
+		{"merged secrets skip users without password", "partial,other", "alice:$apr1$bob$nPhmj9PatrwHt4BKLMXMx1\ndave:$apr1$bob$nPhmj9PatrwHt4BKLMXMx1\n", false, true},
+		{"merged secret without any password is denied", "other,empty", "", true, true},
+	}
+
+	for name, p := range parse {
+		for _, tc := range testCases {
+			if tc.mciOnly && name != "multiclusteringress" {
+				continue
+			}
+
+			i, err := p(tc.secrets)
+			if tc.expectErr {
+				if !ing_errors.IsLocationDenied(err) {
+					t.Errorf("%v, %v: expected a LocationDenied error but returned %v", name, tc.title, err)
+				}
+				continue
+			}
+			if err != nil {
+				t.Fatalf("%v, %v: unexpected error: %v", name, tc.title, err)
+			}
+
+			content, err := os.ReadFile(i.(*Config).File)
+			if err != nil {
+				t.Fatalf("%v, %v: unexpected error reading password file: %v", name, tc.title, err)
+			}
+			if string(content) != tc.expected {
+				t.Errorf("%v, %v: expected password file %q but returned %q", name, tc.title, tc.expected, string(content))
+			}
+		}
+	}
+}
+
+func TestDumpSecretAuthMapOrder(t *testing.T) {
+	tmpfile, dir, s := dummySecretContent(t)
+	defer os.RemoveAll(dir)
+
+	s.Data = map[string][]byte{}
+	for _, user := range []string{"mallory", "alice", "trent", "bob", "eve", "carol", "dave", "oscar"} {
+		s.Data[user] = []byte("$apr1$bob$nPhmj9PatrwHt4BKLMXMx1")
+	}
+
+	var content []byte
+	var sha string
+	for i := 0; i < 2; i++ {
+		if err := dumpSecretAuthMap(tmpfile, s); err != nil {
+			t.Fatalf("unexpected error creating htpasswd file %v: %v", tmpfile, err)
+		}
+
+		written, err := os.ReadFile(tmpfile)
+		if err != nil {
+			t.Fatalf("unexpected error reading htpasswd file %v: %v", tmpfile, err)
+		}
+
+		if i == 0 {
+			content, sha = written, file.SHA1(tmpfile)
+			continue
+		}
+
+		if !bytes.Equal(written, content) {
+			t.Errorf("expected identical password files but returned %q and %q", string(content), string(written))
+		}
+		if written := file.SHA1(tmpfile); written != sha {
+			t.Errorf("expected identical file checksums but returned %v and %v", sha, written)
+		}
+	}
+
+	if !strings.HasPrefix(string(content), "alice:") || !strings.HasSuffix(string(content), "trent:$apr1$bob$nPhmj9PatrwHt4BKLMXMx1\n") {
+		t.Errorf("expected the users sorted by name but returned %q", string(content))
+	}
+
+	ing := buildIngress()
+	ing.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix("auth-type"):        "basic",
+		parser.GetAnnotationWithPrefix("auth-secret"):      "users",
+		parser.GetAnnotationWithPrefix("auth-secret-type"): "auth-map",
+	})
+	r := mockSecrets{secrets: map[string]*api.Secret{"default/users": s}}
+
+	var checksums []string
+	for i := 0; i < 2; i++ {
+		cfg, err := NewParser(dir, r).Parse(ing)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		checksums = append(checksums, cfg.(*Config).FileSHA)
+	}
+	if checksums[0] != checksums[1] {
+		t.Errorf("expected identical FileSHA but returned %v and %v", checksums[0], checksums[1])
+	}
+}
+
+type mockSecrets struct {
+	resolver.Mock
+	secrets map[string]*api.Secret
+}
+
+func (m mockSecrets) GetSecret(name string) (*api.Secret, error) {
+	secret, ok := m.secrets[name]
+	if !ok {
+		return nil, fmt.Errorf("there is no secret with name %v", name)
+	}
+
+	return secret, nil
+}
+
+func TestMCIAuthMultipleSecrets(t *testing.T) {
+	mci := &karmadanetworking.MultiClusterIngress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+			UID:       "mci-uid",
+		},
+	}
+	mci.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix("auth-type"):   "basic",
+		parser.GetAnnotationWithPrefix("auth-secret"): "team-b, other/team-a",
+	})
+
+	r := mockSecrets{
+		secrets: map[string]*api.Secret{
+			"default/team-b": {
+				ObjectMeta: meta_v1.ObjectMeta{Namespace: api.NamespaceDefault, Name: "team-b", UID: "b-uid"},
+				Data:       map[string][]byte{"auth": []byte("zoe:$apr1$zoe$nPhmj9PatrwHt4BKLMXMx1\nbob:$apr1$bobb$nPhmj9PatrwHt4BKLMXMx1\n")},
+			},
+			"other/team-a": {
+				ObjectMeta: meta_v1.ObjectMeta{Namespace: "other", Name: "team-a", UID: "a-uid"},
+				Data:       map[string][]byte{"auth": []byte("# team a\nbob:$apr1$boba$nPhmj9PatrwHt4BKLMXMx1\nalice:$apr1$alice$nPhmj9PatrwHt4BKLMXMx1\n")},
+			},
+		},
+	}
+
+	_, dir, _ := dummySecretContent(t)
+	defer os.RemoveAll(dir)
+
+	i, err := NewParser(dir, r).ParseByMCI(mci)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	auth, ok := i.(*Config)
+	if !ok {
+		t.Fatalf("expected a Config type")
+	}
+
+	expectedSecrets := []string{"default/team-b", "other/team-a"}
+	if !reflect.DeepEqual(auth.Secrets, expectedSecrets) {
+		t.Errorf("expected secrets %v but returned %v", expectedSecrets, auth.Secrets)
+	}
+
+	if auth.Secret != "default/team-b" {
+		t.Errorf("expected secret default/team-b but returned %v", auth.Secret)
+	}
+
+	expectedFile := fmt.Sprintf("%v/default-mci-uid-b-uid-a-uid.passwd", dir)
+	if auth.File != expectedFile {
+		t.Errorf("expected file %v but returned %v", expectedFile, auth.File)
+	}
+
+	content, err := os.ReadFile(auth.File)
+	if err != nil {
+		t.Fatalf("unexpected error reading password file: %v", err)
+	}
+
+	expected := "alice:$apr1$alice$nPhmj9PatrwHt4BKLMXMx1\nbob:$apr1$bobb$nPhmj9PatrwHt4BKLMXMx1\nzoe:$apr1$zoe$nPhmj9PatrwHt4BKLMXMx1\n"
+	if string(content) != expected {
+		t.Errorf("expected password file %q but returned %q", expected, string(content))
+	}
+
+	other := *auth
+	other.Secrets = []string{"other/team-a", "default/team-b"}
+	if auth.Equal(&other) {
+		t.Errorf("expected configurations with different secrets to differ")
+	}
+
+	if auth.FileSHA == authFileSHA(auth.File, other.Secrets) {
+		t.Errorf("expected file checksum to depend on the secrets")
+	}
+}
+
+func TestMCIAuthRealmSecretKey(t *testing.T) {
+	r := mockSecrets{
+		secrets: map[string]*api.Secret{
+			"default/file": {
+				ObjectMeta: meta_v1.ObjectMeta{Namespace: api.NamespaceDefault, Name: "file", UID: "file-uid"},
+				Data: map[string][]byte{
+					"auth":  []byte("foo:$apr1$foo$.lPtau/qFW01QB33YrKbV1\n"),
+					"realm": []byte("Restricted area\n"),
+				},
+			},
+			"default/map": {
+				ObjectMeta: meta_v1.ObjectMeta{Namespace: api.NamespaceDefault, Name: "map", UID: "map-uid"},
+				Data: map[string][]byte{
+					"foo":   []byte("$apr1$foo"),
+					"realm": []byte("Restricted area"),
+				},
+			},
+			"default/multiline": {
+				ObjectMeta: meta_v1.ObjectMeta{Namespace: api.NamespaceDefault, Name: "multiline", UID: "multiline-uid"},
+				Data: map[string][]byte{
+					"auth":  []byte("foo:$apr1$foo\n"),
+					"realm": []byte("Restricted\narea"),
+				},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		realm       string
+		passwd      string
+		expectErr   bool
+	}{
+		{"realm from annotation", map[string]string{"auth-secret": "file", "auth-realm": "From annotation"}, "From annotation", "foo:$apr1$foo$.lPtau/qFW01QB33YrKbV1\n", false},
+		{"realm from auth file secret", map[string]string{"auth-secret": "file", "auth-realm": "From annotation", "auth-realm-secret-key": "realm"}, "Restricted area", "foo:$apr1$foo$.lPtau/qFW01QB33YrKbV1\n", false},
+		{"realm from auth map secret", map[string]string{"auth-secret": "map", "auth-secret-type": "auth-map", "auth-realm-secret-key": "realm"}, "Restricted area", "foo:$apr1$foo\n", false},
+		{"missing realm key", map[string]string{"auth-secret": "file", "auth-realm-secret-key": "missing"}, "", "", true},
+		{"realm with line breaks", map[string]string{"auth-secret": "multiline", "auth-realm-secret-key": "realm"}, "", "", true},
+	}
+
+	_, dir, _ := dummySecretContent(t)
+	defer os.RemoveAll(dir)
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mci := &karmadanetworking.MultiClusterIngress{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      "foo",
+					Namespace: api.NamespaceDefault,
+					UID:       "mci-uid",
+				},
+			}
+			annotations := map[string]string{parser.GetAnnotationWithPrefix("auth-type"): "basic"}
+			for name, value := range tc.annotations {
+				annotations[parser.GetAnnotationWithPrefix(name)] = value
+			}
+			mci.SetAnnotations(annotations)
+
+			i, err := NewParser(dir, r).ParseByMCI(mci)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("expected an error but none returned")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			auth, ok := i.(*Config)
+			if !ok {
+				t.Fatalf("expected a Config type")
+			}
+
+			if auth.Realm != tc.realm {
+				t.Errorf("expected realm %q but returned %q", tc.realm, auth.Realm)
+			}
+
+			other := *auth
+			other.RealmSecretKey = "other"
+			if auth.Equal(&other) {
+				t.Errorf("expected configurations with different realm sources to differ")
+			}
+
+			content, err := os.ReadFile(auth.File)
+			if err != nil {
+				t.Fatalf("unexpected error reading password file: %v", err)
+			}
+			if string(content) != tc.passwd {
+				t.Errorf("expected password file %q but returned %q", tc.passwd, string(content))
+			}
+		})
+	}
+}
+
+func TestMCIAuthFileNaming(t *testing.T) {
+	defer func() { FileNaming = FileNamingUID }()
+
+	mci := &karmadanetworking.MultiClusterIngress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+			UID:       "mci-uid",
+		},
+	}
+	mci.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix("auth-type"):   "basic",
+		parser.GetAnnotationWithPrefix("auth-secret"): "team",
+	})
+
+	secret := func(uid, content string) mockSecrets {
+		return mockSecrets{
+			secrets: map[string]*api.Secret{
+				"default/team": {
+					ObjectMeta: meta_v1.ObjectMeta{Namespace: api.NamespaceDefault, Name: "team", UID: types.UID(uid)},
+					Data:       map[string][]byte{"auth": []byte(content)},
+				},
+			},
+		}
+	}
+
+	_, dir, _ := dummySecretContent(t)
+	defer os.RemoveAll(dir)
+
+	testCases := []struct {
+		naming       string
+		expectedFile string
+		rotatedFile  string
+	}{
+		{FileNamingUID, "default-mci-uid-team-uid.passwd", "default-mci-uid-rotated-uid.passwd"},
+		{FileNamingStable, "default-foo.passwd", "default-foo.passwd"},
+	}
+
+	for _, tc := range testCases {
+		FileNaming = tc.naming
+
+		i, err := NewParser(dir, secret("team-uid", "bob:$apr1$bob$nPhmj9PatrwHt4BKLMXMx1\n")).ParseByMCI(mci)
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %v", tc.naming, err)
+		}
+		before := i.(*Config)
+
+		if before.File != fmt.Sprintf("%v/%v", dir, tc.expectedFile) {
+			t.Errorf("%v: expected file %v but returned %v", tc.naming, tc.expectedFile, before.File)
+		}
+
+		i, err = NewParser(dir, secret("rotated-uid", "bob:$apr1$rotated$7oIrgnLB8dYcDC5t.e4Xw0\n")).ParseByMCI(mci)
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %v", tc.naming, err)
+		}
+		after := i.(*Config)
+
+		if after.File != fmt.Sprintf("%v/%v", dir, tc.rotatedFile) {
+			t.Errorf("%v: expected file %v after rotation but returned %v", tc.naming, tc.rotatedFile, after.File)
+		}
+
+		if before.FileSHA == after.FileSHA {
+			t.Errorf("%v: expected file checksum to change after rotation", tc.naming)
+		}
+
+		if before.Equal(after) {
+			t.Errorf("%v: expected configurations to differ after rotation", tc.naming)
+		}
+
+		content, err := os.ReadFile(after.File)
+		if err != nil {
+			t.Fatalf("%v: unexpected error reading password file: %v", tc.naming, err)
+		}
+		if string(content) != "bob:$apr1$rotated$7oIrgnLB8dYcDC5t.e4Xw0\n" {
+			t.Errorf("%v: expected rotated password file but returned %q", tc.naming, string(content))
+		}
+	}
+}
+
+func TestAuthFileNaming(t *testing.T) {
+	defer func() { FileNaming = FileNamingUID }()
+
+	ing := buildIngress()
+	ing.SetUID("ing-uid")
+	ing.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix("auth-type"):   "basic",
+		parser.GetAnnotationWithPrefix("auth-secret"): "demo-secret",
+	})
+
+	_, dir, _ := dummySecretContent(t)
+	defer os.RemoveAll(dir)
+
+	FileNaming = FileNamingStable
+	i, err := NewParser(dir, &mockSecret{}).Parse(ing)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := fmt.Sprintf("%v/%v-%v.passwd", dir, ing.GetNamespace(), ing.GetName())
+	if file := i.(*Config).File; file != expected {
+		t.Errorf("expected file %v but returned %v", expected, file)
+	}
+}
+
+func TestAuthFilenameBuilder(t *testing.T) {
+	_, dir, _ := dummySecretContent(t)
+	defer os.RemoveAll(dir)
+
+	builder := func(namespace, uid, secretUID string) string {
+		return fmt.Sprintf("%v/cluster-a-%v-%v-%v.passwd", dir, namespace, uid, secretUID)
+	}
+
+	ing := buildIngress()
+	ing.SetUID("ing-uid")
+	ing.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix("auth-type"):   "basic",
+		parser.GetAnnotationWithPrefix("auth-secret"): "demo-secret",
+	})
+
+	i, err := NewParser(dir, &mockSecret{}, builder).Parse(ing)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := fmt.Sprintf("%v/cluster-a-%v-ing-uid-.passwd", dir, ing.GetNamespace())
+	if file := i.(*Config).File; file != expected {
+		t.Errorf("expected file %v but returned %v", expected, file)
+	}
+
+	mci := &karmadanetworking.MultiClusterIngress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+			UID:       "mci-uid",
+		},
+	}
+	mci.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix("auth-type"):   "basic",
+		parser.GetAnnotationWithPrefix("auth-secret"): "team-a,team-b",
+	})
+
+	secret := func(name, content string) *api.Secret {
+		return &api.Secret{
+			ObjectMeta: meta_v1.ObjectMeta{Namespace: api.NamespaceDefault, Name: name, UID: types.UID(name + "-uid")},
+			Data:       map[string][]byte{"auth": []byte(content)},
+		}
+	}
+	r := mockSecrets{
+		secrets: map[string]*api.Secret{
+			"default/team-a": secret("team-a", "alice:$apr1$bob$nPhmj9PatrwHt4BKLMXMx1\n"),
+			"default/team-b": secret("team-b", "bob:$apr1$bob$nPhmj9PatrwHt4BKLMXMx1\n"),
+		},
+	}
+
+	i, err = NewParser(dir, r, builder).ParseByMCI(mci)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected = fmt.Sprintf("%v/cluster-a-default-mci-uid-team-a-uid-team-b-uid.passwd", dir)
+	if file := i.(*Config).File; file != expected {
+		t.Errorf("expected file %v but returned %v", expected, file)
+	}
+	if _, err := os.Stat(expected); err != nil {
+		t.Errorf("expected the password file to be written: %v", err)
+	}
+}
+
+func TestAuthFilenameBuilderNamesEveryFile(t *testing.T) {
+	defer func() { FileNaming = FileNamingUID }()
+
+	_, dir, _ := dummySecretContent(t)
+	defer os.RemoveAll(dir)
+
+	builder := func(namespace, uid, secretUID string) string {
+		return fmt.Sprintf("%v/cluster-a-%v-%v-%v.passwd", dir, namespace, uid, secretUID)
+	}
+
+	secret := func(name string, data map[string]string) *api.Secret {
+		s := &api.Secret{
+			ObjectMeta: meta_v1.ObjectMeta{Namespace: api.NamespaceDefault, Name: name, UID: types.UID(name + "-uid")},
+			Data:       map[string][]byte{},
+		}
+		for k, v := range data {
+			s.Data[k] = []byte(v)
+		}
+		return s
+	}
+	r := mockSecrets{
+		secrets: map[string]*api.Secret{
+			"default/file":  secret("file", map[string]string{"auth": "alice:$apr1$bob$nPhmj9PatrwHt4BKLMXMx1", "realm": "Files"}),
+			"default/map":   secret("map", map[string]string{"bob": "$apr1$bob$nPhmj9PatrwHt4BKLMXMx1"}),
+			"default/other": secret("other", map[string]string{"auth": "carol:$apr1$bob$nPhmj9PatrwHt4BKLMXMx1"}),
+		},
+	}
+
+	testCases := []struct {
+		title       string
+		naming      string
+		annotations map[string]string
+	}{
+		{"auth-file", FileNamingUID, map[string]string{"auth-secret": "file"}},
+		{"auth-map", FileNamingUID, map[string]string{"auth-secret": "map", "auth-secret-type": "auth-map"}},
+		{"merged secrets", FileNamingUID, map[string]string{"auth-secret": "file,other"}},
+		{"realm from the secret", FileNamingUID, map[string]string{"auth-secret": "file", "auth-realm-secret-key": "realm"}},
+		{"stable file naming", FileNamingStable, map[string]string{"auth-secret": "file"}},
+	}
+
+	for _, tc := range testCases {
+		FileNaming = tc.naming
+
+		annotations := map[string]string{parser.GetAnnotationWithPrefix("auth-type"): "basic"}
+		for k, v := range tc.annotations {
+			annotations[parser.GetAnnotationWithPrefix(k)] = v
+		}
+
+		mci := &karmadanetworking.MultiClusterIngress{
+			ObjectMeta: meta_v1.ObjectMeta{Name: "foo", Namespace: api.NamespaceDefault, UID: "mci-uid"},
+		}
+		mci.SetAnnotations(annotations)
+
+		if _, err := NewParser(dir, r, builder).ParseByMCI(mci); err != nil {
+			t.Fatalf("%v: unexpected error: %v", tc.title, err)
+		}
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("unexpected error reading %v: %v", dir, err)
+	}
+	if len(files) == 0 {
+		t.Fatalf("expected the parser to write files in %v", dir)
+	}
+	for _, f := range files {
+		if !strings.HasPrefix(f.Name(), "cluster-a-") {
+			t.Errorf("expected every file to be named by the builder but found %v", f.Name())
+		}
+	}
+}
+
+func TestAuthChecksum(t *testing.T) {
+	_, dir, _ := dummySecretContent(t)
+	defer os.RemoveAll(dir)
+
+	ing := buildIngress()
+	mci := &karmadanetworking.MultiClusterIngress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+	}
+
+	parsers := map[string]func(annotations map[string]string) (interface{}, error){
+		"ingress": func(annotations map[string]string) (interface{}, error) {
+			ing.SetAnnotations(annotations)
+			return NewParser(dir, &mockSecret{}).Parse(ing)
+		},
+		"multiclusteringress": func(annotations map[string]string) (interface{}, error) {
+			mci.SetAnnotations(annotations)
+			return NewParser(dir, &mockSecret{}).ParseByMCI(mci)
+		},
+	}
+
+	for name, parse := range parsers {
+		annotations := func(authType, realm string) map[string]string {
+			return map[string]string{
+				parser.GetAnnotationWithPrefix("auth-type"):   authType,
+				parser.GetAnnotationWithPrefix("auth-secret"): "demo-secret",
+				parser.GetAnnotationWithPrefix("auth-realm"):  realm,
+			}
+		}
+
+		configs := []*Config{}
+		for _, anns := range []map[string]string{
+			annotations("basic", "-realm-"),
+			annotations("basic", "-realm-"),
+			annotations("basic", "-other-realm-"),
+			annotations("digest", "-realm-"),
+		} {
+			i, err := parse(anns)
+			if err != nil {
+				t.Fatalf("%v: unexpected error: %v", name, err)
+			}
+			configs = append(configs, i.(*Config))
+		}
+
+		base := configs[0]
+		if base.Checksum == "" || base.Checksum != base.ComputeChecksum() {
+			t.Errorf("%v: expected the checksum %v but returned %q", name, base.ComputeChecksum(), base.Checksum)
+		}
+		if configs[1].Checksum != base.Checksum {
+			t.Errorf("%v: expected the same checksum for the same annotations", name)
+		}
+
+		for _, changed := range configs[2:] {
+			if changed.FileSHA != base.FileSHA {
+				t.Errorf("%v: expected the file checksum to ignore the type and the realm", name)
+			}
+			if changed.Checksum == base.Checksum {
+				t.Errorf("%v: expected the checksum to change with type %v and realm %v", name, changed.Type, changed.Realm)
+			}
+		}
+	}
+}
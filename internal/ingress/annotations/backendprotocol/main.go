@@ -28,13 +28,24 @@ import (
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 )
 
-// HTTP protocol
-const HTTP = "HTTP"
+const (
+	// HTTP protocol
+	HTTP = "HTTP"
+	// WS protocol, WebSocket over HTTP
+	WS = "WS"
+	// WSS protocol, WebSocket over HTTPS
+	WSS = "WSS"
+)
 
 var (
-	validProtocols = regexp.MustCompile(`^(AUTO_HTTP|HTTP|HTTPS|AJP|GRPC|GRPCS|FCGI)$`)
+	validProtocols = regexp.MustCompile(`^(AUTO_HTTP|HTTP|HTTPS|AJP|GRPC|GRPCS|FCGI|WS|WSS)$`)
 )
 
+// IsWebSocket returns true if the backend protocol proxies WebSocket connections
+func IsWebSocket(proto string) bool {
+	return proto == WS || proto == WSS
+}
+
 type backendProtocol struct {
 	r resolver.Resolver
 }
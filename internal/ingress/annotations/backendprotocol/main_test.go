@@ -112,3 +112,29 @@ func TestParseAnnotations(t *testing.T) {
 		t.Errorf("expected HTTPS but %v returned", val)
 	}
 }
+
+func TestParseWebSocketAnnotations(t *testing.T) {
+	ing := buildIngress()
+
+	for _, proto := range []string{"ws", "WSS"} {
+		ing.SetAnnotations(map[string]string{
+			parser.GetAnnotationWithPrefix("backend-protocol"): proto,
+		})
+
+		i, err := NewParser(&resolver.Mock{}).Parse(ing)
+		if err != nil {
+			t.Errorf("unexpected error parsing ingress with backend-protocol %v", proto)
+		}
+		val, ok := i.(string)
+		if !ok {
+			t.Errorf("expected a string type")
+		}
+		if !IsWebSocket(val) {
+			t.Errorf("expected a WebSocket protocol but %v returned", val)
+		}
+	}
+
+	if IsWebSocket(HTTP) {
+		t.Errorf("expected %v not to be a WebSocket protocol", HTTP)
+	}
+}
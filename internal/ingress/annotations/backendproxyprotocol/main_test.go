@@ -0,0 +1,136 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backendproxyprotocol
+
+import (
+	"testing"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	api "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+func buildIngress() *networking.Ingress {
+	return &networking.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "passthrough",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: networking.IngressSpec{
+			DefaultBackend: &networking.IngressBackend{
+				Service: &networking.IngressServiceBackend{
+					Name: "tls-backend",
+					Port: networking.ServiceBackendPort{
+						Number: 443,
+					},
+				},
+			},
+			TLS: []networking.IngressTLS{
+				{
+					Hosts: []string{"tls.example.com"},
+				},
+			},
+		},
+	}
+}
+
+func TestParseAnnotations(t *testing.T) {
+	ing := buildIngress()
+
+	_, err := NewParser(&resolver.Mock{}).Parse(ing)
+	if !errors.IsMissingAnnotations(err) {
+		t.Errorf("expected a missing annotation error but returned %v", err)
+	}
+
+	// accepting the PROXY protocol from the clients does not send it to the upstreams
+	ing.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix("use-proxy-protocol"): "true",
+	})
+	_, err = NewParser(&resolver.Mock{}).Parse(ing)
+	if !errors.IsMissingAnnotations(err) {
+		t.Errorf("expected a missing annotation error with use-proxy-protocol only but returned %v", err)
+	}
+
+	// test with the SSL passthrough servers, which forward the PROXY protocol header
+	ing.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix("backend-proxy-protocol"): "TRUE",
+		parser.GetAnnotationWithPrefix("ssl-passthrough"):        "true",
+	})
+	i, err := NewParser(&resolver.Mock{}).Parse(ing)
+	if err != nil {
+		t.Errorf("unexpected error parsing ingress with backend-proxy-protocol: %v", err)
+	}
+	val, ok := i.(bool)
+	if !ok {
+		t.Errorf("expected a bool type")
+	}
+	if !val {
+		t.Errorf("expected true but false returned")
+	}
+
+	// the version of the protocol is not configurable
+	ing.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix("backend-proxy-protocol"): "v2",
+	})
+	_, err = NewParser(&resolver.Mock{}).Parse(ing)
+	if !errors.IsInvalidContent(err) {
+		t.Errorf("expected an invalid content error for a protocol version but returned %v", err)
+	}
+}
+
+func TestParseAnnotationsByMCI(t *testing.T) {
+	mci := &karmadanetworking.MultiClusterIngress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "passthrough",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: buildIngress().Spec,
+	}
+
+	_, err := NewParser(&resolver.Mock{}).ParseByMCI(mci)
+	if !errors.IsMissingAnnotations(err) {
+		t.Errorf("expected a missing annotation error but returned %v", err)
+	}
+
+	mci.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix("backend-proxy-protocol"): "true",
+		parser.GetAnnotationWithPrefix("ssl-passthrough"):        "true",
+	})
+	i, err := NewParser(&resolver.Mock{}).ParseByMCI(mci)
+	if err != nil {
+		t.Errorf("unexpected error parsing multiclusteringress with backend-proxy-protocol: %v", err)
+	}
+	if val, _ := i.(bool); !val {
+		t.Errorf("expected true but false returned")
+	}
+
+	mci.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix("backend-proxy-protocol"): "false",
+	})
+	i, err = NewParser(&resolver.Mock{}).ParseByMCI(mci)
+	if err != nil {
+		t.Errorf("unexpected error parsing multiclusteringress with backend-proxy-protocol: %v", err)
+	}
+	if val, _ := i.(bool); val {
+		t.Errorf("expected false but true returned")
+	}
+}
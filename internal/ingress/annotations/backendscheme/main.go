@@ -0,0 +1,76 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backendscheme
+
+import (
+	"regexp"
+	"strings"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	networking "k8s.io/api/networking/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+var validSchemes = regexp.MustCompile(`^(http|https|grpc|grpcs)$`)
+
+// IsGRPC returns true if the scheme proxies the requests with grpc_pass
+func IsGRPC(scheme string) bool {
+	return scheme == "grpc" || scheme == "grpcs"
+}
+
+type backendScheme struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new backend scheme annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return backendScheme{r}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to override the scheme of the upstream
+func (b backendScheme) Parse(ing *networking.Ingress) (interface{}, error) {
+	scheme, err := parser.GetStringAnnotation("backend-scheme", ing)
+	if err != nil {
+		return "", err
+	}
+
+	return validate(scheme)
+}
+
+// ParseByMCI parses the annotations contained in the multiclusteringress rule
+// used to override the scheme of the upstream
+func (b backendScheme) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interface{}, error) {
+	scheme, err := parser.GetStringAnnotationFromMCI("backend-scheme", mci)
+	if err != nil {
+		return "", err
+	}
+
+	return validate(scheme)
+}
+
+func validate(scheme string) (string, error) {
+	scheme = strings.TrimSpace(strings.ToLower(scheme))
+	if !validSchemes.MatchString(scheme) {
+		return "", ing_errors.NewInvalidAnnotationContent("backend-scheme", scheme)
+	}
+
+	return scheme, nil
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backendscheme
+
+import (
+	"testing"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	api "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+func TestParseByMCI(t *testing.T) {
+	annotation := parser.GetAnnotationWithPrefix("backend-scheme")
+
+	ap := NewParser(&resolver.Mock{})
+	if ap == nil {
+		t.Fatalf("expected a parser.IngressAnnotation but returned nil")
+	}
+
+	testCases := []struct {
+		annotations map[string]string
+		expected    string
+		expectErr   bool
+	}{
+		{nil, "", true},
+		{map[string]string{annotation: "http"}, "http", false},
+		{map[string]string{annotation: "https"}, "https", false},
+		{map[string]string{annotation: "grpc"}, "grpc", false},
+		{map[string]string{annotation: "grpcs"}, "grpcs", false},
+		{map[string]string{annotation: " HTTPS "}, "https", false},
+		{map[string]string{annotation: "ajp"}, "", true},
+		{map[string]string{annotation: "https://"}, "", true},
+		{map[string]string{annotation: ""}, "", true},
+	}
+
+	mci := &karmadanetworking.MultiClusterIngress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+	}
+
+	for _, testCase := range testCases {
+		mci.SetAnnotations(testCase.annotations)
+		i, err := ap.ParseByMCI(mci)
+		if testCase.expectErr != (err != nil) {
+			t.Errorf("expected error %v but returned %v, annotations: %s", testCase.expectErr, err, testCase.annotations)
+		}
+
+		if i != testCase.expected {
+			t.Errorf("expected %q but returned %q, annotations: %s", testCase.expected, i, testCase.annotations)
+		}
+	}
+}
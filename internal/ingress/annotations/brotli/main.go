@@ -0,0 +1,145 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package brotli
+
+import (
+	"strings"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	networking "k8s.io/api/networking/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+const (
+	// DefaultLevel indicates the compression level configured
+	// in the global brotli-level setting should be used
+	DefaultLevel = -1
+
+	minLevel = 0
+	maxLevel = 11
+)
+
+// Config contains the brotli compression configuration for a location
+type Config struct {
+	Enabled bool `json:"enabled"`
+	// Types is a space separated list of MIME types to compress.
+	// When empty the global brotli-types setting is used
+	Types string `json:"types,omitempty"`
+	// Level is the compression level, between 0 and 11.
+	// DefaultLevel uses the global brotli-level setting
+	Level int `json:"level"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if c1.Enabled != c2.Enabled {
+		return false
+	}
+	if c1.Types != c2.Types {
+		return false
+	}
+	if c1.Level != c2.Level {
+		return false
+	}
+
+	return true
+}
+
+type brotli struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new brotli annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return brotli{r}
+}
+
+// Parse parses the annotations contained in the ingress
+// rule used to configure brotli compression for a location
+func (b brotli) Parse(ing *networking.Ingress) (interface{}, error) {
+	enabled, err := parser.GetBoolAnnotation("enable-brotli", ing)
+	if err != nil || !enabled {
+		return &Config{}, nil
+	}
+
+	types, err := parser.GetStringAnnotation("brotli-types", ing)
+	if err != nil && !ing_errors.IsMissingAnnotations(err) {
+		return &Config{}, err
+	}
+
+	level, err := parser.GetIntAnnotation("brotli-level", ing)
+	if err != nil {
+		if !ing_errors.IsMissingAnnotations(err) {
+			return &Config{}, err
+		}
+		level = DefaultLevel
+	} else if level < minLevel || level > maxLevel {
+		return &Config{}, ing_errors.NewInvalidAnnotationContent("brotli-level", level)
+	}
+
+	return newConfig(types, level)
+}
+
+// ParseByMCI parses the annotations contained in the multiclusteringress
+// rule used to configure brotli compression for a location
+func (b brotli) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interface{}, error) {
+	enabled, err := parser.GetBoolAnnotationFromMCI("enable-brotli", mci)
+	if err != nil || !enabled {
+		return &Config{}, nil
+	}
+
+	types, err := parser.GetStringAnnotationFromMCI("brotli-types", mci)
+	if err != nil && !ing_errors.IsMissingAnnotations(err) {
+		return &Config{}, err
+	}
+
+	level, err := parser.GetIntAnnotationFromMCI("brotli-level", mci)
+	if err != nil {
+		if !ing_errors.IsMissingAnnotations(err) {
+			return &Config{}, err
+		}
+		level = DefaultLevel
+	} else if level < minLevel || level > maxLevel {
+		return &Config{}, ing_errors.NewInvalidAnnotationContent("brotli-level", level)
+	}
+
+	return newConfig(types, level)
+}
+
+func newConfig(types string, level int) (*Config, error) {
+	mimeTypes := strings.Fields(types)
+	for _, mimeType := range mimeTypes {
+		if !parser.IsValidMIMEType(mimeType) {
+			return &Config{}, ing_errors.NewInvalidAnnotationContent("brotli-types", types)
+		}
+	}
+
+	return &Config{
+		Enabled: true,
+		Types:   strings.Join(mimeTypes, " "),
+		Level:   level,
+	}, nil
+}
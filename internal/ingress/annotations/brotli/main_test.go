@@ -0,0 +1,76 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package brotli
+
+import (
+	"testing"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	api "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+func TestParseByMCI(t *testing.T) {
+	enable := parser.GetAnnotationWithPrefix("enable-brotli")
+	types := parser.GetAnnotationWithPrefix("brotli-types")
+	level := parser.GetAnnotationWithPrefix("brotli-level")
+
+	ap := NewParser(&resolver.Mock{})
+	if ap == nil {
+		t.Fatalf("expected a parser.IngressAnnotation but returned nil")
+	}
+
+	testCases := []struct {
+		annotations map[string]string
+		expected    *Config
+		expectErr   bool
+	}{
+		{nil, &Config{}, false},
+		{map[string]string{enable: "false", level: "5"}, &Config{}, false},
+		{map[string]string{enable: "true"}, &Config{Enabled: true, Level: DefaultLevel}, false},
+		{map[string]string{enable: "true", level: "0"}, &Config{Enabled: true, Level: 0}, false},
+		{map[string]string{enable: "true", level: "11", types: "text/css application/json"}, &Config{Enabled: true, Types: "text/css application/json", Level: 11}, false},
+		{map[string]string{enable: "true", level: "-1"}, &Config{}, true},
+		{map[string]string{enable: "true", level: "12"}, &Config{}, true},
+		{map[string]string{enable: "true", level: "max"}, &Config{}, true},
+		{map[string]string{enable: "true", types: "text/css;"}, &Config{}, true},
+		{map[string]string{enable: "true", types: "/json"}, &Config{}, true},
+	}
+
+	mci := &karmadanetworking.MultiClusterIngress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+	}
+
+	for _, testCase := range testCases {
+		mci.SetAnnotations(testCase.annotations)
+		i, err := ap.ParseByMCI(mci)
+		if testCase.expectErr != (err != nil) {
+			t.Errorf("expected error %v but returned %v, annotations: %s", testCase.expectErr, err, testCase.annotations)
+		}
+
+		p, _ := i.(*Config)
+		if !p.Equal(testCase.expected) {
+			t.Errorf("expected %v but returned %v, annotations: %s", testCase.expected, p, testCase.annotations)
+		}
+	}
+}
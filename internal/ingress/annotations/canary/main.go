@@ -25,6 +25,13 @@ import (
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 )
 
+const (
+	// PriorityHeader evaluates the header and cookie rules before the weight
+	PriorityHeader = "header"
+	// PriorityWeight evaluates the weight before the header and cookie rules
+	PriorityWeight = "weight"
+)
+
 type canary struct {
 	r resolver.Resolver
 }
@@ -38,6 +45,7 @@ type Config struct {
 	HeaderValue   string
 	HeaderPattern string
 	Cookie        string
+	Priority      string
 }
 
 // NewParser parses the ingress for canary related annotations
@@ -86,6 +94,15 @@ func (c canary) Parse(ing *networking.Ingress) (interface{}, error) {
 		config.Cookie = ""
 	}
 
+	config.Priority, err = parser.GetStringAnnotation("canary-priority", ing)
+	if err != nil {
+		config.Priority = PriorityHeader
+	}
+
+	if config.Priority != PriorityHeader && config.Priority != PriorityWeight {
+		return nil, errors.NewInvalidAnnotationContent("canary-priority", config.Priority)
+	}
+
 	if !config.Enabled && (config.Weight > 0 || len(config.Header) > 0 || len(config.HeaderValue) > 0 || len(config.Cookie) > 0 ||
 		len(config.HeaderPattern) > 0) {
 		return nil, errors.NewInvalidAnnotationConfiguration("canary", "configured but not enabled")
@@ -135,6 +152,15 @@ func (c canary) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interfac
 		config.Cookie = ""
 	}
 
+	config.Priority, err = parser.GetStringAnnotationFromMCI("canary-priority", mci)
+	if err != nil {
+		config.Priority = PriorityHeader
+	}
+
+	if config.Priority != PriorityHeader && config.Priority != PriorityWeight {
+		return nil, errors.NewInvalidAnnotationContent("canary-priority", config.Priority)
+	}
+
 	if !config.Enabled && (config.Weight > 0 || len(config.Header) > 0 || len(config.HeaderValue) > 0 || len(config.Cookie) > 0 ||
 		len(config.HeaderPattern) > 0) {
 		return nil, errors.NewInvalidAnnotationConfiguration("canary", "configured but not enabled")
@@ -19,6 +19,7 @@ package canary
 import (
 	"testing"
 
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
 	api "k8s.io/api/core/v1"
 	networking "k8s.io/api/networking/v1"
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -157,3 +158,51 @@ func TestAnnotations(t *testing.T) {
 		}
 	}
 }
+
+func TestMCICanaryPriority(t *testing.T) {
+	mci := &karmadanetworking.MultiClusterIngress{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+	}
+
+	tests := []struct {
+		title    string
+		priority string
+		expected string
+		expErr   bool
+	}{
+		{"default priority", "", PriorityHeader, false},
+		{"header priority", "header", PriorityHeader, false},
+		{"weight priority", "weight", PriorityWeight, false},
+		{"invalid priority", "cookie", "", true},
+	}
+
+	for _, test := range tests {
+		data := map[string]string{
+			parser.GetAnnotationWithPrefix("canary"):        "true",
+			parser.GetAnnotationWithPrefix("canary-weight"): "20",
+		}
+		if test.priority != "" {
+			data[parser.GetAnnotationWithPrefix("canary-priority")] = test.priority
+		}
+		mci.SetAnnotations(data)
+
+		i, err := NewParser(&resolver.Mock{}).ParseByMCI(mci)
+		if test.expErr != (err != nil) {
+			t.Errorf("%v: expected error %v but returned %v", test.title, test.expErr, err)
+		}
+		if err != nil {
+			continue
+		}
+
+		canaryConfig, ok := i.(*Config)
+		if !ok {
+			t.Fatalf("%v: expected a Config type", test.title)
+		}
+		if canaryConfig.Priority != test.expected {
+			t.Errorf("%v: expected priority %q but %q was returned", test.title, test.expected, canaryConfig.Priority)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package charset
+
+import (
+	"strings"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	networking "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+// knownCharsets contains the charsets accepted by the charset annotation.
+// "off" disables a charset configured in the http or server block.
+var knownCharsets = sets.NewString(
+	"off",
+	"utf-8",
+	"us-ascii",
+	"iso-8859-1",
+	"iso-8859-2",
+	"iso-8859-15",
+	"windows-1250",
+	"windows-1251",
+	"windows-1252",
+	"koi8-r",
+	"koi8-u",
+	"big5",
+	"gb2312",
+	"gbk",
+	"gb18030",
+	"euc-jp",
+	"euc-kr",
+	"shift_jis",
+)
+
+// Config contains the charset added to the Content-Type header of the responses
+type Config struct {
+	// Charset is the value of the charset directive
+	Charset string `json:"charset,omitempty"`
+	// Types is the space separated list of MIME types of the charset_types directive
+	Types string `json:"types,omitempty"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if c1.Charset != c2.Charset {
+		return false
+	}
+	if c1.Types != c2.Types {
+		return false
+	}
+
+	return true
+}
+
+type charset struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new charset annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return charset{r}
+}
+
+// Parse parses the annotations contained in the ingress
+// rule used to configure the charset of the responses
+func (a charset) Parse(ing *networking.Ingress) (interface{}, error) {
+	cs, err := parser.GetStringAnnotation("charset", ing)
+	if err != nil {
+		return &Config{}, nil
+	}
+
+	types, err := parser.GetStringAnnotation("charset-types", ing)
+	if err != nil && !ing_errors.IsMissingAnnotations(err) {
+		return &Config{}, err
+	}
+
+	return newConfig(cs, types)
+}
+
+// ParseByMCI parses the annotations contained in the multiclusteringress
+// rule used to configure the charset of the responses
+func (a charset) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interface{}, error) {
+	cs, err := parser.GetStringAnnotationFromMCI("charset", mci)
+	if err != nil {
+		return &Config{}, nil
+	}
+
+	types, err := parser.GetStringAnnotationFromMCI("charset-types", mci)
+	if err != nil && !ing_errors.IsMissingAnnotations(err) {
+		return &Config{}, err
+	}
+
+	return newConfig(cs, types)
+}
+
+func newConfig(rawCharset, rawTypes string) (*Config, error) {
+	cs := strings.ToLower(strings.TrimSpace(rawCharset))
+	if !knownCharsets.Has(cs) {
+		return &Config{}, ing_errors.NewInvalidAnnotationContent("charset", rawCharset)
+	}
+
+	// charset-types accepts MIME types separated by commas or spaces
+	types := strings.FieldsFunc(strings.ToLower(rawTypes), func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+	for _, t := range types {
+		if !parser.IsValidMIMEType(t) {
+			return &Config{}, ing_errors.NewInvalidAnnotationContent("charset-types", rawTypes)
+		}
+	}
+
+	return &Config{
+		Charset: cs,
+		Types:   strings.Join(types, " "),
+	}, nil
+}
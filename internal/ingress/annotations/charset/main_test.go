@@ -0,0 +1,92 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package charset
+
+import (
+	"testing"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	api "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+func TestParseByMCI(t *testing.T) {
+	cs := parser.GetAnnotationWithPrefix("charset")
+	types := parser.GetAnnotationWithPrefix("charset-types")
+
+	ap := NewParser(&resolver.Mock{})
+	if ap == nil {
+		t.Fatalf("expected a parser.IngressAnnotation but returned nil")
+	}
+
+	testCases := []struct {
+		annotations map[string]string
+		expected    *Config
+		expectErr   bool
+	}{
+		{nil, &Config{}, false},
+		{map[string]string{types: "text/css"}, &Config{}, false},
+		{map[string]string{cs: "utf-8"}, &Config{Charset: "utf-8"}, false},
+		{map[string]string{cs: "UTF-8"}, &Config{Charset: "utf-8"}, false},
+		{map[string]string{cs: "off"}, &Config{Charset: "off"}, false},
+		{map[string]string{cs: "utf-8", types: "text/css, application/json"}, &Config{Charset: "utf-8", Types: "text/css application/json"}, false},
+		{map[string]string{cs: "utf-8", types: "*"}, &Config{Charset: "utf-8", Types: "*"}, false},
+		{map[string]string{cs: "utf-8", types: "text/css; charset"}, &Config{}, true},
+		{map[string]string{cs: "utf-9"}, &Config{}, true},
+		{map[string]string{cs: "utf-8; return 200"}, &Config{}, true},
+	}
+
+	mci := &karmadanetworking.MultiClusterIngress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+	}
+
+	for _, testCase := range testCases {
+		mci.SetAnnotations(testCase.annotations)
+		i, err := ap.ParseByMCI(mci)
+		if testCase.expectErr != (err != nil) {
+			t.Errorf("expected error %v but returned %v, annotations: %s", testCase.expectErr, err, testCase.annotations)
+		}
+
+		c, _ := i.(*Config)
+		if !c.Equal(testCase.expected) {
+			t.Errorf("expected %v but returned %v, annotations: %s", testCase.expected, c, testCase.annotations)
+		}
+	}
+}
+
+func TestEqual(t *testing.T) {
+	c1 := &Config{Charset: "utf-8", Types: "text/css"}
+
+	if !c1.Equal(&Config{Charset: "utf-8", Types: "text/css"}) {
+		t.Errorf("expected equal configurations")
+	}
+	if c1.Equal(&Config{Charset: "koi8-r", Types: "text/css"}) {
+		t.Errorf("expected different charsets to not be equal")
+	}
+	if c1.Equal(&Config{Charset: "utf-8"}) {
+		t.Errorf("expected different types to not be equal")
+	}
+	if c1.Equal(nil) {
+		t.Errorf("expected a nil configuration to not be equal")
+	}
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clientbodyinfileonly
+
+import (
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	networking "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+// validValues contains the values of the client_body_in_file_only directive
+var validValues = sets.NewString("off", "clean", "on")
+
+type clientBodyInFileOnly struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new clientBodyInFileOnly annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return clientBodyInFileOnly{r}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to add a client-body-in-file-only to the provided locations
+func (cbif clientBodyInFileOnly) Parse(ing *networking.Ingress) (interface{}, error) {
+	val, err := parser.GetStringAnnotation("client-body-in-file-only", ing)
+	if err != nil {
+		return "", err
+	}
+
+	return validate(val)
+}
+
+// ParseByMCI parses the annotations contained in the multiclusteringress rule
+// used to add a client-body-in-file-only to the provided locations
+func (cbif clientBodyInFileOnly) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interface{}, error) {
+	val, err := parser.GetStringAnnotationFromMCI("client-body-in-file-only", mci)
+	if err != nil {
+		return "", err
+	}
+
+	return validate(val)
+}
+
+func validate(val string) (string, error) {
+	if !validValues.Has(val) {
+		return "", ing_errors.NewInvalidAnnotationContent("client-body-in-file-only", val)
+	}
+
+	return val, nil
+}
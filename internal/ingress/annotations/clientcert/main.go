@@ -0,0 +1,148 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clientcert
+
+import (
+	"strings"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	networking "k8s.io/api/networking/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+// Header is a detail of the client certificate sent to the upstream
+type Header struct {
+	// Name of the request header
+	Name string `json:"name"`
+	// Variable is the NGINX variable holding the detail
+	Variable string `json:"variable"`
+}
+
+// headers maps the values accepted by the client-cert-headers annotation to
+// the request header sent to the upstream
+var headers = map[string]Header{
+	"subject-dn":  {Name: "ssl-client-subject-dn", Variable: "$ssl_client_s_dn"},
+	"issuer-dn":   {Name: "ssl-client-issuer-dn", Variable: "$ssl_client_i_dn"},
+	"fingerprint": {Name: "ssl-client-fingerprint", Variable: "$ssl_client_fingerprint"},
+	"pem":         {Name: "ssl-client-cert", Variable: "$ssl_client_escaped_cert"},
+}
+
+// defaultHeaders are sent when client-cert-headers is not set
+var defaultHeaders = []string{"subject-dn", "issuer-dn", "fingerprint", "pem"}
+
+// Config contains the details of the client certificate sent to the upstream
+type Config struct {
+	// Enabled replaces the default client certificate headers by Headers
+	Enabled bool `json:"enabled"`
+	// Headers are the details of the client certificate sent to the upstream
+	Headers []Header `json:"headers,omitempty"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if c1.Enabled != c2.Enabled {
+		return false
+	}
+	if len(c1.Headers) != len(c2.Headers) {
+		return false
+	}
+	for i := range c1.Headers {
+		if c1.Headers[i] != c2.Headers[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+type clientCert struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new client certificate headers annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return clientCert{r}
+}
+
+// Parse parses the annotations contained in the ingress
+// rule used to send details of the client certificate to the upstream
+func (a clientCert) Parse(ing *networking.Ingress) (interface{}, error) {
+	enabled, err := parser.GetBoolAnnotation("pass-client-cert", ing)
+	if err != nil || !enabled {
+		return &Config{}, nil
+	}
+
+	selection, err := parser.GetStringAnnotation("client-cert-headers", ing)
+	if err != nil && !ing_errors.IsMissingAnnotations(err) {
+		return &Config{}, err
+	}
+
+	return newConfig(selection)
+}
+
+// ParseByMCI parses the annotations contained in the multiclusteringress
+// rule used to send details of the client certificate to the upstream
+func (a clientCert) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interface{}, error) {
+	enabled, err := parser.GetBoolAnnotationFromMCI("pass-client-cert", mci)
+	if err != nil || !enabled {
+		return &Config{}, nil
+	}
+
+	selection, err := parser.GetStringAnnotationFromMCI("client-cert-headers", mci)
+	if err != nil && !ing_errors.IsMissingAnnotations(err) {
+		return &Config{}, err
+	}
+
+	return newConfig(selection)
+}
+
+// newConfig returns the headers of the comma separated selection, in the
+// order of the selection, or the default headers when it is empty
+func newConfig(selection string) (*Config, error) {
+	names := defaultHeaders
+	if strings.TrimSpace(selection) != "" {
+		names = strings.Split(selection, ",")
+	}
+
+	config := &Config{Enabled: true}
+	found := make(map[string]bool)
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		header, ok := headers[name]
+		if !ok {
+			return &Config{}, ing_errors.NewInvalidAnnotationContent("client-cert-headers", selection)
+		}
+
+		if found[name] {
+			continue
+		}
+		found[name] = true
+
+		config.Headers = append(config.Headers, header)
+	}
+
+	return config, nil
+}
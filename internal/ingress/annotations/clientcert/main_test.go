@@ -0,0 +1,97 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clientcert
+
+import (
+	"testing"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	api "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+func TestParseByMCI(t *testing.T) {
+	pass := parser.GetAnnotationWithPrefix("pass-client-cert")
+	selection := parser.GetAnnotationWithPrefix("client-cert-headers")
+
+	subjectDN := Header{Name: "ssl-client-subject-dn", Variable: "$ssl_client_s_dn"}
+	issuerDN := Header{Name: "ssl-client-issuer-dn", Variable: "$ssl_client_i_dn"}
+	fingerprint := Header{Name: "ssl-client-fingerprint", Variable: "$ssl_client_fingerprint"}
+	pem := Header{Name: "ssl-client-cert", Variable: "$ssl_client_escaped_cert"}
+
+	ap := NewParser(&resolver.Mock{})
+	if ap == nil {
+		t.Fatalf("expected a parser.IngressAnnotation but returned nil")
+	}
+
+	testCases := []struct {
+		annotations map[string]string
+		expected    *Config
+		expectErr   bool
+	}{
+		{nil, &Config{}, false},
+		{map[string]string{pass: "false", selection: "pem"}, &Config{}, false},
+		{map[string]string{pass: "true"}, &Config{Enabled: true, Headers: []Header{subjectDN, issuerDN, fingerprint, pem}}, false},
+		{map[string]string{pass: "true", selection: "subject-dn"}, &Config{Enabled: true, Headers: []Header{subjectDN}}, false},
+		{map[string]string{pass: "true", selection: "issuer-dn"}, &Config{Enabled: true, Headers: []Header{issuerDN}}, false},
+		{map[string]string{pass: "true", selection: "fingerprint"}, &Config{Enabled: true, Headers: []Header{fingerprint}}, false},
+		{map[string]string{pass: "true", selection: "pem"}, &Config{Enabled: true, Headers: []Header{pem}}, false},
+		{map[string]string{pass: "true", selection: "PEM, fingerprint,pem"}, &Config{Enabled: true, Headers: []Header{pem, fingerprint}}, false},
+		{map[string]string{pass: "true", selection: "subject-dn,serial"}, &Config{}, true},
+		{map[string]string{pass: "true", selection: "pem;"}, &Config{}, true},
+	}
+
+	mci := &karmadanetworking.MultiClusterIngress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+	}
+
+	for _, testCase := range testCases {
+		mci.SetAnnotations(testCase.annotations)
+		i, err := ap.ParseByMCI(mci)
+		if testCase.expectErr != (err != nil) {
+			t.Errorf("expected error %v but returned %v, annotations: %s", testCase.expectErr, err, testCase.annotations)
+		}
+
+		c, _ := i.(*Config)
+		if !c.Equal(testCase.expected) {
+			t.Errorf("expected %v but returned %v, annotations: %s", testCase.expected, c, testCase.annotations)
+		}
+	}
+}
+
+func TestEqual(t *testing.T) {
+	c1 := &Config{Enabled: true, Headers: []Header{headers["pem"]}}
+
+	if !c1.Equal(&Config{Enabled: true, Headers: []Header{headers["pem"]}}) {
+		t.Errorf("expected equal configurations")
+	}
+	if c1.Equal(&Config{Enabled: true, Headers: []Header{headers["fingerprint"]}}) {
+		t.Errorf("expected different headers to not be equal")
+	}
+	if c1.Equal(&Config{Headers: []Header{headers["pem"]}}) {
+		t.Errorf("expected different enabled to not be equal")
+	}
+	if c1.Equal(nil) {
+		t.Errorf("expected a nil configuration to not be equal")
+	}
+}
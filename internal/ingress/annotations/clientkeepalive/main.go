@@ -0,0 +1,137 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clientkeepalive
+
+import (
+	"strconv"
+	"time"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	networking "k8s.io/api/networking/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+type clientKeepalive struct {
+	r resolver.Resolver
+}
+
+// Config contains the keep-alive configuration of the client connections of a server.
+// Zero values mean the global settings apply.
+type Config struct {
+	// Requests is the maximum number of requests served through a connection
+	Requests int `json:"requests,omitempty"`
+	// Timeout is the time, in seconds, an idle connection stays open
+	Timeout int `json:"timeout,omitempty"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if c1.Requests != c2.Requests {
+		return false
+	}
+
+	return c1.Timeout == c2.Timeout
+}
+
+// NewParser creates a new client keep-alive annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return clientKeepalive{r}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to configure the keep-alive of the client connections of the server
+func (ck clientKeepalive) Parse(ing *networking.Ingress) (interface{}, error) {
+	requests, err := parser.GetStringAnnotation("client-keepalive-requests", ing)
+	if err != nil && !ing_errors.IsMissingAnnotations(err) {
+		return &Config{}, err
+	}
+
+	timeout, err := parser.GetStringAnnotation("client-keepalive-timeout", ing)
+	if err != nil && !ing_errors.IsMissingAnnotations(err) {
+		return &Config{}, err
+	}
+
+	return newConfig(requests, timeout)
+}
+
+// ParseByMCI parses the annotations contained in the multiclusteringress rule
+// used to configure the keep-alive of the client connections of the server
+func (ck clientKeepalive) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interface{}, error) {
+	requests, err := parser.GetStringAnnotationFromMCI("client-keepalive-requests", mci)
+	if err != nil && !ing_errors.IsMissingAnnotations(err) {
+		return &Config{}, err
+	}
+
+	timeout, err := parser.GetStringAnnotationFromMCI("client-keepalive-timeout", mci)
+	if err != nil && !ing_errors.IsMissingAnnotations(err) {
+		return &Config{}, err
+	}
+
+	return newConfig(requests, timeout)
+}
+
+// newConfig checks the number of requests is positive and the timeout is a
+// positive number of seconds, or a duration like "75s" or "2m"
+func newConfig(rawRequests, rawTimeout string) (*Config, error) {
+	config := &Config{}
+
+	if rawRequests != "" {
+		requests, err := strconv.Atoi(rawRequests)
+		if err != nil || requests <= 0 {
+			return &Config{}, ing_errors.NewInvalidAnnotationContent("client-keepalive-requests", rawRequests)
+		}
+		config.Requests = requests
+	}
+
+	if rawTimeout != "" {
+		timeout, err := parseTimeout(rawTimeout)
+		if err != nil {
+			return &Config{}, ing_errors.NewInvalidAnnotationContent("client-keepalive-timeout", rawTimeout)
+		}
+		config.Timeout = timeout
+	}
+
+	return config, nil
+}
+
+func parseTimeout(raw string) (int, error) {
+	if seconds, err := strconv.Atoi(raw); err == nil {
+		if seconds <= 0 {
+			return 0, strconv.ErrRange
+		}
+		return seconds, nil
+	}
+
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, err
+	}
+	if d < time.Second || d%time.Second != 0 {
+		return 0, strconv.ErrRange
+	}
+
+	return int(d / time.Second), nil
+}
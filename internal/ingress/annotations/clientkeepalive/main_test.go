@@ -0,0 +1,77 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clientkeepalive
+
+import (
+	"testing"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	api "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+func TestParseByMCI(t *testing.T) {
+	requests := parser.GetAnnotationWithPrefix("client-keepalive-requests")
+	timeout := parser.GetAnnotationWithPrefix("client-keepalive-timeout")
+
+	ap := NewParser(&resolver.Mock{})
+	if ap == nil {
+		t.Fatalf("expected a parser.IngressAnnotation but returned nil")
+	}
+
+	testCases := []struct {
+		annotations map[string]string
+		expected    *Config
+		expectErr   bool
+	}{
+		{nil, &Config{}, false},
+		{map[string]string{requests: "500"}, &Config{Requests: 500}, false},
+		{map[string]string{timeout: "30"}, &Config{Timeout: 30}, false},
+		{map[string]string{timeout: "2m"}, &Config{Timeout: 120}, false},
+		{map[string]string{requests: "100", timeout: "75s"}, &Config{Requests: 100, Timeout: 75}, false},
+		{map[string]string{requests: "0"}, &Config{}, true},
+		{map[string]string{requests: "-1"}, &Config{}, true},
+		{map[string]string{requests: "many"}, &Config{}, true},
+		{map[string]string{timeout: "0"}, &Config{}, true},
+		{map[string]string{timeout: "-5s"}, &Config{}, true},
+		{map[string]string{timeout: "1500ms"}, &Config{}, true},
+		{map[string]string{timeout: "forever"}, &Config{}, true},
+	}
+
+	mci := &karmadanetworking.MultiClusterIngress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+	}
+
+	for _, testCase := range testCases {
+		mci.SetAnnotations(testCase.annotations)
+		i, err := ap.ParseByMCI(mci)
+		if testCase.expectErr != (err != nil) {
+			t.Errorf("expected error %v but returned %v, annotations: %s", testCase.expectErr, err, testCase.annotations)
+		}
+
+		c, _ := i.(*Config)
+		if !c.Equal(testCase.expected) {
+			t.Errorf("expected %v but returned %v, annotations: %s", testCase.expected, c, testCase.annotations)
+		}
+	}
+}
@@ -64,6 +64,7 @@ type Config struct {
 	CorsAllowCredentials bool     `json:"corsAllowCredentials"`
 	CorsExposeHeaders    string   `json:"corsExposeHeaders"`
 	CorsMaxAge           int      `json:"corsMaxAge"`
+	CorsVaryOrigin       bool     `json:"corsVaryOrigin"`
 }
 
 // NewParser creates a new CORS annotation parser
@@ -98,6 +99,10 @@ func (c1 *Config) Equal(c2 *Config) bool {
 		return false
 	}
 
+	if c1.CorsVaryOrigin != c2.CorsVaryOrigin {
+		return false
+	}
+
 	if len(c1.CorsAllowOrigin) != len(c2.CorsAllowOrigin) {
 		return false
 	}
@@ -166,6 +171,12 @@ func (c cors) Parse(ing *networking.Ingress) (interface{}, error) {
 		config.CorsMaxAge = defaultCorsMaxAge
 	}
 
+	addVary, err := parser.GetBoolAnnotation("cors-add-vary", ing)
+	if err != nil {
+		addVary = true
+	}
+	config.CorsVaryOrigin = addVary && isReflectedOrigin(config.CorsAllowOrigin)
+
 	return config, nil
 }
 
@@ -224,5 +235,23 @@ func (c cors) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interface{
 		config.CorsMaxAge = defaultCorsMaxAge
 	}
 
+	addVary, err := parser.GetBoolAnnotationFromMCI("cors-add-vary", mci)
+	if err != nil {
+		addVary = true
+	}
+	config.CorsVaryOrigin = addVary && isReflectedOrigin(config.CorsAllowOrigin)
+
 	return config, nil
 }
+
+// isReflectedOrigin returns true when the Access-Control-Allow-Origin header reflects
+// the Origin of the request instead of a single fixed origin, so responses need
+// a Vary: Origin header to be cached correctly
+func isReflectedOrigin(origins []string) bool {
+	if len(origins) != 1 {
+		return len(origins) > 1
+	}
+
+	origin := strings.TrimSpace(origins[0])
+	return origin == "*" || strings.Contains(origin, "*.")
+}
@@ -19,6 +19,7 @@ package cors
 import (
 	"testing"
 
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
 	api "k8s.io/api/core/v1"
 	networking "k8s.io/api/networking/v1"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -172,3 +173,128 @@ func TestIngressCorsConfigInvalid(t *testing.T) {
 		t.Errorf("expected %v but returned %v", defaultCorsMaxAge, nginxCors.CorsMaxAge)
 	}
 }
+
+func TestMCICorsVaryOrigin(t *testing.T) {
+	enable := parser.GetAnnotationWithPrefix("enable-cors")
+	origin := parser.GetAnnotationWithPrefix("cors-allow-origin")
+	credentials := parser.GetAnnotationWithPrefix("cors-allow-credentials")
+	addVary := parser.GetAnnotationWithPrefix("cors-add-vary")
+
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		expected    bool
+	}{
+		{"multiple origins", map[string]string{enable: "true", origin: "https://a.example.com, https://b.example.com"}, true},
+		{"wildcard subdomain", map[string]string{enable: "true", origin: "https://*.example.com"}, true},
+		{"wildcard with credentials", map[string]string{enable: "true", origin: "*", credentials: "true"}, true},
+		{"default origin", map[string]string{enable: "true"}, true},
+		{"single origin", map[string]string{enable: "true", origin: "https://a.example.com"}, false},
+		{"disabled", map[string]string{enable: "true", origin: "https://a.example.com, https://b.example.com", addVary: "false"}, false},
+	}
+
+	mci := &karmadanetworking.MultiClusterIngress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+	}
+
+	for _, tc := range testCases {
+		mci.SetAnnotations(tc.annotations)
+
+		i, err := NewParser(&resolver.Mock{}).ParseByMCI(mci)
+		if err != nil {
+			t.Errorf("%v: unexpected error: %v", tc.name, err)
+		}
+
+		config, ok := i.(*Config)
+		if !ok {
+			t.Fatalf("%v: expected a Config type", tc.name)
+		}
+
+		if config.CorsVaryOrigin != tc.expected {
+			t.Errorf("%v: expected CorsVaryOrigin %v but returned %v", tc.name, tc.expected, config.CorsVaryOrigin)
+		}
+	}
+}
+
+func TestIsReflectedOrigin(t *testing.T) {
+	testCases := []struct {
+		name     string
+		origins  []string
+		expected bool
+	}{
+		{"no origin", nil, false},
+		{"single origin", []string{"https://a.example.com"}, false},
+		{"wildcard", []string{"*"}, true},
+		{"wildcard with spaces", []string{" * "}, true},
+		{"wildcard subdomain", []string{"https://*.example.com"}, true},
+		{"wildcard subdomain with port", []string{"https://*.example.com:8443"}, true},
+		{"multiple origins", []string{"https://a.example.com", "https://b.example.com"}, true},
+		{"multiple origins with a wildcard subdomain", []string{"https://a.example.com", "https://*.example.com"}, true},
+	}
+
+	for _, tc := range testCases {
+		if reflected := isReflectedOrigin(tc.origins); reflected != tc.expected {
+			t.Errorf("%v: expected %v but returned %v", tc.name, tc.expected, reflected)
+		}
+	}
+}
+
+func TestMCICorsExposeHeaders(t *testing.T) {
+	enable := parser.GetAnnotationWithPrefix("enable-cors")
+	exposeHeaders := parser.GetAnnotationWithPrefix("cors-expose-headers")
+
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		expected    string
+	}{
+		{"not set", map[string]string{enable: "true"}, ""},
+		{"single header", map[string]string{enable: "true", exposeHeaders: "X-Request-ID"}, "X-Request-ID"},
+		{"header list", map[string]string{enable: "true", exposeHeaders: "X-Request-ID, Content-Length,X-Total_Count"}, "X-Request-ID, Content-Length,X-Total_Count"},
+		{"wildcard", map[string]string{enable: "true", exposeHeaders: "*"}, "*"},
+		{"invalid token", map[string]string{enable: "true", exposeHeaders: "X-Request-ID, X-Bad:Header"}, ""},
+		{"variable", map[string]string{enable: "true", exposeHeaders: "$upstream_addr"}, ""},
+		{"header injection", map[string]string{enable: "true", exposeHeaders: "X-Request-ID'; more_set_headers 'X-Evil: 1"}, ""},
+	}
+
+	mci := &karmadanetworking.MultiClusterIngress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+	}
+
+	for _, tc := range testCases {
+		mci.SetAnnotations(tc.annotations)
+
+		i, err := NewParser(&resolver.Mock{}).ParseByMCI(mci)
+		if err != nil {
+			t.Errorf("%v: unexpected error: %v", tc.name, err)
+		}
+
+		config, ok := i.(*Config)
+		if !ok {
+			t.Fatalf("%v: expected a Config type", tc.name)
+		}
+
+		if config.CorsExposeHeaders != tc.expected {
+			t.Errorf("%v: expected CorsExposeHeaders %q but returned %q", tc.name, tc.expected, config.CorsExposeHeaders)
+		}
+	}
+}
+
+func TestCorsEqualExposeHeaders(t *testing.T) {
+	c1 := &Config{CorsExposeHeaders: "X-Request-ID"}
+	c2 := &Config{CorsExposeHeaders: "X-Request-ID"}
+	c3 := &Config{CorsExposeHeaders: "Content-Length"}
+
+	if !c1.Equal(c2) {
+		t.Errorf("expected configs with the same expose headers to be equal")
+	}
+	if c1.Equal(c3) {
+		t.Errorf("expected configs with different expose headers not to be equal")
+	}
+}
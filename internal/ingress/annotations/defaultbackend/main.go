@@ -18,14 +18,25 @@ package defaultbackend
 
 import (
 	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
 
 	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	apiv1 "k8s.io/api/core/v1"
 	networking "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 
 	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 )
 
+// ExternalURLAnnotation is set on the Service synthesized from the
+// default-backend-url annotation and contains the configured URL
+const ExternalURLAnnotation = "nginx.ingress.kubernetes.io/default-backend-url"
+
 type backend struct {
 	r resolver.Resolver
 }
@@ -53,8 +64,17 @@ func (db backend) Parse(ing *networking.Ingress) (interface{}, error) {
 }
 
 // ParseByMCI parses the annotations contained in the multiclusteringress to use
-// a custom default backend
+// a custom default backend. The default-backend-url annotation takes precedence
+// over default-backend and points the default backend to an external URL.
 func (db backend) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interface{}, error) {
+	rawURL, err := parser.GetStringAnnotationFromMCI("default-backend-url", mci)
+	if err == nil {
+		return db.externalService(mci, rawURL)
+	}
+	if !ing_errors.IsMissingAnnotations(err) {
+		return nil, err
+	}
+
 	s, err := parser.GetStringAnnotationFromMCI("default-backend", mci)
 	if err != nil {
 		return nil, err
@@ -68,3 +88,76 @@ func (db backend) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interf
 
 	return svc, nil
 }
+
+// externalService validates the external default backend URL and synthesizes
+// a Service of type ExternalName pointing to it, so the upstream is built the
+// same way as for any other custom default backend
+func (db backend) externalService(mci *karmadanetworking.MultiClusterIngress, rawURL string) (*apiv1.Service, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || !u.IsAbs() || u.Hostname() == "" {
+		return nil, ing_errors.NewInvalidAnnotationContent("default-backend-url", rawURL)
+	}
+
+	// the requests keep their path, a path of the URL would be silently dropped
+	if (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+		return nil, ing_errors.NewInvalidAnnotationConfiguration("default-backend-url",
+			"the URL must not contain a path, a query, a fragment or credentials")
+	}
+
+	var port int
+	switch u.Scheme {
+	case "https":
+		port = 443
+	case "http":
+		if !db.r.GetDefaultBackend().AllowHTTPDefaultBackendURL {
+			return nil, ing_errors.NewInvalidAnnotationConfiguration("default-backend-url",
+				"http URLs are not allowed unless allow-http-default-backend-url is enabled")
+		}
+		port = 80
+	default:
+		return nil, ing_errors.NewInvalidAnnotationContent("default-backend-url", rawURL)
+	}
+
+	if u.Port() != "" {
+		port, err = strconv.Atoi(u.Port())
+		if err != nil || port <= 0 || port > 65535 {
+			return nil, ing_errors.NewInvalidAnnotationContent("default-backend-url", rawURL)
+		}
+	}
+
+	return &apiv1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: mci.Namespace,
+			Name:      fmt.Sprintf("%v-default-backend-url", mci.Name),
+			Annotations: map[string]string{
+				ExternalURLAnnotation: rawURL,
+			},
+		},
+		Spec: apiv1.ServiceSpec{
+			Type:         apiv1.ServiceTypeExternalName,
+			ExternalName: u.Hostname(),
+			Ports: []apiv1.ServicePort{
+				{
+					Name:       u.Scheme,
+					Port:       int32(port),
+					TargetPort: intstr.FromInt(port),
+				},
+			},
+		},
+	}, nil
+}
+
+// ExternalBackend returns the host and the backend protocol, HTTP or HTTPS,
+// of a Service synthesized from the default-backend-url annotation. ok is
+// false for any other Service.
+func ExternalBackend(svc *apiv1.Service) (host, protocol string, ok bool) {
+	if svc == nil || len(svc.Spec.Ports) == 0 {
+		return "", "", false
+	}
+
+	if _, found := svc.Annotations[ExternalURLAnnotation]; !found {
+		return "", "", false
+	}
+
+	return svc.Spec.ExternalName, strings.ToUpper(svc.Spec.Ports[0].Name), true
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package defaultsslcertificate
+
+import (
+	"fmt"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	networking "k8s.io/api/networking/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+	"k8s.io/ingress-nginx/internal/k8s"
+)
+
+// Config contains the secret with the certificate used for the hosts
+// without a matching TLS certificate.
+// An empty Secret means the global default certificate applies.
+type Config struct {
+	Secret string `json:"secret,omitempty"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+
+	return c1.Secret == c2.Secret
+}
+
+type defaultSSLCertificate struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new default SSL certificate annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return defaultSSLCertificate{r}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to define the fallback certificate of its hosts
+func (a defaultSSLCertificate) Parse(ing *networking.Ingress) (interface{}, error) {
+	secret, err := parser.GetStringAnnotation("default-ssl-certificate", ing)
+	if err != nil {
+		return &Config{}, err
+	}
+
+	return a.newConfig(secret)
+}
+
+// ParseByMCI parses the annotations contained in the multiclusteringress rule
+// used to define the fallback certificate of its hosts
+func (a defaultSSLCertificate) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interface{}, error) {
+	secret, err := parser.GetStringAnnotationFromMCI("default-ssl-certificate", mci)
+	if err != nil {
+		return &Config{}, err
+	}
+
+	return a.newConfig(secret)
+}
+
+// newConfig checks the secret reference has the format namespace/name
+// and the secret exists
+func (a defaultSSLCertificate) newConfig(secret string) (*Config, error) {
+	_, _, err := k8s.ParseNameNS(secret)
+	if err != nil {
+		return &Config{}, ing_errors.NewInvalidAnnotationContent("default-ssl-certificate", secret)
+	}
+
+	_, err = a.r.GetSecret(secret)
+	if err != nil {
+		return &Config{}, fmt.Errorf("unexpected error reading secret %s: %w", secret, err)
+	}
+
+	return &Config{Secret: secret}, nil
+}
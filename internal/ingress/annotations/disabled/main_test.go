@@ -0,0 +1,127 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package disabled
+
+import (
+	"testing"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	api "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+func buildIngress() *networking.Ingress {
+	return &networking.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "legacy",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: networking.IngressSpec{
+			Rules: []networking.IngressRule{
+				{
+					Host: "legacy.example.com",
+				},
+			},
+		},
+	}
+}
+
+func TestParseAnnotations(t *testing.T) {
+	ing := buildIngress()
+
+	_, err := NewParser(&resolver.Mock{}).Parse(ing)
+	if !errors.IsMissingAnnotations(err) {
+		t.Errorf("expected a missing annotation error but returned %v", err)
+	}
+
+	// an ingress class no controller handles is not the disabled annotation
+	ing.SetAnnotations(map[string]string{
+		"kubernetes.io/ingress.class": "none",
+	})
+	_, err = NewParser(&resolver.Mock{}).Parse(ing)
+	if !errors.IsMissingAnnotations(err) {
+		t.Errorf("expected a missing annotation error with an ingress class only but returned %v", err)
+	}
+
+	ing.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix("disabled"): "true",
+	})
+	i, err := NewParser(&resolver.Mock{}).Parse(ing)
+	if err != nil {
+		t.Errorf("unexpected error parsing ingress with disabled: %v", err)
+	}
+	val, ok := i.(bool)
+	if !ok {
+		t.Errorf("expected a bool type")
+	}
+	if !val {
+		t.Errorf("expected true but false returned")
+	}
+
+	ing.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix("disabled"): "yes",
+	})
+	_, err = NewParser(&resolver.Mock{}).Parse(ing)
+	if !errors.IsInvalidContent(err) {
+		t.Errorf("expected an invalid content error but returned %v", err)
+	}
+}
+
+func TestParseAnnotationsByMCI(t *testing.T) {
+	mci := &karmadanetworking.MultiClusterIngress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "legacy",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: buildIngress().Spec,
+	}
+
+	_, err := NewParser(&resolver.Mock{}).ParseByMCI(mci)
+	if !errors.IsMissingAnnotations(err) {
+		t.Errorf("expected a missing annotation error but returned %v", err)
+	}
+
+	// a canary is disabled like any other multiclusteringress
+	mci.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix("disabled"): "true",
+		parser.GetAnnotationWithPrefix("canary"):   "true",
+	})
+	i, err := NewParser(&resolver.Mock{}).ParseByMCI(mci)
+	if err != nil {
+		t.Errorf("unexpected error parsing multiclusteringress with disabled: %v", err)
+	}
+	if val, _ := i.(bool); !val {
+		t.Errorf("expected true but false returned")
+	}
+
+	// setting the annotation to false adds the multiclusteringress back
+	mci.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix("disabled"): "false",
+	})
+	i, err = NewParser(&resolver.Mock{}).ParseByMCI(mci)
+	if err != nil {
+		t.Errorf("unexpected error parsing multiclusteringress with disabled: %v", err)
+	}
+	if val, _ := i.(bool); val {
+		t.Errorf("expected false but true returned")
+	}
+}
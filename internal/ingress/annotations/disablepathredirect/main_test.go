@@ -0,0 +1,149 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package disablepathredirect
+
+import (
+	"testing"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	api "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+// buildSpec returns a rule with a path ending with a slash, the paths
+// NGINX redirects to when they are requested without it
+func buildSpec() networking.IngressSpec {
+	pathType := networking.PathTypePrefix
+
+	return networking.IngressSpec{
+		Rules: []networking.IngressRule{
+			{
+				Host: "docs.example.com",
+				IngressRuleValue: networking.IngressRuleValue{
+					HTTP: &networking.HTTPIngressRuleValue{
+						Paths: []networking.HTTPIngressPath{
+							{
+								Path:     "/api/",
+								PathType: &pathType,
+								Backend: networking.IngressBackend{
+									Service: &networking.IngressServiceBackend{
+										Name: "api",
+										Port: networking.ServiceBackendPort{
+											Number: 80,
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestParseAnnotations(t *testing.T) {
+	ing := &networking.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "docs",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: buildSpec(),
+	}
+
+	_, err := NewParser(&resolver.Mock{}).Parse(ing)
+	if !errors.IsMissingAnnotations(err) {
+		t.Errorf("expected a missing annotation error but returned %v", err)
+	}
+
+	// turning off the HTTPS redirect keeps the trailing slash redirect
+	ing.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix("ssl-redirect"): "false",
+	})
+	_, err = NewParser(&resolver.Mock{}).Parse(ing)
+	if !errors.IsMissingAnnotations(err) {
+		t.Errorf("expected a missing annotation error with ssl-redirect only but returned %v", err)
+	}
+
+	ing.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix("disable-path-redirect"): "true",
+	})
+	i, err := NewParser(&resolver.Mock{}).Parse(ing)
+	if err != nil {
+		t.Errorf("unexpected error parsing ingress with disable-path-redirect: %v", err)
+	}
+	val, ok := i.(bool)
+	if !ok {
+		t.Errorf("expected a bool type")
+	}
+	if !val {
+		t.Errorf("expected true but false returned")
+	}
+
+	// the annotation does not choose the code of the redirect
+	ing.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix("disable-path-redirect"): "301",
+	})
+	_, err = NewParser(&resolver.Mock{}).Parse(ing)
+	if !errors.IsInvalidContent(err) {
+		t.Errorf("expected an invalid content error for a redirect code but returned %v", err)
+	}
+}
+
+func TestParseAnnotationsByMCI(t *testing.T) {
+	mci := &karmadanetworking.MultiClusterIngress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "docs",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: buildSpec(),
+	}
+
+	_, err := NewParser(&resolver.Mock{}).ParseByMCI(mci)
+	if !errors.IsMissingAnnotations(err) {
+		t.Errorf("expected a missing annotation error but returned %v", err)
+	}
+
+	// test together with a rewrite of the path
+	mci.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix("disable-path-redirect"): "true",
+		parser.GetAnnotationWithPrefix("rewrite-target"):        "/",
+	})
+	i, err := NewParser(&resolver.Mock{}).ParseByMCI(mci)
+	if err != nil {
+		t.Errorf("unexpected error parsing multiclusteringress with disable-path-redirect: %v", err)
+	}
+	if val, _ := i.(bool); !val {
+		t.Errorf("expected true but false returned")
+	}
+
+	mci.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix("disable-path-redirect"): "false",
+	})
+	i, err = NewParser(&resolver.Mock{}).ParseByMCI(mci)
+	if err != nil {
+		t.Errorf("unexpected error parsing multiclusteringress with disable-path-redirect: %v", err)
+	}
+	if val, _ := i.(bool); val {
+		t.Errorf("expected false but true returned")
+	}
+}
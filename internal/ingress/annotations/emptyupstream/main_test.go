@@ -0,0 +1,137 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package emptyupstream
+
+import (
+	"testing"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	api "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+const annotation = "service-unavailable-on-empty-upstream"
+
+func buildIngress() *networking.Ingress {
+	return &networking.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: networking.IngressSpec{
+			Rules: []networking.IngressRule{
+				{
+					Host: "api.example.com",
+					IngressRuleValue: networking.IngressRuleValue{
+						HTTP: &networking.HTTPIngressRuleValue{
+							Paths: []networking.HTTPIngressPath{
+								{
+									Path: "/",
+									Backend: networking.IngressBackend{
+										Service: &networking.IngressServiceBackend{
+											Name: "scaled-to-zero",
+											Port: networking.ServiceBackendPort{
+												Number: 80,
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestParseAnnotations(t *testing.T) {
+	ing := buildIngress()
+
+	// without the annotation the locations keep using the default backend
+	_, err := NewParser(&resolver.Mock{}).Parse(ing)
+	if !errors.IsMissingAnnotations(err) {
+		t.Errorf("expected a missing annotation error but returned %v", err)
+	}
+
+	// a custom default backend does not return 503 by itself
+	ing.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix("default-backend"): "errors",
+	})
+	_, err = NewParser(&resolver.Mock{}).Parse(ing)
+	if !errors.IsMissingAnnotations(err) {
+		t.Errorf("expected a missing annotation error with default-backend only but returned %v", err)
+	}
+
+	ing.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix(annotation):        "true",
+		parser.GetAnnotationWithPrefix("default-backend"): "errors",
+	})
+	i, err := NewParser(&resolver.Mock{}).Parse(ing)
+	if err != nil {
+		t.Errorf("unexpected error parsing ingress with %v: %v", annotation, err)
+	}
+	val, ok := i.(bool)
+	if !ok {
+		t.Errorf("expected a bool type")
+	}
+	if !val {
+		t.Errorf("expected true but false returned")
+	}
+
+	// the annotation is a switch, not the status code to return
+	ing.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix(annotation): "503",
+	})
+	_, err = NewParser(&resolver.Mock{}).Parse(ing)
+	if !errors.IsInvalidContent(err) {
+		t.Errorf("expected an invalid content error for a status code but returned %v", err)
+	}
+}
+
+func TestParseAnnotationsByMCI(t *testing.T) {
+	mci := &karmadanetworking.MultiClusterIngress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: buildIngress().Spec,
+	}
+
+	_, err := NewParser(&resolver.Mock{}).ParseByMCI(mci)
+	if !errors.IsMissingAnnotations(err) {
+		t.Errorf("expected a missing annotation error but returned %v", err)
+	}
+
+	for value, expected := range map[string]bool{"true": true, "false": false} {
+		mci.SetAnnotations(map[string]string{
+			parser.GetAnnotationWithPrefix(annotation): value,
+		})
+		i, err := NewParser(&resolver.Mock{}).ParseByMCI(mci)
+		if err != nil {
+			t.Errorf("unexpected error parsing multiclusteringress with %v %v: %v", annotation, value, err)
+		}
+		if val, _ := i.(bool); val != expected {
+			t.Errorf("expected %v for %v but returned %v", expected, value, val)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ewmadecay
+
+import (
+	"strconv"
+	"time"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	networking "k8s.io/api/networking/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+type ewmaDecay struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new EWMA decay annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return ewmaDecay{r}
+}
+
+// Parse parses the annotations contained in the ingress rule used to
+// configure the decay time, in seconds, of the EWMA load balancer
+func (e ewmaDecay) Parse(ing *networking.Ingress) (interface{}, error) {
+	decay, err := parser.GetStringAnnotation("ewma-decay", ing)
+	if err != nil {
+		return 0.0, err
+	}
+
+	return parseDecay(decay)
+}
+
+// ParseByMCI parses the annotations contained in the multiclusteringress rule used
+// to configure the decay time, in seconds, of the EWMA load balancer
+func (e ewmaDecay) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interface{}, error) {
+	decay, err := parser.GetStringAnnotationFromMCI("ewma-decay", mci)
+	if err != nil {
+		return 0.0, err
+	}
+
+	return parseDecay(decay)
+}
+
+// parseDecay returns the seconds of a positive number of seconds,
+// or of a positive duration like "500ms" or "1m"
+func parseDecay(raw string) (float64, error) {
+	if seconds, err := strconv.Atoi(raw); err == nil {
+		if seconds <= 0 {
+			return 0, ing_errors.NewInvalidAnnotationContent("ewma-decay", raw)
+		}
+
+		return float64(seconds), nil
+	}
+
+	duration, err := time.ParseDuration(raw)
+	if err != nil || duration <= 0 {
+		return 0, ing_errors.NewInvalidAnnotationContent("ewma-decay", raw)
+	}
+
+	return duration.Seconds(), nil
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package geoipaccess
+
+import (
+	"strings"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	networking "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+// countryCodes contains the ISO 3166-1 alpha-2 codes of the countries
+var countryCodes = sets.NewString(
+	"AD", "AE", "AF", "AG", "AI", "AL", "AM", "AO", "AQ", "AR", "AS", "AT", "AU", "AW", "AX", "AZ",
+	"BA", "BB", "BD", "BE", "BF", "BG", "BH", "BI", "BJ", "BL", "BM", "BN", "BO", "BQ", "BR", "BS",
+	"BT", "BV", "BW", "BY", "BZ", "CA", "CC", "CD", "CF", "CG", "CH", "CI", "CK", "CL", "CM", "CN",
+	"CO", "CR", "CU", "CV", "CW", "CX", "CY", "CZ", "DE", "DJ", "DK", "DM", "DO", "DZ", "EC", "EE",
+	"EG", "EH", "ER", "ES", "ET", "FI", "FJ", "FK", "FM", "FO", "FR", "GA", "GB", "GD", "GE", "GF",
+	"GG", "GH", "GI", "GL", "GM", "GN", "GP", "GQ", "GR", "GS", "GT", "GU", "GW", "GY", "HK", "HM",
+	"HN", "HR", "HT", "HU", "ID", "IE", "IL", "IM", "IN", "IO", "IQ", "IR", "IS", "IT", "JE", "JM",
+	"JO", "JP", "KE", "KG", "KH", "KI", "KM", "KN", "KP", "KR", "KW", "KY", "KZ", "LA", "LB", "LC",
+	"LI", "LK", "LR", "LS", "LT", "LU", "LV", "LY", "MA", "MC", "MD", "ME", "MF", "MG", "MH", "MK",
+	"ML", "MM", "MN", "MO", "MP", "MQ", "MR", "MS", "MT", "MU", "MV", "MW", "MX", "MY", "MZ", "NA",
+	"NC", "NE", "NF", "NG", "NI", "NL", "NO", "NP", "NR", "NU", "NZ", "OM", "PA", "PE", "PF", "PG",
+	"PH", "PK", "PL", "PM", "PN", "PR", "PS", "PT", "PW", "PY", "QA", "RE", "RO", "RS", "RU", "RW",
+	"SA", "SB", "SC", "SD", "SE", "SG", "SH", "SI", "SJ", "SK", "SL", "SM", "SN", "SO", "SR", "SS",
+	"ST", "SV", "SX", "SY", "SZ", "TC", "TD", "TF", "TG", "TH", "TJ", "TK", "TL", "TM", "TN", "TO",
+	"TR", "TT", "TV", "TW", "TZ", "UA", "UG", "UM", "US", "UY", "UZ", "VA", "VC", "VE", "VG", "VI",
+	"VN", "VU", "WF", "WS", "YE", "YT", "ZA", "ZM", "ZW",
+)
+
+// Config contains the countries allowed or denied to access a location,
+// as ISO 3166-1 alpha-2 codes. At most one of the lists is set.
+type Config struct {
+	AllowCountries []string `json:"allowCountries,omitempty"`
+	DenyCountries  []string `json:"denyCountries,omitempty"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if !sets.NewString(c1.AllowCountries...).Equal(sets.NewString(c2.AllowCountries...)) {
+		return false
+	}
+
+	return sets.NewString(c1.DenyCountries...).Equal(sets.NewString(c2.DenyCountries...))
+}
+
+// Enabled returns true when the access to the location depends on the country
+func (c Config) Enabled() bool {
+	return len(c.AllowCountries) > 0 || len(c.DenyCountries) > 0
+}
+
+type geoIPAccess struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new GeoIP access annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return geoIPAccess{r}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to allow or deny the access to the locations by country
+func (a geoIPAccess) Parse(ing *networking.Ingress) (interface{}, error) {
+	allow, err := parser.GetStringAnnotation("geoip-allow-countries", ing)
+	if err != nil && !ing_errors.IsMissingAnnotations(err) {
+		return &Config{}, err
+	}
+
+	deny, err := parser.GetStringAnnotation("geoip-deny-countries", ing)
+	if err != nil && !ing_errors.IsMissingAnnotations(err) {
+		return &Config{}, err
+	}
+
+	return newConfig(allow, deny)
+}
+
+// ParseByMCI parses the annotations contained in the multiclusteringress rule
+// used to allow or deny the access to the locations by country
+func (a geoIPAccess) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interface{}, error) {
+	allow, err := parser.GetStringAnnotationFromMCI("geoip-allow-countries", mci)
+	if err != nil && !ing_errors.IsMissingAnnotations(err) {
+		return &Config{}, err
+	}
+
+	deny, err := parser.GetStringAnnotationFromMCI("geoip-deny-countries", mci)
+	if err != nil && !ing_errors.IsMissingAnnotations(err) {
+		return &Config{}, err
+	}
+
+	return newConfig(allow, deny)
+}
+
+func newConfig(rawAllow, rawDeny string) (*Config, error) {
+	if rawAllow != "" && rawDeny != "" {
+		return &Config{}, ing_errors.NewInvalidAnnotationConfiguration("geoip-allow-countries",
+			"geoip-deny-countries cannot be used at the same time")
+	}
+
+	allow, err := parseCountries("geoip-allow-countries", rawAllow)
+	if err != nil {
+		return &Config{}, err
+	}
+
+	deny, err := parseCountries("geoip-deny-countries", rawDeny)
+	if err != nil {
+		return &Config{}, err
+	}
+
+	return &Config{
+		AllowCountries: allow,
+		DenyCountries:  deny,
+	}, nil
+}
+
+// parseCountries returns the sorted country codes of a list separated by commas or spaces
+func parseCountries(name, raw string) ([]string, error) {
+	codes := sets.NewString()
+	for _, code := range strings.FieldsFunc(strings.ToUpper(raw), func(r rune) bool {
+		return r == ',' || r == ' '
+	}) {
+		if !countryCodes.Has(code) {
+			return nil, ing_errors.NewInvalidAnnotationContent(name, raw)
+		}
+		codes.Insert(code)
+	}
+
+	if codes.Len() == 0 {
+		return nil, nil
+	}
+
+	return codes.List(), nil
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpctimeout
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	networking "k8s.io/api/networking/v1"
+	"k8s.io/klog/v2"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+type grpcTimeout struct {
+	r resolver.Resolver
+}
+
+// Config contains the timeouts of the gRPC streams of a location.
+// Zero values mean the proxy timeouts apply.
+type Config struct {
+	// ReadTimeout is the time, in seconds, between two reads from the upstream
+	ReadTimeout int `json:"readTimeout,omitempty"`
+	// SendTimeout is the time, in seconds, between two writes to the upstream
+	SendTimeout int `json:"sendTimeout,omitempty"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if c1.ReadTimeout != c2.ReadTimeout {
+		return false
+	}
+
+	return c1.SendTimeout == c2.SendTimeout
+}
+
+// NewParser creates a new gRPC timeouts annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return grpcTimeout{r}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to configure the timeouts of the gRPC backends
+func (g grpcTimeout) Parse(ing *networking.Ingress) (interface{}, error) {
+	read, err := parser.GetStringAnnotation("grpc-read-timeout", ing)
+	if err != nil && !ing_errors.IsMissingAnnotations(err) {
+		return &Config{}, err
+	}
+
+	send, err := parser.GetStringAnnotation("grpc-send-timeout", ing)
+	if err != nil && !ing_errors.IsMissingAnnotations(err) {
+		return &Config{}, err
+	}
+
+	protocol, _ := parser.GetStringAnnotation("backend-protocol", ing)
+
+	return newConfig(read, send, protocol, ing.Namespace+"/"+ing.Name)
+}
+
+// ParseByMCI parses the annotations contained in the multiclusteringress rule
+// used to configure the timeouts of the gRPC backends
+func (g grpcTimeout) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interface{}, error) {
+	read, err := parser.GetStringAnnotationFromMCI("grpc-read-timeout", mci)
+	if err != nil && !ing_errors.IsMissingAnnotations(err) {
+		return &Config{}, err
+	}
+
+	send, err := parser.GetStringAnnotationFromMCI("grpc-send-timeout", mci)
+	if err != nil && !ing_errors.IsMissingAnnotations(err) {
+		return &Config{}, err
+	}
+
+	protocol, _ := parser.GetStringAnnotationFromMCI("backend-protocol", mci)
+
+	return newConfig(read, send, protocol, mci.Namespace+"/"+mci.Name)
+}
+
+// newConfig checks the timeouts are a positive number of seconds, or a
+// duration like "75s" or "2m", and drops them when the backend protocol
+// is not GRPC or GRPCS
+func newConfig(rawRead, rawSend, protocol, key string) (*Config, error) {
+	config := &Config{}
+
+	if rawRead != "" {
+		timeout, err := parseTimeout(rawRead)
+		if err != nil {
+			return &Config{}, ing_errors.NewInvalidAnnotationContent("grpc-read-timeout", rawRead)
+		}
+		config.ReadTimeout = timeout
+	}
+
+	if rawSend != "" {
+		timeout, err := parseTimeout(rawSend)
+		if err != nil {
+			return &Config{}, ing_errors.NewInvalidAnnotationContent("grpc-send-timeout", rawSend)
+		}
+		config.SendTimeout = timeout
+	}
+
+	if *config == (Config{}) {
+		return config, nil
+	}
+
+	protocol = strings.TrimSpace(strings.ToUpper(protocol))
+	if protocol != "GRPC" && protocol != "GRPCS" {
+		klog.Warningf("Ignoring the gRPC timeouts of %q as its backend protocol is not GRPC or GRPCS", key)
+		return &Config{}, nil
+	}
+
+	return config, nil
+}
+
+func parseTimeout(raw string) (int, error) {
+	if seconds, err := strconv.Atoi(raw); err == nil {
+		if seconds <= 0 {
+			return 0, strconv.ErrRange
+		}
+		return seconds, nil
+	}
+
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, err
+	}
+	if d < time.Second || d%time.Second != 0 {
+		return 0, strconv.ErrRange
+	}
+
+	return int(d / time.Second), nil
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gzip
+
+import (
+	"strings"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	networking "k8s.io/api/networking/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+// DefaultMinLength indicates the minimum length configured
+// in the global gzip-min-length setting should be used
+const DefaultMinLength = -1
+
+// Config contains the gzip compression configuration for a location
+type Config struct {
+	Enabled bool `json:"enabled"`
+	// Types is a space separated list of MIME types to compress.
+	// When empty the global gzip-types setting is used
+	Types string `json:"types,omitempty"`
+	// MinLength is the minimum length of a response to compress, in bytes.
+	// DefaultMinLength uses the global gzip-min-length setting
+	MinLength int `json:"minLength"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if c1.Enabled != c2.Enabled {
+		return false
+	}
+	if c1.Types != c2.Types {
+		return false
+	}
+	if c1.MinLength != c2.MinLength {
+		return false
+	}
+
+	return true
+}
+
+type gzip struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new gzip annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return gzip{r}
+}
+
+// Parse parses the annotations contained in the ingress
+// rule used to configure gzip compression for a location
+func (g gzip) Parse(ing *networking.Ingress) (interface{}, error) {
+	enabled, err := parser.GetBoolAnnotation("enable-gzip", ing)
+	if err != nil || !enabled {
+		return &Config{}, nil
+	}
+
+	types, err := parser.GetStringAnnotation("gzip-types", ing)
+	if err != nil && !ing_errors.IsMissingAnnotations(err) {
+		return &Config{}, err
+	}
+
+	minLength, err := parser.GetIntAnnotation("gzip-min-length", ing)
+	if err != nil {
+		if !ing_errors.IsMissingAnnotations(err) {
+			return &Config{}, err
+		}
+		minLength = DefaultMinLength
+	} else if minLength < 0 {
+		return &Config{}, ing_errors.NewInvalidAnnotationContent("gzip-min-length", minLength)
+	}
+
+	return newConfig(types, minLength)
+}
+
+// ParseByMCI parses the annotations contained in the multiclusteringress
+// rule used to configure gzip compression for a location
+func (g gzip) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interface{}, error) {
+	enabled, err := parser.GetBoolAnnotationFromMCI("enable-gzip", mci)
+	if err != nil || !enabled {
+		return &Config{}, nil
+	}
+
+	types, err := parser.GetStringAnnotationFromMCI("gzip-types", mci)
+	if err != nil && !ing_errors.IsMissingAnnotations(err) {
+		return &Config{}, err
+	}
+
+	minLength, err := parser.GetIntAnnotationFromMCI("gzip-min-length", mci)
+	if err != nil {
+		if !ing_errors.IsMissingAnnotations(err) {
+			return &Config{}, err
+		}
+		minLength = DefaultMinLength
+	} else if minLength < 0 {
+		return &Config{}, ing_errors.NewInvalidAnnotationContent("gzip-min-length", minLength)
+	}
+
+	return newConfig(types, minLength)
+}
+
+func newConfig(types string, minLength int) (*Config, error) {
+	mimeTypes := strings.Fields(types)
+	for _, mimeType := range mimeTypes {
+		if !parser.IsValidMIMEType(mimeType) {
+			return &Config{}, ing_errors.NewInvalidAnnotationContent("gzip-types", types)
+		}
+	}
+
+	return &Config{
+		Enabled:   true,
+		Types:     strings.Join(mimeTypes, " "),
+		MinLength: minLength,
+	}, nil
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gzip
+
+import (
+	"testing"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	api "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+func TestParseByMCI(t *testing.T) {
+	enable := parser.GetAnnotationWithPrefix("enable-gzip")
+	types := parser.GetAnnotationWithPrefix("gzip-types")
+	minLength := parser.GetAnnotationWithPrefix("gzip-min-length")
+
+	ap := NewParser(&resolver.Mock{})
+	if ap == nil {
+		t.Fatalf("expected a parser.IngressAnnotation but returned nil")
+	}
+
+	testCases := []struct {
+		annotations map[string]string
+		expected    *Config
+		expectErr   bool
+	}{
+		{nil, &Config{}, false},
+		{map[string]string{enable: "false", types: "text/css"}, &Config{}, false},
+		{map[string]string{enable: "true"}, &Config{Enabled: true, MinLength: DefaultMinLength}, false},
+		{map[string]string{enable: "true", types: "text/css  application/json", minLength: "1024"}, &Config{Enabled: true, Types: "text/css application/json", MinLength: 1024}, false},
+		{map[string]string{enable: "true", types: "*", minLength: "0"}, &Config{Enabled: true, Types: "*", MinLength: 0}, false},
+		{map[string]string{enable: "true", types: "application/vnd.api+json image/*"}, &Config{Enabled: true, Types: "application/vnd.api+json image/*", MinLength: DefaultMinLength}, false},
+		{map[string]string{enable: "true", minLength: "-1"}, &Config{}, true},
+		{map[string]string{enable: "true", minLength: "1k"}, &Config{}, true},
+		{map[string]string{enable: "true", types: "text"}, &Config{}, true},
+		{map[string]string{enable: "true", types: "text/css; gzip off"}, &Config{}, true},
+	}
+
+	mci := &karmadanetworking.MultiClusterIngress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+	}
+
+	for _, testCase := range testCases {
+		mci.SetAnnotations(testCase.annotations)
+		i, err := ap.ParseByMCI(mci)
+		if testCase.expectErr != (err != nil) {
+			t.Errorf("expected error %v but returned %v, annotations: %s", testCase.expectErr, err, testCase.annotations)
+		}
+
+		p, _ := i.(*Config)
+		if !p.Equal(testCase.expected) {
+			t.Errorf("expected %v but returned %v, annotations: %s", testCase.expected, p, testCase.annotations)
+		}
+	}
+}
+
+func TestEqual(t *testing.T) {
+	c1 := &Config{Enabled: true, Types: "text/css", MinLength: 256}
+
+	if !c1.Equal(&Config{Enabled: true, Types: "text/css", MinLength: 256}) {
+		t.Errorf("expected configurations to be equal")
+	}
+
+	for _, c2 := range []*Config{
+		nil,
+		{Types: "text/css", MinLength: 256},
+		{Enabled: true, Types: "text/plain", MinLength: 256},
+		{Enabled: true, Types: "text/css", MinLength: DefaultMinLength},
+	} {
+		if c1.Equal(c2) {
+			t.Errorf("expected %v and %v to differ", c1, c2)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package healthcheck
+
+import (
+	"strings"
+	"time"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	networking "k8s.io/api/networking/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+const (
+	// DefaultInterval is the time between two probes of an endpoint
+	// when healthcheck-interval is not set
+	DefaultInterval = 10 * time.Second
+
+	// DefaultStatus is the HTTP status code expected from a healthy endpoint
+	// when healthcheck-status is not set
+	DefaultStatus = 200
+)
+
+// Config contains the active health check configuration of a backend
+type Config struct {
+	// Path is the HTTP path requested to probe an endpoint
+	Path string `json:"path,omitempty"`
+	// Interval is the time in seconds between two probes of an endpoint
+	Interval int `json:"interval,omitempty"`
+	// Status is the HTTP status code returned by a healthy endpoint
+	Status int `json:"status,omitempty"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if c1.Path != c2.Path {
+		return false
+	}
+	if c1.Interval != c2.Interval {
+		return false
+	}
+	if c1.Status != c2.Status {
+		return false
+	}
+
+	return true
+}
+
+type healthCheck struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new health check annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return healthCheck{r}
+}
+
+// Parse parses the annotations contained in the ingress
+// rule used to actively probe the endpoints of the upstream
+func (a healthCheck) Parse(ing *networking.Ingress) (interface{}, error) {
+	path, err := parser.GetStringAnnotation("healthcheck-path", ing)
+	if err != nil {
+		return &Config{}, nil
+	}
+
+	interval, err := parser.GetStringAnnotation("healthcheck-interval", ing)
+	if err != nil && !ing_errors.IsMissingAnnotations(err) {
+		return &Config{}, err
+	}
+
+	status, err := parser.GetIntAnnotation("healthcheck-status", ing)
+	if err != nil && !ing_errors.IsMissingAnnotations(err) {
+		return &Config{}, err
+	}
+
+	return newConfig(path, interval, status)
+}
+
+// ParseByMCI parses the annotations contained in the multiclusteringress
+// rule used to actively probe the endpoints of the upstream
+func (a healthCheck) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interface{}, error) {
+	path, err := parser.GetStringAnnotationFromMCI("healthcheck-path", mci)
+	if err != nil {
+		return &Config{}, nil
+	}
+
+	interval, err := parser.GetStringAnnotationFromMCI("healthcheck-interval", mci)
+	if err != nil && !ing_errors.IsMissingAnnotations(err) {
+		return &Config{}, err
+	}
+
+	status, err := parser.GetIntAnnotationFromMCI("healthcheck-status", mci)
+	if err != nil && !ing_errors.IsMissingAnnotations(err) {
+		return &Config{}, err
+	}
+
+	return newConfig(path, interval, status)
+}
+
+func newConfig(path, rawInterval string, status int) (*Config, error) {
+	if !strings.HasPrefix(path, "/") || strings.ContainsAny(path, " \t\r\n") {
+		return &Config{}, ing_errors.NewInvalidAnnotationContent("healthcheck-path", path)
+	}
+
+	interval := DefaultInterval
+	if rawInterval != "" {
+		d, err := time.ParseDuration(rawInterval)
+		if err != nil || d < time.Second {
+			return &Config{}, ing_errors.NewInvalidAnnotationContent("healthcheck-interval", rawInterval)
+		}
+		interval = d
+	}
+
+	if status == 0 {
+		status = DefaultStatus
+	}
+	if status < 100 || status > 599 {
+		return &Config{}, ing_errors.NewInvalidAnnotationContent("healthcheck-status", status)
+	}
+
+	return &Config{
+		Path:     path,
+		Interval: int(interval.Seconds()),
+		Status:   status,
+	}, nil
+}
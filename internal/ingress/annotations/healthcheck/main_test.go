@@ -0,0 +1,78 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package healthcheck
+
+import (
+	"testing"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	api "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+func TestParseByMCI(t *testing.T) {
+	path := parser.GetAnnotationWithPrefix("healthcheck-path")
+	interval := parser.GetAnnotationWithPrefix("healthcheck-interval")
+	status := parser.GetAnnotationWithPrefix("healthcheck-status")
+
+	ap := NewParser(&resolver.Mock{})
+	if ap == nil {
+		t.Fatalf("expected a parser.IngressAnnotation but returned nil")
+	}
+
+	testCases := []struct {
+		annotations map[string]string
+		expected    *Config
+		expectErr   bool
+	}{
+		{nil, &Config{}, false},
+		{map[string]string{interval: "5s", status: "204"}, &Config{}, false},
+		{map[string]string{path: "/healthz"}, &Config{Path: "/healthz", Interval: 10, Status: 200}, false},
+		{map[string]string{path: "/healthz", interval: "1m", status: "204"}, &Config{Path: "/healthz", Interval: 60, Status: 204}, false},
+		{map[string]string{path: "healthz"}, &Config{}, true},
+		{map[string]string{path: "/health z"}, &Config{}, true},
+		{map[string]string{path: "/healthz", interval: "0s"}, &Config{}, true},
+		{map[string]string{path: "/healthz", interval: "-5s"}, &Config{}, true},
+		{map[string]string{path: "/healthz", interval: "500ms"}, &Config{}, true},
+		{map[string]string{path: "/healthz", interval: "often"}, &Config{}, true},
+		{map[string]string{path: "/healthz", status: "600"}, &Config{}, true},
+		{map[string]string{path: "/healthz", status: "ok"}, &Config{}, true},
+	}
+
+	mci := &karmadanetworking.MultiClusterIngress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+	}
+
+	for _, testCase := range testCases {
+		mci.SetAnnotations(testCase.annotations)
+		i, err := ap.ParseByMCI(mci)
+		if testCase.expectErr != (err != nil) {
+			t.Errorf("expected error %v but returned %v, annotations: %s", testCase.expectErr, err, testCase.annotations)
+		}
+
+		c, _ := i.(*Config)
+		if !c.Equal(testCase.expected) {
+			t.Errorf("expected %v but returned %v, annotations: %s", testCase.expected, c, testCase.annotations)
+		}
+	}
+}
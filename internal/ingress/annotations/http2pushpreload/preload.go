@@ -0,0 +1,84 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http2pushpreload
+
+import (
+	"regexp"
+	"strings"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	networking "k8s.io/api/networking/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+// assetRegex matches the paths of the preloaded assets. The paths are
+// emitted between angle brackets in a quoted header value.
+var assetRegex = regexp.MustCompile(`^/[A-Za-z0-9_\-./~%]*$`)
+
+type preloadLinks struct {
+	r resolver.Resolver
+}
+
+// NewPreloadLinksParser creates a new preload links annotation parser. The
+// assets are announced with Link rel=preload headers instead of HTTP/2 push.
+func NewPreloadLinksParser(r resolver.Resolver) parser.IngressAnnotation {
+	return preloadLinks{r}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to announce the assets to preload
+func (pl preloadLinks) Parse(ing *networking.Ingress) (interface{}, error) {
+	val, err := parser.GetStringAnnotation("preload-links", ing)
+	if err != nil {
+		return []string{}, err
+	}
+
+	return parseAssets(val)
+}
+
+// ParseByMCI parses the annotations contained in the multiclusteringress rule
+// used to announce the assets to preload
+func (pl preloadLinks) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interface{}, error) {
+	val, err := parser.GetStringAnnotationFromMCI("preload-links", mci)
+	if err != nil {
+		return []string{}, err
+	}
+
+	return parseAssets(val)
+}
+
+// parseAssets returns the paths of a comma separated list of assets
+func parseAssets(val string) ([]string, error) {
+	assets := []string{}
+	for _, asset := range strings.Split(val, ",") {
+		asset = strings.TrimSpace(asset)
+		if asset == "" {
+			continue
+		}
+
+		if !assetRegex.MatchString(asset) || strings.Contains(asset, "..") {
+			return []string{}, ing_errors.NewInvalidAnnotationContent("preload-links", val)
+		}
+
+		assets = append(assets, asset)
+	}
+
+	return assets, nil
+}
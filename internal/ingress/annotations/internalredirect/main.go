@@ -0,0 +1,200 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internalredirect
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	networking "k8s.io/api/networking/v1"
+	"k8s.io/klog/v2"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+	"k8s.io/ingress-nginx/internal/k8s"
+)
+
+// pathRegex matches the paths of the internal locations. Characters used by
+// the NGINX configuration syntax and regular expressions are not allowed, '.'
+// being the only exception as it also matches itself when the path is used in
+// the regular expression rewriting the URI.
+var pathRegex = regexp.MustCompile(`^/[A-Za-z0-9_\-./~]*$`)
+
+// Location is an internal location, only reachable through the
+// X-Accel-Redirect header of the responses of the upstreams
+type Location struct {
+	// Path is the prefix of the internal location
+	Path string `json:"path"`
+	// Target is the scheme and the host the requests redirected to the
+	// location are proxied to. The host is resolved when the request is
+	// proxied, not when NGINX loads the configuration.
+	Target string `json:"target"`
+	// URI replaces the path of the location in the proxied requests, they
+	// keep their URI when it is empty
+	URI string `json:"uri,omitempty"`
+}
+
+// Config contains the internal locations of a server
+type Config struct {
+	Enabled   bool       `json:"enabled"`
+	Locations []Location `json:"locations,omitempty"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if c1.Enabled != c2.Enabled {
+		return false
+	}
+	if len(c1.Locations) != len(c2.Locations) {
+		return false
+	}
+	for i := range c1.Locations {
+		if c1.Locations[i] != c2.Locations[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+type internalRedirect struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new internal redirect annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return internalRedirect{r}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to define the internal locations of the server
+func (a internalRedirect) Parse(ing *networking.Ingress) (interface{}, error) {
+	enabled, err := parser.GetBoolAnnotation("enable-internal-redirect", ing)
+	if err != nil || !enabled {
+		return &Config{}, err
+	}
+
+	configMap, err := parser.GetStringAnnotation("internal-redirect-locations", ing)
+	if err != nil {
+		return &Config{}, err
+	}
+
+	return a.newConfig(ing.Namespace, configMap)
+}
+
+// ParseByMCI parses the annotations contained in the multiclusteringress rule
+// used to define the internal locations of the server
+func (a internalRedirect) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interface{}, error) {
+	enabled, err := parser.GetBoolAnnotationFromMCI("enable-internal-redirect", mci)
+	if err != nil || !enabled {
+		return &Config{}, err
+	}
+
+	configMap, err := parser.GetStringAnnotationFromMCI("internal-redirect-locations", mci)
+	if err != nil {
+		if ing_errors.IsMissingAnnotations(err) {
+			return &Config{}, ing_errors.NewInvalidAnnotationConfiguration("enable-internal-redirect",
+				"internal-redirect-locations is required")
+		}
+		return &Config{}, err
+	}
+
+	return a.newConfig(mci.Namespace, configMap)
+}
+
+// newConfig reads the internal locations from the referenced configmap, where
+// the keys are the paths of the locations and the values the proxied URLs.
+// The configmap must be in the namespace of the resource referencing it.
+func (a internalRedirect) newConfig(namespace, configMap string) (*Config, error) {
+	ns, _, err := k8s.ParseNameNS(configMap)
+	if err != nil {
+		return &Config{}, ing_errors.NewInvalidAnnotationContent("internal-redirect-locations", configMap)
+	}
+
+	if ns != namespace {
+		return &Config{}, ing_errors.NewInvalidAnnotationConfiguration("internal-redirect-locations",
+			fmt.Sprintf("the configmap %v is not in the namespace %v", configMap, namespace))
+	}
+
+	cmap, err := a.r.GetConfigMap(configMap)
+	if err != nil {
+		klog.Warningf("Error reading ConfigMap %q referenced in internal-redirect-locations: %v", configMap, err)
+		return &Config{}, fmt.Errorf("unable to find configMap %q: %w", configMap, err)
+	}
+
+	locations := make([]Location, 0, len(cmap.Data))
+	for path, target := range cmap.Data {
+		if !pathRegex.MatchString(path) || strings.Contains(path, "..") {
+			return &Config{}, fmt.Errorf("invalid internal location path %q in configMap %q", path, configMap)
+		}
+
+		u, ok := parseTarget(target)
+		if !ok {
+			return &Config{}, fmt.Errorf("invalid target %q of internal location %q in configMap %q", target, path, configMap)
+		}
+
+		locations = append(locations, Location{
+			Path:   path,
+			Target: fmt.Sprintf("%v://%v", u.Scheme, u.Host),
+			URI:    u.EscapedPath(),
+		})
+	}
+
+	sort.Slice(locations, func(i, j int) bool {
+		return locations[i].Path < locations[j].Path
+	})
+
+	return &Config{
+		Enabled:   true,
+		Locations: locations,
+	}, nil
+}
+
+// parseTarget parses the target, an absolute HTTP or HTTPS URL without
+// credentials, query or fragment that cannot inject other directives in the
+// configuration
+func parseTarget(target string) (*url.URL, bool) {
+	if strings.ContainsAny(target, " \t\r\n\"';{}$\\") {
+		return nil, false
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, false
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return nil, false
+	}
+
+	if u.User != nil || u.RawQuery != "" || u.ForceQuery || u.Fragment != "" {
+		return nil, false
+	}
+
+	return u, true
+}
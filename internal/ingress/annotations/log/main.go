@@ -17,10 +17,14 @@ limitations under the License.
 package log
 
 import (
+	"strconv"
+	"strings"
+
 	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
 	networking "k8s.io/api/networking/v1"
 
 	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 )
 
@@ -32,6 +36,10 @@ type log struct {
 type Config struct {
 	Access  bool `json:"accessLog"`
 	Rewrite bool `json:"rewriteLog"`
+	// SampleRate is the fraction of the requests written to the access log
+	SampleRate float64 `json:"accessLogSampleRate"`
+	// RequestBodySize sets the $request_body_size log format variable
+	RequestBodySize bool `json:"logRequestBodySize"`
 }
 
 // Equal tests for equality between two Config types
@@ -44,6 +52,14 @@ func (bd1 *Config) Equal(bd2 *Config) bool {
 		return false
 	}
 
+	if bd1.SampleRate != bd2.SampleRate {
+		return false
+	}
+
+	if bd1.RequestBodySize != bd2.RequestBodySize {
+		return false
+	}
+
 	return true
 }
 
@@ -68,6 +84,8 @@ func (l log) Parse(ing *networking.Ingress) (interface{}, error) {
 		config.Rewrite = false
 	}
 
+	config.SampleRate = 1
+
 	return config, nil
 }
 
@@ -87,5 +105,26 @@ func (l log) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interface{}
 		config.Rewrite = false
 	}
 
+	config.RequestBodySize, err = parser.GetBoolAnnotationFromMCI("log-request-body-size", mci)
+	if err != nil {
+		config.RequestBodySize = false
+	}
+
+	config.SampleRate = 1
+	rate, err := parser.GetStringAnnotationFromMCI("access-log-sample-rate", mci)
+	if err != nil {
+		return config, nil
+	}
+
+	config.SampleRate, err = strconv.ParseFloat(strings.TrimSpace(rate), 64)
+	if err != nil || !(config.SampleRate >= 0 && config.SampleRate <= 1) {
+		return nil, ing_errors.NewInvalidAnnotationContent("access-log-sample-rate", rate)
+	}
+
+	// sampling none of the requests is disabling the access log
+	if config.SampleRate == 0 {
+		config.Access = false
+	}
+
 	return config, nil
 }
@@ -19,6 +19,7 @@ package log
 import (
 	"testing"
 
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
 	api "k8s.io/api/core/v1"
 	networking "k8s.io/api/networking/v1"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -104,3 +105,60 @@ func TestIngressRewriteLogConfig(t *testing.T) {
 		t.Errorf("expected rewrite log to be enabled but it is disabled")
 	}
 }
+
+func TestParseByMCI(t *testing.T) {
+	accessLog := parser.GetAnnotationWithPrefix("enable-access-log")
+	sampleRate := parser.GetAnnotationWithPrefix("access-log-sample-rate")
+	bodySize := parser.GetAnnotationWithPrefix("log-request-body-size")
+
+	ap := NewParser(&resolver.Mock{})
+	if ap == nil {
+		t.Fatalf("expected a parser.IngressAnnotation but returned nil")
+	}
+
+	testCases := []struct {
+		annotations map[string]string
+		expected    *Config
+		expectErr   bool
+	}{
+		{nil, &Config{Access: true, SampleRate: 1}, false},
+		{map[string]string{accessLog: "false"}, &Config{SampleRate: 1}, false},
+		{map[string]string{sampleRate: "0.25"}, &Config{Access: true, SampleRate: 0.25}, false},
+		{map[string]string{sampleRate: " 0.001 "}, &Config{Access: true, SampleRate: 0.001}, false},
+		{map[string]string{sampleRate: "1"}, &Config{Access: true, SampleRate: 1}, false},
+		{map[string]string{sampleRate: "0"}, &Config{SampleRate: 0}, false},
+		{map[string]string{accessLog: "false", sampleRate: "0.5"}, &Config{SampleRate: 0.5}, false},
+		{map[string]string{sampleRate: "1.5"}, nil, true},
+		{map[string]string{sampleRate: "-0.1"}, nil, true},
+		{map[string]string{sampleRate: "NaN"}, nil, true},
+		{map[string]string{sampleRate: "half"}, nil, true},
+		{map[string]string{bodySize: "true"}, &Config{Access: true, SampleRate: 1, RequestBodySize: true}, false},
+		{map[string]string{bodySize: "false"}, &Config{Access: true, SampleRate: 1}, false},
+		{map[string]string{bodySize: "yes please"}, &Config{Access: true, SampleRate: 1}, false},
+		{map[string]string{bodySize: "true", sampleRate: "0.5"}, &Config{Access: true, SampleRate: 0.5, RequestBodySize: true}, false},
+	}
+
+	mci := &karmadanetworking.MultiClusterIngress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+	}
+
+	for _, testCase := range testCases {
+		mci.SetAnnotations(testCase.annotations)
+		i, err := ap.ParseByMCI(mci)
+		if testCase.expectErr != (err != nil) {
+			t.Errorf("expected error %v but returned %v, annotations: %s", testCase.expectErr, err, testCase.annotations)
+		}
+
+		if testCase.expected == nil {
+			continue
+		}
+
+		c, _ := i.(*Config)
+		if c == nil || !c.Equal(testCase.expected) {
+			t.Errorf("expected %v but returned %v, annotations: %s", testCase.expected, c, testCase.annotations)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package maintenancemode
+
+import (
+	"testing"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	api "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+func buildMCI() *karmadanetworking.MultiClusterIngress {
+	return &karmadanetworking.MultiClusterIngress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "shop",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: networking.IngressSpec{
+			DefaultBackend: &networking.IngressBackend{
+				Service: &networking.IngressServiceBackend{
+					Name: "shop",
+					Port: networking.ServiceBackendPort{
+						Number: 80,
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestParseAnnotationsByMCI(t *testing.T) {
+	mci := buildMCI()
+
+	_, err := NewParser(&resolver.Mock{}).ParseByMCI(mci)
+	if !errors.IsMissingAnnotations(err) {
+		t.Errorf("expected a missing annotation error but returned %v", err)
+	}
+
+	// returning 503 for the empty upstreams does not put the locations in maintenance
+	mci.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix("service-unavailable-on-empty-upstream"): "true",
+	})
+	_, err = NewParser(&resolver.Mock{}).ParseByMCI(mci)
+	if !errors.IsMissingAnnotations(err) {
+		t.Errorf("expected a missing annotation error with service-unavailable-on-empty-upstream only but returned %v", err)
+	}
+
+	// test the maintenance served by the custom default backend
+	mci.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix("maintenance-mode"): "true",
+		parser.GetAnnotationWithPrefix("default-backend"):  "maintenance-page",
+	})
+	i, err := NewParser(&resolver.Mock{}).ParseByMCI(mci)
+	if err != nil {
+		t.Errorf("unexpected error parsing multiclusteringress with maintenance-mode: %v", err)
+	}
+	val, ok := i.(bool)
+	if !ok {
+		t.Errorf("expected a bool type")
+	}
+	if !val {
+		t.Errorf("expected true but false returned")
+	}
+
+	// the end of the maintenance can be set with any false value of strconv.ParseBool
+	mci.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix("maintenance-mode"): "0",
+	})
+	i, err = NewParser(&resolver.Mock{}).ParseByMCI(mci)
+	if err != nil {
+		t.Errorf("unexpected error parsing multiclusteringress with maintenance-mode: %v", err)
+	}
+	if val, _ := i.(bool); val {
+		t.Errorf("expected false but true returned")
+	}
+
+	mci.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix("maintenance-mode"): "planned",
+	})
+	_, err = NewParser(&resolver.Mock{}).ParseByMCI(mci)
+	if !errors.IsInvalidContent(err) {
+		t.Errorf("expected an invalid content error but returned %v", err)
+	}
+}
+
+func TestParseAnnotations(t *testing.T) {
+	ing := &networking.Ingress{
+		ObjectMeta: buildMCI().ObjectMeta,
+		Spec:       buildMCI().Spec,
+	}
+
+	_, err := NewParser(&resolver.Mock{}).Parse(ing)
+	if !errors.IsMissingAnnotations(err) {
+		t.Errorf("expected a missing annotation error but returned %v", err)
+	}
+
+	ing.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix("maintenance-mode"): "true",
+	})
+	i, err := NewParser(&resolver.Mock{}).Parse(ing)
+	if err != nil {
+		t.Errorf("unexpected error parsing ingress with maintenance-mode: %v", err)
+	}
+	if val, _ := i.(bool); !val {
+		t.Errorf("expected true but false returned")
+	}
+
+	ing.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix("maintenance-mode"): "on",
+	})
+	_, err = NewParser(&resolver.Mock{}).Parse(ing)
+	if !errors.IsInvalidContent(err) {
+		t.Errorf("expected an invalid content error but returned %v", err)
+	}
+}
@@ -19,6 +19,7 @@ package parser
 import (
 	"fmt"
 	"net/url"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -37,6 +38,15 @@ var (
 	AnnotationsPrefix = DefaultAnnotationsPrefix
 )
 
+var (
+	// mimeTypeRegex matches a MIME type, a type with any subtype or the
+	// special value * matching any MIME type
+	mimeTypeRegex = regexp.MustCompile(`^(\*|[a-zA-Z0-9][a-zA-Z0-9!#&^_.+-]*/(\*|[a-zA-Z0-9][a-zA-Z0-9!#&^_.+-]*))$`)
+	// cipherListRegex matches OpenSSL cipher strings separated by colons or
+	// commas, each one optionally prefixed by !, - or +
+	cipherListRegex = regexp.MustCompile(`^[!+-]?[A-Za-z0-9_.=@+-]+([:,][!+-]?[A-Za-z0-9_.=@+-]+)*$`)
+)
+
 // IngressAnnotation has a method to parse annotations located in Ingress
 type IngressAnnotation interface {
 	Parse(ing *networking.Ingress) (interface{}, error)
@@ -236,3 +246,15 @@ func StringToURL(input string) (*url.URL, error) {
 
 	return parsedURL, nil
 }
+
+// IsValidMIMEType checks the value can be used as a MIME type in the
+// *_types directives of NGINX, like text/html, text/* or *
+func IsValidMIMEType(mimeType string) bool {
+	return mimeTypeRegex.MatchString(mimeType)
+}
+
+// IsValidCipherList checks the value is a list of cipher strings understood
+// by OpenSSL that can be used unquoted in the *ssl_ciphers directives of NGINX
+func IsValidCipherList(ciphers string) bool {
+	return cipherListRegex.MatchString(ciphers)
+}
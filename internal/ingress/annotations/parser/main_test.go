@@ -206,3 +206,50 @@ func TestStringToURL(t *testing.T) {
 		}
 	}
 }
+
+func TestIsValidMIMEType(t *testing.T) {
+	tests := []struct {
+		mimeType string
+		valid    bool
+	}{
+		{"text/html", true},
+		{"application/vnd.api+json", true},
+		{"Text/CSS", true},
+		{"text/*", true},
+		{"*", true},
+		{"", false},
+		{"text", false},
+		{"*/html", false},
+		{"text/html;", false},
+		{"text/$type", false},
+		{"text/html charset=utf-8", false},
+	}
+
+	for _, test := range tests {
+		if valid := IsValidMIMEType(test.mimeType); valid != test.valid {
+			t.Errorf("%q: expected %v but %v was returned", test.mimeType, test.valid, valid)
+		}
+	}
+}
+
+func TestIsValidCipherList(t *testing.T) {
+	tests := []struct {
+		ciphers string
+		valid   bool
+	}{
+		{"HIGH:!aNULL:!MD5", true},
+		{"ECDHE-RSA-AES128-GCM-SHA256:-SHA:+RSA:@STRENGTH", true},
+		{"HIGH,!aNULL", true},
+		{"", false},
+		{"HIGH::", false},
+		{"HIGH !aNULL", false},
+		{"'HIGH'", false},
+		{"HIGH; return 200", false},
+	}
+
+	for _, test := range tests {
+		if valid := IsValidCipherList(test.ciphers); valid != test.valid {
+			t.Errorf("%q: expected %v but %v was returned", test.ciphers, test.valid, valid)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package passivehealthcheck
+
+import (
+	"strconv"
+	"time"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	networking "k8s.io/api/networking/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+// DefaultFailTimeout is the time an endpoint is considered unavailable
+// when upstream-fail-timeout is not set, like the default of NGINX
+const DefaultFailTimeout = 10 * time.Second
+
+// Config contains the passive health check configuration of the endpoints
+// of a backend, like the max_fails and fail_timeout parameters of the
+// servers of an NGINX upstream
+type Config struct {
+	// MaxFails is the number of failed attempts to reach an endpoint, in
+	// FailTimeout seconds, after which the endpoint is considered unavailable.
+	// Zero disables the passive health check
+	MaxFails int `json:"maxFails,omitempty"`
+	// FailTimeout is the time in seconds the failures are counted in, and
+	// the endpoint is then considered unavailable for
+	FailTimeout int `json:"failTimeout,omitempty"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if c1.MaxFails != c2.MaxFails {
+		return false
+	}
+	if c1.FailTimeout != c2.FailTimeout {
+		return false
+	}
+
+	return true
+}
+
+type passiveHealthCheck struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new passive health check annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return passiveHealthCheck{r}
+}
+
+// Parse parses the annotations contained in the ingress rule used
+// to stop sending requests to the endpoints failing too often
+func (a passiveHealthCheck) Parse(ing *networking.Ingress) (interface{}, error) {
+	maxFails, err := parser.GetIntAnnotation("upstream-max-fails", ing)
+	if err != nil && !ing_errors.IsMissingAnnotations(err) {
+		return &Config{}, err
+	}
+
+	failTimeout, err := parser.GetStringAnnotation("upstream-fail-timeout", ing)
+	if err != nil && !ing_errors.IsMissingAnnotations(err) {
+		return &Config{}, err
+	}
+
+	return newConfig(maxFails, failTimeout)
+}
+
+// ParseByMCI parses the annotations contained in the multiclusteringress rule
+// used to stop sending requests to the endpoints failing too often
+func (a passiveHealthCheck) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interface{}, error) {
+	maxFails, err := parser.GetIntAnnotationFromMCI("upstream-max-fails", mci)
+	if err != nil && !ing_errors.IsMissingAnnotations(err) {
+		return &Config{}, err
+	}
+
+	failTimeout, err := parser.GetStringAnnotationFromMCI("upstream-fail-timeout", mci)
+	if err != nil && !ing_errors.IsMissingAnnotations(err) {
+		return &Config{}, err
+	}
+
+	return newConfig(maxFails, failTimeout)
+}
+
+func newConfig(maxFails int, rawFailTimeout string) (*Config, error) {
+	if maxFails < 0 {
+		return &Config{}, ing_errors.NewInvalidAnnotationContent("upstream-max-fails", maxFails)
+	}
+
+	failTimeout := DefaultFailTimeout
+	if rawFailTimeout != "" {
+		d, err := parseDuration(rawFailTimeout)
+		if err != nil || d < time.Second {
+			return &Config{}, ing_errors.NewInvalidAnnotationContent("upstream-fail-timeout", rawFailTimeout)
+		}
+		failTimeout = d
+	}
+
+	if maxFails == 0 {
+		return &Config{}, nil
+	}
+
+	return &Config{
+		MaxFails:    maxFails,
+		FailTimeout: int(failTimeout.Seconds()),
+	}, nil
+}
+
+// parseDuration accepts a duration like "30s" or a number of seconds
+func parseDuration(value string) (time.Duration, error) {
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+
+	return time.ParseDuration(value)
+}
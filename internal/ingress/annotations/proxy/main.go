@@ -17,13 +17,25 @@ limitations under the License.
 package proxy
 
 import (
+	"regexp"
+	"strings"
+
 	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
 	networking "k8s.io/api/networking/v1"
+	"k8s.io/klog/v2"
 
+	"k8s.io/ingress-nginx/internal/ingress/annotations/backendprotocol"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/errors"
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 )
 
+// sizeRegex matches the NGINX size syntax, see http://nginx.org/en/docs/syntax.html
+var sizeRegex = regexp.MustCompile("^[0-9]+[kKmM]?$")
+
+// httpVersionRegex matches the versions accepted by proxy_http_version
+var httpVersionRegex = regexp.MustCompile(`^1\.[01]$`)
+
 // Config returns the proxy timeout to use in the upstream server/s
 type Config struct {
 	BodySize             string `json:"bodySize"`
@@ -43,6 +55,9 @@ type Config struct {
 	ProxyBuffering       string `json:"proxyBuffering"`
 	ProxyHTTPVersion     string `json:"proxyHTTPVersion"`
 	ProxyMaxTempFileSize string `json:"proxyMaxTempFileSize"`
+	// ConnectionClose sends "Connection: close" to the upstream, which is
+	// required when HTTP/1.0 is used to talk to the backend
+	ConnectionClose bool `json:"connectionClose"`
 }
 
 // Equal tests for equality between two Configuration types
@@ -105,6 +120,9 @@ func (l1 *Config) Equal(l2 *Config) bool {
 	if l1.ProxyMaxTempFileSize != l2.ProxyMaxTempFileSize {
 		return false
 	}
+	if l1.ConnectionClose != l2.ConnectionClose {
+		return false
+	}
 
 	return true
 }
@@ -222,7 +240,7 @@ func (a proxy) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interface
 
 	var err error
 
-	config.ConnectTimeout, err = parser.GetIntAnnotationFromMCI("proxy-connect-timeout", mci)
+	config.ConnectTimeout, err = getPositiveIntAnnotationFromMCI("proxy-connect-timeout", mci)
 	if err != nil {
 		config.ConnectTimeout = defBackend.ProxyConnectTimeout
 	}
@@ -237,22 +255,22 @@ func (a proxy) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interface
 		config.ReadTimeout = defBackend.ProxyReadTimeout
 	}
 
-	config.BuffersNumber, err = parser.GetIntAnnotationFromMCI("proxy-buffers-number", mci)
+	config.BuffersNumber, err = getPositiveIntAnnotationFromMCI("proxy-buffers-number", mci)
 	if err != nil {
 		config.BuffersNumber = defBackend.ProxyBuffersNumber
 	}
 
-	config.BufferSize, err = parser.GetStringAnnotationFromMCI("proxy-buffer-size", mci)
+	config.BufferSize, err = getSizeAnnotationFromMCI("proxy-buffer-size", mci)
 	if err != nil {
 		config.BufferSize = defBackend.ProxyBufferSize
 	}
 
-	config.CookiePath, err = parser.GetStringAnnotationFromMCI("proxy-cookie-path", mci)
+	config.CookiePath, err = getCookieRewriteAnnotationFromMCI("proxy-cookie-path", mci)
 	if err != nil {
 		config.CookiePath = defBackend.ProxyCookiePath
 	}
 
-	config.CookieDomain, err = parser.GetStringAnnotationFromMCI("proxy-cookie-domain", mci)
+	config.CookieDomain, err = getCookieRewriteAnnotationFromMCI("proxy-cookie-domain", mci)
 	if err != nil {
 		config.CookieDomain = defBackend.ProxyCookieDomain
 	}
@@ -297,10 +315,11 @@ func (a proxy) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interface
 		config.ProxyBuffering = defBackend.ProxyBuffering
 	}
 
-	config.ProxyHTTPVersion, err = parser.GetStringAnnotationFromMCI("proxy-http-version", mci)
+	config.ProxyHTTPVersion, err = getHTTPVersionAnnotationFromMCI("proxy-http-version", mci)
 	if err != nil {
 		config.ProxyHTTPVersion = defBackend.ProxyHTTPVersion
 	}
+	config.ConnectionClose = config.ProxyHTTPVersion == "1.0"
 
 	config.ProxyMaxTempFileSize, err = parser.GetStringAnnotationFromMCI("proxy-max-temp-file-size", mci)
 	if err != nil {
@@ -309,3 +328,91 @@ func (a proxy) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interface
 
 	return config, nil
 }
+
+// getCookieRewriteAnnotationFromMCI reads a proxy_cookie_domain or proxy_cookie_path
+// annotation. Valid values are the special value "off" or a "from to" rewrite pair.
+func getCookieRewriteAnnotationFromMCI(name string, mci *karmadanetworking.MultiClusterIngress) (string, error) {
+	val, err := parser.GetStringAnnotationFromMCI(name, mci)
+	if err != nil {
+		return "", err
+	}
+
+	if val != "off" && len(strings.Fields(val)) != 2 {
+		klog.Warningf("%v annotation of multiclusteringress %v/%v must be 'off' or a 'from to' pair, ignoring value %q",
+			name, mci.Namespace, mci.Name, val)
+		return "", errors.NewInvalidAnnotationContent(name, val)
+	}
+
+	return val, nil
+}
+
+// getHTTPVersionAnnotationFromMCI reads the HTTP version used to talk to the
+// backend. HTTP/1.0 closes the upstream connection after every request, so it
+// cannot be combined with a WebSocket backend protocol or a connection-proxy-header
+// other than close.
+func getHTTPVersionAnnotationFromMCI(name string, mci *karmadanetworking.MultiClusterIngress) (string, error) {
+	val, err := parser.GetStringAnnotationFromMCI(name, mci)
+	if err != nil {
+		return "", err
+	}
+
+	if !httpVersionRegex.MatchString(val) {
+		klog.Warningf("%v annotation of multiclusteringress %v/%v must be 1.0 or 1.1, ignoring value %q",
+			name, mci.Namespace, mci.Name, val)
+		return "", errors.NewInvalidAnnotationContent(name, val)
+	}
+
+	if val != "1.0" {
+		return val, nil
+	}
+
+	proto, err := parser.GetStringAnnotationFromMCI("backend-protocol", mci)
+	if err == nil && backendprotocol.IsWebSocket(strings.ToUpper(strings.TrimSpace(proto))) {
+		klog.Warningf("%v annotation of multiclusteringress %v/%v cannot be 1.0 with the %v backend protocol, ignoring value %q",
+			name, mci.Namespace, mci.Name, proto, val)
+		return "", errors.NewInvalidAnnotationContent(name, val)
+	}
+
+	header, err := parser.GetStringAnnotationFromMCI("connection-proxy-header", mci)
+	if err == nil && !strings.EqualFold(header, "close") {
+		klog.Warningf("%v annotation of multiclusteringress %v/%v cannot be 1.0 with the connection-proxy-header %q, ignoring value %q",
+			name, mci.Namespace, mci.Name, header, val)
+		return "", errors.NewInvalidAnnotationContent(name, val)
+	}
+
+	return val, nil
+}
+
+// getPositiveIntAnnotationFromMCI reads an annotation which must be a positive
+// integer, such as a number of buffers or a timeout in seconds.
+func getPositiveIntAnnotationFromMCI(name string, mci *karmadanetworking.MultiClusterIngress) (int, error) {
+	val, err := parser.GetIntAnnotationFromMCI(name, mci)
+	if err != nil {
+		return 0, err
+	}
+
+	if val <= 0 {
+		klog.Warningf("%v annotation of multiclusteringress %v/%v must be a positive integer, ignoring value %v",
+			name, mci.Namespace, mci.Name, val)
+		return 0, errors.NewInvalidAnnotationContent(name, val)
+	}
+
+	return val, nil
+}
+
+// getSizeAnnotationFromMCI reads an annotation using the NGINX size syntax,
+// a number optionally followed by k or m.
+func getSizeAnnotationFromMCI(name string, mci *karmadanetworking.MultiClusterIngress) (string, error) {
+	val, err := parser.GetStringAnnotationFromMCI(name, mci)
+	if err != nil {
+		return "", err
+	}
+
+	if !sizeRegex.MatchString(val) {
+		klog.Warningf("%v annotation of multiclusteringress %v/%v must be a size such as 8k or 1m, ignoring value %q",
+			name, mci.Namespace, mci.Name, val)
+		return "", errors.NewInvalidAnnotationContent(name, val)
+	}
+
+	return val, nil
+}
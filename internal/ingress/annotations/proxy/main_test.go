@@ -19,6 +19,7 @@ package proxy
 import (
 	"testing"
 
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
 	api "k8s.io/api/core/v1"
 	networking "k8s.io/api/networking/v1"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -71,6 +72,15 @@ func buildIngress() *networking.Ingress {
 	}
 }
 
+func buildMCI() *karmadanetworking.MultiClusterIngress {
+	ing := buildIngress()
+
+	return &karmadanetworking.MultiClusterIngress{
+		ObjectMeta: ing.ObjectMeta,
+		Spec:       ing.Spec,
+	}
+}
+
 type mockBackend struct {
 	resolver.Mock
 }
@@ -90,6 +100,8 @@ func (m mockBackend) GetDefaultBackend() defaults.Backend {
 		ProxyBuffering:           "off",
 		ProxyHTTPVersion:         "1.1",
 		ProxyMaxTempFileSize:     "1024m",
+		ProxyCookieDomain:        "off",
+		ProxyCookiePath:          "off",
 	}
 }
 
@@ -212,3 +224,201 @@ func TestProxyWithNoAnnotation(t *testing.T) {
 		t.Errorf("expected 1024m as proxy-max-temp-file-size but returned %v", p.ProxyMaxTempFileSize)
 	}
 }
+
+func TestProxyCookieRewriteByMCI(t *testing.T) {
+	testCases := []struct {
+		title          string
+		annotations    map[string]string
+		expectedDomain string
+		expectedPath   string
+	}{
+		{"no annotations use the global defaults", map[string]string{}, "off", "off"},
+		{"off disables the rewrite", map[string]string{
+			parser.GetAnnotationWithPrefix("proxy-cookie-domain"): "off",
+			parser.GetAnnotationWithPrefix("proxy-cookie-path"):   "off",
+		}, "off", "off"},
+		{"domain and path rewrite pairs", map[string]string{
+			parser.GetAnnotationWithPrefix("proxy-cookie-domain"): "localhost example.org",
+			parser.GetAnnotationWithPrefix("proxy-cookie-path"):   "/one/ /",
+		}, "localhost example.org", "/one/ /"},
+		{"invalid syntax falls back to the global defaults", map[string]string{
+			parser.GetAnnotationWithPrefix("proxy-cookie-domain"): "example.org",
+			parser.GetAnnotationWithPrefix("proxy-cookie-path"):   "/one/ / /two/",
+		}, "off", "off"},
+	}
+
+	for _, tc := range testCases {
+		mci := buildMCI()
+		mci.SetAnnotations(tc.annotations)
+
+		i, err := NewParser(mockBackend{}).ParseByMCI(mci)
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %v", tc.title, err)
+		}
+		p, ok := i.(*Config)
+		if !ok {
+			t.Fatalf("%v: expected a Config type", tc.title)
+		}
+		if p.CookieDomain != tc.expectedDomain {
+			t.Errorf("%v: expected %q as proxy-cookie-domain but returned %q", tc.title, tc.expectedDomain, p.CookieDomain)
+		}
+		if p.CookiePath != tc.expectedPath {
+			t.Errorf("%v: expected %q as proxy-cookie-path but returned %q", tc.title, tc.expectedPath, p.CookiePath)
+		}
+	}
+}
+
+func TestProxyBuffersByMCI(t *testing.T) {
+	testCases := []struct {
+		title          string
+		annotations    map[string]string
+		expectedNumber int
+		expectedSize   string
+	}{
+		{"no annotations use the global defaults", map[string]string{}, 4, "10k"},
+		{"valid number and size", map[string]string{
+			parser.GetAnnotationWithPrefix("proxy-buffers-number"): "8",
+			parser.GetAnnotationWithPrefix("proxy-buffer-size"):    "16k",
+		}, 8, "16k"},
+		{"size without unit", map[string]string{
+			parser.GetAnnotationWithPrefix("proxy-buffer-size"): "4096",
+		}, 4, "4096"},
+		{"size in megabytes", map[string]string{
+			parser.GetAnnotationWithPrefix("proxy-buffer-size"): "1M",
+		}, 4, "1M"},
+		{"invalid size syntax falls back to the global default", map[string]string{
+			parser.GetAnnotationWithPrefix("proxy-buffer-size"): "16kb",
+		}, 4, "10k"},
+		{"gigabytes are not a valid size", map[string]string{
+			parser.GetAnnotationWithPrefix("proxy-buffer-size"): "1g",
+		}, 4, "10k"},
+		{"size injecting a directive falls back to the global default", map[string]string{
+			parser.GetAnnotationWithPrefix("proxy-buffer-size"): "8k; return 200",
+		}, 4, "10k"},
+		{"zero buffers fall back to the global default", map[string]string{
+			parser.GetAnnotationWithPrefix("proxy-buffers-number"): "0",
+		}, 4, "10k"},
+		{"negative buffers fall back to the global default", map[string]string{
+			parser.GetAnnotationWithPrefix("proxy-buffers-number"): "-2",
+		}, 4, "10k"},
+	}
+
+	for _, tc := range testCases {
+		mci := buildMCI()
+		mci.SetAnnotations(tc.annotations)
+
+		i, err := NewParser(mockBackend{}).ParseByMCI(mci)
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %v", tc.title, err)
+		}
+		p, ok := i.(*Config)
+		if !ok {
+			t.Fatalf("%v: expected a Config type", tc.title)
+		}
+		if p.BuffersNumber != tc.expectedNumber {
+			t.Errorf("%v: expected %v as proxy-buffers-number but returned %v", tc.title, tc.expectedNumber, p.BuffersNumber)
+		}
+		if p.BufferSize != tc.expectedSize {
+			t.Errorf("%v: expected %q as proxy-buffer-size but returned %q", tc.title, tc.expectedSize, p.BufferSize)
+		}
+	}
+}
+
+func TestProxyConnectTimeoutByMCI(t *testing.T) {
+	testCases := []struct {
+		title    string
+		value    string
+		expected int
+	}{
+		{"valid connect timeout", "3", 3},
+		{"zero falls back to the global default", "0", 10},
+		{"negative falls back to the global default", "-5", 10},
+		{"not a number falls back to the global default", "5s", 10},
+	}
+
+	defBackend := mockBackend{}.GetDefaultBackend()
+
+	for _, tc := range testCases {
+		mci := buildMCI()
+		mci.SetAnnotations(map[string]string{
+			parser.GetAnnotationWithPrefix("proxy-connect-timeout"): tc.value,
+		})
+
+		i, err := NewParser(mockBackend{}).ParseByMCI(mci)
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %v", tc.title, err)
+		}
+		p, ok := i.(*Config)
+		if !ok {
+			t.Fatalf("%v: expected a Config type", tc.title)
+		}
+		if p.ConnectTimeout != tc.expected {
+			t.Errorf("%v: expected %v as proxy-connect-timeout but returned %v", tc.title, tc.expected, p.ConnectTimeout)
+		}
+		if p.SendTimeout != defBackend.ProxySendTimeout {
+			t.Errorf("%v: expected the global %v as proxy-send-timeout but returned %v", tc.title, defBackend.ProxySendTimeout, p.SendTimeout)
+		}
+		if p.ReadTimeout != defBackend.ProxyReadTimeout {
+			t.Errorf("%v: expected the global %v as proxy-read-timeout but returned %v", tc.title, defBackend.ProxyReadTimeout, p.ReadTimeout)
+		}
+	}
+}
+
+func TestProxyHTTPVersionByMCI(t *testing.T) {
+	testCases := []struct {
+		title                   string
+		annotations             map[string]string
+		expectedVersion         string
+		expectedConnectionClose bool
+	}{
+		{"no annotations use the global default", map[string]string{}, "1.1", false},
+		{"http 1.1", map[string]string{
+			parser.GetAnnotationWithPrefix("proxy-http-version"): "1.1",
+		}, "1.1", false},
+		{"http 1.0 closes the upstream connection", map[string]string{
+			parser.GetAnnotationWithPrefix("proxy-http-version"): "1.0",
+		}, "1.0", true},
+		{"http 1.0 with a close connection header", map[string]string{
+			parser.GetAnnotationWithPrefix("proxy-http-version"):      "1.0",
+			parser.GetAnnotationWithPrefix("connection-proxy-header"): "close",
+		}, "1.0", true},
+		{"http 1.0 with a keep-alive connection header falls back to the global default", map[string]string{
+			parser.GetAnnotationWithPrefix("proxy-http-version"):      "1.0",
+			parser.GetAnnotationWithPrefix("connection-proxy-header"): "keep-alive",
+		}, "1.1", false},
+		{"http 1.0 with a websocket backend falls back to the global default", map[string]string{
+			parser.GetAnnotationWithPrefix("proxy-http-version"): "1.0",
+			parser.GetAnnotationWithPrefix("backend-protocol"):   "wss",
+		}, "1.1", false},
+		{"http 1.0 with a http backend", map[string]string{
+			parser.GetAnnotationWithPrefix("proxy-http-version"): "1.0",
+			parser.GetAnnotationWithPrefix("backend-protocol"):   "HTTP",
+		}, "1.0", true},
+		{"unsupported version falls back to the global default", map[string]string{
+			parser.GetAnnotationWithPrefix("proxy-http-version"): "2.0",
+		}, "1.1", false},
+		{"version injecting a directive falls back to the global default", map[string]string{
+			parser.GetAnnotationWithPrefix("proxy-http-version"): "1.0; return 200",
+		}, "1.1", false},
+	}
+
+	for _, tc := range testCases {
+		mci := buildMCI()
+		mci.SetAnnotations(tc.annotations)
+
+		i, err := NewParser(mockBackend{}).ParseByMCI(mci)
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %v", tc.title, err)
+		}
+		p, ok := i.(*Config)
+		if !ok {
+			t.Fatalf("%v: expected a Config type", tc.title)
+		}
+		if p.ProxyHTTPVersion != tc.expectedVersion {
+			t.Errorf("%v: expected %q as proxy-http-version but returned %q", tc.title, tc.expectedVersion, p.ProxyHTTPVersion)
+		}
+		if p.ConnectionClose != tc.expectedConnectionClose {
+			t.Errorf("%v: expected %v as connection close but returned %v", tc.title, tc.expectedConnectionClose, p.ConnectionClose)
+		}
+	}
+}
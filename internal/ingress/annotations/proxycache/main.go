@@ -0,0 +1,184 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxycache
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	networking "k8s.io/api/networking/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+// defaultKey includes the host, as the proxy_host of the locations is the
+// shared upstream_balancer
+const defaultKey = "$scheme$host$request_uri"
+
+var (
+	// durationRegex matches the NGINX time syntax, like 10m or 1h30m
+	durationRegex = regexp.MustCompile(`^([0-9]+(ms|s|m|h|d|w|M|y)?)+$`)
+	// the key is emitted as a quoted string
+	keyRegex = regexp.MustCompile(`^[^"\\\x00-\x1f\x7f]+$`)
+)
+
+// Config contains the cache of the responses of a location
+type Config struct {
+	Enabled bool   `json:"enabled"`
+	Zone    string `json:"zone,omitempty"`
+	// Valid contains the caching times of the responses, each one with
+	// the syntax of proxy_cache_valid, like "200 302 10m" or "1m"
+	Valid []string `json:"valid,omitempty"`
+	Key   string   `json:"key,omitempty"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if c1.Enabled != c2.Enabled {
+		return false
+	}
+	if c1.Zone != c2.Zone {
+		return false
+	}
+	if c1.Key != c2.Key {
+		return false
+	}
+	if len(c1.Valid) != len(c2.Valid) {
+		return false
+	}
+	for i := range c1.Valid {
+		if c1.Valid[i] != c2.Valid[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+type proxyCache struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new proxy cache annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return proxyCache{r}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to cache the responses of the locations
+func (a proxyCache) Parse(ing *networking.Ingress) (interface{}, error) {
+	enabled, err := parser.GetBoolAnnotation("enable-proxy-cache", ing)
+	if err != nil || !enabled {
+		return &Config{}, err
+	}
+
+	zone, _ := parser.GetStringAnnotation("proxy-cache-zone", ing)
+	valid, _ := parser.GetStringAnnotation("proxy-cache-valid", ing)
+	key, _ := parser.GetStringAnnotation("proxy-cache-key", ing)
+
+	return a.newConfig(zone, valid, key)
+}
+
+// ParseByMCI parses the annotations contained in the multiclusteringress rule
+// used to cache the responses of the locations
+func (a proxyCache) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interface{}, error) {
+	enabled, err := parser.GetBoolAnnotationFromMCI("enable-proxy-cache", mci)
+	if err != nil || !enabled {
+		return &Config{}, err
+	}
+
+	zone, _ := parser.GetStringAnnotationFromMCI("proxy-cache-zone", mci)
+	valid, _ := parser.GetStringAnnotationFromMCI("proxy-cache-valid", mci)
+	key, _ := parser.GetStringAnnotationFromMCI("proxy-cache-key", mci)
+
+	return a.newConfig(zone, valid, key)
+}
+
+// newConfig checks the zone is one of the proxy-cache-zones of the
+// configmap and the caching times use the proxy_cache_valid syntax
+func (a proxyCache) newConfig(zone, rawValid, key string) (*Config, error) {
+	if zone == "" {
+		return &Config{}, ing_errors.NewInvalidAnnotationConfiguration("enable-proxy-cache",
+			"proxy-cache-zone is required")
+	}
+
+	if _, ok := a.r.GetDefaultBackend().ProxyCacheZones[zone]; !ok {
+		return &Config{}, ing_errors.NewInvalidAnnotationConfiguration("proxy-cache-zone",
+			"the zone "+zone+" is not defined in proxy-cache-zones")
+	}
+
+	var valid []string
+	for _, entry := range strings.Split(rawValid, ",") {
+		entry = strings.Join(strings.Fields(entry), " ")
+		if entry == "" {
+			continue
+		}
+
+		if !isValidEntry(entry) {
+			return &Config{}, ing_errors.NewInvalidAnnotationContent("proxy-cache-valid", rawValid)
+		}
+
+		valid = append(valid, entry)
+	}
+
+	if key == "" {
+		key = defaultKey
+	}
+
+	if !keyRegex.MatchString(key) {
+		return &Config{}, ing_errors.NewInvalidAnnotationContent("proxy-cache-key", key)
+	}
+
+	return &Config{
+		Enabled: true,
+		Zone:    zone,
+		Valid:   valid,
+		Key:     key,
+	}, nil
+}
+
+// isValidEntry checks an entry of proxy-cache-valid is a time, optionally
+// preceded by status codes or "any"
+func isValidEntry(entry string) bool {
+	fields := strings.Fields(entry)
+	if !durationRegex.MatchString(fields[len(fields)-1]) {
+		return false
+	}
+
+	for _, code := range fields[:len(fields)-1] {
+		if code == "any" {
+			continue
+		}
+
+		status, err := strconv.Atoi(code)
+		if err != nil || status < 100 || status > 599 {
+			return false
+		}
+	}
+
+	return true
+}
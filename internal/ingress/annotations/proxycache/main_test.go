@@ -0,0 +1,93 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxycache
+
+import (
+	"testing"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	api "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/defaults"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+type mockBackend struct {
+	resolver.Mock
+}
+
+func (m mockBackend) GetDefaultBackend() defaults.Backend {
+	return defaults.Backend{
+		ProxyCacheZones: map[string]string{"api": "10m"},
+	}
+}
+
+func TestParseByMCI(t *testing.T) {
+	enable := parser.GetAnnotationWithPrefix("enable-proxy-cache")
+	zone := parser.GetAnnotationWithPrefix("proxy-cache-zone")
+	valid := parser.GetAnnotationWithPrefix("proxy-cache-valid")
+	key := parser.GetAnnotationWithPrefix("proxy-cache-key")
+
+	ap := NewParser(mockBackend{})
+	if ap == nil {
+		t.Fatalf("expected a parser.IngressAnnotation but returned nil")
+	}
+
+	testCases := []struct {
+		annotations map[string]string
+		expected    *Config
+		expectErr   bool
+	}{
+		{nil, &Config{}, true},
+		{map[string]string{enable: "false", zone: "api"}, &Config{}, false},
+		{map[string]string{enable: "true", zone: "api"}, &Config{Enabled: true, Zone: "api", Key: defaultKey}, false},
+		{map[string]string{enable: "true", zone: "api", valid: "200 302 10m, 404  1m,any 1h30m", key: "$host$request_uri$http_accept"}, &Config{
+			Enabled: true,
+			Zone:    "api",
+			Valid:   []string{"200 302 10m", "404 1m", "any 1h30m"},
+			Key:     "$host$request_uri$http_accept",
+		}, false},
+		{map[string]string{enable: "true"}, &Config{}, true},
+		{map[string]string{enable: "true", zone: "static"}, &Config{}, true},
+		{map[string]string{enable: "true", zone: "api", valid: "200 ten minutes"}, &Config{}, true},
+		{map[string]string{enable: "true", zone: "api", valid: "200 10x"}, &Config{}, true},
+		{map[string]string{enable: "true", zone: "api", valid: "700 10m"}, &Config{}, true},
+		{map[string]string{enable: "true", zone: "api", key: `$host"; return 200; "`}, &Config{}, true},
+	}
+
+	mci := &karmadanetworking.MultiClusterIngress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+	}
+
+	for _, testCase := range testCases {
+		mci.SetAnnotations(testCase.annotations)
+		i, err := ap.ParseByMCI(mci)
+		if testCase.expectErr != (err != nil) {
+			t.Errorf("expected error %v but returned %v, annotations: %s", testCase.expectErr, err, testCase.annotations)
+		}
+
+		p, _ := i.(*Config)
+		if !p.Equal(testCase.expected) {
+			t.Errorf("expected %v but returned %v, annotations: %s", testCase.expected, p, testCase.annotations)
+		}
+	}
+}
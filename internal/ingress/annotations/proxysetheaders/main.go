@@ -0,0 +1,120 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxysetheaders
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	networking "k8s.io/api/networking/v1"
+	"k8s.io/klog/v2"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+	"k8s.io/ingress-nginx/internal/k8s"
+)
+
+var headerRegexp = regexp.MustCompile(`^[a-zA-Z\d\-_]+$`)
+
+// Config contains the headers set in the requests sent to the upstream
+type Config struct {
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if len(c1.Headers) != len(c2.Headers) {
+		return false
+	}
+	for name, value := range c1.Headers {
+		if v, ok := c2.Headers[name]; !ok || v != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+type proxySetHeaders struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new proxy set headers annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return proxySetHeaders{r}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to set headers in the requests sent to the upstream
+func (a proxySetHeaders) Parse(ing *networking.Ingress) (interface{}, error) {
+	configMap, err := parser.GetStringAnnotation("proxy-set-headers", ing)
+	if err != nil {
+		return &Config{}, err
+	}
+
+	return a.newConfig(configMap)
+}
+
+// ParseByMCI parses the annotations contained in the multiclusteringress rule
+// used to set headers in the requests sent to the upstream
+func (a proxySetHeaders) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interface{}, error) {
+	configMap, err := parser.GetStringAnnotationFromMCI("proxy-set-headers", mci)
+	if err != nil {
+		return &Config{}, err
+	}
+
+	return a.newConfig(configMap)
+}
+
+// newConfig reads the headers from the referenced configmap, checking the
+// names are valid header names and the values cannot inject other directives
+func (a proxySetHeaders) newConfig(configMap string) (*Config, error) {
+	_, _, err := k8s.ParseNameNS(configMap)
+	if err != nil {
+		return &Config{}, ing_errors.NewInvalidAnnotationContent("proxy-set-headers", configMap)
+	}
+
+	cmap, err := a.r.GetConfigMap(configMap)
+	if err != nil {
+		klog.Warningf("Error reading ConfigMap %q referenced in proxy-set-headers: %v", configMap, err)
+		return &Config{}, fmt.Errorf("unable to find configMap %q: %w", configMap, err)
+	}
+
+	headers := make(map[string]string, len(cmap.Data))
+	for name, value := range cmap.Data {
+		if !headerRegexp.MatchString(name) {
+			return &Config{}, fmt.Errorf("invalid header name %q in configMap %q", name, configMap)
+		}
+
+		if strings.ContainsAny(value, "\r\n") {
+			return &Config{}, fmt.Errorf("invalid value of header %q in configMap %q: line breaks are not allowed", name, configMap)
+		}
+
+		headers[name] = value
+	}
+
+	return &Config{Headers: headers}, nil
+}
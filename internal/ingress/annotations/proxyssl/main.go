@@ -42,6 +42,8 @@ const (
 var (
 	proxySSLOnOffRegex    = regexp.MustCompile(`^(on|off)$`)
 	proxySSLProtocolRegex = regexp.MustCompile(`^(SSLv2|SSLv3|TLSv1|TLSv1\.1|TLSv1\.2|TLSv1\.3)$`)
+	// multiclusteringresses only allow the protocols considered secure
+	proxySSLMCIProtocolRegex = regexp.MustCompile(`^(TLSv1\.2|TLSv1\.3)$`)
 )
 
 // Config contains the AuthSSLCert used for mutual authentication
@@ -116,6 +118,23 @@ func sortProtocols(protocols string) string {
 	return strings.Join(protolist, " ")
 }
 
+// validateMCIProtocols checks the space separated list of protocols
+// only contains TLSv1.2 and TLSv1.3
+func validateMCIProtocols(protocols string) error {
+	protolist := strings.Fields(protocols)
+	if len(protolist) == 0 {
+		return fmt.Errorf("proxy-ssl-protocols is empty")
+	}
+
+	for _, proto := range protolist {
+		if !proxySSLMCIProtocolRegex.MatchString(proto) {
+			return fmt.Errorf("invalid proxy-ssl-protocols %q: only TLSv1.2 and TLSv1.3 are allowed", proto)
+		}
+	}
+
+	return nil
+}
+
 // Parse parses the annotations contained in the ingress
 // rule used to use a Certificate as authentication method
 func (p proxySSL) Parse(ing *networking.Ingress) (interface{}, error) {
@@ -200,12 +219,17 @@ func (p proxySSL) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interf
 	config.Ciphers, err = parser.GetStringAnnotationFromMCI("proxy-ssl-ciphers", mci)
 	if err != nil {
 		config.Ciphers = defaultProxySSLCiphers
+	} else if !parser.IsValidCipherList(config.Ciphers) {
+		return &Config{}, ing_errors.NewLocationDenied(fmt.Sprintf("invalid proxy-ssl-ciphers %q", config.Ciphers))
 	}
 
 	config.Protocols, err = parser.GetStringAnnotationFromMCI("proxy-ssl-protocols", mci)
 	if err != nil {
 		config.Protocols = defaultProxySSLProtocols
 	} else {
+		if err := validateMCIProtocols(config.Protocols); err != nil {
+			return &Config{}, ing_errors.NewLocationDenied(err.Error())
+		}
 		config.Protocols = sortProtocols(config.Protocols)
 	}
 
@@ -19,6 +19,7 @@ package proxyssl
 import (
 	"testing"
 
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
 	api "k8s.io/api/core/v1"
 	networking "k8s.io/api/networking/v1"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -289,3 +290,90 @@ func TestEquals(t *testing.T) {
 		t.Errorf("Expected true")
 	}
 }
+
+func TestCiphersAndProtocolsByMCI(t *testing.T) {
+	testCases := map[string]struct {
+		ciphers   string
+		protocols string
+		expectErr bool
+		expected  *Config
+	}{
+		"defaults": {
+			expected: &Config{Ciphers: defaultProxySSLCiphers, Protocols: defaultProxySSLProtocols},
+		},
+		"valid cipher list": {
+			ciphers:  "ECDHE-ECDSA-AES128-GCM-SHA256:ECDHE-RSA-AES128-GCM-SHA256:!aNULL:!MD5",
+			expected: &Config{Ciphers: "ECDHE-ECDSA-AES128-GCM-SHA256:ECDHE-RSA-AES128-GCM-SHA256:!aNULL:!MD5", Protocols: defaultProxySSLProtocols},
+		},
+		"valid cipher list with combinations": {
+			ciphers:  "HIGH:-SHA:ECDH+AESGCM:@STRENGTH",
+			expected: &Config{Ciphers: "HIGH:-SHA:ECDH+AESGCM:@STRENGTH", Protocols: defaultProxySSLProtocols},
+		},
+		"invalid cipher list": {
+			ciphers:   "HIGH; return 200",
+			expectErr: true,
+		},
+		"valid protocol list": {
+			protocols: "TLSv1.3   TLSv1.2",
+			expected:  &Config{Ciphers: defaultProxySSLCiphers, Protocols: "TLSv1.2 TLSv1.3"},
+		},
+		"single valid protocol": {
+			protocols: "TLSv1.3",
+			expected:  &Config{Ciphers: defaultProxySSLCiphers, Protocols: "TLSv1.3"},
+		},
+		"protocol list with an insecure protocol": {
+			protocols: "TLSv1 TLSv1.2",
+			expectErr: true,
+		},
+		"protocol list with an unknown protocol": {
+			protocols: "TLSv1.4",
+			expectErr: true,
+		},
+	}
+
+	for title, tc := range testCases {
+		t.Run(title, func(t *testing.T) {
+			data := map[string]string{
+				parser.GetAnnotationWithPrefix("proxy-ssl-secret"): "default/demo-secret",
+			}
+			if tc.ciphers != "" {
+				data[parser.GetAnnotationWithPrefix("proxy-ssl-ciphers")] = tc.ciphers
+			}
+			if tc.protocols != "" {
+				data[parser.GetAnnotationWithPrefix("proxy-ssl-protocols")] = tc.protocols
+			}
+
+			mci := &karmadanetworking.MultiClusterIngress{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:        "foo",
+					Namespace:   api.NamespaceDefault,
+					Annotations: data,
+				},
+			}
+
+			i, err := NewParser(&mockSecret{}).ParseByMCI(mci)
+			if tc.expectErr {
+				if !errors.IsLocationDenied(err) {
+					t.Errorf("expected a location denied error but got %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			u, ok := i.(*Config)
+			if !ok {
+				t.Fatalf("expected *Config but got %v", i)
+			}
+
+			if u.Ciphers != tc.expected.Ciphers {
+				t.Errorf("expected ciphers %v but got %v", tc.expected.Ciphers, u.Ciphers)
+			}
+			if u.Protocols != tc.expected.Protocols {
+				t.Errorf("expected protocols %v but got %v", tc.expected.Protocols, u.Protocols)
+			}
+		})
+	}
+}
@@ -19,12 +19,14 @@ package ratelimit
 import (
 	"encoding/base64"
 	"fmt"
+	"regexp"
 	"strings"
 
 	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
 	networking "k8s.io/api/networking/v1"
 
 	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 	"k8s.io/ingress-nginx/internal/net"
 	"k8s.io/ingress-nginx/internal/sets"
@@ -39,6 +41,12 @@ const (
 	defSharedSize = 5
 )
 
+var (
+	headerRegex = regexp.MustCompile(`^[A-Za-z0-9-]+$`)
+	// the value is used as a quoted key of a map
+	headerValueRegex = regexp.MustCompile(`^[^"\\\x00-\x1f\x7f]+$`)
+)
+
 // Config returns rate limit configuration for an Ingress rule limiting the
 // number of connections per IP address and/or connections per second.
 // If you both annotations are specified in a single Ingress rule, RPS limits
@@ -60,6 +68,20 @@ type Config struct {
 	ID string `json:"id"`
 
 	Whitelist []string `json:"whitelist"`
+
+	// ExemptHeader is the name of the request header exempting trusted
+	// callers from the limits when it contains ExemptHeaderValue
+	ExemptHeader string `json:"exemptHeader,omitempty"`
+
+	ExemptHeaderValue string `json:"exemptHeaderValue,omitempty"`
+
+	// ReqStatusCode is the status code of the requests rejected by the RPS and
+	// RPM limits. Zero means the global limit-req-status-code applies.
+	ReqStatusCode int `json:"reqStatusCode,omitempty"`
+
+	// ConnStatusCode is the status code of the requests rejected by the
+	// connections limit. Zero means the global limit-conn-status-code applies.
+	ConnStatusCode int `json:"connStatusCode,omitempty"`
 }
 
 // Equal tests for equality between two RateLimit types
@@ -91,6 +113,18 @@ func (rt1 *Config) Equal(rt2 *Config) bool {
 	if rt1.Name != rt2.Name {
 		return false
 	}
+	if rt1.ExemptHeader != rt2.ExemptHeader {
+		return false
+	}
+	if rt1.ExemptHeaderValue != rt2.ExemptHeaderValue {
+		return false
+	}
+	if rt1.ReqStatusCode != rt2.ReqStatusCode {
+		return false
+	}
+	if rt1.ConnStatusCode != rt2.ConnStatusCode {
+		return false
+	}
 	if len(rt1.Whitelist) != len(rt2.Whitelist) {
 		return false
 	}
@@ -236,6 +270,21 @@ func (a ratelimit) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (inter
 		return nil, err
 	}
 
+	exemptHeader, exemptHeaderValue, err := parseExemptHeaderFromMCI(mci)
+	if err != nil {
+		return nil, err
+	}
+
+	reqStatusCode, err := parseStatusCodeFromMCI("limit-req-status-code", mci)
+	if err != nil {
+		return nil, err
+	}
+
+	connStatusCode, err := parseStatusCodeFromMCI("limit-conn-status-code", mci)
+	if err != nil {
+		return nil, err
+	}
+
 	if rpm == 0 && rps == 0 && conn == 0 {
 		return &Config{
 			Connections:    Zone{},
@@ -267,14 +316,63 @@ func (a ratelimit) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (inter
 			Burst:      rpm * burstMultiplier,
 			SharedSize: defSharedSize,
 		},
-		LimitRate:      lr,
-		LimitRateAfter: lra,
-		Name:           zoneName,
-		ID:             encode(zoneName),
-		Whitelist:      cidrs,
+		LimitRate:         lr,
+		LimitRateAfter:    lra,
+		Name:              zoneName,
+		ID:                encode(zoneName),
+		Whitelist:         cidrs,
+		ExemptHeader:      exemptHeader,
+		ExemptHeaderValue: exemptHeaderValue,
+		ReqStatusCode:     reqStatusCode,
+		ConnStatusCode:    connStatusCode,
 	}, nil
 }
 
+// parseStatusCodeFromMCI returns the status code of the requests rejected by
+// a limit of a multiclusteringress, or zero when the annotation is not present
+func parseStatusCodeFromMCI(name string, mci *karmadanetworking.MultiClusterIngress) (int, error) {
+	code, err := parser.GetIntAnnotationFromMCI(name, mci)
+	if err != nil {
+		if ing_errors.IsMissingAnnotations(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	if code < 400 || code > 599 {
+		return 0, ing_errors.NewInvalidAnnotationContent(name, code)
+	}
+
+	return code, nil
+}
+
+// parseExemptHeaderFromMCI returns the request header, and its value, exempting
+// trusted callers from the limits of a multiclusteringress
+func parseExemptHeaderFromMCI(mci *karmadanetworking.MultiClusterIngress) (string, string, error) {
+	header, err := parser.GetStringAnnotationFromMCI("limit-exempt-header", mci)
+	if err != nil {
+		if ing_errors.IsMissingAnnotations(err) {
+			return "", "", nil
+		}
+		return "", "", err
+	}
+
+	if !headerRegex.MatchString(header) {
+		return "", "", ing_errors.NewInvalidAnnotationContent("limit-exempt-header", header)
+	}
+
+	value, err := parser.GetStringAnnotationFromMCI("limit-exempt-header-value", mci)
+	if err != nil {
+		return "", "", ing_errors.NewInvalidAnnotationConfiguration("limit-exempt-header", "limit-exempt-header-value is required")
+	}
+
+	if !headerValueRegex.MatchString(value) {
+		return "", "", ing_errors.NewInvalidAnnotationContent("limit-exempt-header-value", value)
+	}
+
+	return header, value, nil
+}
+
 func encode(s string) string {
 	str := base64.URLEncoding.EncodeToString([]byte(s))
 	return strings.Replace(str, "=", "", -1)
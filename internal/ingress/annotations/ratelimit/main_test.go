@@ -19,6 +19,7 @@ package ratelimit
 import (
 	"testing"
 
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
 	api "k8s.io/api/core/v1"
 	networking "k8s.io/api/networking/v1"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -189,3 +190,113 @@ func TestRateLimiting(t *testing.T) {
 		t.Errorf("expected 10 in limit by limitrate but %v was returned", rateLimit.LimitRate)
 	}
 }
+
+func TestExemptHeaderByMCI(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		header      string
+		value       string
+		expErr      bool
+	}{
+		{"without exemption", map[string]string{"limit-rps": "10"}, "", "", false},
+		{"with exemption", map[string]string{"limit-rps": "10", "limit-exempt-header": "X-Internal-Caller", "limit-exempt-header-value": "trusted"}, "X-Internal-Caller", "trusted", false},
+		{"invalid header name", map[string]string{"limit-rps": "10", "limit-exempt-header": "X-Internal Caller", "limit-exempt-header-value": "trusted"}, "", "", true},
+		{"missing header value", map[string]string{"limit-rps": "10", "limit-exempt-header": "X-Internal-Caller"}, "", "", true},
+		{"invalid header value", map[string]string{"limit-rps": "10", "limit-exempt-header": "X-Internal-Caller", "limit-exempt-header-value": `trusted"`}, "", "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data := map[string]string{}
+			for k, v := range test.annotations {
+				data[parser.GetAnnotationWithPrefix(k)] = v
+			}
+
+			mci := &karmadanetworking.MultiClusterIngress{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:        "foo",
+					Namespace:   api.NamespaceDefault,
+					Annotations: data,
+				},
+			}
+
+			i, err := NewParser(mockBackend{}).ParseByMCI(mci)
+			if test.expErr {
+				if err == nil {
+					t.Fatalf("expected an error but none returned")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			rateLimit, ok := i.(*Config)
+			if !ok {
+				t.Fatalf("expected a RateLimit type")
+			}
+			if rateLimit.ExemptHeader != test.header {
+				t.Errorf("expected exempt header %q but %q was returned", test.header, rateLimit.ExemptHeader)
+			}
+			if rateLimit.ExemptHeaderValue != test.value {
+				t.Errorf("expected exempt header value %q but %q was returned", test.value, rateLimit.ExemptHeaderValue)
+			}
+		})
+	}
+}
+
+func TestStatusCodesByMCI(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		reqCode     int
+		connCode    int
+		expErr      bool
+	}{
+		{"without status codes", map[string]string{"limit-rps": "10", "limit-connections": "5"}, 0, 0, false},
+		{"with status codes", map[string]string{"limit-rps": "10", "limit-connections": "5", "limit-req-status-code": "429", "limit-conn-status-code": "429"}, 429, 429, false},
+		{"out of range request status code", map[string]string{"limit-rps": "10", "limit-req-status-code": "200"}, 0, 0, true},
+		{"out of range connection status code", map[string]string{"limit-connections": "5", "limit-conn-status-code": "600"}, 0, 0, true},
+		{"invalid status code", map[string]string{"limit-rps": "10", "limit-req-status-code": "too-many"}, 0, 0, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data := map[string]string{}
+			for k, v := range test.annotations {
+				data[parser.GetAnnotationWithPrefix(k)] = v
+			}
+
+			mci := &karmadanetworking.MultiClusterIngress{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:        "foo",
+					Namespace:   api.NamespaceDefault,
+					Annotations: data,
+				},
+			}
+
+			i, err := NewParser(mockBackend{}).ParseByMCI(mci)
+			if test.expErr {
+				if err == nil {
+					t.Fatalf("expected an error but none returned")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			rateLimit, ok := i.(*Config)
+			if !ok {
+				t.Fatalf("expected a RateLimit type")
+			}
+			if rateLimit.ReqStatusCode != test.reqCode {
+				t.Errorf("expected request status code %v but %v was returned", test.reqCode, rateLimit.ReqStatusCode)
+			}
+			if rateLimit.ConnStatusCode != test.connCode {
+				t.Errorf("expected connection status code %v but %v was returned", test.connCode, rateLimit.ConnStatusCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package requestid
+
+import (
+	"regexp"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	networking "k8s.io/api/networking/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+// DefaultHeader is the header used to propagate the request ID
+// when no custom header is configured
+const DefaultHeader = "X-Request-ID"
+
+var headerRegexp = regexp.MustCompile(`^[a-zA-Z\d\-_]+$`)
+
+// Config contains the request ID propagation configuration for a location
+type Config struct {
+	Enabled bool   `json:"enabled"`
+	Header  string `json:"header"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if c1.Enabled != c2.Enabled {
+		return false
+	}
+	if c1.Header != c2.Header {
+		return false
+	}
+
+	return true
+}
+
+type requestID struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new request ID annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return requestID{r}
+}
+
+// Parse parses the annotations contained in the ingress
+// rule used to propagate a request ID header to the upstream
+func (a requestID) Parse(ing *networking.Ingress) (interface{}, error) {
+	enabled, err := parser.GetBoolAnnotation("enable-request-id", ing)
+	if err != nil || !enabled {
+		return &Config{}, nil
+	}
+
+	header, err := parser.GetStringAnnotation("request-id-header", ing)
+	if err != nil {
+		if !ing_errors.IsMissingAnnotations(err) {
+			return &Config{}, err
+		}
+		header = DefaultHeader
+	}
+
+	return newConfig(header)
+}
+
+// ParseByMCI parses the annotations contained in the multiclusteringress
+// rule used to propagate a request ID header to the upstream
+func (a requestID) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interface{}, error) {
+	enabled, err := parser.GetBoolAnnotationFromMCI("enable-request-id", mci)
+	if err != nil || !enabled {
+		return &Config{}, nil
+	}
+
+	header, err := parser.GetStringAnnotationFromMCI("request-id-header", mci)
+	if err != nil {
+		if !ing_errors.IsMissingAnnotations(err) {
+			return &Config{}, err
+		}
+		header = DefaultHeader
+	}
+
+	return newConfig(header)
+}
+
+func newConfig(header string) (*Config, error) {
+	if !headerRegexp.MatchString(header) {
+		return &Config{}, ing_errors.NewInvalidAnnotationContent("request-id-header", header)
+	}
+
+	return &Config{
+		Enabled: true,
+		Header:  header,
+	}, nil
+}
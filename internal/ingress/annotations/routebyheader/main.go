@@ -0,0 +1,134 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package routebyheader
+
+import (
+	"fmt"
+	"regexp"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	"github.com/karmada-io/karmada/pkg/util/names"
+	networking "k8s.io/api/networking/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+var headerRegex = regexp.MustCompile(`^[A-Za-z0-9-]+$`)
+
+// Config contains the header used to route requests to an alternate service,
+// typically to run A/B tests
+type Config struct {
+	// Header is the name of the request header deciding the routing
+	Header string `json:"header,omitempty"`
+	// HeaderValue is the value of the header routing to the alternate service.
+	// When empty the values "always" and "never" are used, like for canaries
+	HeaderValue string `json:"headerValue,omitempty"`
+	// Service is the name of the alternate service, in the namespace of the
+	// multiclusteringress, using the port of the backend of each path
+	Service string `json:"service,omitempty"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if c1.Header != c2.Header {
+		return false
+	}
+	if c1.HeaderValue != c2.HeaderValue {
+		return false
+	}
+	if c1.Service != c2.Service {
+		return false
+	}
+
+	return true
+}
+
+type routeByHeader struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new route by header annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return routeByHeader{r}
+}
+
+// Parse parses the annotations contained in the ingress
+// rule used to route requests to an alternate service
+func (a routeByHeader) Parse(ing *networking.Ingress) (interface{}, error) {
+	header, err := parser.GetStringAnnotation("route-by-header", ing)
+	if err != nil {
+		return &Config{}, nil
+	}
+
+	value, err := parser.GetStringAnnotation("route-by-header-value", ing)
+	if err != nil && !ing_errors.IsMissingAnnotations(err) {
+		return &Config{}, err
+	}
+
+	service, err := parser.GetStringAnnotation("route-alternate-service", ing)
+	if err != nil {
+		return &Config{}, ing_errors.NewInvalidAnnotationConfiguration("route-by-header", "route-alternate-service is required")
+	}
+
+	return a.newConfig(ing.Namespace, header, value, service)
+}
+
+// ParseByMCI parses the annotations contained in the multiclusteringress
+// rule used to route requests to an alternate service
+func (a routeByHeader) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interface{}, error) {
+	header, err := parser.GetStringAnnotationFromMCI("route-by-header", mci)
+	if err != nil {
+		return &Config{}, nil
+	}
+
+	value, err := parser.GetStringAnnotationFromMCI("route-by-header-value", mci)
+	if err != nil && !ing_errors.IsMissingAnnotations(err) {
+		return &Config{}, err
+	}
+
+	service, err := parser.GetStringAnnotationFromMCI("route-alternate-service", mci)
+	if err != nil {
+		return &Config{}, ing_errors.NewInvalidAnnotationConfiguration("route-by-header", "route-alternate-service is required")
+	}
+
+	return a.newConfig(mci.Namespace, header, value, service)
+}
+
+func (a routeByHeader) newConfig(namespace, header, value, service string) (*Config, error) {
+	if !headerRegex.MatchString(header) {
+		return &Config{}, ing_errors.NewInvalidAnnotationContent("route-by-header", header)
+	}
+
+	svcKey := fmt.Sprintf("%v/%v", namespace, names.GenerateDerivedServiceName(service))
+	if _, err := a.r.GetService(svcKey); err != nil {
+		return &Config{}, fmt.Errorf("unexpected error reading service %s: %w", svcKey, err)
+	}
+
+	return &Config{
+		Header:      header,
+		HeaderValue: value,
+		Service:     service,
+	}, nil
+}
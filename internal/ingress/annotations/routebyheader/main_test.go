@@ -0,0 +1,92 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package routebyheader
+
+import (
+	"fmt"
+	"testing"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	api "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+type mockService struct {
+	resolver.Mock
+}
+
+// GetService mocks the GetService call from the routebyheader package
+func (m mockService) GetService(name string) (*api.Service, error) {
+	if name != "default/derived-http-svc-b" {
+		return nil, fmt.Errorf("there is no service with name %v", name)
+	}
+
+	return &api.Service{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Namespace: api.NamespaceDefault,
+			Name:      "derived-http-svc-b",
+		},
+	}, nil
+}
+
+func TestParseByMCI(t *testing.T) {
+	header := parser.GetAnnotationWithPrefix("route-by-header")
+	value := parser.GetAnnotationWithPrefix("route-by-header-value")
+	service := parser.GetAnnotationWithPrefix("route-alternate-service")
+
+	ap := NewParser(mockService{})
+	if ap == nil {
+		t.Fatalf("expected a parser.IngressAnnotation but returned nil")
+	}
+
+	testCases := []struct {
+		annotations map[string]string
+		expected    *Config
+		expectErr   bool
+	}{
+		{nil, &Config{}, false},
+		{map[string]string{value: "b", service: "http-svc-b"}, &Config{}, false},
+		{map[string]string{header: "X-Variant", value: "b", service: "http-svc-b"}, &Config{Header: "X-Variant", HeaderValue: "b", Service: "http-svc-b"}, false},
+		{map[string]string{header: "X-Variant", service: "http-svc-b"}, &Config{Header: "X-Variant", Service: "http-svc-b"}, false},
+		{map[string]string{header: "X-Variant", value: "b"}, &Config{}, true},
+		{map[string]string{header: "X-Variant", value: "b", service: "missing"}, &Config{}, true},
+		{map[string]string{header: "X Variant", value: "b", service: "http-svc-b"}, &Config{}, true},
+	}
+
+	mci := &karmadanetworking.MultiClusterIngress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+	}
+
+	for _, testCase := range testCases {
+		mci.SetAnnotations(testCase.annotations)
+		i, err := ap.ParseByMCI(mci)
+		if testCase.expectErr != (err != nil) {
+			t.Errorf("expected error %v but returned %v, annotations: %s", testCase.expectErr, err, testCase.annotations)
+		}
+
+		c, _ := i.(*Config)
+		if !c.Equal(testCase.expected) {
+			t.Errorf("expected %v but returned %v, annotations: %s", testCase.expected, c, testCase.annotations)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package securityheaders
+
+import (
+	"regexp"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	networking "k8s.io/api/networking/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+const presetAnnotation = "security-headers-preset"
+
+// presets are the headers of each value of the security-headers-preset annotation
+var presets = map[string]map[string]string{
+	"strict": {
+		"Strict-Transport-Security": "max-age=63072000; includeSubDomains; preload",
+		"X-Content-Type-Options":    "nosniff",
+		"X-Frame-Options":           "DENY",
+		"Referrer-Policy":           "no-referrer",
+	},
+	"moderate": {
+		"Strict-Transport-Security": "max-age=15724800",
+		"X-Content-Type-Options":    "nosniff",
+		"X-Frame-Options":           "SAMEORIGIN",
+		"Referrer-Policy":           "strict-origin-when-cross-origin",
+	},
+}
+
+// overrides are the annotations replacing the value of a header of the preset
+var overrides = map[string]string{
+	"strict-transport-security": "Strict-Transport-Security",
+	"x-content-type-options":    "X-Content-Type-Options",
+	"x-frame-options":           "X-Frame-Options",
+	"referrer-policy":           "Referrer-Policy",
+}
+
+var headerValueRegex = regexp.MustCompile(`^[A-Za-z0-9 =;,:/._+*()'-]+$`)
+
+type securityHeaders struct {
+	r resolver.Resolver
+}
+
+// Config contains the security headers set in the responses of a server
+type Config struct {
+	// Preset is the value of the security-headers-preset annotation
+	Preset string `json:"preset,omitempty"`
+	// Headers are the values of the headers by name
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if c1.Preset != c2.Preset {
+		return false
+	}
+	if len(c1.Headers) != len(c2.Headers) {
+		return false
+	}
+	for name, value := range c1.Headers {
+		if v, ok := c2.Headers[name]; !ok || v != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// NewParser creates a new security headers annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return securityHeaders{r}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to set security headers in the responses of the server
+func (sh securityHeaders) Parse(ing *networking.Ingress) (interface{}, error) {
+	return newConfig(func(name string) (string, error) {
+		return parser.GetStringAnnotation(name, ing)
+	})
+}
+
+// ParseByMCI parses the annotations contained in the multiclusteringress rule
+// used to set security headers in the responses of the server
+func (sh securityHeaders) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interface{}, error) {
+	return newConfig(func(name string) (string, error) {
+		return parser.GetStringAnnotationFromMCI(name, mci)
+	})
+}
+
+// newConfig expands the preset into its headers, then replaces their values
+// with the ones of the annotations named after the headers
+func newConfig(annotation func(string) (string, error)) (*Config, error) {
+	config := &Config{}
+
+	preset, err := annotation(presetAnnotation)
+	if err != nil && !ing_errors.IsMissingAnnotations(err) {
+		return &Config{}, err
+	}
+
+	if preset != "" {
+		headers, ok := presets[preset]
+		if !ok {
+			return &Config{}, ing_errors.NewInvalidAnnotationContent(presetAnnotation, preset)
+		}
+
+		config.Preset = preset
+		config.Headers = make(map[string]string, len(headers))
+		for name, value := range headers {
+			config.Headers[name] = value
+		}
+	}
+
+	for name, header := range overrides {
+		value, err := annotation(name)
+		if ing_errors.IsMissingAnnotations(err) {
+			continue
+		}
+		if err != nil {
+			return &Config{}, err
+		}
+
+		if !headerValueRegex.MatchString(value) {
+			return &Config{}, ing_errors.NewInvalidAnnotationContent(name, value)
+		}
+
+		if config.Headers == nil {
+			config.Headers = make(map[string]string, len(overrides))
+		}
+		config.Headers[header] = value
+	}
+
+	return config, nil
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package servertokens
+
+import (
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	networking "k8s.io/api/networking/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+type serverTokens struct {
+	r resolver.Resolver
+}
+
+// Config contains the server-tokens configuration of a server.
+// An empty ServerTokens means the global setting applies.
+type Config struct {
+	ServerTokens string `json:"serverTokens,omitempty"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+
+	return c1.ServerTokens == c2.ServerTokens
+}
+
+// NewParser creates a new server-tokens annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return serverTokens{r}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to show or hide the NGINX version in the server
+func (st serverTokens) Parse(ing *networking.Ingress) (interface{}, error) {
+	enabled, err := parser.GetBoolAnnotation("server-tokens", ing)
+	if err != nil {
+		return &Config{}, nil
+	}
+
+	return newConfig(enabled), nil
+}
+
+// ParseByMCI parses the annotations contained in the multiclusteringress rule
+// used to show or hide the NGINX version in the server
+func (st serverTokens) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interface{}, error) {
+	enabled, err := parser.GetBoolAnnotationFromMCI("server-tokens", mci)
+	if err != nil {
+		return &Config{}, nil
+	}
+
+	return newConfig(enabled), nil
+}
+
+func newConfig(enabled bool) *Config {
+	if enabled {
+		return &Config{ServerTokens: "on"}
+	}
+
+	return &Config{ServerTokens: "off"}
+}
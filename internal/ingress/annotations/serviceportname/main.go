@@ -0,0 +1,66 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package serviceportname
+
+import (
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	networking "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+type servicePortName struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new service-port-name annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return servicePortName{r}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to select a named port of the backend services
+func (s servicePortName) Parse(ing *networking.Ingress) (interface{}, error) {
+	name, err := parser.GetStringAnnotation("service-port-name", ing)
+	if err != nil {
+		return "", err
+	}
+
+	return validatePortName(name)
+}
+
+// ParseByMCI parses the annotations contained in the multiclusteringress rule
+// used to select a named port of the backend services
+func (s servicePortName) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interface{}, error) {
+	name, err := parser.GetStringAnnotationFromMCI("service-port-name", mci)
+	if err != nil {
+		return "", err
+	}
+
+	return validatePortName(name)
+}
+
+func validatePortName(name string) (string, error) {
+	if errs := validation.IsValidPortName(name); len(errs) > 0 {
+		return "", ing_errors.NewInvalidAnnotationContent("service-port-name", name)
+	}
+
+	return name, nil
+}
@@ -17,10 +17,13 @@ limitations under the License.
 package sessionaffinity
 
 import (
+	"fmt"
 	"regexp"
+	"strings"
 
 	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
 	networking "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
 
 	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
@@ -53,6 +56,10 @@ const (
 	// This is used to control the cookie path when use-regex is set to true
 	annotationAffinityCookiePath = "session-cookie-path"
 
+	// This is used to restrict the affinity cookie to a subset of the paths
+	// defined in the multiclusteringress
+	annotationAffinityCookiePaths = "session-cookie-paths"
+
 	// This is used to control the SameSite attribute of the cookie
 	annotationAffinityCookieSameSite = "session-cookie-samesite"
 
@@ -88,6 +95,8 @@ type Cookie struct {
 	MaxAge string `json:"maxage"`
 	// The path that a cookie will be set on
 	Path string `json:"path"`
+	// The paths of the multiclusteringress using the affinity cookie. Empty means all paths.
+	Paths []string `json:"paths,omitempty"`
 	// Flag that allows cookie regeneration on request failure
 	ChangeOnFailure bool `json:"changeonfailure"`
 	// Secure flag to be set
@@ -181,6 +190,16 @@ func (a affinity) cookieAffinityParseByMCI(mci *karmadanetworking.MultiClusterIn
 		klog.V(3).InfoS("Invalid or no annotation value found. Ignoring", "ingress", klog.KObj(mci), "annotation", annotationAffinityCookiePath)
 	}
 
+	paths, err := parser.GetStringAnnotationFromMCI(annotationAffinityCookiePaths, mci)
+	if err != nil {
+		klog.V(3).InfoS("Invalid or no annotation value found. Ignoring", "ingress", klog.KObj(mci), "annotation", annotationAffinityCookiePaths)
+	} else {
+		cookie.Paths, err = cookiePathsFromMCI(paths, mci)
+		if err != nil {
+			klog.Warningf("%v. Applying the affinity cookie to all paths of multiclusteringress %v", err, klog.KObj(mci))
+		}
+	}
+
 	cookie.SameSite, err = parser.GetStringAnnotationFromMCI(annotationAffinityCookieSameSite, mci)
 	if err != nil {
 		klog.V(3).InfoS("Invalid or no annotation value found. Ignoring", "ingress", klog.KObj(mci), "annotation", annotationAffinityCookieSameSite)
@@ -204,6 +223,36 @@ func (a affinity) cookieAffinityParseByMCI(mci *karmadanetworking.MultiClusterIn
 	return cookie
 }
 
+// cookiePathsFromMCI splits the comma-separated list of paths and checks
+// that every path is defined in one of the rules of the multiclusteringress
+func cookiePathsFromMCI(value string, mci *karmadanetworking.MultiClusterIngress) ([]string, error) {
+	defined := sets.NewString()
+	for _, rule := range mci.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			defined.Insert(path.Path)
+		}
+	}
+
+	var paths []string
+	for _, path := range strings.Split(value, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+
+		if !defined.Has(path) {
+			return nil, fmt.Errorf("path %q in annotation %v is not defined in the multiclusteringress", path, annotationAffinityCookiePaths)
+		}
+
+		paths = append(paths, path)
+	}
+
+	return paths, nil
+}
+
 // NewParser creates a new Affinity annotation parser
 func NewParser(r resolver.Resolver) parser.IngressAnnotation {
 	return affinity{r}
@@ -17,8 +17,10 @@ limitations under the License.
 package sessionaffinity
 
 import (
+	"reflect"
 	"testing"
 
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
 	api "k8s.io/api/core/v1"
 	networking "k8s.io/api/networking/v1"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -120,3 +122,44 @@ func TestIngressAffinityCookieConfig(t *testing.T) {
 		t.Errorf("expected secure parameter set to true but returned %v", nginxAffinity.Cookie.Secure)
 	}
 }
+
+func TestMCIAffinityCookiePaths(t *testing.T) {
+	ing := buildIngress()
+	ing.Spec.Rules[0].HTTP.Paths = append(ing.Spec.Rules[0].HTTP.Paths, networking.HTTPIngressPath{
+		Path:    "/bar",
+		Backend: ing.Spec.Rules[0].HTTP.Paths[0].Backend,
+	})
+
+	testCases := map[string]struct {
+		paths    string
+		expected []string
+	}{
+		"single path":         {"/foo", []string{"/foo"}},
+		"multiple paths":      {"/foo, /bar", []string{"/foo", "/bar"}},
+		"path not defined":    {"/foo,/baz", nil},
+		"empty list of paths": {",", nil},
+	}
+
+	for title, tc := range testCases {
+		t.Run(title, func(t *testing.T) {
+			mci := &karmadanetworking.MultiClusterIngress{
+				ObjectMeta: ing.ObjectMeta,
+				Spec:       ing.Spec,
+			}
+			mci.SetAnnotations(map[string]string{
+				parser.GetAnnotationWithPrefix(annotationAffinityType):        "cookie",
+				parser.GetAnnotationWithPrefix(annotationAffinityCookiePaths): tc.paths,
+			})
+
+			affin, _ := NewParser(&resolver.Mock{}).ParseByMCI(mci)
+			nginxAffinity, ok := affin.(*Config)
+			if !ok {
+				t.Fatalf("expected a Config type")
+			}
+
+			if !reflect.DeepEqual(nginxAffinity.Cookie.Paths, tc.expected) {
+				t.Errorf("expected %v as session-cookie-paths but returned %v", tc.expected, nginxAffinity.Cookie.Paths)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sse
+
+import (
+	"testing"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	api "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+func buildIngress() *networking.Ingress {
+	return &networking.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "events",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: networking.IngressSpec{
+			DefaultBackend: &networking.IngressBackend{
+				Service: &networking.IngressServiceBackend{
+					Name: "event-stream",
+					Port: networking.ServiceBackendPort{
+						Number: 8080,
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestParseAnnotations(t *testing.T) {
+	ing := buildIngress()
+
+	_, err := NewParser(&resolver.Mock{}).Parse(ing)
+	if !errors.IsMissingAnnotations(err) {
+		t.Errorf("expected a missing annotation error but returned %v", err)
+	}
+
+	// disabling the proxy buffering does not turn the locations into SSE streams
+	ing.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix("proxy-buffering"): "off",
+	})
+	_, err = NewParser(&resolver.Mock{}).Parse(ing)
+	if !errors.IsMissingAnnotations(err) {
+		t.Errorf("expected a missing annotation error with proxy-buffering only but returned %v", err)
+	}
+
+	// enable-sse takes precedence over proxy-buffering in the locations, the
+	// parser still returns true when buffering is requested
+	ing.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix("enable-sse"):      "true",
+		parser.GetAnnotationWithPrefix("proxy-buffering"): "on",
+	})
+	i, err := NewParser(&resolver.Mock{}).Parse(ing)
+	if err != nil {
+		t.Errorf("unexpected error parsing ingress with enable-sse: %v", err)
+	}
+	val, ok := i.(bool)
+	if !ok {
+		t.Errorf("expected a bool type")
+	}
+	if !val {
+		t.Errorf("expected true but false returned")
+	}
+
+	ing.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix("enable-sse"): "text/event-stream",
+	})
+	_, err = NewParser(&resolver.Mock{}).Parse(ing)
+	if !errors.IsInvalidContent(err) {
+		t.Errorf("expected an invalid content error for a content type but returned %v", err)
+	}
+}
+
+func TestParseAnnotationsByMCI(t *testing.T) {
+	mci := &karmadanetworking.MultiClusterIngress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "events",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: buildIngress().Spec,
+	}
+
+	_, err := NewParser(&resolver.Mock{}).ParseByMCI(mci)
+	if !errors.IsMissingAnnotations(err) {
+		t.Errorf("expected a missing annotation error but returned %v", err)
+	}
+
+	mci.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix("enable-sse"): "true",
+	})
+	i, err := NewParser(&resolver.Mock{}).ParseByMCI(mci)
+	if err != nil {
+		t.Errorf("unexpected error parsing multiclusteringress with enable-sse: %v", err)
+	}
+	if val, _ := i.(bool); !val {
+		t.Errorf("expected true but false returned")
+	}
+
+	// the streams can be turned off again without removing the annotation
+	mci.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix("enable-sse"): "false",
+	})
+	i, err = NewParser(&resolver.Mock{}).ParseByMCI(mci)
+	if err != nil {
+		t.Errorf("unexpected error parsing multiclusteringress with enable-sse: %v", err)
+	}
+	if val, _ := i.(bool); val {
+		t.Errorf("expected false but true returned")
+	}
+}
@@ -21,6 +21,7 @@ import (
 	networking "k8s.io/api/networking/v1"
 
 	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 )
 
@@ -59,7 +60,7 @@ func (sc sslCipher) Parse(ing *networking.Ingress) (interface{}, error) {
 
 	config.SSLCiphers, _ = parser.GetStringAnnotation("ssl-ciphers", ing)
 
-	return config, nil
+	return validateCiphers(config)
 }
 
 // ParseByMCI parses the annotations contained in the multiclusteringress rule
@@ -82,5 +83,21 @@ func (sc sslCipher) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (inte
 
 	config.SSLCiphers, _ = parser.GetStringAnnotationFromMCI("ssl-ciphers", mci)
 
+	return validateCiphers(config)
+}
+
+// validateCiphers checks the ssl-ciphers value is a list of cipher strings
+// understood by OpenSSL, so an invalid value cannot break the reload
+func validateCiphers(config *Config) (*Config, error) {
+	if config.SSLCiphers == "" {
+		return config, nil
+	}
+
+	if !parser.IsValidCipherList(config.SSLCiphers) {
+		ciphers := config.SSLCiphers
+		config.SSLCiphers = ""
+		return config, ing_errors.NewInvalidAnnotationContent("ssl-ciphers", ciphers)
+	}
+
 	return config, nil
 }
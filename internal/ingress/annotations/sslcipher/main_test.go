@@ -20,6 +20,7 @@ import (
 	"reflect"
 	"testing"
 
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
 	api "k8s.io/api/core/v1"
 	networking "k8s.io/api/networking/v1"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -67,3 +68,40 @@ func TestParse(t *testing.T) {
 		}
 	}
 }
+
+func TestParseByMCIInvalidCiphers(t *testing.T) {
+	ap := NewParser(&resolver.Mock{})
+
+	annotationSSLCiphers := parser.GetAnnotationWithPrefix("ssl-ciphers")
+
+	testCases := []struct {
+		annotations map[string]string
+		expected    Config
+		expectErr   bool
+	}{
+		{map[string]string{annotationSSLCiphers: "ECDHE-RSA-AES128-GCM-SHA256:!aNULL:@STRENGTH"}, Config{"ECDHE-RSA-AES128-GCM-SHA256:!aNULL:@STRENGTH", ""}, false},
+		{map[string]string{annotationSSLCiphers: "HIGH,!aNULL:!MD5"}, Config{"HIGH,!aNULL:!MD5", ""}, false},
+		{map[string]string{annotationSSLCiphers: "HIGH, !aNULL !MD5"}, Config{"", ""}, true},
+		{map[string]string{annotationSSLCiphers: "HIGH:!aNULL; ssl_protocols SSLv3"}, Config{"", ""}, true},
+		{map[string]string{annotationSSLCiphers: "HIGH::"}, Config{"", ""}, true},
+		{map[string]string{annotationSSLCiphers: "'HIGH'"}, Config{"", ""}, true},
+	}
+
+	mci := &karmadanetworking.MultiClusterIngress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+	}
+
+	for _, testCase := range testCases {
+		mci.SetAnnotations(testCase.annotations)
+		result, err := ap.ParseByMCI(mci)
+		if testCase.expectErr != (err != nil) {
+			t.Errorf("expected error %v but returned %v, annotations: %s", testCase.expectErr, err, testCase.annotations)
+		}
+		if !reflect.DeepEqual(result, &testCase.expected) {
+			t.Errorf("expected %v but returned %v, annotations: %s", testCase.expected, result, testCase.annotations)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sslprotocols
+
+import (
+	"strings"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	networking "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+var validProtocols = sets.NewString("TLSv1", "TLSv1.1", "TLSv1.2", "TLSv1.3")
+
+type sslProtocols struct {
+	r resolver.Resolver
+}
+
+// Config contains the ssl-protocols configuration of a server.
+// An empty SSLProtocols means the global setting applies.
+type Config struct {
+	SSLProtocols string `json:"sslProtocols,omitempty"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+
+	return c1.SSLProtocols == c2.SSLProtocols
+}
+
+// NewParser creates a new ssl-protocols annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return sslProtocols{r}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to restrict the TLS protocols enabled in the server
+func (sp sslProtocols) Parse(ing *networking.Ingress) (interface{}, error) {
+	protocols, err := parser.GetStringAnnotation("ssl-protocols", ing)
+	if err != nil {
+		return &Config{}, nil
+	}
+
+	return newConfig(protocols)
+}
+
+// ParseByMCI parses the annotations contained in the multiclusteringress rule
+// used to restrict the TLS protocols enabled in the server
+func (sp sslProtocols) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interface{}, error) {
+	protocols, err := parser.GetStringAnnotationFromMCI("ssl-protocols", mci)
+	if err != nil {
+		return &Config{}, nil
+	}
+
+	return newConfig(protocols)
+}
+
+// newConfig checks every protocol in the space separated list is supported
+func newConfig(protocols string) (*Config, error) {
+	fields := strings.Fields(protocols)
+	for _, protocol := range fields {
+		if !validProtocols.Has(protocol) {
+			return &Config{}, ing_errors.NewInvalidAnnotationContent("ssl-protocols", protocols)
+		}
+	}
+
+	return &Config{SSLProtocols: strings.Join(fields, " ")}, nil
+}
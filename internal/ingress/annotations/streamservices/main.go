@@ -0,0 +1,182 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package streamservices
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	networking "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+const (
+	tcpServicesAnnotation = "tcp-services"
+	udpServicesAnnotation = "udp-services"
+)
+
+type streamServices struct {
+	r resolver.Resolver
+}
+
+// Config contains the TCP and UDP services exposed by the ingress, by external
+// port. The services are referenced with the format of the tcp-services and
+// udp-services ConfigMaps: <namespace>/<service>:<port>[:PROXY[:PROXY]]
+type Config struct {
+	TCP map[int]string `json:"tcp,omitempty"`
+	UDP map[int]string `json:"udp,omitempty"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+
+	return equalServices(c1.TCP, c2.TCP) && equalServices(c1.UDP, c2.UDP)
+}
+
+func equalServices(s1, s2 map[int]string) bool {
+	if len(s1) != len(s2) {
+		return false
+	}
+
+	for port, svc := range s1 {
+		if s2[port] != svc {
+			return false
+		}
+	}
+
+	return true
+}
+
+// NewParser creates a new TCP and UDP services annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return streamServices{r}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to expose services of the namespace on TCP and UDP ports
+func (s streamServices) Parse(ing *networking.Ingress) (interface{}, error) {
+	tcp, tcpErr := parser.GetStringAnnotation(tcpServicesAnnotation, ing)
+	udp, udpErr := parser.GetStringAnnotation(udpServicesAnnotation, ing)
+
+	return parseServices(ing.Namespace, tcp, tcpErr, udp, udpErr)
+}
+
+// ParseByMCI parses the annotations contained in the multiclusteringress rule
+// used to expose services of the namespace on TCP and UDP ports
+func (s streamServices) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interface{}, error) {
+	tcp, tcpErr := parser.GetStringAnnotationFromMCI(tcpServicesAnnotation, mci)
+	udp, udpErr := parser.GetStringAnnotationFromMCI(udpServicesAnnotation, mci)
+
+	return parseServices(mci.Namespace, tcp, tcpErr, udp, udpErr)
+}
+
+func parseServices(namespace, tcp string, tcpErr error, udp string, udpErr error) (*Config, error) {
+	if tcpErr != nil && udpErr != nil {
+		return &Config{}, tcpErr
+	}
+
+	config := &Config{}
+	var err error
+
+	if tcpErr == nil {
+		config.TCP, err = parseMappings(tcpServicesAnnotation, namespace, tcp, true)
+		if err != nil {
+			return &Config{}, err
+		}
+	}
+
+	if udpErr == nil {
+		config.UDP, err = parseMappings(udpServicesAnnotation, namespace, udp, false)
+		if err != nil {
+			return &Config{}, err
+		}
+	}
+
+	return config, nil
+}
+
+// parseMappings parses a comma separated list of
+// <external port>:<service>:<service port>[:PROXY[:PROXY]] mappings.
+// The services always belong to the namespace of the ingress, and the
+// PROXY protocol is only allowed for TCP services.
+func parseMappings(name, namespace, raw string, allowProxy bool) (map[int]string, error) {
+	services := make(map[int]string)
+
+	for _, mapping := range strings.Split(raw, ",") {
+		mapping = strings.TrimSpace(mapping)
+		if mapping == "" {
+			continue
+		}
+
+		parts := strings.Split(mapping, ":")
+		if len(parts) < 3 || len(parts) > 5 {
+			return nil, ing_errors.NewInvalidAnnotationContent(name, mapping)
+		}
+
+		port, err := strconv.Atoi(parts[0])
+		if err != nil || len(validation.IsValidPortNum(port)) > 0 {
+			return nil, ing_errors.NewInvalidAnnotationContent(name, mapping)
+		}
+
+		if _, ok := services[port]; ok {
+			return nil, ing_errors.NewInvalidAnnotationContent(name, mapping)
+		}
+
+		if len(validation.IsDNS1035Label(parts[1])) > 0 {
+			return nil, ing_errors.NewInvalidAnnotationContent(name, mapping)
+		}
+
+		if !isValidServicePort(parts[2]) {
+			return nil, ing_errors.NewInvalidAnnotationContent(name, mapping)
+		}
+
+		for _, proxy := range parts[3:] {
+			if !allowProxy || proxy != "PROXY" {
+				return nil, ing_errors.NewInvalidAnnotationContent(name, mapping)
+			}
+		}
+
+		services[port] = fmt.Sprintf("%v/%v", namespace, strings.Join(parts[1:], ":"))
+	}
+
+	if len(services) == 0 {
+		return nil, ing_errors.NewInvalidAnnotationContent(name, raw)
+	}
+
+	return services, nil
+}
+
+// isValidServicePort checks a port number or the name of a port
+func isValidServicePort(port string) bool {
+	if number, err := strconv.Atoi(port); err == nil {
+		return len(validation.IsValidPortNum(number)) == 0
+	}
+
+	return len(validation.IsValidPortName(port)) == 0
+}
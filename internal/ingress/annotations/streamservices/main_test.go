@@ -0,0 +1,86 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package streamservices
+
+import (
+	"testing"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	api "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+func TestParseByMCI(t *testing.T) {
+	tcp := parser.GetAnnotationWithPrefix("tcp-services")
+	udp := parser.GetAnnotationWithPrefix("udp-services")
+
+	ap := NewParser(&resolver.Mock{})
+	if ap == nil {
+		t.Fatalf("expected a parser.IngressAnnotation but returned nil")
+	}
+
+	testCases := []struct {
+		annotations map[string]string
+		expected    *Config
+		expectErr   bool
+	}{
+		{nil, &Config{}, true},
+		{map[string]string{tcp: "9000:mysql:3306"}, &Config{TCP: map[int]string{9000: "default/mysql:3306"}}, false},
+		{map[string]string{tcp: "9000:mysql:3306, 9001:redis:client:PROXY:PROXY"}, &Config{TCP: map[int]string{
+			9000: "default/mysql:3306",
+			9001: "default/redis:client:PROXY:PROXY",
+		}}, false},
+		{map[string]string{udp: "53:dns:53"}, &Config{UDP: map[int]string{53: "default/dns:53"}}, false},
+		{map[string]string{tcp: "53:dns:53", udp: "53:dns:53"}, &Config{
+			TCP: map[int]string{53: "default/dns:53"},
+			UDP: map[int]string{53: "default/dns:53"},
+		}, false},
+		{map[string]string{tcp: ""}, &Config{}, true},
+		{map[string]string{tcp: "9000:mysql"}, &Config{}, true},
+		{map[string]string{tcp: "mysql:mysql:3306"}, &Config{}, true},
+		{map[string]string{tcp: "70000:mysql:3306"}, &Config{}, true},
+		{map[string]string{tcp: "9000:other/mysql:3306"}, &Config{}, true},
+		{map[string]string{tcp: "9000:mysql:0"}, &Config{}, true},
+		{map[string]string{tcp: "9000:mysql:3306:HAPROXY"}, &Config{}, true},
+		{map[string]string{tcp: "9000:mysql:3306,9000:redis:6379"}, &Config{}, true},
+		{map[string]string{udp: "53:dns:53:PROXY"}, &Config{}, true},
+		{map[string]string{tcp: "9000:mysql:3306", udp: "53"}, &Config{}, true},
+	}
+
+	mci := &karmadanetworking.MultiClusterIngress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+	}
+
+	for _, testCase := range testCases {
+		mci.SetAnnotations(testCase.annotations)
+		i, err := ap.ParseByMCI(mci)
+		if testCase.expectErr != (err != nil) {
+			t.Errorf("expected error %v but returned %v, annotations: %s", testCase.expectErr, err, testCase.annotations)
+		}
+
+		c, _ := i.(*Config)
+		if !c.Equal(testCase.expected) {
+			t.Errorf("expected %v but returned %v, annotations: %s", testCase.expected, c, testCase.annotations)
+		}
+	}
+}
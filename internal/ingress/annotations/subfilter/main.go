@@ -0,0 +1,159 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package subfilter
+
+import (
+	"regexp"
+	"strings"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	networking "k8s.io/api/networking/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+var (
+	// the strings are quoted in the sub_filter directive and must not contain
+	// variables, which would expose request data in the responses
+	replacementRegex = regexp.MustCompile(`^[^"\\$\x00-\x20\x7f]+$`)
+)
+
+// Config contains the string replaced in the bodies of the responses
+type Config struct {
+	// From is the string replaced
+	From string `json:"from,omitempty"`
+	// To is the replacement string
+	To string `json:"to,omitempty"`
+	// Types is the space separated list of MIME types of the sub_filter_types
+	// directive, text/html is always included
+	Types string `json:"types,omitempty"`
+	// Once replaces only the first occurrence of the string
+	Once bool `json:"once,omitempty"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if c1.From != c2.From {
+		return false
+	}
+	if c1.To != c2.To {
+		return false
+	}
+	if c1.Types != c2.Types {
+		return false
+	}
+	if c1.Once != c2.Once {
+		return false
+	}
+
+	return true
+}
+
+type subFilter struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new sub filter annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return subFilter{r}
+}
+
+// Parse parses the annotations contained in the ingress
+// rule used to rewrite the bodies of the responses
+func (a subFilter) Parse(ing *networking.Ingress) (interface{}, error) {
+	filter, err := parser.GetStringAnnotation("sub-filter", ing)
+	if err != nil {
+		return &Config{}, nil
+	}
+
+	types, err := parser.GetStringAnnotation("sub-filter-types", ing)
+	if err != nil && !ing_errors.IsMissingAnnotations(err) {
+		return &Config{}, err
+	}
+
+	once, err := parser.GetBoolAnnotation("sub-filter-once", ing)
+	if err != nil {
+		if !ing_errors.IsMissingAnnotations(err) {
+			return &Config{}, err
+		}
+		once = true
+	}
+
+	return newConfig(filter, types, once)
+}
+
+// ParseByMCI parses the annotations contained in the multiclusteringress
+// rule used to rewrite the bodies of the responses
+func (a subFilter) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interface{}, error) {
+	filter, err := parser.GetStringAnnotationFromMCI("sub-filter", mci)
+	if err != nil {
+		return &Config{}, nil
+	}
+
+	types, err := parser.GetStringAnnotationFromMCI("sub-filter-types", mci)
+	if err != nil && !ing_errors.IsMissingAnnotations(err) {
+		return &Config{}, err
+	}
+
+	once, err := parser.GetBoolAnnotationFromMCI("sub-filter-once", mci)
+	if err != nil {
+		if !ing_errors.IsMissingAnnotations(err) {
+			return &Config{}, err
+		}
+		once = true
+	}
+
+	return newConfig(filter, types, once)
+}
+
+func newConfig(rawFilter, rawTypes string, once bool) (*Config, error) {
+	// sub-filter is the string to replace and its replacement, separated by spaces
+	pair := strings.Fields(rawFilter)
+	if len(pair) != 2 {
+		return &Config{}, ing_errors.NewInvalidAnnotationConfiguration("sub-filter", "a string and its replacement are required")
+	}
+	for _, s := range pair {
+		if !replacementRegex.MatchString(s) {
+			return &Config{}, ing_errors.NewInvalidAnnotationContent("sub-filter", rawFilter)
+		}
+	}
+
+	// sub-filter-types accepts MIME types separated by commas or spaces
+	types := strings.FieldsFunc(strings.ToLower(rawTypes), func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+	for _, t := range types {
+		if !parser.IsValidMIMEType(t) {
+			return &Config{}, ing_errors.NewInvalidAnnotationContent("sub-filter-types", rawTypes)
+		}
+	}
+
+	return &Config{
+		From:  pair[0],
+		To:    pair[1],
+		Types: strings.Join(types, " "),
+		Once:  once,
+	}, nil
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package subfilter
+
+import (
+	"testing"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	api "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+func TestParseByMCI(t *testing.T) {
+	filter := parser.GetAnnotationWithPrefix("sub-filter")
+	types := parser.GetAnnotationWithPrefix("sub-filter-types")
+	once := parser.GetAnnotationWithPrefix("sub-filter-once")
+
+	ap := NewParser(&resolver.Mock{})
+	if ap == nil {
+		t.Fatalf("expected a parser.IngressAnnotation but returned nil")
+	}
+
+	testCases := []struct {
+		annotations map[string]string
+		expected    *Config
+		expectErr   bool
+	}{
+		{nil, &Config{}, false},
+		{map[string]string{types: "text/css"}, &Config{}, false},
+		{map[string]string{filter: "http://old.example.com https://new.example.com"}, &Config{From: "http://old.example.com", To: "https://new.example.com", Once: true}, false},
+		{map[string]string{filter: "/old/ /new/", once: "false"}, &Config{From: "/old/", To: "/new/"}, false},
+		{map[string]string{filter: "/old/ /new/", types: "text/css, Application/JavaScript"}, &Config{From: "/old/", To: "/new/", Types: "text/css application/javascript", Once: true}, false},
+		{map[string]string{filter: "/old/ /new/", types: "*"}, &Config{From: "/old/", To: "/new/", Types: "*", Once: true}, false},
+		{map[string]string{filter: "/old/"}, &Config{}, true},
+		{map[string]string{filter: "/old/ /new/ /other/"}, &Config{}, true},
+		{map[string]string{filter: "/old/ $http_authorization"}, &Config{}, true},
+		{map[string]string{filter: `/old/ /new/";`}, &Config{}, true},
+		{map[string]string{filter: "/old/ /new/", once: "maybe"}, &Config{}, true},
+		{map[string]string{filter: "/old/ /new/", types: "text/css; return 200"}, &Config{}, true},
+	}
+
+	mci := &karmadanetworking.MultiClusterIngress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+	}
+
+	for _, testCase := range testCases {
+		mci.SetAnnotations(testCase.annotations)
+		i, err := ap.ParseByMCI(mci)
+		if testCase.expectErr != (err != nil) {
+			t.Errorf("expected error %v but returned %v, annotations: %s", testCase.expectErr, err, testCase.annotations)
+		}
+
+		c, _ := i.(*Config)
+		if !c.Equal(testCase.expected) {
+			t.Errorf("expected %v but returned %v, annotations: %s", testCase.expected, c, testCase.annotations)
+		}
+	}
+}
+
+func TestEqual(t *testing.T) {
+	c1 := &Config{From: "/old/", To: "/new/", Types: "text/css", Once: true}
+
+	if !c1.Equal(&Config{From: "/old/", To: "/new/", Types: "text/css", Once: true}) {
+		t.Errorf("expected equal configurations")
+	}
+	if c1.Equal(&Config{From: "/old/", To: "/other/", Types: "text/css", Once: true}) {
+		t.Errorf("expected different replacements to not be equal")
+	}
+	if c1.Equal(&Config{From: "/old/", To: "/new/", Types: "text/css"}) {
+		t.Errorf("expected different once to not be equal")
+	}
+	if c1.Equal(nil) {
+		t.Errorf("expected a nil configuration to not be equal")
+	}
+}
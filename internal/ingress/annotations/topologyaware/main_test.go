@@ -0,0 +1,120 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package topologyaware
+
+import (
+	"testing"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	api "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+// topologyHintsAnnotation is the annotation of the services asking the
+// EndpointSlice controller to fill the zone hints
+const topologyHintsAnnotation = "service.kubernetes.io/topology-aware-hints"
+
+func buildMCI() *karmadanetworking.MultiClusterIngress {
+	return &karmadanetworking.MultiClusterIngress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "zonal",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: networking.IngressSpec{
+			DefaultBackend: &networking.IngressBackend{
+				Service: &networking.IngressServiceBackend{
+					Name: "zonal-svc",
+					Port: networking.ServiceBackendPort{
+						Number: 80,
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestParseAnnotationsByMCI(t *testing.T) {
+	mci := buildMCI()
+
+	_, err := NewParser(&resolver.Mock{}).ParseByMCI(mci)
+	if !errors.IsMissingAnnotations(err) {
+		t.Errorf("expected a missing annotation error but returned %v", err)
+	}
+
+	// the annotation of the services is not read from the multiclusteringress
+	mci.SetAnnotations(map[string]string{
+		topologyHintsAnnotation: "auto",
+	})
+	_, err = NewParser(&resolver.Mock{}).ParseByMCI(mci)
+	if !errors.IsMissingAnnotations(err) {
+		t.Errorf("expected a missing annotation error with %v only but returned %v", topologyHintsAnnotation, err)
+	}
+
+	mci.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix("upstream-topology-aware"): "true",
+	})
+	i, err := NewParser(&resolver.Mock{}).ParseByMCI(mci)
+	if err != nil {
+		t.Errorf("unexpected error parsing multiclusteringress with upstream-topology-aware: %v", err)
+	}
+	val, ok := i.(bool)
+	if !ok {
+		t.Errorf("expected a bool type")
+	}
+	if !val {
+		t.Errorf("expected true but false returned")
+	}
+
+	// the hint modes of the services are not values of the annotation
+	mci.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix("upstream-topology-aware"): "auto",
+	})
+	_, err = NewParser(&resolver.Mock{}).ParseByMCI(mci)
+	if !errors.IsInvalidContent(err) {
+		t.Errorf("expected an invalid content error for a hint mode but returned %v", err)
+	}
+}
+
+func TestParseAnnotations(t *testing.T) {
+	ing := &networking.Ingress{
+		ObjectMeta: buildMCI().ObjectMeta,
+		Spec:       buildMCI().Spec,
+	}
+
+	_, err := NewParser(&resolver.Mock{}).Parse(ing)
+	if !errors.IsMissingAnnotations(err) {
+		t.Errorf("expected a missing annotation error but returned %v", err)
+	}
+
+	for value, expected := range map[string]bool{"true": true, "false": false} {
+		ing.SetAnnotations(map[string]string{
+			parser.GetAnnotationWithPrefix("upstream-topology-aware"): value,
+		})
+		i, err := NewParser(&resolver.Mock{}).Parse(ing)
+		if err != nil {
+			t.Errorf("unexpected error parsing ingress with upstream-topology-aware %v: %v", value, err)
+		}
+		if val, _ := i.(bool); val != expected {
+			t.Errorf("expected %v for %v but returned %v", expected, value, val)
+		}
+	}
+}
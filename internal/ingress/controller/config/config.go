@@ -393,6 +393,14 @@ type Configuration struct {
 	// Default: false
 	SSLRejectHandshake bool `json:"ssl-reject-handshake"`
 
+	// DefaultJSONResponses contains the bodies, indexed by status code, the
+	// default server returns directly instead of using the default backend
+	DefaultJSONResponses map[int]string `json:"default-json-responses"`
+
+	// DefaultResponseContentType is the content type of the DefaultJSONResponses
+	// Default: application/json
+	DefaultResponseContentType string `json:"default-response-content-type"`
+
 	// Enables or disables the use of the PROXY protocol to receive client connection
 	// (real IP address) information passed through proxy servers and load balancers
 	// such as HAproxy and Amazon Elastic Load Balancer (ELB).
@@ -847,6 +855,8 @@ func NewDefault() Configuration {
 		SSLProtocols:                     sslProtocols,
 		SSLEarlyData:                     sslEarlyData,
 		SSLRejectHandshake:               false,
+		DefaultJSONResponses:             map[int]string{},
+		DefaultResponseContentType:       "application/json",
 		SSLSessionCache:                  true,
 		SSLSessionCacheSize:              sslSessionCacheSize,
 		SSLSessionTickets:                false,
@@ -865,31 +875,33 @@ func NewDefault() Configuration {
 		ProxyStreamNextUpstreamTimeout:   "600s",
 		ProxyStreamNextUpstreamTries:     3,
 		Backend: defaults.Backend{
-			ProxyBodySize:            bodySize,
-			ProxyConnectTimeout:      5,
-			ProxyReadTimeout:         60,
-			ProxySendTimeout:         60,
-			ProxyBuffersNumber:       4,
-			ProxyBufferSize:          "4k",
-			ProxyCookieDomain:        "off",
-			ProxyCookiePath:          "off",
-			ProxyNextUpstream:        "error timeout",
-			ProxyNextUpstreamTimeout: 0,
-			ProxyNextUpstreamTries:   3,
-			ProxyRequestBuffering:    "on",
-			ProxyRedirectFrom:        "off",
-			ProxyRedirectTo:          "off",
-			PreserveTrailingSlash:    false,
-			SSLRedirect:              true,
-			CustomHTTPErrors:         []int{},
-			WhitelistSourceRange:     []string{},
-			SkipAccessLogURLs:        []string{},
-			LimitRate:                0,
-			LimitRateAfter:           0,
-			ProxyBuffering:           "off",
-			ProxyHTTPVersion:         "1.1",
-			ProxyMaxTempFileSize:     "1024m",
-			ServiceUpstream:          false,
+			ProxyBodySize:              bodySize,
+			ProxyConnectTimeout:        5,
+			ProxyReadTimeout:           60,
+			ProxySendTimeout:           60,
+			ProxyBuffersNumber:         4,
+			ProxyBufferSize:            "4k",
+			ProxyCookieDomain:          "off",
+			ProxyCookiePath:            "off",
+			ProxyNextUpstream:          "error timeout",
+			ProxyNextUpstreamTimeout:   0,
+			ProxyNextUpstreamTries:     3,
+			ProxyRequestBuffering:      "on",
+			ProxyRedirectFrom:          "off",
+			ProxyRedirectTo:            "off",
+			PreserveTrailingSlash:      false,
+			SSLRedirect:                true,
+			CustomHTTPErrors:           []int{},
+			WhitelistSourceRange:       []string{},
+			SkipAccessLogURLs:          []string{},
+			LimitRate:                  0,
+			LimitRateAfter:             0,
+			ProxyBuffering:             "off",
+			ProxyHTTPVersion:           "1.1",
+			ProxyMaxTempFileSize:       "1024m",
+			ServiceUpstream:            false,
+			AllowHTTPDefaultBackendURL: false,
+			ProxyCacheZones:            map[string]string{},
 		},
 		UpstreamKeepaliveConnections:           320,
 		UpstreamKeepaliveTimeout:               60,
@@ -17,6 +17,7 @@ limitations under the License.
 package controller
 
 import (
+	"context"
 	"fmt"
 	"sort"
 	"strconv"
@@ -35,6 +36,7 @@ import (
 	clientset "k8s.io/client-go/kubernetes"
 	"k8s.io/ingress-nginx/internal/ingress"
 	"k8s.io/ingress-nginx/internal/ingress/annotations"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/backendprotocol"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/log"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/proxy"
@@ -52,6 +54,10 @@ const (
 	defUpstreamName = "upstream-default-backend"
 	defServerName   = "_"
 	rootLocation    = "/"
+
+	// sseReadTimeout is the minimum proxy read timeout, in seconds,
+	// of the locations streaming Server-Sent Events
+	sseReadTimeout = 3600
 )
 
 // Configuration contains all the settings required by an Ingress controller
@@ -79,6 +85,10 @@ type Configuration struct {
 	TCPConfigMapName string
 	// +optional
 	UDPConfigMapName string
+	// HostPortsConfigMapName is the ConfigMap mapping hostnames to custom
+	// HTTP and HTTPS listen ports
+	// +optional
+	HostPortsConfigMapName string
 
 	DefaultSSLCertificate string
 
@@ -124,6 +134,78 @@ type Configuration struct {
 	ShutdownGracePeriod int
 
 	DeepInspector bool
+
+	// LocationTiebreak defines how locations with paths of equal length are ordered
+	LocationTiebreak string
+
+	// RejectUnwatchedNamespace makes the admission check fail for objects
+	// outside the watched namespace instead of ignoring them
+	RejectUnwatchedNamespace bool
+
+	// MaxLocationsPerServer is the maximum number of locations a server can
+	// have before the admission check rejects a change. Zero means no limit
+	MaxLocationsPerServer int
+
+	// GroupCanaryUpstreams orders the upstreams so the alternative backends
+	// follow their primary backend instead of sorting them by name only
+	GroupCanaryUpstreams bool
+
+	// DefaultBackendConnectTimeout and DefaultBackendReadTimeout override, in
+	// seconds, the proxy timeouts of the catch-all location. Zero keeps the
+	// timeouts of the configmap
+	DefaultBackendConnectTimeout int
+	DefaultBackendReadTimeout    int
+
+	// NamespaceDefaultBackendService is the name of the Service used, in the
+	// namespace of a multiclusteringress without default backend, instead of
+	// the global default backend when it exists
+	NamespaceDefaultBackendService string
+
+	// EnableMisdirectedRequestCheck returns 421 in the servers terminating TLS
+	// when the SNI of the connection does not match the Host of the request
+	EnableMisdirectedRequestCheck bool
+
+	// UpstreamDNSResolver are the addresses, as host:port, of the DNS servers
+	// NGINX uses to resolve the ExternalName upstreams at request time
+	UpstreamDNSResolver []string
+	// UpstreamDNSValid is the time NGINX caches the answers of UpstreamDNSResolver
+	UpstreamDNSValid time.Duration
+
+	// ValidateCanaryPrimary rejects in the validating webhook the canary
+	// multiclusteringresses with host/path pairs no primary defines
+	ValidateCanaryPrimary bool
+
+	// AddMCIDebugHeader adds to the responses the X-Served-By-MCI header
+	// with the multiclusteringress of the location
+	AddMCIDebugHeader bool
+
+	// ValidateBackendServices rejects in the validating webhook the
+	// multiclusteringresses referencing services not found in the store
+	ValidateBackendServices bool
+
+	// TopologyZone is the zone of the controller, whose endpoints are used by
+	// the upstreams of the multiclusteringresses with upstream-topology-aware
+	TopologyZone string
+
+	// ExplodeAliases generates a server per alias, sharing the locations of
+	// its server, instead of adding the aliases to the server_name of the server
+	ExplodeAliases bool
+
+	// AdmissionTimeout is the maximum time spent by the validating webhook
+	// rendering and testing the configuration, 0 means no limit
+	AdmissionTimeout time.Duration
+
+	// EmitAdmissionEvents records an event in the multiclusteringresses
+	// checked by the validating webhook with the decision
+	EmitAdmissionEvents bool
+
+	// UseEndpointSlices reads the endpoints of the services from their
+	// EndpointSlices instead of their Endpoints
+	UseEndpointSlices bool
+
+	// IncludeTerminatingServing keeps in the upstreams the endpoints of the
+	// EndpointSlices that are terminating but still serving
+	IncludeTerminatingServing bool
 }
 
 // GetPublishService returns the Service used to set the load-balancer status of Ingresses.
@@ -149,9 +231,16 @@ func (n *NGINXController) syncIngress(interface{}) error {
 	//ings := n.store.ListIngresses()
 	//hosts, servers, pcfg := n.getConfiguration(ings)
 	mcis := n.store.ListMultiClusterIngresses()
-	hosts, servers, pcfg := n.getConfigurationFromMCI(mcis)
+	if checksum := mciChecksum(mcis); checksum != n.mciChecksum {
+		n.mciChecksum = checksum
+		n.metricCollector.MCIChanged()
+	}
+
+	hosts, servers, pcfg, notices := n.getConfigurationFromMCI(mcis)
+	n.reportMCINotices(notices)
 
 	n.metricCollector.SetSSLExpireTime(servers)
+	n.metricCollector.SetMCIBuildMetrics(mciBuildMetrics(pcfg.Backends, servers))
 
 	if n.runningConfig.Equal(pcfg) {
 		klog.V(3).Infof("No configuration change detected, skipping backend reload")
@@ -219,8 +308,12 @@ func (n *NGINXController) syncIngress(interface{}) error {
 	ri := getRemovedMCIs(n.runningConfig, pcfg)
 	re := getRemovedHosts(n.runningConfig, pcfg)
 	n.metricCollector.RemoveMetrics(ri, re)
+	if len(ri) > 0 {
+		n.metricCollector.MCIChanged()
+	}
 
 	n.runningConfig = pcfg
+	n.metricCollector.SetConfigScale(n.Scale())
 
 	return nil
 }
@@ -322,7 +415,7 @@ func (n *NGINXController) CheckIngress(ing *networking.Ingress) error {
 		return err
 	}
 
-	err = n.testTemplate(content)
+	err = n.testTemplate(context.Background(), content)
 	if err != nil {
 		n.metricCollector.IncCheckErrorCount(ing.ObjectMeta.Namespace, ing.Name)
 		return err
@@ -357,9 +450,26 @@ func (n *NGINXController) getStreamServices(configmapName string, proto apiv1.Pr
 	}
 
 	var svcs []ingress.L4Service
-	var svcProxyProtocol ingress.ProxyProtocol
 
-	rp := []int{
+	reservedPorts := n.streamReservedPorts()
+	for port, svcRef := range configmap.Data {
+		svc := n.getStreamService(port, svcRef, proto, reservedPorts)
+		if svc == nil {
+			continue
+		}
+		svcs = append(svcs, *svc)
+	}
+	// Keep upstream order sorted to reduce unnecessary nginx config reloads.
+	sort.SliceStable(svcs, func(i, j int) bool {
+		return svcs[i].Port < svcs[j].Port
+	})
+	return svcs
+}
+
+// streamReservedPorts returns the ports used by the controller
+// itself, which cannot be used by stream services
+func (n *NGINXController) streamReservedPorts() sets.Int {
+	return sets.NewInt(
 		n.cfg.ListenPorts.HTTP,
 		n.cfg.ListenPorts.HTTPS,
 		n.cfg.ListenPorts.SSLProxy,
@@ -368,99 +478,95 @@ func (n *NGINXController) getStreamServices(configmapName string, proto apiv1.Pr
 		nginx.ProfilerPort,
 		nginx.StatusPort,
 		nginx.StreamPort,
-	}
+	)
+}
 
-	reservedPorts := sets.NewInt(rp...)
-	// svcRef format: <(str)namespace>/<(str)service>:<(intstr)port>[:<("PROXY")decode>:<("PROXY")encode>]
-	for port, svcRef := range configmap.Data {
-		externalPort, err := strconv.Atoi(port) // #nosec
-		if err != nil {
-			klog.Warningf("%q is not a valid %v port number", port, proto)
-			continue
-		}
-		if reservedPorts.Has(externalPort) {
-			klog.Warningf("Port %d cannot be used for %v stream services. It is reserved for the Ingress controller.", externalPort, proto)
-			continue
-		}
-		nsSvcPort := strings.Split(svcRef, ":")
-		if len(nsSvcPort) < 2 {
-			klog.Warningf("Invalid Service reference %q for %v port %d", svcRef, proto, externalPort)
-			continue
-		}
-		nsName := nsSvcPort[0]
-		svcPort := nsSvcPort[1]
-		svcProxyProtocol.Decode = false
-		svcProxyProtocol.Encode = false
-		// Proxy Protocol is only compatible with TCP Services
-		if len(nsSvcPort) >= 3 && proto == apiv1.ProtocolTCP {
-			if len(nsSvcPort) >= 3 && strings.ToUpper(nsSvcPort[2]) == "PROXY" {
-				svcProxyProtocol.Decode = true
-			}
-			if len(nsSvcPort) == 4 && strings.ToUpper(nsSvcPort[3]) == "PROXY" {
-				svcProxyProtocol.Encode = true
-			}
-		}
-		svcNs, svcName, err := k8s.ParseNameNS(nsName)
-		if err != nil {
-			klog.Warningf("%v", err)
-			continue
+// getStreamService returns the stream service exposing a service reference on
+// an external port, or nil when the reference is invalid or has no endpoints.
+// svcRef format: <(str)namespace>/<(str)service>:<(intstr)port>[:<("PROXY")decode>:<("PROXY")encode>]
+func (n *NGINXController) getStreamService(port, svcRef string, proto apiv1.Protocol, reservedPorts sets.Int) *ingress.L4Service {
+	var svcProxyProtocol ingress.ProxyProtocol
+
+	externalPort, err := strconv.Atoi(port) // #nosec
+	if err != nil {
+		klog.Warningf("%q is not a valid %v port number", port, proto)
+		return nil
+	}
+	if reservedPorts.Has(externalPort) {
+		klog.Warningf("Port %d cannot be used for %v stream services. It is reserved for the Ingress controller.", externalPort, proto)
+		return nil
+	}
+	nsSvcPort := strings.Split(svcRef, ":")
+	if len(nsSvcPort) < 2 {
+		klog.Warningf("Invalid Service reference %q for %v port %d", svcRef, proto, externalPort)
+		return nil
+	}
+	nsName := nsSvcPort[0]
+	svcPort := nsSvcPort[1]
+	// Proxy Protocol is only compatible with TCP Services
+	if len(nsSvcPort) >= 3 && proto == apiv1.ProtocolTCP {
+		if len(nsSvcPort) >= 3 && strings.ToUpper(nsSvcPort[2]) == "PROXY" {
+			svcProxyProtocol.Decode = true
 		}
-		svc, err := n.store.GetService(nsName)
-		if err != nil {
-			klog.Warningf("Error getting Service %q: %v", nsName, err)
-			continue
+		if len(nsSvcPort) == 4 && strings.ToUpper(nsSvcPort[3]) == "PROXY" {
+			svcProxyProtocol.Encode = true
 		}
-		var endps []ingress.Endpoint
-		/* #nosec */
-		targetPort, err := strconv.Atoi(svcPort) // #nosec
-		if err != nil {
-			// not a port number, fall back to using port name
-			klog.V(3).Infof("Searching Endpoints with %v port name %q for Service %q", proto, svcPort, nsName)
-			for i := range svc.Spec.Ports {
-				sp := svc.Spec.Ports[i]
-				if sp.Name == svcPort {
-					if sp.Protocol == proto {
-						endps = getEndpoints(svc, &sp, proto, n.store.GetServiceEndpoints)
-						break
-					}
+	}
+	svcNs, svcName, err := k8s.ParseNameNS(nsName)
+	if err != nil {
+		klog.Warningf("%v", err)
+		return nil
+	}
+	svc, err := n.store.GetService(nsName)
+	if err != nil {
+		klog.Warningf("Error getting Service %q: %v", nsName, err)
+		return nil
+	}
+	var endps []ingress.Endpoint
+	/* #nosec */
+	targetPort, err := strconv.Atoi(svcPort) // #nosec
+	if err != nil {
+		// not a port number, fall back to using port name
+		klog.V(3).Infof("Searching Endpoints with %v port name %q for Service %q", proto, svcPort, nsName)
+		for i := range svc.Spec.Ports {
+			sp := svc.Spec.Ports[i]
+			if sp.Name == svcPort {
+				if sp.Protocol == proto {
+					endps = n.resolveEndpoints(svc, &sp, proto)
+					break
 				}
 			}
-		} else {
-			klog.V(3).Infof("Searching Endpoints with %v port number %d for Service %q", proto, targetPort, nsName)
-			for i := range svc.Spec.Ports {
-				sp := svc.Spec.Ports[i]
-				if sp.Port == int32(targetPort) {
-					if sp.Protocol == proto {
-						endps = getEndpoints(svc, &sp, proto, n.store.GetServiceEndpoints)
-						break
-					}
+		}
+	} else {
+		klog.V(3).Infof("Searching Endpoints with %v port number %d for Service %q", proto, targetPort, nsName)
+		for i := range svc.Spec.Ports {
+			sp := svc.Spec.Ports[i]
+			if sp.Port == int32(targetPort) {
+				if sp.Protocol == proto {
+					endps = n.resolveEndpoints(svc, &sp, proto)
+					break
 				}
 			}
 		}
-		// stream services cannot contain empty upstreams and there is
-		// no default backend equivalent
-		if len(endps) == 0 {
-			klog.Warningf("Service %q does not have any active Endpoint for %v port %v", nsName, proto, svcPort)
-			continue
-		}
-		svcs = append(svcs, ingress.L4Service{
-			Port: externalPort,
-			Backend: ingress.L4Backend{
-				Name:          svcName,
-				Namespace:     svcNs,
-				Port:          intstr.FromString(svcPort),
-				Protocol:      proto,
-				ProxyProtocol: svcProxyProtocol,
-			},
-			Endpoints: endps,
-			Service:   svc,
-		})
 	}
-	// Keep upstream order sorted to reduce unnecessary nginx config reloads.
-	sort.SliceStable(svcs, func(i, j int) bool {
-		return svcs[i].Port < svcs[j].Port
-	})
-	return svcs
+	// stream services cannot contain empty upstreams and there is
+	// no default backend equivalent
+	if len(endps) == 0 {
+		klog.Warningf("Service %q does not have any active Endpoint for %v port %v", nsName, proto, svcPort)
+		return nil
+	}
+	return &ingress.L4Service{
+		Port: externalPort,
+		Backend: ingress.L4Backend{
+			Name:          svcName,
+			Namespace:     svcNs,
+			Port:          intstr.FromString(svcPort),
+			Protocol:      proto,
+			ProxyProtocol: svcProxyProtocol,
+		},
+		Endpoints: endps,
+		Service:   svc,
+	}
 }
 
 // getDefaultUpstream returns the upstream associated with the default backend.
@@ -483,7 +589,7 @@ func (n *NGINXController) getDefaultUpstream() *ingress.Backend {
 		return upstream
 	}
 
-	endps := getEndpoints(svc, &svc.Spec.Ports[0], apiv1.ProtocolTCP, n.store.GetServiceEndpoints)
+	endps := n.resolveEndpoints(svc, &svc.Spec.Ports[0], apiv1.ProtocolTCP)
 	if len(endps) == 0 {
 		klog.Warningf("Service %q does not have any active Endpoint", svcKey)
 		endps = []ingress.Endpoint{n.DefaultEndpoint()}
@@ -810,7 +916,7 @@ func (n *NGINXController) getBackendServers(ingresses []*ingress.Ingress) ([]*in
 				}
 
 				sp := location.DefaultBackend.Spec.Ports[0]
-				endps := getEndpoints(location.DefaultBackend, &sp, apiv1.ProtocolTCP, n.store.GetServiceEndpoints)
+				endps := n.resolveEndpoints(location.DefaultBackend, &sp, apiv1.ProtocolTCP)
 				// custom backend is valid only if contains at least one endpoint
 				if len(endps) > 0 {
 					name := fmt.Sprintf("custom-default-backend-%v-%v", location.DefaultBackend.GetNamespace(), location.DefaultBackend.GetName())
@@ -922,6 +1028,7 @@ func (n *NGINXController) createUpstreams(data []*ingress.Ingress, du *ingress.B
 					HeaderValue:   anns.Canary.HeaderValue,
 					HeaderPattern: anns.Canary.HeaderPattern,
 					Cookie:        anns.Canary.Cookie,
+					Priority:      anns.Canary.Priority,
 				}
 			}
 
@@ -993,6 +1100,7 @@ func (n *NGINXController) createUpstreams(data []*ingress.Ingress, du *ingress.B
 						HeaderValue:   anns.Canary.HeaderValue,
 						HeaderPattern: anns.Canary.HeaderPattern,
 						Cookie:        anns.Canary.Cookie,
+						Priority:      anns.Canary.Priority,
 					}
 				}
 
@@ -1076,7 +1184,7 @@ func (n *NGINXController) serviceEndpoints(svcKey, backendPort string) ([]ingres
 			return upstreams, nil
 		}
 		servicePort := externalNamePorts(backendPort, svc)
-		endps := getEndpointsByEps(svc, servicePort, apiv1.ProtocolTCP, n.store.GetServiceEndpointSlices)
+		endps := n.resolveEndpoints(svc, servicePort, apiv1.ProtocolTCP)
 		if len(endps) == 0 {
 			klog.Warningf("Service %q does not have any active Endpoint.", svcKey)
 			return upstreams, nil
@@ -1093,7 +1201,7 @@ func (n *NGINXController) serviceEndpoints(svcKey, backendPort string) ([]ingres
 			servicePort.TargetPort.String() == backendPort ||
 			servicePort.Name == backendPort {
 
-			endps := getEndpointsByEps(svc, &servicePort, apiv1.ProtocolTCP, n.store.GetServiceEndpointSlices)
+			endps := n.resolveEndpoints(svc, &servicePort, apiv1.ProtocolTCP)
 			if len(endps) == 0 {
 				klog.Warningf("Service %q does not have any active Endpoint.", svcKey)
 			}
@@ -1106,6 +1214,22 @@ func (n *NGINXController) serviceEndpoints(svcKey, backendPort string) ([]ingres
 	return upstreams, nil
 }
 
+// resolveEndpoints returns the endpoints of a service port. With --use-endpointslices
+// the endpoints are read from the EndpointSlices of the service, falling back to
+// its Endpoints when the service has no EndpointSlice.
+func (n *NGINXController) resolveEndpoints(svc *apiv1.Service, port *apiv1.ServicePort, proto apiv1.Protocol) []ingress.Endpoint {
+	if n.cfg.UseEndpointSlices && svc != nil {
+		endpointSlices, err := n.store.GetServiceEndpointSlices(k8s.MetaNamespaceKey(svc))
+		if err == nil && len(endpointSlices) > 0 {
+			return getEndpointsByEps(svc, port, proto, n.cfg.IncludeTerminatingServing, n.store.GetServiceEndpointSlices)
+		}
+
+		klog.V(3).Infof("No EndpointSlice found for Service %q, using its Endpoints", k8s.MetaNamespaceKey(svc))
+	}
+
+	return getEndpoints(svc, port, proto, n.store.GetServiceEndpoints)
+}
+
 func (n *NGINXController) getDefaultSSLCertificate() *ingress.SSLCert {
 	// read custom default SSL certificate, fall back to generated default certificate
 	if n.cfg.DefaultSSLCertificate != "" {
@@ -1164,8 +1288,9 @@ func (n *NGINXController) createServers(data []*ingress.Ingress,
 				Proxy:        ngxProxy,
 				Service:      du.Service,
 				Logs: log.Config{
-					Access:  n.store.GetBackendConfiguration().EnableAccessLogForDefaultBackend,
-					Rewrite: false,
+					Access:     n.store.GetBackendConfiguration().EnableAccessLogForDefaultBackend,
+					Rewrite:    false,
+					SampleRate: 1,
 				},
 			},
 		}}
@@ -1387,13 +1512,27 @@ func (n *NGINXController) createServers(data []*ingress.Ingress,
 func locationApplyAnnotations(loc *ingress.Location, anns *annotations.Ingress) {
 	loc.BasicDigestAuth = anns.BasicDigestAuth
 	loc.ClientBodyBufferSize = anns.ClientBodyBufferSize
+	loc.ClientBodyInFileOnly = anns.ClientBodyInFileOnly
 	loc.ConfigurationSnippet = anns.ConfigurationSnippet
 	loc.CorsConfig = anns.CorsConfig
 	loc.ExternalAuth = anns.ExternalAuth
 	loc.EnableGlobalAuth = anns.EnableGlobalAuth
+	loc.Gzip = anns.Gzip
+	loc.Brotli = anns.Brotli
+	loc.Charset = anns.Charset
+	loc.SubFilter = anns.SubFilter
+	loc.GeoIPAccess = anns.GeoIPAccess
+	loc.ClientCert = anns.ClientCert
 	loc.HTTP2PushPreload = anns.HTTP2PushPreload
+	loc.PreloadLinks = anns.PreloadLinks
 	loc.Opentracing = anns.Opentracing
 	loc.Proxy = anns.Proxy
+	if anns.SSE {
+		applySSE(loc)
+	}
+	loc.ProxyCache = anns.ProxyCache
+	loc.GRPCTimeout = anns.GRPCTimeout
+	loc.ProxySetHeaders = anns.ProxySetHeaders
 	loc.ProxySSL = anns.ProxySSL
 	loc.RateLimit = anns.RateLimit
 	loc.GlobalRateLimit = anns.GlobalRateLimit
@@ -1409,15 +1548,32 @@ func locationApplyAnnotations(loc *ingress.Location, anns *annotations.Ingress)
 	loc.InfluxDB = anns.InfluxDB
 	loc.DefaultBackend = anns.DefaultBackend
 	loc.BackendProtocol = anns.BackendProtocol
+	loc.BackendScheme = anns.BackendScheme
+	loc.WebSocket = backendprotocol.IsWebSocket(anns.BackendProtocol)
 	loc.FastCGI = anns.FastCGI
 	loc.CustomHTTPErrors = anns.CustomHTTPErrors
 	loc.ModSecurity = anns.ModSecurity
 	loc.Satisfy = anns.Satisfy
 	loc.Mirror = anns.Mirror
+	loc.RequestID = anns.RequestID
+	loc.ServiceUnavailableOnEmptyUpstream = anns.ServiceUnavailableOnEmptyUpstream
+	loc.MaintenanceMode = anns.MaintenanceMode
+	loc.DisablePathRedirect = anns.DisablePathRedirect
+	loc.AbsoluteRedirect = anns.AbsoluteRedirect
 
 	loc.DefaultBackendUpstreamName = defUpstreamName
 }
 
+// applySSE configures the location to stream Server-Sent Events, overriding
+// the proxy settings that would buffer or close the long-lived responses
+func applySSE(loc *ingress.Location) {
+	loc.Proxy.ProxyBuffering = "off"
+	loc.Proxy.ProxyMaxTempFileSize = "0"
+	if loc.Proxy.ReadTimeout < sseReadTimeout {
+		loc.Proxy.ReadTimeout = sseReadTimeout
+	}
+}
+
 // OK to merge canary ingresses iff there exists one or more ingresses to potentially merge into
 func nonCanaryIngressExists(ingresses []*ingress.Ingress, canaryIngresses []*ingress.Ingress) bool {
 	return len(ingresses)-len(canaryIngresses) > 0
@@ -1,33 +1,96 @@
 package controller
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"net"
+	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	karmadanetwork "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
 	"github.com/karmada-io/karmada/pkg/util/names"
+	"github.com/mitchellh/hashstructure"
 	apiv1 "k8s.io/api/core/v1"
 	networking "k8s.io/api/networking/v1"
-	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
 
 	"k8s.io/ingress-nginx/internal/ingress"
 	"k8s.io/ingress-nginx/internal/ingress/annotations"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/backendscheme"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/clientbodyinfileonly"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/clientcert"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/clientkeepalive"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/defaultbackend"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/ewmadecay"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/geoipaccess"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/grpctimeout"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/http2pushpreload"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/internalredirect"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/log"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/proxy"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/proxycache"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/ratelimit"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/routebyheader"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/securityheaders"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/sslcipher"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/sslprotocols"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/streamservices"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/subfilter"
+	ngx_config "k8s.io/ingress-nginx/internal/ingress/controller/config"
 	"k8s.io/ingress-nginx/internal/ingress/controller/store"
+	ngx_template "k8s.io/ingress-nginx/internal/ingress/controller/template"
 	"k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
 	"k8s.io/ingress-nginx/internal/k8s"
 	"k8s.io/ingress-nginx/internal/karmada"
+	"k8s.io/ingress-nginx/internal/nginx"
 )
 
+// mciNotice is an event, and its metric, about a multiclusteringress found while
+// building the configuration. The validating webhook builds the configuration
+// as well, so the notices are returned by the build and reported by syncIngress.
+type mciNotice struct {
+	mci *ingress.MultiClusterIngress
+	// key identifies the multiclusteringress and the state reported
+	key    string
+	report func(n *NGINXController, mci *ingress.MultiClusterIngress)
+}
+
+// newMCINotice returns the notice of the multiclusteringress reported by
+// report, the details identify the state reported
+func newMCINotice(mci *ingress.MultiClusterIngress, report func(*NGINXController, *ingress.MultiClusterIngress), details ...string) mciNotice {
+	return mciNotice{
+		mci:    mci,
+		key:    strings.Join(append([]string{k8s.MetaNamespaceKey(mci)}, details...), "/"),
+		report: report,
+	}
+}
+
+// reportMCINotices reports the notices the last sync did not report, so the
+// events and metrics follow the changes of the multiclusteringresses instead
+// of the syncs
+func (n *NGINXController) reportMCINotices(notices []mciNotice) {
+	reported := sets.NewString()
+	for _, notice := range notices {
+		if !reported.Has(notice.key) && !n.reportedMCINotices.Has(notice.key) {
+			notice.report(n, notice.mci)
+		}
+		reported.Insert(notice.key)
+	}
+
+	n.reportedMCINotices = reported
+}
+
 // getConfigurationFromMCI returns the configuration matching the multiclusteringress
-func (n *NGINXController) getConfigurationFromMCI(mcis []*ingress.MultiClusterIngress) (sets.String, []*ingress.Server, *ingress.Configuration) {
-	upstreams, servers := n.getBackendServersFromMCIs(mcis)
+// and the notices found while building it
+func (n *NGINXController) getConfigurationFromMCI(mcis []*ingress.MultiClusterIngress) (sets.String, []*ingress.Server, *ingress.Configuration, []mciNotice) {
+	upstreams, servers, notices := n.getBackendServersFromMCIs(mcis)
 	var passUpstreams []*ingress.SSLPassthroughBackend
 
 	hosts := sets.NewString()
@@ -66,11 +129,19 @@ func (n *NGINXController) getConfigurationFromMCI(mcis []*ingress.MultiClusterIn
 				klog.Warningf("Ignoring SSL Passthrough for location %q in server %q", loc.Path, server.Hostname)
 				continue
 			}
+			var proxyProtocol bool
+			for _, upstream := range upstreams {
+				if upstream.Name == loc.Backend {
+					proxyProtocol = upstream.ProxyProtocol
+					break
+				}
+			}
 			passUpstreams = append(passUpstreams, &ingress.SSLPassthroughBackend{
-				Backend:  loc.Backend,
-				Hostname: server.Hostname,
-				Service:  loc.Service,
-				Port:     loc.Port,
+				Backend:       loc.Backend,
+				Hostname:      server.Hostname,
+				Service:       loc.Service,
+				Port:          loc.Port,
+				ProxyProtocol: proxyProtocol,
 			})
 			break
 		}
@@ -79,19 +150,225 @@ func (n *NGINXController) getConfigurationFromMCI(mcis []*ingress.MultiClusterIn
 	return hosts, servers, &ingress.Configuration{
 		Backends:              upstreams,
 		Servers:               servers,
-		TCPEndpoints:          n.getStreamServices(n.cfg.TCPConfigMapName, apiv1.ProtocolTCP),
-		UDPEndpoints:          n.getStreamServices(n.cfg.UDPConfigMapName, apiv1.ProtocolUDP),
+		TCPEndpoints:          n.getStreamServicesFromMCIs(n.cfg.TCPConfigMapName, apiv1.ProtocolTCP, mcis),
+		UDPEndpoints:          n.getStreamServicesFromMCIs(n.cfg.UDPConfigMapName, apiv1.ProtocolUDP, mcis),
 		PassthroughBackends:   passUpstreams,
 		BackendConfigChecksum: n.store.GetBackendConfiguration().Checksum,
 		DefaultSSLCertificate: n.getDefaultSSLCertificate(),
 		StreamSnippets:        n.getStreamSnippetsFromMCIs(mcis),
+	}, notices
+}
+
+// StreamConfiguration returns a configuration containing only the L4 (stream)
+// parts of the current multiclusteringresses: TCP and UDP endpoints and stream
+// snippets. HTTP servers and backends are left empty.
+func (n *NGINXController) StreamConfiguration() (*ingress.Configuration, error) {
+	if n == nil || n.store == nil {
+		return nil, fmt.Errorf("controller is not initialized")
+	}
+
+	mcis := n.store.ListMultiClusterIngresses()
+
+	return &ingress.Configuration{
+		TCPEndpoints:   n.getStreamServicesFromMCIs(n.cfg.TCPConfigMapName, apiv1.ProtocolTCP, mcis),
+		UDPEndpoints:   n.getStreamServicesFromMCIs(n.cfg.UDPConfigMapName, apiv1.ProtocolUDP, mcis),
+		StreamSnippets: n.getStreamSnippetsFromMCIs(mcis),
+	}, nil
+}
+
+// ServedHosts returns the sorted hostnames, including aliases, of the servers
+// in the running configuration. The catch-all default server is excluded.
+func (n *NGINXController) ServedHosts() []string {
+	hosts := sets.NewString()
+
+	for _, server := range n.runningConfig.Servers {
+		if server.Hostname != defServerName {
+			hosts.Insert(server.Hostname)
+		}
+
+		hosts.Insert(server.Aliases...)
+	}
+
+	return hosts.List()
+}
+
+// Scale returns the number of servers, upstreams and locations of the running
+// configuration, so an external autoscaler can size the NGINX worker processes
+func (n *NGINXController) Scale() (servers, upstreams, locations int) {
+	for _, server := range n.runningConfig.Servers {
+		locations += len(server.Locations)
+	}
+
+	return len(n.runningConfig.Servers), len(n.runningConfig.Backends), locations
+}
+
+// exportedServer is the representation of a server returned by ExportServers
+type exportedServer struct {
+	Hostname  string             `json:"hostname"`
+	Aliases   []string           `json:"aliases,omitempty"`
+	Locations []exportedLocation `json:"locations"`
+}
+
+// exportedLocation is the representation of a location returned by ExportServers
+type exportedLocation struct {
+	Path     string `json:"path"`
+	PathType string `json:"pathType,omitempty"`
+	Backend  string `json:"backend"`
+	Service  string `json:"service,omitempty"`
+	Port     string `json:"port,omitempty"`
+}
+
+// ExportServers returns the servers of the running configuration as JSON, to
+// snapshot the computed routing. Only the hostnames, aliases and the backends
+// of the locations are exported, sorted so the same routing always produces
+// the same output.
+func (n *NGINXController) ExportServers() ([]byte, error) {
+	return exportServers(n.runningConfig.Servers)
+}
+
+func exportServers(servers []*ingress.Server) ([]byte, error) {
+	exported := make([]exportedServer, 0, len(servers))
+
+	for _, server := range servers {
+		es := exportedServer{
+			Hostname:  server.Hostname,
+			Aliases:   sets.NewString(server.Aliases...).List(),
+			Locations: make([]exportedLocation, 0, len(server.Locations)),
+		}
+
+		for _, location := range server.Locations {
+			el := exportedLocation{
+				Path:    location.Path,
+				Backend: location.Backend,
+			}
+
+			if location.PathType != nil {
+				el.PathType = string(*location.PathType)
+			}
+
+			if location.Service != nil {
+				el.Service = fmt.Sprintf("%v/%v", location.Service.Namespace, location.Service.Name)
+				el.Port = location.Port.String()
+			}
+
+			es.Locations = append(es.Locations, el)
+		}
+
+		sort.SliceStable(es.Locations, func(i, j int) bool {
+			a, b := es.Locations[i], es.Locations[j]
+			if a.Path != b.Path {
+				return a.Path < b.Path
+			}
+			if a.PathType != b.PathType {
+				return a.PathType < b.PathType
+			}
+			return a.Backend < b.Backend
+		})
+
+		exported = append(exported, es)
+	}
+
+	sort.SliceStable(exported, func(i, j int) bool {
+		return exported[i].Hostname < exported[j].Hostname
+	})
+
+	return json.MarshalIndent(exported, "", "  ")
+}
+
+// dropDisabledMCIs returns the multiclusteringresses without the ones using the
+// disabled annotation, which contribute neither servers nor upstreams, and the
+// notices of the dropped ones.
+func dropDisabledMCIs(mcis []*ingress.MultiClusterIngress) ([]*ingress.MultiClusterIngress, []mciNotice) {
+	result := make([]*ingress.MultiClusterIngress, 0, len(mcis))
+	var notices []mciNotice
+
+	for _, mci := range mcis {
+		if mci.ParsedAnnotations != nil && mci.ParsedAnnotations.Disabled {
+			notices = append(notices, newMCINotice(mci, (*NGINXController).recordDisabled, "Disabled"))
+			continue
+		}
+
+		result = append(result, mci)
+	}
+
+	return result, notices
+}
+
+// applyServicePortNames returns the multiclusteringresses with the service backends of
+// those using the service-port-name annotation pointing to the named service port.
+// A backend keeps its original port when the service does not expose a port with that name.
+func (n *NGINXController) applyServicePortNames(mcis []*ingress.MultiClusterIngress) []*ingress.MultiClusterIngress {
+	result := make([]*ingress.MultiClusterIngress, 0, len(mcis))
+
+	for _, mci := range mcis {
+		portName := mci.ParsedAnnotations.ServicePortName
+		if portName == "" {
+			result = append(result, mci)
+			continue
+		}
+
+		mciKey := k8s.MetaNamespaceKey(mci)
+		named := &ingress.MultiClusterIngress{
+			MultiClusterIngress: *mci.MultiClusterIngress.DeepCopy(),
+			ParsedAnnotations:   mci.ParsedAnnotations,
+		}
+
+		setPortName := func(backend *networking.IngressBackend) {
+			if backend == nil || backend.Service == nil {
+				return
+			}
+
+			svcKey := fmt.Sprintf("%v/%v", named.Namespace, names.GenerateDerivedServiceName(backend.Service.Name))
+			if !n.serviceHasPortName(svcKey, portName) {
+				klog.Warningf("Service %q does not expose a port named %q, ignoring service-port-name (MultiClusterIngress %q)",
+					svcKey, portName, mciKey)
+				return
+			}
+
+			backend.Service.Port = networking.ServiceBackendPort{Name: portName}
+		}
+
+		setPortName(named.Spec.DefaultBackend)
+		for _, rule := range named.Spec.Rules {
+			if rule.HTTP == nil {
+				continue
+			}
+
+			for i := range rule.HTTP.Paths {
+				setPortName(&rule.HTTP.Paths[i].Backend)
+			}
+		}
+
+		result = append(result, named)
+	}
+
+	return result
+}
+
+// serviceHasPortName returns true if the service exposes a port with the given name
+func (n *NGINXController) serviceHasPortName(svcKey, portName string) bool {
+	svc, err := n.store.GetService(svcKey)
+	if err != nil {
+		klog.Warningf("Error obtaining Service %q: %v", svcKey, err)
+		return false
+	}
+
+	for _, port := range svc.Spec.Ports {
+		if port.Name == portName {
+			return true
+		}
 	}
+
+	return false
 }
 
 // getBackendServersFromMCI returns a list of Upstream and Server to be used by the
-// backend.  An upstream can be used in multiple servers if the namespace,
-// service name and port are the same.
-func (n *NGINXController) getBackendServersFromMCIs(mcis []*ingress.MultiClusterIngress) ([]*ingress.Backend, []*ingress.Server) {
+// backend, and the notices about the multiclusteringresses.  An upstream can be
+// used in multiple servers if the namespace, service name and port are the same.
+func (n *NGINXController) getBackendServersFromMCIs(mcis []*ingress.MultiClusterIngress) ([]*ingress.Backend, []*ingress.Server, []mciNotice) {
+	mcis, notices := dropDisabledMCIs(mcis)
+	mcis = n.applyServicePortNames(mcis)
+
 	defaultUpstream := n.getDefaultUpstream()
 	upstreams := n.createUpstreamsFromMCIs(mcis, defaultUpstream)
 	servers := n.createServersFromMCIs(mcis, upstreams, defaultUpstream)
@@ -106,6 +383,23 @@ func (n *NGINXController) getBackendServersFromMCIs(mcis []*ingress.MultiCluster
 			dropSnippetDirectives(anns, mciKey)
 		}
 
+		if anns.SSE {
+			if buffering, err := parser.GetStringAnnotationFromMCI("proxy-buffering", &mci.MultiClusterIngress); err == nil && buffering != "off" {
+				notices = append(notices, newMCINotice(mci, func(n *NGINXController, mci *ingress.MultiClusterIngress) {
+					n.warnSSEProxyBuffering(mci, buffering)
+				}, "ProxyBufferingOverridden", buffering))
+			}
+		}
+
+		if anns.MaintenanceMode {
+			notices = append(notices, newMCINotice(mci, (*NGINXController).recordMaintenanceMode, "MaintenanceMode"))
+		}
+
+		if anns.GeoIPAccess.Enabled() && ngx_template.GeoIPCountryVariable(n.store.GetBackendConfiguration(), n.cfg.MaxmindEditionFiles) == "" {
+			notices = append(notices, newMCINotice(mci, (*NGINXController).warnGeoIPUnavailable,
+				"GeoIPUnavailable", strconv.FormatBool(len(anns.GeoIPAccess.AllowCountries) > 0)))
+		}
+
 		for _, rule := range mci.Spec.Rules {
 			host := rule.Host
 			if host == "" {
@@ -136,13 +430,19 @@ func (n *NGINXController) getBackendServersFromMCIs(mcis []*ingress.MultiCluster
 			} else {
 				klog.V(3).Infof("Server %q is already configured for mutual authentication (MultiClusterIngress %q)",
 					server.Hostname, mciKey)
+				if anns.CertificateAuth.Secret != "" && anns.CertificateAuth.Secret != server.CertificateAuth.Secret {
+					hostname, caSecret := server.Hostname, server.CertificateAuth.Secret
+					notices = append(notices, newMCINotice(mci, func(n *NGINXController, mci *ingress.MultiClusterIngress) {
+						n.recordCertificateAuthConflict(mci, hostname, caSecret)
+					}, "MutualTLSConflict", hostname, caSecret, anns.CertificateAuth.Secret))
+				}
 			}
 
 			if !n.store.GetBackendConfiguration().ProxySSLLocationOnly {
-				if server.ProxySSL.CAFileName == "" {
+				if server.ProxySSL.CAFileName == "" && server.ProxySSL.PemFileName == "" {
 					server.ProxySSL = anns.ProxySSL
 					if server.ProxySSL.Secret != "" && server.ProxySSL.CAFileName == "" {
-						klog.V(3).Infof("Secret %q has no 'ca.crt' key, client cert authentication disabled for MultiClusterIngress %q",
+						klog.V(3).Infof("Secret %q has no 'ca.crt' key, upstream certificate verification disabled for MultiClusterIngress %q",
 							server.ProxySSL.Secret, mciKey)
 					}
 				} else {
@@ -185,7 +485,7 @@ func (n *NGINXController) getBackendServersFromMCIs(mcis []*ingress.MultiCluster
 
 					// Same paths but different types are allowed
 					// (same type means overlap in the path definition)
-					if !apiequality.Semantic.DeepEqual(loc.PathType, path.PathType) {
+					if pathTypeOrDefault(loc.PathType) != pathTypeOrDefault(path.PathType) {
 						break
 					}
 
@@ -219,9 +519,10 @@ func (n *NGINXController) getBackendServersFromMCIs(mcis []*ingress.MultiCluster
 				if addLoc {
 					klog.V(3).Infof("Adding location %q for server %q with upstream %q (MultiClusterIngress %q)",
 						nginxPath, server.Hostname, ups.Name, mciKey)
+					pathType := pathTypeOrDefault(path.PathType)
 					loc := &ingress.Location{
 						Path:                nginxPath,
-						PathType:            path.PathType,
+						PathType:            &pathType,
 						Backend:             ups.Name,
 						IsDefBackend:        false,
 						Service:             ups.Service,
@@ -259,20 +560,16 @@ func (n *NGINXController) getBackendServersFromMCIs(mcis []*ingress.MultiCluster
 					ups.SessionAffinity.CookieSessionAffinity.ConditionalSameSiteNone = anns.SessionAffinity.Cookie.ConditionalSameSiteNone
 					ups.SessionAffinity.CookieSessionAffinity.ChangeOnFailure = anns.SessionAffinity.Cookie.ChangeOnFailure
 
+					if !hasAffinityCookiePath(anns.SessionAffinity.Cookie.Paths, path.Path) {
+						klog.V(3).Infof("Path %q is not listed in session-cookie-paths, skipping affinity cookie (MultiClusterIngress %q)", path.Path, mciKey)
+						continue
+					}
+
 					locs := ups.SessionAffinity.CookieSessionAffinity.Locations
 					if _, ok := locs[host]; !ok {
 						locs[host] = []string{}
 					}
 					locs[host] = append(locs[host], path.Path)
-
-					if len(server.Aliases) > 0 {
-						for _, alias := range server.Aliases {
-							if _, ok := locs[alias]; !ok {
-								locs[alias] = []string{}
-							}
-							locs[alias] = append(locs[alias], path.Path)
-						}
-					}
 				}
 			}
 		}
@@ -283,9 +580,11 @@ func (n *NGINXController) getBackendServersFromMCIs(mcis []*ingress.MultiCluster
 		}
 	}
 
+	addAffinityLocationsForAliases(upstreams, servers)
+
 	if nonCanaryMCIExists(mcis, canaryMCIs) {
 		for _, canaryMCI := range canaryMCIs {
-			mergeAlternativeBackendsByMCI(canaryMCI, upstreams, servers)
+			notices = append(notices, mergeAlternativeBackendsByMCI(canaryMCI, upstreams, servers)...)
 		}
 	}
 
@@ -301,18 +600,37 @@ func (n *NGINXController) getBackendServersFromMCIs(mcis []*ingress.MultiCluster
 		isHTTPSfrom := []*ingress.Server{}
 		for _, server := range servers {
 			for _, location := range server.Locations {
+				maintenance := upstream.Name == location.Backend && location.MaintenanceMode
+				if maintenance && location.DefaultBackend == nil {
+					klog.V(3).Infof("Location %q in server %q is in maintenance mode, returning 503", location.Path, server.Hostname)
+					denyMaintenanceLocation(location)
+					continue
+				}
+
+				if upstream.Name == location.Backend && len(upstream.Endpoints) == 0 && location.ServiceUnavailableOnEmptyUpstream && !location.MaintenanceMode {
+					klog.V(3).Infof("Upstream %q has no active Endpoint, so returning 503 for location %q in server %q",
+						upstream.Name, location.Path, server.Hostname)
+
+					reason := fmt.Sprintf("upstream %v has no active endpoints", upstream.Name)
+					location.Denied = &reason
+					continue
+				}
+
 				// use default backend
-				if !shouldCreateUpstreamForLocationDefaultBackend(upstream, location) {
+				if !maintenance && !shouldCreateUpstreamForLocationDefaultBackend(upstream, location) {
 					continue
 				}
 
 				if len(location.DefaultBackend.Spec.Ports) == 0 {
 					klog.Errorf("Custom default backend service %v/%v has no ports. Ignoring", location.DefaultBackend.Namespace, location.DefaultBackend.Name)
+					if maintenance {
+						denyMaintenanceLocation(location)
+					}
 					continue
 				}
 
 				sp := location.DefaultBackend.Spec.Ports[0]
-				endps := getEndpoints(location.DefaultBackend, &sp, apiv1.ProtocolTCP, n.store.GetServiceEndpoints)
+				endps := n.resolveEndpoints(location.DefaultBackend, &sp, apiv1.ProtocolTCP)
 				// custom backend is valid only if contains at least one endpoint
 				if len(endps) > 0 {
 					name := fmt.Sprintf("custom-default-backend-%v-%v", location.DefaultBackend.GetNamespace(), location.DefaultBackend.GetName())
@@ -324,12 +642,15 @@ func (n *NGINXController) getBackendServersFromMCIs(mcis []*ingress.MultiCluster
 					aUpstreams = append(aUpstreams, nb)
 					location.DefaultBackendUpstreamName = name
 
-					if len(upstream.Endpoints) == 0 {
-						klog.V(3).Infof("Upstream %q has no active Endpoint, so using custom default backend for location %q in server %q (Service \"%v/%v\")",
+					if len(upstream.Endpoints) == 0 || maintenance {
+						klog.V(3).Infof("Upstream %q has no active Endpoint or is in maintenance mode, so using custom default backend for location %q in server %q (Service \"%v/%v\")",
 							upstream.Name, location.Path, server.Hostname, location.DefaultBackend.Namespace, location.DefaultBackend.Name)
 
 						location.Backend = name
+						applyExternalDefaultBackend(location)
 					}
+				} else if maintenance {
+					denyMaintenanceLocation(location)
 				}
 
 				if server.SSLPassthrough {
@@ -349,152 +670,421 @@ func (n *NGINXController) getBackendServersFromMCIs(mcis []*ingress.MultiCluster
 		}
 	}
 
+	// the locations using the default upstream are skipped above, the ones
+	// in maintenance mode must not serve it either
+	for _, server := range servers {
+		for _, location := range server.Locations {
+			if location.MaintenanceMode && location.Backend == defUpstreamName {
+				denyMaintenanceLocation(location)
+			}
+		}
+	}
+
+	backends := make(map[string]*ingress.Backend, len(aUpstreams))
+	for _, upstream := range aUpstreams {
+		backends[upstream.Name] = upstream
+	}
+
 	aServers := make([]*ingress.Server, 0, len(servers))
 	for _, value := range servers {
-		sort.SliceStable(value.Locations, func(i, j int) bool {
-			return value.Locations[i].Path > value.Locations[j].Path
-		})
+		sortLocations(value.Locations, n.cfg.LocationTiebreak)
+
+		if len(n.cfg.UpstreamDNSResolver) > 0 {
+			for _, location := range value.Locations {
+				n.applyUpstreamDNSResolver(location, backends[location.Backend])
+			}
+		}
+
+		if n.cfg.AddMCIDebugHeader {
+			for _, location := range value.Locations {
+				if location.MultiClusterIngress != nil {
+					location.ServedByMCI = k8s.MetaNamespaceKey(location.MultiClusterIngress)
+				}
+			}
+		}
+
+		warnClientCertWithoutMutualTLS(value)
+		dropConflictingInternalRedirects(value)
+
+		// the catch-all server answers any SNI, and NGINX does not terminate
+		// the TLS connections of the SSL passthrough servers
+		if n.cfg.EnableMisdirectedRequestCheck && value.Hostname != defServerName && !value.SSLPassthrough {
+			value.MisdirectedRequestCheck = true
+		}
 
-		sort.SliceStable(value.Locations, func(i, j int) bool {
-			return len(value.Locations[i].Path) > len(value.Locations[j].Path)
-		})
 		aServers = append(aServers, value)
 	}
 
-	sort.SliceStable(aUpstreams, func(a, b int) bool {
-		return aUpstreams[a].Name < aUpstreams[b].Name
-	})
+	if n.cfg.ExplodeAliases {
+		aServers = explodeAliases(aServers)
+	}
+
+	for _, upstream := range aUpstreams {
+		applyPassiveHealthCheck(upstream)
+	}
+
+	sortUpstreams(aUpstreams, n.cfg.GroupCanaryUpstreams)
 
 	sort.SliceStable(aServers, func(i, j int) bool {
 		return aServers[i].Hostname < aServers[j].Hostname
 	})
 
-	return aUpstreams, aServers
+	return aUpstreams, aServers, notices
 }
 
-// createUpstreamsFromMCI creates the NGINX upstreams (Endpoints) for each Service
-// referenced in MultiClusterIngress rules.
-func (n *NGINXController) createUpstreamsFromMCIs(mcis []*ingress.MultiClusterIngress, defaultUpstream *ingress.Backend) map[string]*ingress.Backend {
-	upstreams := make(map[string]*ingress.Backend)
-	upstreams[defUpstreamName] = defaultUpstream
+// mciBuildMetrics counts the upstreams, servers and locations of a configuration
+// by the namespace of their Service or MultiClusterIngress, so the build metrics
+// can be sliced by tenant. A server is counted once for every namespace with a
+// location in it, and the default upstream is not counted.
+func mciBuildMetrics(upstreams []*ingress.Backend, servers []*ingress.Server) (map[string]int, map[string]int, map[string]int) {
+	upstreamsByNamespace := map[string]int{}
+	for _, upstream := range upstreams {
+		if upstream.Name == defUpstreamName || upstream.Service == nil {
+			continue
+		}
+		upstreamsByNamespace[upstream.Service.Namespace]++
+	}
 
-	for _, mci := range mcis {
-		mciKey := k8s.MetaNamespaceKey(mci)
-		anns := mci.ParsedAnnotations
+	serversByNamespace := map[string]int{}
+	locationsByNamespace := map[string]int{}
+	for _, server := range servers {
+		namespaces := sets.NewString()
+		for _, location := range server.Locations {
+			if location.MultiClusterIngress == nil {
+				continue
+			}
+			namespaces.Insert(location.MultiClusterIngress.Namespace)
+			locationsByNamespace[location.MultiClusterIngress.Namespace]++
+		}
 
-		if !n.store.GetBackendConfiguration().AllowSnippetAnnotations {
-			dropSnippetDirectives(anns, mciKey)
+		for _, namespace := range namespaces.UnsortedList() {
+			serversByNamespace[namespace]++
 		}
+	}
 
-		var defBackend string
-		if mci.Spec.DefaultBackend != nil && mci.Spec.DefaultBackend.Service != nil {
-			defBackend = upstreamName(mci.Namespace, mci.Spec.DefaultBackend.Service)
+	return upstreamsByNamespace, serversByNamespace, locationsByNamespace
+}
 
-			klog.V(3).Infof("Creating upstream %q", defBackend)
-			upstreams[defBackend] = newUpstream(defBackend)
+// explodeAliases replaces the aliases of each server with a server per alias,
+// sharing the locations and the certificate of the server, so every hostname
+// gets its own server block. The aliases never redirect from or to www, as
+// they do not when folded into the server_name of the server.
+func explodeAliases(servers []*ingress.Server) []*ingress.Server {
+	exploded := make([]*ingress.Server, 0, len(servers))
 
-			upstreams[defBackend].UpstreamHashBy.UpstreamHashBy = anns.UpstreamHashBy.UpstreamHashBy
-			upstreams[defBackend].UpstreamHashBy.UpstreamHashBySubset = anns.UpstreamHashBy.UpstreamHashBySubset
-			upstreams[defBackend].UpstreamHashBy.UpstreamHashBySubsetSize = anns.UpstreamHashBy.UpstreamHashBySubsetSize
+	for _, server := range servers {
+		aliases := server.Aliases
+		server.Aliases = nil
+		exploded = append(exploded, server)
+
+		for _, alias := range aliases {
+			klog.V(3).Infof("Creating server %q for the alias of server %q", alias, server.Hostname)
+			aliasServer := *server
+			aliasServer.Hostname = alias
+			aliasServer.RedirectFromToWWW = false
+			exploded = append(exploded, &aliasServer)
+		}
+	}
 
-			upstreams[defBackend].LoadBalancing = anns.LoadBalancing
-			if upstreams[defBackend].LoadBalancing == "" {
-				upstreams[defBackend].LoadBalancing = n.store.GetBackendConfiguration().LoadBalancing
-			}
+	return exploded
+}
 
-			svcKey := fmt.Sprintf("%v/%v", mci.Namespace, names.GenerateDerivedServiceName(mci.Spec.DefaultBackend.Service.Name))
+// applyUpstreamDNSResolver configures a location proxying to an ExternalName
+// Service, or to the host of a default-backend-url, to be resolved by NGINX
+// at request time so changes of the DNS records are followed without a reload
+func (n *NGINXController) applyUpstreamDNSResolver(location *ingress.Location, upstream *ingress.Backend) {
+	if upstream == nil || len(upstream.Endpoints) == 0 {
+		return
+	}
 
-			// add the service ClusterIP as a single Endpoint instead of individual Endpoints
-			if anns.ServiceUpstream {
-				endpoint, err := n.getServiceClusterEndpoint(svcKey, mci.Spec.DefaultBackend)
-				if err != nil {
-					klog.Errorf("Failed to determine a suitable ClusterIP Endpoint for Service %q: %v", svcKey, err)
-				} else {
-					upstreams[defBackend].Endpoints = []ingress.Endpoint{endpoint}
-				}
-			}
+	svc := upstream.Service
+	if location.DefaultBackend != nil && location.Backend == location.DefaultBackendUpstreamName {
+		svc = location.DefaultBackend
+	}
 
-			// configure traffic shaping for canary
-			if anns.Canary.Enabled {
-				upstreams[defBackend].NoServer = true
-				upstreams[defBackend].TrafficShapingPolicy = ingress.TrafficShapingPolicy{
-					Weight:        anns.Canary.Weight,
-					WeightTotal:   anns.Canary.WeightTotal,
-					Header:        anns.Canary.Header,
-					HeaderValue:   anns.Canary.HeaderValue,
-					HeaderPattern: anns.Canary.HeaderPattern,
-					Cookie:        anns.Canary.Cookie,
-				}
-			}
+	if svc == nil || svc.Spec.Type != apiv1.ServiceTypeExternalName {
+		return
+	}
 
-			if len(upstreams[defBackend].Endpoints) == 0 {
-				_, port := upstreamServiceNameAndPort(mci.Spec.DefaultBackend.Service)
-				endps, err := n.serviceEndpoints(svcKey, port.String())
-				upstreams[defBackend].Endpoints = append(upstreams[defBackend].Endpoints, endps...)
-				if err != nil {
-					klog.Warningf("Error creating upstream %q: %v", defBackend, err)
-				}
-			}
+	// IP addresses do not need to be resolved
+	host := strings.TrimSuffix(svc.Spec.ExternalName, ".")
+	if net.ParseIP(host) != nil {
+		return
+	}
 
-			s, err := n.store.GetService(svcKey)
-			if err != nil {
-				klog.Warningf("Error obtaining Service %q: %v", svcKey, err)
-			}
-			upstreams[defBackend].Service = s
-		}
+	location.ExternalUpstream = &ingress.ExternalUpstream{
+		Host:      host,
+		Port:      upstream.Endpoints[0].Port,
+		Resolvers: n.cfg.UpstreamDNSResolver,
+		Valid:     int(n.cfg.UpstreamDNSValid.Seconds()),
+	}
+}
 
-		for _, rule := range mci.Spec.Rules {
-			if rule.HTTP == nil {
-				continue
-			}
+// warnClientCertWithoutMutualTLS logs the locations with the pass-client-cert
+// annotation in servers not verifying client certificates, as no details of
+// the client certificate can be sent to the upstream
+func warnClientCertWithoutMutualTLS(server *ingress.Server) {
+	if server.CertificateAuth.CAFileName != "" {
+		return
+	}
 
-			for _, path := range rule.HTTP.Paths {
-				if path.Backend.Service == nil {
-					// skip non-service backends
-					klog.V(3).Infof("MultiClusterIngress %q and path %q does not contain a service backend, using default backend", mciKey, path.Path)
-					continue
-				}
+	for _, location := range server.Locations {
+		if location.ClientCert.Enabled {
+			klog.Warningf("pass-client-cert is ignored in location %q of server %q as mutual TLS is not configured (auth-tls-secret)",
+				location.Path, server.Hostname)
+		}
+	}
+}
 
-				name := upstreamName(mci.Namespace, path.Backend.Service)
-				svcName, svcPort := upstreamServiceNameAndPort(path.Backend.Service)
-				if _, ok := upstreams[name]; ok {
-					continue
-				}
+// dropConflictingInternalRedirects removes the internal locations using the
+// path of a location of the server, as NGINX rejects duplicate locations
+func dropConflictingInternalRedirects(server *ingress.Server) {
+	if len(server.InternalRedirect.Locations) == 0 {
+		return
+	}
 
-				klog.V(3).Infof("Creating upstream %q", name)
-				upstreams[name] = newUpstream(name)
-				upstreams[name].Port = svcPort
+	paths := sets.NewString()
+	for _, location := range server.Locations {
+		paths.Insert(location.Path)
+	}
 
-				upstreams[name].UpstreamHashBy.UpstreamHashBy = anns.UpstreamHashBy.UpstreamHashBy
-				upstreams[name].UpstreamHashBy.UpstreamHashBySubset = anns.UpstreamHashBy.UpstreamHashBySubset
-				upstreams[name].UpstreamHashBy.UpstreamHashBySubsetSize = anns.UpstreamHashBy.UpstreamHashBySubsetSize
+	internal := make([]internalredirect.Location, 0, len(server.InternalRedirect.Locations))
+	for _, location := range server.InternalRedirect.Locations {
+		if paths.Has(location.Path) {
+			klog.Warningf("Internal location %q of server %q conflicts with a location of the server, ignoring it",
+				location.Path, server.Hostname)
+			continue
+		}
+		internal = append(internal, location)
+	}
 
-				upstreams[name].LoadBalancing = anns.LoadBalancing
-				if upstreams[name].LoadBalancing == "" {
-					upstreams[name].LoadBalancing = n.store.GetBackendConfiguration().LoadBalancing
-				}
+	server.InternalRedirect.Locations = internal
+}
 
-				svcKey := fmt.Sprintf("%v/%v", mci.Namespace, names.GenerateDerivedServiceName(svcName))
+// applyPassiveHealthCheck sets the upstream-max-fails and upstream-fail-timeout
+// of the upstream on each of its endpoints, as the balancer checks them per endpoint
+func applyPassiveHealthCheck(upstream *ingress.Backend) {
+	for i := range upstream.Endpoints {
+		upstream.Endpoints[i].MaxFails = upstream.PassiveHealthCheck.MaxFails
+		upstream.Endpoints[i].FailTimeout = upstream.PassiveHealthCheck.FailTimeout
+	}
+}
 
-				// add the service ClusterIP as a single Endpoint instead of individual Endpoints
-				if anns.ServiceUpstream {
-					endpoint, err := n.getServiceClusterEndpoint(svcKey, &path.Backend)
-					if err != nil {
-						klog.Errorf("Failed to determine a suitable ClusterIP Endpoint for Service %q: %v", svcKey, err)
-					} else {
-						upstreams[name].Endpoints = []ingress.Endpoint{endpoint}
-					}
-				}
+// sortUpstreams orders the upstreams by name. When group is true every
+// alternative backend is placed right after the first (by name) primary
+// backend referencing it, the groups being ordered by primary name.
+func sortUpstreams(upstreams []*ingress.Backend, group bool) {
+	if !group {
+		sort.SliceStable(upstreams, func(a, b int) bool {
+			return upstreams[a].Name < upstreams[b].Name
+		})
+		return
+	}
 
-				// configure traffic shaping for canary
-				if anns.Canary.Enabled {
-					upstreams[name].NoServer = true
-					upstreams[name].TrafficShapingPolicy = ingress.TrafficShapingPolicy{
-						Weight:        anns.Canary.Weight,
-						Header:        anns.Canary.Header,
+	primaries := make(map[string]string)
+	for _, upstream := range upstreams {
+		if upstream.NoServer {
+			continue
+		}
+		for _, alternative := range upstream.AlternativeBackends {
+			if primary, ok := primaries[alternative]; !ok || upstream.Name < primary {
+				primaries[alternative] = upstream.Name
+			}
+		}
+	}
+
+	groupOf := func(upstream *ingress.Backend) (string, bool) {
+		if primary, ok := primaries[upstream.Name]; ok && upstream.NoServer {
+			return primary, true
+		}
+		return upstream.Name, false
+	}
+
+	sort.SliceStable(upstreams, func(a, b int) bool {
+		groupA, alternativeA := groupOf(upstreams[a])
+		groupB, alternativeB := groupOf(upstreams[b])
+		if groupA != groupB {
+			return groupA < groupB
+		}
+		if alternativeA != alternativeB {
+			return !alternativeA
+		}
+		return upstreams[a].Name < upstreams[b].Name
+	})
+}
+
+// applyEWMADecay sets the decay time of the ewma-decay annotation in the upstream,
+// ignoring it when the upstream does not use the EWMA load balancer
+func applyEWMADecay(upstream *ingress.Backend, decay float64, mciKey string) {
+	if decay == 0 {
+		return
+	}
+
+	if upstream.LoadBalancing != "ewma" {
+		klog.Warningf("Ignoring ewma-decay of MultiClusterIngress %q as upstream %q uses the %q load balancer instead of ewma",
+			mciKey, upstream.Name, upstream.LoadBalancing)
+		return
+	}
+
+	upstream.EWMADecay = decay
+}
+
+// newDefaultBackendUpstream creates the upstream of a default backend service,
+// configured by the annotations of the multiclusteringress using it
+func (n *NGINXController) newDefaultBackendUpstream(name, namespace string, backend *networking.IngressServiceBackend,
+	anns *annotations.Ingress, mciKey string) *ingress.Backend {
+	_, port := upstreamServiceNameAndPort(backend)
+
+	upstream := newUpstream(name)
+	upstream.Port = port
+
+	upstream.UpstreamHashBy.UpstreamHashBy = anns.UpstreamHashBy.UpstreamHashBy
+	upstream.UpstreamHashBy.UpstreamHashBySubset = anns.UpstreamHashBy.UpstreamHashBySubset
+	upstream.UpstreamHashBy.UpstreamHashBySubsetSize = anns.UpstreamHashBy.UpstreamHashBySubsetSize
+
+	upstream.ProxyProtocol = anns.BackendProxyProtocol
+	upstream.HealthCheck = anns.HealthCheck
+	upstream.PassiveHealthCheck = anns.PassiveHealthCheck
+
+	upstream.LoadBalancing = anns.LoadBalancing
+	if upstream.LoadBalancing == "" {
+		upstream.LoadBalancing = n.store.GetBackendConfiguration().LoadBalancing
+	}
+	applyEWMADecay(upstream, anns.EWMADecay, mciKey)
+
+	svcKey := fmt.Sprintf("%v/%v", namespace, names.GenerateDerivedServiceName(backend.Name))
+
+	// add the service ClusterIP as a single Endpoint instead of individual Endpoints
+	if anns.ServiceUpstream {
+		endpoint, err := n.getServiceClusterEndpoint(svcKey, &networking.IngressBackend{Service: backend})
+		if err != nil {
+			klog.Errorf("Failed to determine a suitable ClusterIP Endpoint for Service %q: %v", svcKey, err)
+		} else {
+			upstream.Endpoints = []ingress.Endpoint{endpoint}
+		}
+	}
+
+	// configure traffic shaping for canary
+	if anns.Canary.Enabled {
+		upstream.NoServer = true
+		upstream.TrafficShapingPolicy = ingress.TrafficShapingPolicy{
+			Weight:        anns.Canary.Weight,
+			WeightTotal:   anns.Canary.WeightTotal,
+			Header:        anns.Canary.Header,
+			HeaderValue:   anns.Canary.HeaderValue,
+			HeaderPattern: anns.Canary.HeaderPattern,
+			Cookie:        anns.Canary.Cookie,
+			Priority:      anns.Canary.Priority,
+		}
+	}
+
+	if len(upstream.Endpoints) == 0 {
+		endps, err := n.serviceEndpoints(svcKey, port.String())
+		if anns.TopologyAware {
+			endps = n.zoneEndpoints(svcKey, endps)
+		}
+		upstream.Endpoints = append(upstream.Endpoints, endps...)
+		if err != nil {
+			klog.Warningf("Error creating upstream %q: %v", name, err)
+		}
+	}
+
+	s, err := n.store.GetService(svcKey)
+	if err != nil {
+		klog.Warningf("Error obtaining Service %q: %v", svcKey, err)
+	}
+	upstream.Service = s
+
+	return upstream
+}
+
+// createUpstreamsFromMCI creates the NGINX upstreams (Endpoints) for each Service
+// referenced in MultiClusterIngress rules.
+func (n *NGINXController) createUpstreamsFromMCIs(mcis []*ingress.MultiClusterIngress, defaultUpstream *ingress.Backend) map[string]*ingress.Backend {
+	upstreams := make(map[string]*ingress.Backend)
+	upstreams[defUpstreamName] = defaultUpstream
+
+	for _, mci := range mcis {
+		mciKey := k8s.MetaNamespaceKey(mci)
+		anns := mci.ParsedAnnotations
+
+		if !n.store.GetBackendConfiguration().AllowSnippetAnnotations {
+			dropSnippetDirectives(anns, mciKey)
+		}
+
+		if mci.Spec.DefaultBackend != nil && mci.Spec.DefaultBackend.Service != nil {
+			defBackend := upstreamName(mci.Namespace, mci.Spec.DefaultBackend.Service)
+
+			klog.V(3).Infof("Creating upstream %q", defBackend)
+			upstreams[defBackend] = n.newDefaultBackendUpstream(defBackend, mci.Namespace, mci.Spec.DefaultBackend.Service, anns, mciKey)
+		} else if backend := n.namespaceDefaultBackend(mci.Namespace); backend != nil {
+			name := upstreamName(mci.Namespace, backend)
+			if _, ok := upstreams[name]; !ok {
+				klog.V(3).Infof("Creating upstream %q for the default backend of namespace %q", name, mci.Namespace)
+				// the upstream is shared by the multiclusteringresses of the
+				// namespace, it is not configured by their annotations
+				upstreams[name] = n.newDefaultBackendUpstream(name, mci.Namespace, backend, &annotations.Ingress{}, mciKey)
+			}
+		}
+
+		for _, rule := range mci.Spec.Rules {
+			if rule.HTTP == nil {
+				continue
+			}
+
+			for _, path := range rule.HTTP.Paths {
+				if path.Backend.Service == nil {
+					// skip non-service backends
+					klog.V(3).Infof("MultiClusterIngress %q and path %q does not contain a service backend, using default backend", mciKey, path.Path)
+					continue
+				}
+
+				name := upstreamName(mci.Namespace, path.Backend.Service)
+				svcName, svcPort := upstreamServiceNameAndPort(path.Backend.Service)
+				if _, ok := upstreams[name]; ok {
+					continue
+				}
+
+				klog.V(3).Infof("Creating upstream %q", name)
+				upstreams[name] = newUpstream(name)
+				upstreams[name].Port = svcPort
+
+				upstreams[name].UpstreamHashBy.UpstreamHashBy = anns.UpstreamHashBy.UpstreamHashBy
+				upstreams[name].UpstreamHashBy.UpstreamHashBySubset = anns.UpstreamHashBy.UpstreamHashBySubset
+				upstreams[name].UpstreamHashBy.UpstreamHashBySubsetSize = anns.UpstreamHashBy.UpstreamHashBySubsetSize
+
+				upstreams[name].ProxyProtocol = anns.BackendProxyProtocol
+				upstreams[name].HealthCheck = anns.HealthCheck
+				upstreams[name].PassiveHealthCheck = anns.PassiveHealthCheck
+
+				upstreams[name].LoadBalancing = anns.LoadBalancing
+				if upstreams[name].LoadBalancing == "" {
+					upstreams[name].LoadBalancing = n.store.GetBackendConfiguration().LoadBalancing
+				}
+				applyEWMADecay(upstreams[name], anns.EWMADecay, mciKey)
+
+				svcKey := fmt.Sprintf("%v/%v", mci.Namespace, names.GenerateDerivedServiceName(svcName))
+
+				// add the service ClusterIP as a single Endpoint instead of individual Endpoints
+				if anns.ServiceUpstream {
+					endpoint, err := n.getServiceClusterEndpoint(svcKey, &path.Backend)
+					if err != nil {
+						klog.Errorf("Failed to determine a suitable ClusterIP Endpoint for Service %q: %v", svcKey, err)
+					} else {
+						upstreams[name].Endpoints = []ingress.Endpoint{endpoint}
+					}
+				}
+
+				// configure traffic shaping for canary
+				if anns.Canary.Enabled {
+					upstreams[name].NoServer = true
+					upstreams[name].TrafficShapingPolicy = ingress.TrafficShapingPolicy{
+						Weight:        anns.Canary.Weight,
+						Header:        anns.Canary.Header,
 						HeaderValue:   anns.Canary.HeaderValue,
 						HeaderPattern: anns.Canary.HeaderPattern,
 						Cookie:        anns.Canary.Cookie,
+						Priority:      anns.Canary.Priority,
 					}
 				}
 
@@ -505,6 +1095,9 @@ func (n *NGINXController) createUpstreamsFromMCIs(mcis []*ingress.MultiClusterIn
 						klog.Warningf("Error obtaining Endpoints for Service %q: %v", svcKey, err)
 						continue
 					}
+					if anns.TopologyAware {
+						endp = n.zoneEndpoints(svcKey, endp)
+					}
 					upstreams[name].Endpoints = endp
 				}
 
@@ -517,11 +1110,110 @@ func (n *NGINXController) createUpstreamsFromMCIs(mcis []*ingress.MultiClusterIn
 				upstreams[name].Service = s
 			}
 		}
+
+		if anns.RouteByHeader.Header != "" && !anns.Canary.Enabled {
+			n.createRouteByHeaderUpstreams(mci, upstreams)
+		}
 	}
 
 	return upstreams
 }
 
+// zoneEndpoints returns the endpoints of the service hinted for the zone of the
+// controller, configured with --topology-zone, or all of them when none is.
+func (n *NGINXController) zoneEndpoints(svcKey string, endps []ingress.Endpoint) []ingress.Endpoint {
+	if n.cfg.TopologyZone == "" {
+		return endps
+	}
+
+	return getZoneEndpoints(svcKey, n.cfg.TopologyZone, endps, n.store.GetServiceEndpointSlices)
+}
+
+// createRouteByHeaderUpstreams creates, for the upstream of each path of the
+// multiclusteringress, an upstream using the alternate service of the
+// route-by-header annotation with the same port, and adds it to the alternative
+// backends of the path upstream. Unlike a canary, the alternate service is not
+// described by another multiclusteringress.
+func (n *NGINXController) createRouteByHeaderUpstreams(mci *ingress.MultiClusterIngress, upstreams map[string]*ingress.Backend) {
+	cfg := mci.ParsedAnnotations.RouteByHeader
+
+	for _, rule := range mci.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+
+		for _, path := range rule.HTTP.Paths {
+			if path.Backend.Service == nil {
+				continue
+			}
+
+			primary, ok := upstreams[upstreamName(mci.Namespace, path.Backend.Service)]
+			if !ok || primary.NoServer {
+				continue
+			}
+
+			name := fmt.Sprintf("%v-route-by-header-%v", primary.Name, cfg.Service)
+			if _, ok := upstreams[name]; ok {
+				continue
+			}
+
+			alternate := &networking.IngressServiceBackend{
+				Name: cfg.Service,
+				Port: path.Backend.Service.Port,
+			}
+			_, port := upstreamServiceNameAndPort(alternate)
+
+			klog.V(3).Infof("Creating upstream %q for the header %q of upstream %q", name, cfg.Header, primary.Name)
+			upstreams[name] = newUpstream(name)
+			upstreams[name].Port = port
+			upstreams[name].NoServer = true
+			upstreams[name].LoadBalancing = primary.LoadBalancing
+			upstreams[name].TrafficShapingPolicy = ingress.TrafficShapingPolicy{
+				Header:      cfg.Header,
+				HeaderValue: cfg.HeaderValue,
+			}
+
+			svcKey := fmt.Sprintf("%v/%v", mci.Namespace, names.GenerateDerivedServiceName(cfg.Service))
+			endps, err := n.serviceEndpoints(svcKey, port.String())
+			if err != nil {
+				klog.Warningf("Error obtaining Endpoints for Service %q: %v", svcKey, err)
+			}
+			upstreams[name].Endpoints = endps
+
+			s, err := n.store.GetService(svcKey)
+			if err != nil {
+				klog.Warningf("Error obtaining Service %q: %v", svcKey, err)
+			}
+			upstreams[name].Service = s
+
+			primary.AlternativeBackends = append(primary.AlternativeBackends, name)
+		}
+	}
+}
+
+// namespaceDefaultBackend returns the Service backend used as default backend by the
+// multiclusteringresses of the namespace without one, or nil when the controller is
+// not configured with --namespace-default-backend-service or the namespace has no
+// such Service. The first port of the Service is used.
+func (n *NGINXController) namespaceDefaultBackend(namespace string) *networking.IngressServiceBackend {
+	if n.cfg.NamespaceDefaultBackendService == "" {
+		return nil
+	}
+
+	svcKey := fmt.Sprintf("%v/%v", namespace, names.GenerateDerivedServiceName(n.cfg.NamespaceDefaultBackendService))
+	svc, err := n.store.GetService(svcKey)
+	if err != nil || len(svc.Spec.Ports) == 0 {
+		return nil
+	}
+
+	return &networking.IngressServiceBackend{
+		Name: n.cfg.NamespaceDefaultBackendService,
+		Port: networking.ServiceBackendPort{
+			Number: svc.Spec.Ports[0].Port,
+		},
+	}
+}
+
 // createServersFromMCI builds a map of host name to Server structs from a map of
 // already computed Upstream structs. Each Server is configured with at least
 // one root location, which uses a default backend if left unspecified.
@@ -552,6 +1244,16 @@ func (n *NGINXController) createServersFromMCIs(mcis []*ingress.MultiClusterIngr
 		ProxyMaxTempFileSize: bdef.ProxyMaxTempFileSize,
 	}
 
+	// the default backend usually serves error pages and should answer fast,
+	// so it can use shorter timeouts than the other locations
+	defProxy := ngxProxy
+	if n.cfg.DefaultBackendConnectTimeout > 0 {
+		defProxy.ConnectTimeout = n.cfg.DefaultBackendConnectTimeout
+	}
+	if n.cfg.DefaultBackendReadTimeout > 0 {
+		defProxy.ReadTimeout = n.cfg.DefaultBackendReadTimeout
+	}
+
 	// initialize default server and root location
 	pathTypePrefix := networking.PathTypePrefix
 	servers[defServerName] = &ingress.Server{
@@ -563,11 +1265,12 @@ func (n *NGINXController) createServersFromMCIs(mcis []*ingress.MultiClusterIngr
 				PathType:     &pathTypePrefix,
 				IsDefBackend: true,
 				Backend:      defaultUpstream.Name,
-				Proxy:        ngxProxy,
+				Proxy:        defProxy,
 				Service:      defaultUpstream.Service,
 				Logs: log.Config{
-					Access:  n.store.GetBackendConfiguration().EnableAccessLogForDefaultBackend,
-					Rewrite: false,
+					Access:     n.store.GetBackendConfiguration().EnableAccessLogForDefaultBackend,
+					Rewrite:    false,
+					SampleRate: 1,
 				},
 			},
 		}}
@@ -617,6 +1320,11 @@ func (n *NGINXController) createServersFromMCIs(mcis []*ingress.MultiClusterIngr
 					klog.V(3).Infof("MultiClusterIngress %q defines both a backend and rules. Using its backend as default upstream for all its rules.", mciKey)
 				}
 			}
+		} else if backend := n.namespaceDefaultBackend(mci.Namespace); backend != nil {
+			if backendUpstream, ok := upstreams[upstreamName(mci.Namespace, backend)]; ok && len(backendUpstream.Endpoints) > 0 {
+				klog.V(3).Infof("Using the default backend of namespace %q as default upstream for the rules of MultiClusterIngress %q", mci.Namespace, mciKey)
+				un = backendUpstream.Name
+			}
 		}
 
 		for _, rule := range mci.Spec.Rules {
@@ -700,6 +1408,37 @@ func (n *NGINXController) createServersFromMCIs(mcis []*ingress.MultiClusterIngr
 				servers[host].SSLPreferServerCiphers = anns.SSLCipher.SSLPreferServerCiphers
 			}
 
+			// only add SSL protocols if the server does not have them previously configured
+			if servers[host].SSLProtocols == "" && anns.SSLProtocols.SSLProtocols != "" {
+				servers[host].SSLProtocols = anns.SSLProtocols.SSLProtocols
+			}
+
+			// only add server tokens if the server does not have them previously configured
+			if servers[host].ServerTokens == "" && anns.ServerTokens.ServerTokens != "" {
+				servers[host].ServerTokens = anns.ServerTokens.ServerTokens
+			}
+
+			// only add security headers if the server does not have them previously configured
+			if len(servers[host].SecurityHeaders.Headers) == 0 && len(anns.SecurityHeaders.Headers) > 0 {
+				servers[host].SecurityHeaders = anns.SecurityHeaders
+			}
+
+			// only add the client keep-alive settings if the server does not have them previously configured
+			if servers[host].ClientKeepalive.Requests == 0 && anns.ClientKeepalive.Requests > 0 {
+				servers[host].ClientKeepalive.Requests = anns.ClientKeepalive.Requests
+			}
+			if servers[host].ClientKeepalive.Timeout == 0 && anns.ClientKeepalive.Timeout > 0 {
+				servers[host].ClientKeepalive.Timeout = anns.ClientKeepalive.Timeout
+			}
+
+			// only add internal locations if the server does not have them previously configured
+			if !servers[host].InternalRedirect.Enabled && anns.InternalRedirect.Enabled {
+				servers[host].InternalRedirect = anns.InternalRedirect
+			} else if anns.InternalRedirect.Enabled {
+				klog.Warningf("Internal locations already configured for server %q, skipping (MultiClusterIngress %q)",
+					host, mciKey)
+			}
+
 			// only add a certificate if the server does not have one previously configured
 			if servers[host].SSLCert != nil {
 				continue
@@ -713,7 +1452,7 @@ func (n *NGINXController) createServersFromMCIs(mcis []*ingress.MultiClusterIngr
 			tlsSecretName := extractTLSSecretNameFromMCI(host, mci, n.store.GetLocalSSLCert)
 			if tlsSecretName == "" {
 				klog.V(3).Infof("Host %q is listed in the TLS section but secretName is empty. Using default certificate", host)
-				servers[host].SSLCert = n.getDefaultSSLCertificate()
+				servers[host].SSLCert = n.getMCIDefaultSSLCertificate(mci)
 				continue
 			}
 
@@ -721,14 +1460,14 @@ func (n *NGINXController) createServersFromMCIs(mcis []*ingress.MultiClusterIngr
 			cert, err := n.store.GetLocalSSLCert(secrKey)
 			if err != nil {
 				klog.Warningf("Error getting SSL certificate %q: %v. Using default certificate", secrKey, err)
-				servers[host].SSLCert = n.getDefaultSSLCertificate()
+				servers[host].SSLCert = n.getMCIDefaultSSLCertificate(mci)
 				continue
 			}
 
 			if cert.Certificate == nil {
 				klog.Warningf("SSL certificate %q does not contain a valid SSL certificate for server %q", secrKey, host)
 				klog.Warningf("Using default certificate")
-				servers[host].SSLCert = n.getDefaultSSLCertificate()
+				servers[host].SSLCert = n.getMCIDefaultSSLCertificate(mci)
 				continue
 			}
 
@@ -742,7 +1481,7 @@ func (n *NGINXController) createServersFromMCIs(mcis []*ingress.MultiClusterIngr
 				if err != nil {
 					klog.Warningf("SSL certificate %q does not contain a Common Name or Subject Alternative Name for server %q: %v", secrKey, host, err)
 					klog.Warningf("Using default certificate")
-					servers[host].SSLCert = n.getDefaultSSLCertificate()
+					servers[host].SSLCert = n.getMCIDefaultSSLCertificate(mci)
 					continue
 				}
 			}
@@ -783,9 +1522,167 @@ func (n *NGINXController) createServersFromMCIs(mcis []*ingress.MultiClusterIngr
 		servers[host].Aliases = uniqAliases.List()
 	}
 
+	for host, ports := range n.getHostPorts() {
+		if host == defServerName {
+			continue
+		}
+
+		if server, ok := servers[host]; ok {
+			server.HTTPPort = ports.HTTP
+			server.HTTPSPort = ports.HTTPS
+		}
+	}
+
 	return servers
 }
 
+// getMCIDefaultSSLCertificate returns the certificate used for the hosts of the
+// multiclusteringress without a matching TLS certificate: the one referenced by
+// the default-ssl-certificate annotation or else the global default certificate
+func (n *NGINXController) getMCIDefaultSSLCertificate(mci *ingress.MultiClusterIngress) *ingress.SSLCert {
+	mciKey := k8s.MetaNamespaceKey(mci)
+
+	secrKey := mci.ParsedAnnotations.DefaultSSLCertificate.Secret
+	if secrKey == "" {
+		// the annotation extractor discards invalid references
+		if ref, err := parser.GetStringAnnotationFromMCI("default-ssl-certificate", &mci.MultiClusterIngress); err == nil {
+			klog.Warningf("Invalid default SSL certificate %q in MultiClusterIngress %q. Using global default certificate", ref, mciKey)
+		}
+		return n.getDefaultSSLCertificate()
+	}
+
+	cert, err := n.store.GetLocalSSLCert(secrKey)
+	if err != nil {
+		klog.Warningf("Error getting default SSL certificate %q of MultiClusterIngress %q: %v. Using global default certificate", secrKey, mciKey, err)
+		return n.getDefaultSSLCertificate()
+	}
+
+	if cert.Certificate == nil {
+		klog.Warningf("Default SSL certificate %q of MultiClusterIngress %q does not contain a valid SSL certificate. Using global default certificate", secrKey, mciKey)
+		return n.getDefaultSSLCertificate()
+	}
+
+	return cert
+}
+
+// hostPorts contains the custom listen ports of a server. Zero means the
+// default port applies.
+type hostPorts struct {
+	HTTP  int
+	HTTPS int
+}
+
+// getHostPorts returns the custom listen ports defined for each hostname in
+// the ConfigMap referenced by the flag --host-ports-configmap. Entries with
+// invalid or conflicting ports are ignored.
+func (n *NGINXController) getHostPorts() map[string]hostPorts {
+	result := map[string]hostPorts{}
+
+	configmapName := n.cfg.HostPortsConfigMapName
+	if configmapName == "" {
+		return result
+	}
+
+	klog.V(3).Infof("Obtaining information about host ports from ConfigMap %q", configmapName)
+	_, _, err := k8s.ParseNameNS(configmapName)
+	if err != nil {
+		klog.Warningf("Error parsing ConfigMap reference %q: %v", configmapName, err)
+		return result
+	}
+	configmap, err := n.store.GetConfigMap(configmapName)
+	if err != nil {
+		klog.Warningf("Error getting ConfigMap %q: %v", configmapName, err)
+		return result
+	}
+
+	reservedPorts := sets.NewInt(
+		n.cfg.ListenPorts.SSLProxy,
+		n.cfg.ListenPorts.Health,
+		n.cfg.ListenPorts.Default,
+		nginx.ProfilerPort,
+		nginx.StatusPort,
+		nginx.StreamPort,
+	)
+	// plain HTTP and TLS cannot share a port
+	httpPorts := sets.NewInt(n.cfg.ListenPorts.HTTP)
+	httpsPorts := sets.NewInt(n.cfg.ListenPorts.HTTPS)
+
+	// process the hostnames in order so conflicts are resolved consistently
+	hosts := make([]string, 0, len(configmap.Data))
+	for host := range configmap.Data {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	// ports format: <(int)http port>[:<(int)https port>]
+	for _, host := range hosts {
+		ports, err := parseHostPorts(configmap.Data[host])
+		if err != nil {
+			klog.Warningf("Invalid ports for host %q: %v", host, err)
+			continue
+		}
+
+		if reservedPorts.Has(ports.HTTP) || reservedPorts.Has(ports.HTTPS) {
+			klog.Warningf("Invalid ports for host %q: port reserved for the Ingress controller", host)
+			continue
+		}
+
+		if ports.HTTP != 0 && (ports.HTTP == ports.HTTPS || httpsPorts.Has(ports.HTTP)) {
+			klog.Warningf("Invalid ports for host %q: HTTP port %d is already used for HTTPS", host, ports.HTTP)
+			continue
+		}
+
+		if ports.HTTPS != 0 && httpPorts.Has(ports.HTTPS) {
+			klog.Warningf("Invalid ports for host %q: HTTPS port %d is already used for HTTP", host, ports.HTTPS)
+			continue
+		}
+
+		if ports.HTTP != 0 {
+			httpPorts.Insert(ports.HTTP)
+		}
+		if ports.HTTPS != 0 {
+			httpsPorts.Insert(ports.HTTPS)
+		}
+
+		result[host] = ports
+	}
+
+	return result
+}
+
+// parseHostPorts parses a port mapping with the format <http port>[:<https port>]
+// where either port can be empty to keep the default
+func parseHostPorts(value string) (hostPorts, error) {
+	parts := strings.Split(strings.TrimSpace(value), ":")
+	if len(parts) > 2 {
+		return hostPorts{}, fmt.Errorf("%q does not match the format <http port>[:<https port>]", value)
+	}
+
+	ports := make([]int, 2)
+	for i, part := range parts {
+		if part == "" {
+			continue
+		}
+
+		port, err := strconv.Atoi(part)
+		if err != nil {
+			return hostPorts{}, fmt.Errorf("%q is not a valid port number", part)
+		}
+
+		if port < 1 || port > 65535 {
+			return hostPorts{}, fmt.Errorf("port %d is out of range", port)
+		}
+
+		ports[i] = port
+	}
+
+	if ports[0] == 0 && ports[1] == 0 {
+		return hostPorts{}, fmt.Errorf("%q does not define any port", value)
+	}
+
+	return hostPorts{HTTP: ports[0], HTTPS: ports[1]}, nil
+}
+
 // extractTLSSecretNameFromMCI returns the name of the Secret containing a SSL
 // certificate for the given host name, or an empty string.
 func extractTLSSecretNameFromMCI(host string, mci *ingress.MultiClusterIngress,
@@ -841,18 +1738,89 @@ func nonCanaryMCIExists(mcis []*ingress.MultiClusterIngress, canaryMCIs []*ingre
 	return len(mcis)-len(canaryMCIs) > 0
 }
 
-// Compares an Ingress of a potential alternative backend's rules with each existing server and finds matching host + path pairs.
-// If a match is found, we know that this server should back the alternative backend and add the alternative backend
-// to a backend's alternative list.
-// If no match is found, then the serverless backend is deleted.
-func mergeAlternativeBackendsByMCI(mci *ingress.MultiClusterIngress, upstreams map[string]*ingress.Backend,
-	servers map[string]*ingress.Server) {
+// addAffinityLocationsForAliases copies the affinity cookie locations of each
+// server to all its aliases. It runs once every location has been added, so
+// the result does not depend on the order in which aliases were assigned.
+func addAffinityLocationsForAliases(upstreams map[string]*ingress.Backend, servers map[string]*ingress.Server) {
+	for _, ups := range upstreams {
+		locs := ups.SessionAffinity.CookieSessionAffinity.Locations
+		if len(locs) == 0 {
+			continue
+		}
 
-	// merge catch-all alternative backends
-	if mci.Spec.DefaultBackend != nil {
-		upsName := upstreamName(mci.Namespace, mci.Spec.DefaultBackend.Service)
+		hosts := make([]string, 0, len(locs))
+		for host := range locs {
+			hosts = append(hosts, host)
+		}
+		sort.Strings(hosts)
 
-		altUps := upstreams[upsName]
+		for _, host := range hosts {
+			server, ok := servers[host]
+			if !ok {
+				continue
+			}
+
+			for _, alias := range server.Aliases {
+				paths := sets.NewString(locs[alias]...)
+				for _, path := range locs[host] {
+					if paths.Has(path) {
+						continue
+					}
+
+					locs[alias] = append(locs[alias], path)
+					paths.Insert(path)
+				}
+			}
+		}
+	}
+}
+
+// hasAffinityCookiePath returns true if the affinity cookie applies to the
+// path, i.e. no session-cookie-paths are configured or the path is listed
+func hasAffinityCookiePath(paths []string, path string) bool {
+	if len(paths) == 0 {
+		return true
+	}
+
+	for _, p := range paths {
+		if p == path {
+			return true
+		}
+	}
+
+	return false
+}
+
+// applyExternalDefaultBackend configures a location proxying to a default
+// backend synthesized from the default-backend-url annotation, so requests
+// carry the Host header and SNI of the external URL
+func applyExternalDefaultBackend(location *ingress.Location) {
+	host, protocol, ok := defaultbackend.ExternalBackend(location.DefaultBackend)
+	if !ok {
+		return
+	}
+
+	location.UpstreamVhost = host
+	if protocol == "HTTPS" {
+		location.BackendProtocol = protocol
+		location.ProxySSL.ProxySSLName = host
+		location.ProxySSL.ProxySSLServerName = "on"
+	}
+}
+
+// Compares an Ingress of a potential alternative backend's rules with each existing server and finds matching host + path pairs.
+// If a match is found, we know that this server should back the alternative backend and add the alternative backend
+// to a backend's alternative list.
+// If no match is found, then the serverless backend is deleted and a notice of the failure is returned.
+func mergeAlternativeBackendsByMCI(mci *ingress.MultiClusterIngress, upstreams map[string]*ingress.Backend,
+	servers map[string]*ingress.Server) []mciNotice {
+	var notices []mciNotice
+
+	// merge catch-all alternative backends
+	if mci.Spec.DefaultBackend != nil {
+		upsName := upstreamName(mci.Namespace, mci.Spec.DefaultBackend.Service)
+
+		altUps := upstreams[upsName]
 
 		if altUps == nil {
 			klog.Warningf("alternative backend %s has already been removed", upsName)
@@ -874,13 +1842,14 @@ func mergeAlternativeBackendsByMCI(mci *ingress.MultiClusterIngress, upstreams m
 					klog.V(2).Infof("matching backend %v found for alternative backend %v",
 						priUps.Name, altUps.Name)
 
-					merged = mergeAlternativeBackendByMCI(mci, priUps, altUps)
+					merged = mergeAlternativeBackendByMCI(mci, priUps, altUps, upstreams)
 				}
 			}
 
 			if !altEqualsPri && !merged {
 				klog.Warningf("unable to find real backend for alternative backend %v. Deleting.", altUps.Name)
 				delete(upstreams, altUps.Name)
+				notices = append(notices, newMCINotice(mci, (*NGINXController).recordCanaryMergeFailure, "CanaryMergeFailure", altUps.Name))
 			}
 		}
 	}
@@ -891,6 +1860,10 @@ func mergeAlternativeBackendsByMCI(mci *ingress.MultiClusterIngress, upstreams m
 			host = defServerName
 		}
 
+		if rule.HTTP == nil {
+			continue
+		}
+
 		for _, path := range rule.HTTP.Paths {
 			if path.Backend.Service == nil {
 				// skip non-service backends
@@ -929,24 +1902,30 @@ func mergeAlternativeBackendsByMCI(mci *ingress.MultiClusterIngress, upstreams m
 					break
 				}
 
-				if canMergeBackend(priUps, altUps) && loc.Path == path.Path && *loc.PathType == *path.PathType {
+				if canMergeBackend(priUps, altUps) && loc.Path == path.Path && pathTypeOrDefault(loc.PathType) == pathTypeOrDefault(path.PathType) {
 					klog.V(2).Infof("matching backend %v found for alternative backend %v",
 						priUps.Name, altUps.Name)
 
-					merged = mergeAlternativeBackendByMCI(mci, priUps, altUps)
+					merged = mergeAlternativeBackendByMCI(mci, priUps, altUps, upstreams)
 				}
 			}
 
 			if !altEqualsPri && !merged {
 				klog.Warningf("unable to find real backend for alternative backend %v. Deleting.", altUps.Name)
 				delete(upstreams, altUps.Name)
+				notices = append(notices, newMCINotice(mci, (*NGINXController).recordCanaryMergeFailure, "CanaryMergeFailure", altUps.Name))
 			}
 		}
 	}
+
+	return notices
 }
 
-// Performs the merge action and checks to ensure that one two alternative backends do not merge into each other
-func mergeAlternativeBackendByMCI(mci *ingress.MultiClusterIngress, priUps *ingress.Backend, altUps *ingress.Backend) bool {
+// Performs the merge action and checks to ensure that one two alternative backends do not merge into each other.
+// Several alternative backends can be merged into the same primary backend as long as their weights do not add up
+// to more than the weight total, the primary backend receiving the remaining traffic.
+func mergeAlternativeBackendByMCI(mci *ingress.MultiClusterIngress, priUps *ingress.Backend, altUps *ingress.Backend,
+	upstreams map[string]*ingress.Backend) bool {
 	if priUps.NoServer {
 		klog.Warningf("unable to merge alternative backend %v into primary backend %v because %v is a primary backend",
 			altUps.Name, priUps.Name, priUps.Name)
@@ -960,6 +1939,28 @@ func mergeAlternativeBackendByMCI(mci *ingress.MultiClusterIngress, priUps *ingr
 		}
 	}
 
+	if altUps.TrafficShapingPolicy.Weight > 0 {
+		weight := altUps.TrafficShapingPolicy.Weight
+		total := weightTotal(altUps)
+		for _, name := range priUps.AlternativeBackends {
+			ab, ok := upstreams[name]
+			if !ok {
+				continue
+			}
+
+			weight += ab.TrafficShapingPolicy.Weight
+			if t := weightTotal(ab); t > total {
+				total = t
+			}
+		}
+
+		if weight > total {
+			klog.Warningf("unable to merge alternative backend %v into primary backend %v because the weights of its alternative backends would add up to %v, above the weight total %v",
+				altUps.Name, priUps.Name, weight, total)
+			return false
+		}
+	}
+
 	if mci.ParsedAnnotations != nil && mci.ParsedAnnotations.SessionAffinity.CanaryBehavior != "legacy" {
 		priUps.SessionAffinity.DeepCopyInto(&altUps.SessionAffinity)
 	}
@@ -970,15 +1971,158 @@ func mergeAlternativeBackendByMCI(mci *ingress.MultiClusterIngress, priUps *ingr
 	return true
 }
 
+// weightTotal returns the weight total of an alternative backend as used by
+// the balancer, which shares the largest one among the alternative backends
+func weightTotal(backend *ingress.Backend) int {
+	if backend.TrafficShapingPolicy.WeightTotal > 100 {
+		return backend.TrafficShapingPolicy.WeightTotal
+	}
+
+	return 100
+}
+
+// getStreamSnippetsFromMCIs returns the stream snippets of the multiclusteringresses
+// sorted and without exact duplicates, so two objects sharing a snippet do not
+// configure the same directives twice and the order does not depend on the listing
 func (n *NGINXController) getStreamSnippetsFromMCIs(mcis []*ingress.MultiClusterIngress) []string {
-	snippets := make([]string, 0, len(mcis))
+	snippets := sets.NewString()
 	for _, mci := range mcis {
 		if mci.ParsedAnnotations.StreamSnippet == "" {
 			continue
 		}
-		snippets = append(snippets, mci.ParsedAnnotations.StreamSnippet)
+		snippets.Insert(mci.ParsedAnnotations.StreamSnippet)
+	}
+	return snippets.List()
+}
+
+// getStreamServicesFromMCIs returns the stream services of the ConfigMap and
+// of the tcp-services or udp-services annotations of the multiclusteringresses.
+// The ports of the ConfigMap take precedence. MultiClusterIngresses are sorted
+// by creation timestamp, so the oldest one keeps a port used by several of them.
+func (n *NGINXController) getStreamServicesFromMCIs(configmapName string, proto apiv1.Protocol, mcis []*ingress.MultiClusterIngress) []ingress.L4Service {
+	svcs := n.getStreamServices(configmapName, proto)
+
+	owners := make(map[int]string)
+	for port := range n.getStreamConfigMapPorts(configmapName) {
+		owners[port] = fmt.Sprintf("ConfigMap %v", configmapName)
+	}
+
+	reservedPorts := n.streamReservedPorts()
+	for _, mci := range mcis {
+		refs := mciStreamServices(mci, proto)
+
+		ports := make([]int, 0, len(refs))
+		for port := range refs {
+			ports = append(ports, port)
+		}
+		sort.Ints(ports)
+
+		for _, port := range ports {
+			if owner, ok := owners[port]; ok {
+				n.recordStreamServiceConflict(mci, proto, port, owner)
+				continue
+			}
+			owners[port] = fmt.Sprintf("multiclusteringress %v", k8s.MetaNamespaceKey(mci))
+
+			svc := n.getStreamService(strconv.Itoa(port), refs[port], proto, reservedPorts)
+			if svc == nil {
+				continue
+			}
+			svcs = append(svcs, *svc)
+		}
+	}
+
+	// Keep upstream order sorted to reduce unnecessary nginx config reloads.
+	sort.SliceStable(svcs, func(i, j int) bool {
+		return svcs[i].Port < svcs[j].Port
+	})
+	return svcs
+}
+
+// getStreamConfigMapPorts returns the external ports of the tcp-services or
+// udp-services ConfigMap, including the ones without a valid service
+func (n *NGINXController) getStreamConfigMapPorts(configmapName string) sets.Int {
+	ports := sets.NewInt()
+	if configmapName == "" {
+		return ports
+	}
+
+	configmap, err := n.store.GetConfigMap(configmapName)
+	if err != nil {
+		return ports
+	}
+
+	for port := range configmap.Data {
+		if externalPort, err := strconv.Atoi(port); err == nil {
+			ports.Insert(externalPort)
+		}
+	}
+	return ports
+}
+
+// mciStreamServices returns the service references of the tcp-services
+// or udp-services annotation of the multiclusteringress, by external port
+func mciStreamServices(mci *ingress.MultiClusterIngress, proto apiv1.Protocol) map[int]string {
+	if mci.ParsedAnnotations == nil {
+		return nil
+	}
+
+	if proto == apiv1.ProtocolUDP {
+		return mci.ParsedAnnotations.StreamServices.UDP
+	}
+	return mci.ParsedAnnotations.StreamServices.TCP
+}
+
+// recordStreamServiceConflict records a warning event in the multiclusteringress
+// when one of its stream service ports is already used by the ConfigMap or by
+// an older multiclusteringress
+func (n *NGINXController) recordStreamServiceConflict(mci *ingress.MultiClusterIngress, proto apiv1.Protocol, port int, owner string) {
+	klog.Warningf("%v port %d is already used by %v, ignoring the service of multiclusteringress %v",
+		proto, port, owner, k8s.MetaNamespaceKey(mci))
+	n.recorder.Eventf(&mci.MultiClusterIngress, apiv1.EventTypeWarning, "StreamServiceConflict",
+		"%v port %d is already used by %v, the service is ignored", proto, port, owner)
+}
+
+// checkStreamServices returns an error when the tcp-services or udp-services
+// annotations of the multiclusteringress use a port reserved for the controller,
+// or a port already used by the ConfigMaps or by another multiclusteringress
+func (n *NGINXController) checkStreamServices(mci *ingress.MultiClusterIngress, mcis []*ingress.MultiClusterIngress) error {
+	reservedPorts := n.streamReservedPorts()
+
+	for _, stream := range []struct {
+		proto         apiv1.Protocol
+		configmapName string
+	}{
+		{apiv1.ProtocolTCP, n.cfg.TCPConfigMapName},
+		{apiv1.ProtocolUDP, n.cfg.UDPConfigMapName},
+	} {
+		refs := mciStreamServices(mci, stream.proto)
+		if len(refs) == 0 {
+			continue
+		}
+
+		configmapPorts := n.getStreamConfigMapPorts(stream.configmapName)
+		for port := range refs {
+			if reservedPorts.Has(port) {
+				return fmt.Errorf("%v port %d of multiclusteringress %v is reserved for the ingress controller",
+					stream.proto, port, k8s.MetaNamespaceKey(mci))
+			}
+
+			if configmapPorts.Has(port) {
+				return fmt.Errorf("%v port %d of multiclusteringress %v is already used by ConfigMap %v",
+					stream.proto, port, k8s.MetaNamespaceKey(mci), stream.configmapName)
+			}
+
+			for _, other := range mcis {
+				if _, ok := mciStreamServices(other, stream.proto)[port]; ok {
+					return fmt.Errorf("%v port %d of multiclusteringress %v is already used by multiclusteringress %v",
+						stream.proto, port, k8s.MetaNamespaceKey(mci), k8s.MetaNamespaceKey(other))
+				}
+			}
+		}
 	}
-	return snippets
+
+	return nil
 }
 
 func getRemovedMCIs(rucfg, newcfg *ingress.Configuration) []string {
@@ -1014,28 +2158,135 @@ func getRemovedMCIs(rucfg, newcfg *ingress.Configuration) []string {
 	return oldMCIs.Difference(newMCIs).List()
 }
 
+// mciChecksum returns a hash of the specs and annotations of the
+// multiclusteringresses, independent of the order of the list
+func mciChecksum(mcis []*ingress.MultiClusterIngress) uint64 {
+	type mciState struct {
+		Spec        interface{}
+		Annotations map[string]string
+	}
+
+	state := make(map[string]mciState, len(mcis))
+	for _, mci := range mcis {
+		state[k8s.MetaNamespaceKey(mci)] = mciState{
+			Spec:        mci.Spec,
+			Annotations: mci.Annotations,
+		}
+	}
+
+	hash, err := hashstructure.Hash(state, nil)
+	if err != nil {
+		klog.Warningf("Error hashing multiclusteringresses: %v", err)
+	}
+
+	return hash
+}
+
+// AdmissionResult contains the details of the validation of a multiclusteringress
+type AdmissionResult struct {
+	// TestedSize is the number of multiclusteringresses in the tested configuration
+	TestedSize int
+	// RenderDuration is the time spent rendering the configuration
+	RenderDuration time.Duration
+	// TestDuration is the time spent testing the configuration
+	TestDuration time.Duration
+	// ContentLength is the size in bytes of the tested configuration
+	ContentLength int
+	// Ignored is true when the multiclusteringress was not validated because
+	// it is outside the namespace watched by the controller or it is disabled
+	Ignored bool
+}
+
 // CheckMCI returns an error in case the provided multiclusteringress, when added
 // to the current configuration, generates an invalid configuration
 func (n *NGINXController) CheckMCI(mci *karmadanetwork.MultiClusterIngress) error {
+	_, err := n.CheckMCIWithResult(mci)
+	return err
+}
+
+// CheckMCIWithResult checks the provided multiclusteringress like CheckMCI and
+// returns the details of the validation
+func (n *NGINXController) CheckMCIWithResult(mci *karmadanetwork.MultiClusterIngress) (*AdmissionResult, error) {
+	result, err := n.checkMCIWithResult(mci)
+	if n.cfg.EmitAdmissionEvents && mci != nil {
+		n.recordAdmissionDecision(mci, result, err)
+	}
+
+	return result, err
+}
+
+// recordAdmissionDecision records an event in the multiclusteringress with
+// the decision of the validating webhook
+func (n *NGINXController) recordAdmissionDecision(mci *karmadanetwork.MultiClusterIngress, result *AdmissionResult, err error) {
+	switch {
+	case err != nil:
+		n.recorder.Eventf(mci, apiv1.EventTypeWarning, "AdmissionRejected",
+			"rejected by the validating webhook: %v", err)
+	case result.Ignored:
+		n.recorder.Eventf(mci, apiv1.EventTypeNormal, "AdmissionAccepted",
+			"accepted by the validating webhook without validation, the multiclusteringress is not part of the configuration")
+	default:
+		n.recorder.Eventf(mci, apiv1.EventTypeNormal, "AdmissionAccepted",
+			"accepted by the validating webhook")
+	}
+}
+
+// admissionParsers returns the parsers of the annotations validated by the
+// admission webhook. The annotation extractor ignores their invalid values,
+// they are rejected here as they would break the reload.
+func admissionParsers(r resolver.Resolver) []parser.IngressAnnotation {
+	return []parser.IngressAnnotation{
+		sslcipher.NewParser(r),
+		sslprotocols.NewParser(r),
+		routebyheader.NewParser(r),
+		log.NewParser(r),
+		clientcert.NewParser(r),
+		ratelimit.NewParser(r),
+		subfilter.NewParser(r),
+		clientbodyinfileonly.NewParser(r),
+		clientkeepalive.NewParser(r),
+		geoipaccess.NewParser(r),
+		internalredirect.NewParser(r),
+		proxycache.NewParser(r),
+		http2pushpreload.NewPreloadLinksParser(r),
+		grpctimeout.NewParser(r),
+		securityheaders.NewParser(r),
+		backendscheme.NewParser(r),
+		ewmadecay.NewParser(r),
+		streamservices.NewParser(r),
+	}
+}
+
+func (n *NGINXController) checkMCIWithResult(mci *karmadanetwork.MultiClusterIngress) (*AdmissionResult, error) {
 	startCheck := time.Now().UnixNano() / 1000000
 
 	if mci == nil {
 		// no multiclusteringress to add, no state change
-		return nil
+		return &AdmissionResult{}, nil
 	}
 
 	// Skip checks if the multiclusteringress is marked as deleted
 	if !mci.DeletionTimestamp.IsZero() {
-		return nil
+		return &AdmissionResult{}, nil
 	}
 
 	if n.cfg.Namespace != "" && mci.ObjectMeta.Namespace != n.cfg.Namespace {
+		if n.cfg.RejectUnwatchedNamespace {
+			return nil, fmt.Errorf("multiclusteringress %v in namespace %v is outside the namespace watched %s", mci.Name, mci.ObjectMeta.Namespace, n.cfg.Namespace)
+		}
+
 		klog.Warningf("ignoring multiclusteringress %v in namespace %v different from the namespace watched %s", mci.Name, mci.ObjectMeta.Namespace, n.cfg.Namespace)
-		return nil
+		return &AdmissionResult{Ignored: true}, nil
+	}
+
+	// Skip checks if the multiclusteringress is disabled, it is not part of the configuration
+	if disabled, _ := parser.GetBoolAnnotationFromMCI("disabled", mci); disabled {
+		klog.V(2).Infof("ignoring disabled multiclusteringress %v/%v", mci.ObjectMeta.Namespace, mci.Name)
+		return &AdmissionResult{Ignored: true}, nil
 	}
 
 	if n.cfg.DisableCatchAll && mci.Spec.DefaultBackend != nil {
-		return fmt.Errorf("This deployment is trying to create a catch-all multiclusteringress while DisableCatchAll flag is set to true. Remove '.spec.backend' or set DisableCatchAll flag to false. ")
+		return nil, fmt.Errorf("This deployment is trying to create a catch-all multiclusteringress while DisableCatchAll flag is set to true. Remove '.spec.backend' or set DisableCatchAll flag to false. ")
 	}
 
 	startRender := time.Now().UnixNano() / 1000000
@@ -1050,26 +2301,48 @@ func (n *NGINXController) CheckMCI(mci *karmadanetwork.MultiClusterIngress) erro
 	for key, value := range mci.ObjectMeta.GetAnnotations() {
 		if parser.AnnotationsPrefix != parser.DefaultAnnotationsPrefix {
 			if strings.HasPrefix(key, fmt.Sprintf("%s/", parser.DefaultAnnotationsPrefix)) {
-				return fmt.Errorf("This deployment has a custom annotation prefix defined. Use '%s' instead of '%s'", parser.AnnotationsPrefix, parser.DefaultAnnotationsPrefix)
+				return nil, fmt.Errorf("This deployment has a custom annotation prefix defined. Use '%s' instead of '%s'", parser.AnnotationsPrefix, parser.DefaultAnnotationsPrefix)
 			}
 		}
 
 		if strings.HasPrefix(key, fmt.Sprintf("%s/", parser.AnnotationsPrefix)) && len(arrayBadWords) != 0 {
 			for _, forbiddenvalue := range arrayBadWords {
 				if strings.Contains(value, strings.TrimSpace(forbiddenvalue)) {
-					return fmt.Errorf("%s annotation contains invalid word %s", key, forbiddenvalue)
+					return nil, fmt.Errorf("%s annotation contains invalid word %s", key, forbiddenvalue)
 				}
 			}
 		}
 
 		if !cfg.AllowSnippetAnnotations && strings.HasSuffix(key, "-snippet") {
-			return fmt.Errorf("%s annotation cannot be used. Snippet directives are disabled by the MultiClusterIngress administrator", key)
+			return nil, fmt.Errorf("%s annotation cannot be used. Snippet directives are disabled by the MultiClusterIngress administrator", key)
 		}
 
 		if len(cfg.GlobalRateLimitMemcachedHost) == 0 && strings.HasPrefix(key, fmt.Sprintf("%s/%s", parser.AnnotationsPrefix, "global-rate-limit")) {
-			return fmt.Errorf("'global-rate-limit*' annotations require 'global-rate-limit-memcached-host' settings configured in the global configmap")
+			return nil, fmt.Errorf("'global-rate-limit*' annotations require 'global-rate-limit-memcached-host' settings configured in the global configmap")
+		}
+
+	}
+
+	for _, p := range admissionParsers(n.store) {
+		if _, err := p.ParseByMCI(mci); err != nil && !errors.IsMissingAnnotations(err) {
+			n.metricCollector.IncCheckErrorCount(mci.ObjectMeta.Namespace, mci.Name)
+			return nil, err
 		}
+	}
+
+	if err := checkSSLPassthroughWithTLS(mci); err != nil {
+		n.metricCollector.IncCheckErrorCount(mci.ObjectMeta.Namespace, mci.Name)
+		return nil, err
+	}
+
+	if err := checkSSLPassthroughRootOnly(mci); err != nil {
+		n.metricCollector.IncCheckErrorCount(mci.ObjectMeta.Namespace, mci.Name)
+		return nil, err
+	}
 
+	if err := checkStreamSnippet(mci); err != nil {
+		n.metricCollector.IncCheckErrorCount(mci.ObjectMeta.Namespace, mci.Name)
+		return nil, err
 	}
 
 	karmada.SetDefaultNGINXPathType(mci)
@@ -1081,34 +2354,68 @@ func (n *NGINXController) CheckMCI(mci *karmadanetwork.MultiClusterIngress) erro
 			toCheck.ObjectMeta.Name == mci.ObjectMeta.Name
 	}
 	mcis := store.FilterMultiClusterIngress(allMCIs, filter)
-	mcis = append(mcis, &ingress.MultiClusterIngress{
+	newMCI := &ingress.MultiClusterIngress{
 		MultiClusterIngress: *mci,
 		ParsedAnnotations:   annotations.NewAnnotationExtractor(n.store).ExtractFromMCI(mci),
-	})
+	}
+
+	if primary := selfCanaryMCI(newMCI, mcis); primary != nil {
+		klog.Warningf("canary multiclusteringress %v defines the same hosts, paths and services as multiclusteringress %v, no traffic will be shifted",
+			k8s.MetaNamespaceKey(mci), k8s.MetaNamespaceKey(primary))
+	}
+
+	if n.cfg.ValidateCanaryPrimary {
+		if err := checkCanaryPrimary(newMCI, mcis); err != nil {
+			n.metricCollector.IncCheckErrorCount(mci.ObjectMeta.Namespace, mci.Name)
+			return nil, err
+		}
+	}
+
+	if err := n.checkStreamServices(newMCI, mcis); err != nil {
+		n.metricCollector.IncCheckErrorCount(mci.ObjectMeta.Namespace, mci.Name)
+		return nil, err
+	}
+
+	if n.cfg.ValidateBackendServices {
+		if err := n.checkBackendServices(mci); err != nil {
+			n.metricCollector.IncCheckErrorCount(mci.ObjectMeta.Namespace, mci.Name)
+			return nil, err
+		}
+	}
+
+	mcis = append(mcis, newMCI)
 	startTest := time.Now().UnixNano() / 1000000
-	_, servers, pcfg := n.getConfigurationFromMCI(mcis)
+	_, servers, pcfg, _ := n.getConfigurationFromMCI(mcis)
 
 	err := checkOverlapWithMCI(mci, servers)
 	if err != nil {
 		n.metricCollector.IncCheckErrorCount(mci.ObjectMeta.Namespace, mci.Name)
-		return err
+		return nil, err
+	}
+
+	err = checkLocationsPerServer(mci, servers, n.cfg.MaxLocationsPerServer)
+	if err != nil {
+		n.metricCollector.IncCheckErrorCount(mci.ObjectMeta.Namespace, mci.Name)
+		return nil, err
 	}
 	testedSize := len(mcis)
 	if n.cfg.DisableFullValidationTest {
-		_, _, pcfg = n.getConfigurationFromMCI(mcis[len(mcis)-1:])
+		_, _, pcfg, _ = n.getConfigurationFromMCI(mcis[len(mcis)-1:])
 		testedSize = 1
 	}
 
-	content, err := n.generateTemplate(cfg, *pcfg)
-	if err != nil {
+	content, err := n.checkTemplateWithTimeout(cfg, *pcfg)
+	if err == context.DeadlineExceeded {
+		klog.Warningf("checking multiclusteringress %v/%v exceeded the admission timeout of %v", mci.ObjectMeta.Namespace, mci.Name, n.cfg.AdmissionTimeout)
+		n.metricCollector.IncCheckTimeoutCount(mci.ObjectMeta.Namespace, mci.Name)
 		n.metricCollector.IncCheckErrorCount(mci.ObjectMeta.Namespace, mci.Name)
-		return err
+		return nil, fmt.Errorf("timeout checking multiclusteringress %v/%v: rendering and testing the configuration took longer than the admission timeout of %v",
+			mci.ObjectMeta.Namespace, mci.Name, n.cfg.AdmissionTimeout)
 	}
-
-	err = n.testTemplate(content)
 	if err != nil {
+		n.warnMissingBrotliModule(newMCI, err)
 		n.metricCollector.IncCheckErrorCount(mci.ObjectMeta.Namespace, mci.Name)
-		return err
+		return nil, err
 	}
 	n.metricCollector.IncCheckCount(mci.ObjectMeta.Namespace, mci.Name)
 	endCheck := time.Now().UnixNano() / 1000000
@@ -1120,6 +2427,312 @@ func (n *NGINXController) CheckMCI(mci *karmadanetwork.MultiClusterIngress) erro
 		float64(len(content)),
 		float64(endCheck-startCheck)/1000,
 	)
+
+	return &AdmissionResult{
+		TestedSize:     testedSize,
+		RenderDuration: time.Duration(startTest-startRender) * time.Millisecond,
+		TestDuration:   time.Duration(endCheck-startTest) * time.Millisecond,
+		ContentLength:  len(content),
+	}, nil
+}
+
+// checkTemplateWithTimeout renders and tests the configuration, giving up with
+// context.DeadlineExceeded when it takes longer than the admission timeout.
+// The test of the abandoned check is killed, the rendering of the
+// configuration finishes in the background and its result is discarded.
+func (n *NGINXController) checkTemplateWithTimeout(cfg ngx_config.Configuration, pcfg ingress.Configuration) ([]byte, error) {
+	if n.cfg.AdmissionTimeout <= 0 {
+		return n.checkTemplate(context.Background(), cfg, pcfg)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), n.cfg.AdmissionTimeout)
+	defer cancel()
+
+	type result struct {
+		content []byte
+		err     error
+	}
+
+	// buffered, so the abandoned check does not block sending its result
+	done := make(chan result, 1)
+	go func() {
+		content, err := n.checkTemplate(ctx, cfg, pcfg)
+		done <- result{content, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.content, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// checkTemplate renders the configuration and tests it, skipping the
+// test when the context is done once the configuration is rendered and
+// killing it when the context is done during the test
+func (n *NGINXController) checkTemplate(ctx context.Context, cfg ngx_config.Configuration, pcfg ingress.Configuration) ([]byte, error) {
+	content, err := n.generateTemplate(cfg, pcfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := n.testTemplate(ctx, content); err != nil {
+		return nil, err
+	}
+
+	return content, nil
+}
+
+// warnSSEProxyBuffering records a warning event in the multiclusteringress when
+// the enable-sse annotation overrides the buffering of its proxy-buffering annotation
+func (n *NGINXController) warnSSEProxyBuffering(mci *ingress.MultiClusterIngress, buffering string) {
+	klog.Warningf("enable-sse overrides proxy-buffering %q in multiclusteringress %v", buffering, k8s.MetaNamespaceKey(mci))
+	n.recorder.Eventf(&mci.MultiClusterIngress, apiv1.EventTypeWarning, "ProxyBufferingOverridden",
+		"enable-sse overrides proxy-buffering %q, responses are not buffered", buffering)
+}
+
+// recordMaintenanceMode records an event in the multiclusteringress when the
+// maintenance-mode annotation keeps its locations away from their upstreams
+func (n *NGINXController) recordMaintenanceMode(mci *ingress.MultiClusterIngress) {
+	klog.V(2).Infof("MultiClusterIngress %v is in maintenance mode", k8s.MetaNamespaceKey(mci))
+	n.recorder.Eventf(&mci.MultiClusterIngress, apiv1.EventTypeNormal, "MaintenanceMode",
+		"maintenance-mode is enabled, locations return 503 or use the custom default backend")
+}
+
+// recordDisabled records an event in the multiclusteringress when the
+// disabled annotation takes it out of the configuration
+func (n *NGINXController) recordDisabled(mci *ingress.MultiClusterIngress) {
+	klog.V(2).Infof("MultiClusterIngress %v is disabled", k8s.MetaNamespaceKey(mci))
+	n.recorder.Eventf(&mci.MultiClusterIngress, apiv1.EventTypeNormal, "Disabled",
+		"disabled annotation is set, the multiclusteringress is not part of the configuration")
+}
+
+// denyMaintenanceLocation makes the location return 503 instead of using its upstream
+func denyMaintenanceLocation(location *ingress.Location) {
+	reason := "maintenance mode"
+	location.Denied = &reason
+}
+
+// recordCertificateAuthConflict records a warning event in the multiclusteringress
+// when the server of the host already verifies the client certificates with the
+// CA of a previous multiclusteringress. MultiClusterIngresses are sorted by
+// creation timestamp, so the oldest one defines the CA of the server.
+func (n *NGINXController) recordCertificateAuthConflict(mci *ingress.MultiClusterIngress, hostname, caSecret string) {
+	secret := mci.ParsedAnnotations.CertificateAuth.Secret
+
+	klog.Warningf("Server %q already uses the CA secret %q for mutual authentication, ignoring secret %q of multiclusteringress %v",
+		hostname, caSecret, secret, k8s.MetaNamespaceKey(mci))
+	n.recorder.Eventf(&mci.MultiClusterIngress, apiv1.EventTypeWarning, "MutualTLSConflict",
+		"host %q already uses the CA secret %q for mutual authentication, secret %q is ignored",
+		hostname, caSecret, secret)
+	n.metricCollector.IncMTLSConflictCount(mci.Namespace, mci.Name)
+}
+
+// recordCanaryMergeFailure counts a canary upstream of the multiclusteringress
+// deleted because no primary backend matches it
+func (n *NGINXController) recordCanaryMergeFailure(mci *ingress.MultiClusterIngress) {
+	n.metricCollector.IncCanaryMergeFailureCount(mci.Namespace, mci.Name)
+}
+
+// warnGeoIPUnavailable records a warning event in the multiclusteringress when
+// the geoip annotations are used but no GeoIP database provides the country
+func (n *NGINXController) warnGeoIPUnavailable(mci *ingress.MultiClusterIngress) {
+	klog.Warningf("No GeoIP database provides the country of the clients for multiclusteringress %v", k8s.MetaNamespaceKey(mci))
+	if len(mci.ParsedAnnotations.GeoIPAccess.AllowCountries) > 0 {
+		n.recorder.Eventf(&mci.MultiClusterIngress, apiv1.EventTypeWarning, "GeoIPUnavailable",
+			"no GeoIP database is configured, geoip-allow-countries denies every request")
+		return
+	}
+
+	n.recorder.Eventf(&mci.MultiClusterIngress, apiv1.EventTypeWarning, "GeoIPUnavailable",
+		"no GeoIP database is configured, geoip-deny-countries is ignored")
+}
+
+// warnMissingBrotliModule records a warning event in the multiclusteringress when
+// the configuration test failed because the running NGINX lacks the brotli modules
+func (n *NGINXController) warnMissingBrotliModule(mci *ingress.MultiClusterIngress, err error) {
+	if !mci.ParsedAnnotations.Brotli.Enabled || !strings.Contains(err.Error(), "brotli") {
+		return
+	}
+
+	klog.Warningf("NGINX does not provide the brotli modules required by multiclusteringress %v", k8s.MetaNamespaceKey(mci))
+	n.recorder.Eventf(&mci.MultiClusterIngress, apiv1.EventTypeWarning, "BrotliUnavailable",
+		"NGINX does not provide the brotli modules required by the enable-brotli annotation")
+}
+
+// checkSSLPassthroughWithTLS returns an error when the multiclusteringress enables
+// SSL passthrough and also defines a TLS secret for the same host, as NGINX does
+// not terminate TLS for passthrough hosts
+func checkSSLPassthroughWithTLS(mci *karmadanetwork.MultiClusterIngress) error {
+	passthrough, err := parser.GetBoolAnnotationFromMCI("ssl-passthrough", mci)
+	if err != nil || !passthrough {
+		return nil
+	}
+
+	hosts := sets.NewString()
+	for _, rule := range mci.Spec.Rules {
+		if rule.Host != "" {
+			hosts.Insert(rule.Host)
+		}
+	}
+
+	for _, tls := range mci.Spec.TLS {
+		if tls.SecretName == "" {
+			continue
+		}
+
+		for _, host := range tls.Hosts {
+			if hosts.Has(host) {
+				return fmt.Errorf(`host "%s" enables ssl-passthrough but also defines the TLS secret "%s". Remove the secret or the ssl-passthrough annotation`, host, tls.SecretName)
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkBackendServices returns an error when a service backend of the
+// multiclusteringress has no derived service in the store, as the
+// locations would otherwise use the default backend
+func (n *NGINXController) checkBackendServices(mci *karmadanetwork.MultiClusterIngress) error {
+	check := func(svcName, location string) error {
+		svcKey := fmt.Sprintf("%v/%v", mci.Namespace, names.GenerateDerivedServiceName(svcName))
+		if _, err := n.store.GetService(svcKey); err != nil {
+			return fmt.Errorf("service %v/%v referenced by %v does not exist (derived service %v not found)",
+				mci.Namespace, svcName, location, svcKey)
+		}
+		return nil
+	}
+
+	if mci.Spec.DefaultBackend != nil && mci.Spec.DefaultBackend.Service != nil {
+		if err := check(mci.Spec.DefaultBackend.Service.Name, "the default backend"); err != nil {
+			return err
+		}
+	}
+
+	for _, rule := range mci.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+
+		host := rule.Host
+		if host == "" {
+			host = defServerName
+		}
+
+		for _, path := range rule.HTTP.Paths {
+			if path.Backend.Service == nil {
+				continue
+			}
+
+			if err := check(path.Backend.Service.Name, fmt.Sprintf(`path "%v" of host "%v"`, path.Path, host)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkSSLPassthroughRootOnly returns an error when the multiclusteringress sets the
+// ssl-passthrough-root-only annotation and enables SSL passthrough for paths other
+// than the root, which would otherwise be ignored with a warning
+func checkSSLPassthroughRootOnly(mci *karmadanetwork.MultiClusterIngress) error {
+	rootOnly, err := parser.GetBoolAnnotationFromMCI("ssl-passthrough-root-only", mci)
+	if err != nil || !rootOnly {
+		return nil
+	}
+
+	passthrough, err := parser.GetBoolAnnotationFromMCI("ssl-passthrough", mci)
+	if err != nil || !passthrough {
+		return nil
+	}
+
+	for _, rule := range mci.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+
+		host := rule.Host
+		if host == "" {
+			host = defServerName
+		}
+
+		for _, path := range rule.HTTP.Paths {
+			if path.Path != "" && path.Path != rootLocation {
+				return fmt.Errorf(`path "%s" of host "%s" is not supported with ssl-passthrough, only the root path "%s" is passed through`, path.Path, host, rootLocation)
+			}
+		}
+	}
+
+	return nil
+}
+
+var (
+	snippetCommentRegex = regexp.MustCompile(`#[^\n]*`)
+
+	// httpOnlyStreamDirectives matches directives that are only valid in the http
+	// context, at the beginning of a statement so arguments are not considered
+	httpOnlyStreamDirectives = []struct {
+		name  string
+		regex *regexp.Regexp
+	}{
+		{"location", regexp.MustCompile(`(^|[;{}])\s*location\s[^;{}]*\{`)},
+		{"server_name", regexp.MustCompile(`(^|[;{}])\s*server_name\s`)},
+		{"proxy_pass http://", regexp.MustCompile(`(^|[;{}])\s*proxy_pass\s+https?://`)},
+	}
+)
+
+// checkStreamSnippet returns an error when the stream-snippet annotation of the
+// multiclusteringress contains directives only valid in the http context, which
+// would break the reload as the snippet is rendered in the stream block
+func checkStreamSnippet(mci *karmadanetwork.MultiClusterIngress) error {
+	snippet, err := parser.GetStringAnnotationFromMCI("stream-snippet", mci)
+	if err != nil || snippet == "" {
+		return nil
+	}
+
+	snippet = snippetCommentRegex.ReplaceAllString(snippet, "")
+	for _, directive := range httpOnlyStreamDirectives {
+		if directive.regex.MatchString(snippet) {
+			return fmt.Errorf(`stream-snippet annotation contains the http directive "%s", only directives of the stream context are allowed`, directive.name)
+		}
+	}
+
+	return nil
+}
+
+// checkLocationsPerServer returns an error when one of the servers defined in
+// the multiclusteringress has more locations than the allowed maximum
+func checkLocationsPerServer(mci *karmadanetwork.MultiClusterIngress, servers []*ingress.Server, limit int) error {
+	if limit <= 0 {
+		return nil
+	}
+
+	hosts := sets.NewString()
+	for _, rule := range mci.Spec.Rules {
+		if rule.Host == "" {
+			hosts.Insert(defServerName)
+			continue
+		}
+		hosts.Insert(rule.Host)
+	}
+
+	for _, server := range servers {
+		if !hosts.Has(server.Hostname) {
+			continue
+		}
+
+		if len(server.Locations) > limit {
+			return fmt.Errorf(`host "%s" would have %d locations, more than the maximum of %d locations per server`, server.Hostname, len(server.Locations), limit)
+		}
+	}
+
 	return nil
 }
 
@@ -1185,6 +2798,111 @@ func checkOverlapWithMCI(mci *karmadanetwork.MultiClusterIngress, servers []*ing
 	return nil
 }
 
+// selfCanaryMCI returns the non-canary multiclusteringress the canary would be merged
+// into when both define exactly the same host/path set pointing to the same services.
+// Such a canary is a no-op and usually the result of a wrong annotation value.
+func selfCanaryMCI(canary *ingress.MultiClusterIngress, mcis []*ingress.MultiClusterIngress) *ingress.MultiClusterIngress {
+	if canary == nil || canary.ParsedAnnotations == nil || !canary.ParsedAnnotations.Canary.Enabled {
+		return nil
+	}
+
+	canaryPaths := mciHostPathUpstreams(canary)
+	if len(canaryPaths) == 0 {
+		return nil
+	}
+
+	for _, mci := range mcis {
+		if mci.ParsedAnnotations != nil && mci.ParsedAnnotations.Canary.Enabled {
+			continue
+		}
+
+		paths := mciHostPathUpstreams(mci)
+		if len(paths) != len(canaryPaths) {
+			continue
+		}
+
+		identical := true
+		for hostPath, ups := range canaryPaths {
+			if paths[hostPath] != ups {
+				identical = false
+				break
+			}
+		}
+
+		if identical {
+			return mci
+		}
+	}
+
+	return nil
+}
+
+// checkCanaryPrimary returns an error when a host/path pair of the canary
+// multiclusteringress is not defined by any non-canary multiclusteringress, as
+// mergeAlternativeBackendsByMCI drops the canary backends without a primary.
+func checkCanaryPrimary(canary *ingress.MultiClusterIngress, mcis []*ingress.MultiClusterIngress) error {
+	if canary == nil || canary.ParsedAnnotations == nil || !canary.ParsedAnnotations.Canary.Enabled {
+		return nil
+	}
+
+	primaries := sets.NewString()
+	for _, mci := range mcis {
+		if mci.ParsedAnnotations != nil && mci.ParsedAnnotations.Canary.Enabled {
+			continue
+		}
+
+		for hostPath := range mciHostPathUpstreams(mci) {
+			primaries.Insert(hostPath)
+		}
+	}
+
+	missing := sets.NewString()
+	for hostPath := range mciHostPathUpstreams(canary) {
+		if !primaries.Has(hostPath) {
+			missing.Insert(hostPath)
+		}
+	}
+
+	if missing.Len() > 0 {
+		return fmt.Errorf("canary multiclusteringress %v defines host/path pairs without a primary multiclusteringress: %v",
+			k8s.MetaNamespaceKey(canary), strings.Join(missing.List(), ", "))
+	}
+
+	return nil
+}
+
+// mciHostPathUpstreams returns a map of host and path to the upstream name
+// referenced by the rules of a multiclusteringress.
+func mciHostPathUpstreams(mci *ingress.MultiClusterIngress) map[string]string {
+	hostPaths := make(map[string]string)
+
+	for _, rule := range mci.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+
+		host := rule.Host
+		if host == "" {
+			host = defServerName
+		}
+
+		for _, path := range rule.HTTP.Paths {
+			if path.Backend.Service == nil {
+				continue
+			}
+
+			nginxPath := rootLocation
+			if path.Path != "" {
+				nginxPath = path.Path
+			}
+
+			hostPaths[host+nginxPath] = upstreamName(mci.Namespace, path.Backend.Service)
+		}
+	}
+
+	return hostPaths
+}
+
 func mciForHostPath(hostname, path string, servers []*ingress.Server) []*karmadanetwork.MultiClusterIngress {
 	mcis := make([]*karmadanetwork.MultiClusterIngress, 0)
 
@@ -0,0 +1,4043 @@
+package controller
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	karmadanetwork "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	networking "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+
+	"k8s.io/ingress-nginx/internal/file"
+	"k8s.io/ingress-nginx/internal/ingress"
+	"k8s.io/ingress-nginx/internal/ingress/annotations"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/authtls"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/canary"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/clientkeepalive"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/defaultbackend"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/healthcheck"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/internalredirect"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/passivehealthcheck"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/proxy"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/proxyssl"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/routebyheader"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/sessionaffinity"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/streamservices"
+	ngx_config "k8s.io/ingress-nginx/internal/ingress/controller/config"
+	"k8s.io/ingress-nginx/internal/ingress/metric"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+type fakeMCIStore struct {
+	fakeIngressStore
+	mcis       []*ingress.MultiClusterIngress
+	configMaps map[string]*corev1.ConfigMap
+	services   map[string]*corev1.Service
+	endpoints  map[string]*corev1.Endpoints
+	sslCerts   map[string]*ingress.SSLCert
+	// endpointSlices are the EndpointSlices of the services by service key
+	endpointSlices map[string][]*discoveryv1.EndpointSlice
+}
+
+func (fs fakeMCIStore) ListMultiClusterIngresses() []*ingress.MultiClusterIngress {
+	return fs.mcis
+}
+
+func (fs fakeMCIStore) GetConfigMap(key string) (*corev1.ConfigMap, error) {
+	if cm, ok := fs.configMaps[key]; ok {
+		return cm, nil
+	}
+	return nil, fmt.Errorf("configmap %v not found", key)
+}
+
+func (fs fakeMCIStore) GetService(key string) (*corev1.Service, error) {
+	if svc, ok := fs.services[key]; ok {
+		return svc, nil
+	}
+	return nil, fmt.Errorf("service %v not found", key)
+}
+
+func (fs fakeMCIStore) GetServiceEndpoints(key string) (*corev1.Endpoints, error) {
+	if ep, ok := fs.endpoints[key]; ok {
+		return ep, nil
+	}
+	return nil, fmt.Errorf("endpoints %v not found", key)
+}
+
+func (fs fakeMCIStore) GetServiceEndpointSlices(key string) ([]*discoveryv1.EndpointSlice, error) {
+	if eps, ok := fs.endpointSlices[key]; ok {
+		return eps, nil
+	}
+	return nil, fmt.Errorf("endpointslices %v not found", key)
+}
+
+func (fs fakeMCIStore) GetLocalSSLCert(key string) (*ingress.SSLCert, error) {
+	if cert, ok := fs.sslCerts[key]; ok {
+		return cert, nil
+	}
+	return nil, fmt.Errorf("certificate %v not found", key)
+}
+
+func newTestMCI(name, host, path, service string, isCanary bool) *ingress.MultiClusterIngress {
+	pathTypePrefix := networking.PathTypePrefix
+	return &ingress.MultiClusterIngress{
+		MultiClusterIngress: karmadanetwork.MultiClusterIngress{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "example",
+			},
+			Spec: networking.IngressSpec{
+				Rules: []networking.IngressRule{
+					{
+						Host: host,
+						IngressRuleValue: networking.IngressRuleValue{
+							HTTP: &networking.HTTPIngressRuleValue{
+								Paths: []networking.HTTPIngressPath{
+									{
+										Path:     path,
+										PathType: &pathTypePrefix,
+										Backend: networking.IngressBackend{
+											Service: &networking.IngressServiceBackend{
+												Name: service,
+												Port: networking.ServiceBackendPort{
+													Number: 80,
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		ParsedAnnotations: &annotations.Ingress{
+			Canary: canary.Config{
+				Enabled: isCanary,
+			},
+		},
+	}
+}
+
+func TestSelfCanaryMCI(t *testing.T) {
+	testCases := map[string]struct {
+		canary   *ingress.MultiClusterIngress
+		mcis     []*ingress.MultiClusterIngress
+		expected string
+	}{
+		"self canary with identical services": {
+			canary: newTestMCI("example-canary", "example.com", "/", "http-svc", true),
+			mcis: []*ingress.MultiClusterIngress{
+				newTestMCI("example", "example.com", "/", "http-svc", false),
+			},
+			expected: "example",
+		},
+		"legitimate canary with a different service": {
+			canary: newTestMCI("example-canary", "example.com", "/", "http-svc-canary", true),
+			mcis: []*ingress.MultiClusterIngress{
+				newTestMCI("example", "example.com", "/", "http-svc", false),
+			},
+		},
+		"canary with a different path": {
+			canary: newTestMCI("example-canary", "example.com", "/api", "http-svc", true),
+			mcis: []*ingress.MultiClusterIngress{
+				newTestMCI("example", "example.com", "/", "http-svc", false),
+			},
+		},
+		"non canary multiclusteringress": {
+			canary: newTestMCI("example-2", "example.com", "/", "http-svc", false),
+			mcis: []*ingress.MultiClusterIngress{
+				newTestMCI("example", "example.com", "/", "http-svc", false),
+			},
+		},
+	}
+
+	for title, tc := range testCases {
+		t.Run(title, func(t *testing.T) {
+			primary := selfCanaryMCI(tc.canary, tc.mcis)
+			if tc.expected == "" {
+				if primary != nil {
+					t.Errorf("expected no self canary match but got %v", primary.Name)
+				}
+				return
+			}
+
+			if primary == nil {
+				t.Fatalf("expected self canary match with %v but got none", tc.expected)
+			}
+
+			if primary.Name != tc.expected {
+				t.Errorf("expected self canary match with %v but got %v", tc.expected, primary.Name)
+			}
+		})
+	}
+}
+
+func TestStreamConfiguration(t *testing.T) {
+	mci := newTestMCI("example", "example.com", "/", "http-svc", false)
+	mci.ParsedAnnotations.StreamSnippet = "server { listen 8000; proxy_pass 127.0.0.1:80; }"
+
+	service := func(name string, proto corev1.Protocol) *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "example",
+			},
+			Spec: corev1.ServiceSpec{
+				Ports: []corev1.ServicePort{
+					{
+						Port:     53,
+						Protocol: proto,
+					},
+				},
+			},
+		}
+	}
+
+	endpoints := func(proto corev1.Protocol) *corev1.Endpoints {
+		return &corev1.Endpoints{
+			Subsets: []corev1.EndpointSubset{
+				{
+					Addresses: []corev1.EndpointAddress{{IP: "10.0.0.1"}},
+					Ports:     []corev1.EndpointPort{{Port: 53, Protocol: proto}},
+				},
+			},
+		}
+	}
+
+	nginx := &NGINXController{
+		cfg: &Configuration{
+			TCPConfigMapName: "example/tcp",
+			UDPConfigMapName: "example/udp",
+			ListenPorts: &ngx_config.ListenPorts{
+				Default: 80,
+			},
+		},
+		store: fakeMCIStore{
+			mcis: []*ingress.MultiClusterIngress{mci},
+			configMaps: map[string]*corev1.ConfigMap{
+				"example/tcp": {Data: map[string]string{"5353": "example/dns-tcp:53"}},
+				"example/udp": {Data: map[string]string{"5353": "example/dns-udp:53"}},
+			},
+			services: map[string]*corev1.Service{
+				"example/dns-tcp": service("dns-tcp", corev1.ProtocolTCP),
+				"example/dns-udp": service("dns-udp", corev1.ProtocolUDP),
+			},
+			endpoints: map[string]*corev1.Endpoints{
+				"example/dns-tcp": endpoints(corev1.ProtocolTCP),
+				"example/dns-udp": endpoints(corev1.ProtocolUDP),
+			},
+		},
+	}
+
+	cfg, err := nginx.StreamConfiguration()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(cfg.TCPEndpoints) != 1 || cfg.TCPEndpoints[0].Port != 5353 {
+		t.Errorf("expected one TCP endpoint on port 5353 but got %v", cfg.TCPEndpoints)
+	}
+
+	if len(cfg.UDPEndpoints) != 1 || cfg.UDPEndpoints[0].Port != 5353 {
+		t.Errorf("expected one UDP endpoint on port 5353 but got %v", cfg.UDPEndpoints)
+	}
+
+	if len(cfg.StreamSnippets) != 1 || cfg.StreamSnippets[0] != mci.ParsedAnnotations.StreamSnippet {
+		t.Errorf("expected stream snippet %q but got %v", mci.ParsedAnnotations.StreamSnippet, cfg.StreamSnippets)
+	}
+
+	if len(cfg.Servers) != 0 || len(cfg.Backends) != 0 {
+		t.Errorf("expected no HTTP servers or backends but got %v servers and %v backends", len(cfg.Servers), len(cfg.Backends))
+	}
+
+	var uninitialized *NGINXController
+	if _, err := uninitialized.StreamConfiguration(); err == nil {
+		t.Errorf("expected an error with an uninitialized controller")
+	}
+}
+
+func TestStreamServicesFromMCIs(t *testing.T) {
+	service := func(name string, proto corev1.Protocol) *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "example",
+			},
+			Spec: corev1.ServiceSpec{
+				Ports: []corev1.ServicePort{
+					{
+						Port:     53,
+						Protocol: proto,
+					},
+				},
+			},
+		}
+	}
+
+	endpoints := func(proto corev1.Protocol) *corev1.Endpoints {
+		return &corev1.Endpoints{
+			Subsets: []corev1.EndpointSubset{
+				{
+					Addresses: []corev1.EndpointAddress{{IP: "10.0.0.1"}},
+					Ports:     []corev1.EndpointPort{{Port: 53, Protocol: proto}},
+				},
+			},
+		}
+	}
+
+	older := newTestMCI("older", "older.example.com", "/", "http-svc", false)
+	older.ParsedAnnotations.StreamServices = streamservices.Config{
+		TCP: map[int]string{
+			5353: "example/dns-tcp:53",
+			9000: "example/dns-tcp:53",
+		},
+		UDP: map[int]string{
+			9000: "example/dns-udp:53",
+		},
+	}
+	newer := newTestMCI("newer", "newer.example.com", "/", "http-svc", false)
+	newer.ParsedAnnotations.StreamServices = streamservices.Config{
+		TCP: map[int]string{
+			9000: "example/other-tcp:53",
+			9001: "example/other-tcp:53",
+		},
+	}
+
+	recorder := record.NewFakeRecorder(10)
+	nginx := &NGINXController{
+		cfg: &Configuration{
+			TCPConfigMapName: "example/tcp",
+			UDPConfigMapName: "example/udp",
+			ListenPorts: &ngx_config.ListenPorts{
+				Default: 80,
+			},
+		},
+		recorder: recorder,
+		store: fakeMCIStore{
+			mcis: []*ingress.MultiClusterIngress{older, newer},
+			configMaps: map[string]*corev1.ConfigMap{
+				"example/tcp": {Data: map[string]string{"5353": "example/dns-tcp:53"}},
+				"example/udp": {Data: map[string]string{}},
+			},
+			services: map[string]*corev1.Service{
+				"example/dns-tcp":   service("dns-tcp", corev1.ProtocolTCP),
+				"example/dns-udp":   service("dns-udp", corev1.ProtocolUDP),
+				"example/other-tcp": service("other-tcp", corev1.ProtocolTCP),
+			},
+			endpoints: map[string]*corev1.Endpoints{
+				"example/dns-tcp":   endpoints(corev1.ProtocolTCP),
+				"example/dns-udp":   endpoints(corev1.ProtocolUDP),
+				"example/other-tcp": endpoints(corev1.ProtocolTCP),
+			},
+		},
+	}
+
+	cfg, err := nginx.StreamConfiguration()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedTCP := map[int]string{
+		5353: "dns-tcp",
+		9000: "dns-tcp",
+		9001: "other-tcp",
+	}
+	if len(cfg.TCPEndpoints) != len(expectedTCP) {
+		t.Fatalf("expected %v TCP endpoints but got %v", len(expectedTCP), cfg.TCPEndpoints)
+	}
+	for i, svc := range cfg.TCPEndpoints {
+		if i > 0 && cfg.TCPEndpoints[i-1].Port >= svc.Port {
+			t.Errorf("expected the TCP endpoints sorted by port but got %v", cfg.TCPEndpoints)
+		}
+		if expectedTCP[svc.Port] != svc.Backend.Name {
+			t.Errorf("expected service %q on TCP port %v but got %q", expectedTCP[svc.Port], svc.Port, svc.Backend.Name)
+		}
+	}
+
+	if len(cfg.UDPEndpoints) != 1 || cfg.UDPEndpoints[0].Port != 9000 || cfg.UDPEndpoints[0].Backend.Name != "dns-udp" {
+		t.Errorf("expected service dns-udp on UDP port 9000 but got %v", cfg.UDPEndpoints)
+	}
+
+	events := []string{}
+	for len(recorder.Events) > 0 {
+		events = append(events, <-recorder.Events)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected two port conflicts but got %v", events)
+	}
+	if !strings.Contains(events[0], "StreamServiceConflict TCP port 5353 is already used by ConfigMap example/tcp") {
+		t.Errorf("expected a conflict with the ConfigMap but got %q", events[0])
+	}
+	if !strings.Contains(events[1], "StreamServiceConflict TCP port 9000 is already used by multiclusteringress example/older") {
+		t.Errorf("expected a conflict with the older multiclusteringress but got %q", events[1])
+	}
+}
+
+func TestSortLocationsTiebreak(t *testing.T) {
+	testCases := map[string]struct {
+		tiebreak string
+		expected []string
+	}{
+		"reverse tiebreak": {
+			tiebreak: LocationTiebreakReverse,
+			expected: []string{"/longer", "/bar", "/abc", "/"},
+		},
+		"forward tiebreak": {
+			tiebreak: LocationTiebreakForward,
+			expected: []string{"/longer", "/abc", "/bar", "/"},
+		},
+		"default tiebreak": {
+			expected: []string{"/longer", "/bar", "/abc", "/"},
+		},
+	}
+
+	for title, tc := range testCases {
+		t.Run(title, func(t *testing.T) {
+			locations := []*ingress.Location{
+				{Path: "/"},
+				{Path: "/abc"},
+				{Path: "/longer"},
+				{Path: "/bar"},
+			}
+
+			sortLocations(locations, tc.tiebreak)
+
+			for i, location := range locations {
+				if location.Path != tc.expected[i] {
+					t.Errorf("expected location %v at position %v but got %v", tc.expected[i], i, location.Path)
+				}
+			}
+		})
+	}
+}
+
+func TestMergeAlternativeBackendsByMCINilPathType(t *testing.T) {
+	mci := newTestMCI("example-canary", "example.com", "/", "http-svc-canary", true)
+	mci.Spec.Rules[0].HTTP.Paths[0].PathType = nil
+
+	upstreams := map[string]*ingress.Backend{
+		"example-http-svc-80": {
+			Name:     "example-http-svc-80",
+			NoServer: false,
+		},
+		"example-http-svc-canary-80": {
+			Name:     "example-http-svc-canary-80",
+			NoServer: true,
+		},
+	}
+	servers := map[string]*ingress.Server{
+		"example.com": {
+			Hostname: "example.com",
+			Locations: []*ingress.Location{
+				{
+					Path:     "/",
+					PathType: &pathTypePrefix,
+					Backend:  "example-http-svc-80",
+				},
+			},
+		},
+	}
+
+	mergeAlternativeBackendsByMCI(mci, upstreams, servers)
+
+	primary := upstreams["example-http-svc-80"]
+	if len(primary.AlternativeBackends) != 1 || primary.AlternativeBackends[0] != "example-http-svc-canary-80" {
+		t.Errorf("expected alternative backend example-http-svc-canary-80 but got %v", primary.AlternativeBackends)
+	}
+}
+
+func TestExternalDefaultBackendURL(t *testing.T) {
+	mci := newTestMCI("example", "example.com", "/", "http-svc", false)
+	mci.ParsedAnnotations.DefaultBackend = &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example-default-backend-url",
+			Namespace: "example",
+			Annotations: map[string]string{
+				defaultbackend.ExternalURLAnnotation: "https://maintenance.example.org",
+			},
+		},
+		Spec: corev1.ServiceSpec{
+			Type:         corev1.ServiceTypeExternalName,
+			ExternalName: "maintenance.example.org",
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "https",
+					Port:       443,
+					TargetPort: intstr.FromInt(443),
+				},
+			},
+		},
+	}
+
+	nginx := &NGINXController{
+		cfg: &Configuration{
+			ListenPorts: &ngx_config.ListenPorts{
+				Default: 80,
+			},
+		},
+		store: fakeMCIStore{
+			mcis: []*ingress.MultiClusterIngress{mci},
+		},
+	}
+
+	upstreams, servers, _ := nginx.getBackendServersFromMCIs([]*ingress.MultiClusterIngress{mci})
+
+	var external *ingress.Backend
+	for _, upstream := range upstreams {
+		if upstream.Name == "custom-default-backend-example-example-default-backend-url" {
+			external = upstream
+		}
+	}
+
+	if external == nil {
+		t.Fatalf("expected an upstream for the external default backend")
+	}
+
+	if len(external.Endpoints) != 1 || external.Endpoints[0].Address != "maintenance.example.org" || external.Endpoints[0].Port != "443" {
+		t.Errorf("expected endpoint maintenance.example.org:443 but got %v", external.Endpoints)
+	}
+
+	var location *ingress.Location
+	for _, server := range servers {
+		if server.Hostname == "example.com" {
+			location = server.Locations[0]
+		}
+	}
+
+	if location == nil {
+		t.Fatalf("expected a location for server example.com")
+	}
+
+	if location.Backend != external.Name {
+		t.Errorf("expected location backend %v but got %v", external.Name, location.Backend)
+	}
+
+	if location.BackendProtocol != "HTTPS" {
+		t.Errorf("expected backend protocol HTTPS but got %v", location.BackendProtocol)
+	}
+
+	if location.UpstreamVhost != "maintenance.example.org" {
+		t.Errorf("expected upstream vhost maintenance.example.org but got %v", location.UpstreamVhost)
+	}
+
+	if location.ProxySSL.ProxySSLServerName != "on" {
+		t.Errorf("expected proxy_ssl_server_name on but got %v", location.ProxySSL.ProxySSLServerName)
+	}
+}
+
+type canaryMergeFailureCollector struct {
+	metric.DummyCollector
+	failures map[string]int
+}
+
+func (c *canaryMergeFailureCollector) IncCanaryMergeFailureCount(namespace, name string) {
+	c.failures[fmt.Sprintf("%v/%v", namespace, name)]++
+}
+
+func TestMergeAlternativeBackendsByMCIFailureMetric(t *testing.T) {
+	mci := newTestMCI("example-canary", "example.com", "/", "http-svc-canary", true)
+
+	upstreams := map[string]*ingress.Backend{
+		"example-http-svc-canary-80": {
+			Name:     "example-http-svc-canary-80",
+			NoServer: true,
+		},
+	}
+	servers := map[string]*ingress.Server{
+		"example.com": {
+			Hostname:  "example.com",
+			Locations: []*ingress.Location{},
+		},
+	}
+
+	mc := &canaryMergeFailureCollector{failures: map[string]int{}}
+	notices := mergeAlternativeBackendsByMCI(mci, upstreams, servers)
+	if len(mc.failures) != 0 {
+		t.Fatalf("expected the merge to count no failure but got %v", mc.failures)
+	}
+
+	nginx := &NGINXController{metricCollector: mc}
+	nginx.reportMCINotices(notices)
+	nginx.reportMCINotices(notices)
+
+	if _, ok := upstreams["example-http-svc-canary-80"]; ok {
+		t.Errorf("expected dangling canary upstream to be deleted")
+	}
+
+	if mc.failures["example/example-canary"] != 1 {
+		t.Errorf("expected one canary merge failure for example/example-canary but got %v", mc.failures)
+	}
+}
+
+func TestAffinityCookiePaths(t *testing.T) {
+	mci := newTestMCI("example", "example.com", "/", "http-svc", false)
+	paths := &mci.Spec.Rules[0].HTTP.Paths
+	*paths = append(*paths, (*paths)[0], (*paths)[0])
+	(*paths)[1].Path = "/api"
+	(*paths)[2].Path = "/static"
+
+	mci.ParsedAnnotations.SessionAffinity = sessionaffinity.Config{
+		Type: "cookie",
+		Cookie: sessionaffinity.Cookie{
+			Name:  "route",
+			Paths: []string{"/", "/api"},
+		},
+	}
+
+	nginx := &NGINXController{
+		cfg: &Configuration{
+			ListenPorts: &ngx_config.ListenPorts{
+				Default: 80,
+			},
+		},
+		store: fakeMCIStore{
+			mcis: []*ingress.MultiClusterIngress{mci},
+		},
+	}
+
+	upstreams, _, _ := nginx.getBackendServersFromMCIs([]*ingress.MultiClusterIngress{mci})
+
+	var upstream *ingress.Backend
+	for _, u := range upstreams {
+		if u.Name == "example-http-svc-80" {
+			upstream = u
+		}
+	}
+
+	if upstream == nil {
+		t.Fatalf("expected upstream example-http-svc-80")
+	}
+
+	expected := map[string][]string{
+		"example.com": {"/", "/api"},
+	}
+	if !reflect.DeepEqual(upstream.SessionAffinity.CookieSessionAffinity.Locations, expected) {
+		t.Errorf("expected affinity locations %v but got %v", expected, upstream.SessionAffinity.CookieSessionAffinity.Locations)
+	}
+
+	if upstream.SessionAffinity.CookieSessionAffinity.Name != "route" {
+		t.Errorf("expected affinity cookie route but got %v", upstream.SessionAffinity.CookieSessionAffinity.Name)
+	}
+}
+
+func TestAffinityLocationsForAliases(t *testing.T) {
+	mci := newTestMCI("example", "example.com", "/", "http-svc", false)
+	paths := &mci.Spec.Rules[0].HTTP.Paths
+	*paths = append(*paths, (*paths)[0])
+	(*paths)[1].Path = "/api"
+
+	mci.ParsedAnnotations.Aliases = []string{"www.example.com", "example.org"}
+	mci.ParsedAnnotations.SessionAffinity = sessionaffinity.Config{
+		Type: "cookie",
+		Cookie: sessionaffinity.Cookie{
+			Name: "route",
+		},
+	}
+
+	nginx := &NGINXController{
+		cfg: &Configuration{
+			ListenPorts: &ngx_config.ListenPorts{
+				Default: 80,
+			},
+		},
+		store: fakeMCIStore{
+			mcis: []*ingress.MultiClusterIngress{mci},
+		},
+	}
+
+	upstreams, _, _ := nginx.getBackendServersFromMCIs([]*ingress.MultiClusterIngress{mci})
+
+	var upstream *ingress.Backend
+	for _, u := range upstreams {
+		if u.Name == "example-http-svc-80" {
+			upstream = u
+		}
+	}
+
+	if upstream == nil {
+		t.Fatalf("expected upstream example-http-svc-80")
+	}
+
+	expected := map[string][]string{
+		"example.com":     {"/", "/api"},
+		"www.example.com": {"/", "/api"},
+		"example.org":     {"/", "/api"},
+	}
+	if !reflect.DeepEqual(upstream.SessionAffinity.CookieSessionAffinity.Locations, expected) {
+		t.Errorf("expected affinity locations %v but got %v", expected, upstream.SessionAffinity.CookieSessionAffinity.Locations)
+	}
+}
+
+func TestExplodeAliases(t *testing.T) {
+	testCases := []struct {
+		name     string
+		explode  bool
+		expected map[string][]string
+	}{
+		{"folded by default", false, map[string][]string{
+			"_":           nil,
+			"example.com": {"example.org", "www.example.com"},
+		}},
+		{"server per alias", true, map[string][]string{
+			"_":               nil,
+			"example.com":     nil,
+			"example.org":     nil,
+			"www.example.com": nil,
+		}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mci := newTestMCI("example", "example.com", "/", "http-svc", false)
+			mci.ParsedAnnotations.Aliases = []string{"www.example.com", "example.org"}
+
+			nginx := &NGINXController{
+				cfg: &Configuration{
+					ListenPorts: &ngx_config.ListenPorts{
+						Default: 80,
+					},
+					ExplodeAliases: tc.explode,
+				},
+				store: fakeMCIStore{
+					mcis: []*ingress.MultiClusterIngress{mci},
+				},
+			}
+
+			_, servers, _ := nginx.getBackendServersFromMCIs([]*ingress.MultiClusterIngress{mci})
+
+			aliases := make(map[string][]string, len(servers))
+			for _, server := range servers {
+				aliases[server.Hostname] = server.Aliases
+
+				if server.Hostname == defServerName {
+					continue
+				}
+				if len(server.Locations) != 1 || server.Locations[0].Backend != "example-http-svc-80" {
+					t.Errorf("expected the location of example.com in server %v", server.Hostname)
+				}
+			}
+
+			if !reflect.DeepEqual(aliases, tc.expected) {
+				t.Errorf("expected the servers and aliases %v but got %v", tc.expected, aliases)
+			}
+		})
+	}
+}
+
+func TestCheckMCIOverlapWithExplodedAliases(t *testing.T) {
+	if err := file.CreateRequiredDirectories(); err != nil {
+		t.Fatal(err)
+	}
+
+	existing := newTestMCI("existing", "example.com", "/", "http-svc", false)
+	existing.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix("server-alias"): "www.example.com",
+	})
+	existing.ParsedAnnotations.Aliases = []string{"www.example.com"}
+
+	nginx := newNGINXController(t)
+	nginx.metricCollector = metric.DummyCollector{}
+	nginx.t = fakeTemplate{}
+	nginx.store = fakeMCIStore{
+		mcis: []*ingress.MultiClusterIngress{existing},
+	}
+	nginx.cfg.ExplodeAliases = true
+	nginx.command = testNginxTestCommand{
+		t:        t,
+		expected: "_,example.com,www.example.com",
+	}
+
+	mci := newTestMCI("example", "example.com", "/", "other-svc", false)
+	err := nginx.CheckMCI(&mci.MultiClusterIngress)
+	if err == nil || !strings.Contains(err.Error(), "already defined") {
+		t.Errorf("expected the path of example.com to overlap but got %v", err)
+	}
+}
+
+func TestCheckMCIInvalidSSLCiphers(t *testing.T) {
+	nginx := &NGINXController{
+		cfg:             &Configuration{},
+		store:           fakeMCIStore{},
+		metricCollector: metric.DummyCollector{},
+	}
+
+	mci := newTestMCI("example", "example.com", "/", "http-svc", false)
+	mci.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix("ssl-ciphers"): "HIGH:!aNULL; ssl_protocols SSLv3",
+	})
+
+	if err := nginx.CheckMCI(&mci.MultiClusterIngress); err == nil {
+		t.Errorf("expected an error with an invalid ssl-ciphers annotation")
+	}
+}
+
+func TestServiceUnavailableOnEmptyUpstream(t *testing.T) {
+	testCases := []struct {
+		name            string
+		enabled         bool
+		expectedDenied  bool
+		expectedBackend string
+	}{
+		{"fallback to custom default backend", false, false, "custom-default-backend-example-maintenance"},
+		{"service unavailable", true, true, "example-http-svc-80"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mci := newTestMCI("example", "example.com", "/", "http-svc", false)
+			mci.ParsedAnnotations.ServiceUnavailableOnEmptyUpstream = tc.enabled
+			mci.ParsedAnnotations.DefaultBackend = &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "maintenance",
+					Namespace: "example",
+				},
+				Spec: corev1.ServiceSpec{
+					Type:         corev1.ServiceTypeExternalName,
+					ExternalName: "maintenance.example.org",
+					Ports: []corev1.ServicePort{
+						{
+							Port:       80,
+							TargetPort: intstr.FromInt(80),
+						},
+					},
+				},
+			}
+
+			nginx := &NGINXController{
+				cfg: &Configuration{
+					ListenPorts: &ngx_config.ListenPorts{
+						Default: 80,
+					},
+				},
+				store: fakeMCIStore{
+					mcis: []*ingress.MultiClusterIngress{mci},
+				},
+			}
+
+			_, servers, _ := nginx.getBackendServersFromMCIs([]*ingress.MultiClusterIngress{mci})
+
+			var location *ingress.Location
+			for _, server := range servers {
+				if server.Hostname == "example.com" {
+					location = server.Locations[0]
+				}
+			}
+
+			if location == nil {
+				t.Fatalf("expected a location for server example.com")
+			}
+
+			if (location.Denied != nil) != tc.expectedDenied {
+				t.Errorf("expected denied %v but got %v", tc.expectedDenied, location.Denied)
+			}
+
+			if location.Backend != tc.expectedBackend {
+				t.Errorf("expected location backend %v but got %v", tc.expectedBackend, location.Backend)
+			}
+		})
+	}
+}
+
+func TestMCIMaintenanceMode(t *testing.T) {
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "derived-http-svc",
+			Namespace: "example",
+		},
+		Spec: corev1.ServiceSpec{
+			ClusterIP: "10.0.0.1",
+			Ports: []corev1.ServicePort{
+				{Port: 80, TargetPort: intstr.FromInt(8080)},
+			},
+		},
+	}
+
+	maintenanceBackend := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "maintenance",
+			Namespace: "example",
+		},
+		Spec: corev1.ServiceSpec{
+			Type:         corev1.ServiceTypeExternalName,
+			ExternalName: "maintenance.example.org",
+			Ports: []corev1.ServicePort{
+				{
+					Port:       80,
+					TargetPort: intstr.FromInt(80),
+				},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name            string
+		enabled         bool
+		defaultBackend  *corev1.Service
+		expectedDenied  bool
+		expectedBackend string
+	}{
+		{"disabled", false, nil, false, "example-http-svc-80"},
+		{"disabled with custom default backend", false, maintenanceBackend, false, "example-http-svc-80"},
+		{"enabled", true, nil, true, "example-http-svc-80"},
+		{"enabled with custom default backend", true, maintenanceBackend, false, "custom-default-backend-example-maintenance"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mci := newTestMCI("example", "example.com", "/", "http-svc", false)
+			mci.ParsedAnnotations.ServiceUpstream = true
+			mci.ParsedAnnotations.MaintenanceMode = tc.enabled
+			mci.ParsedAnnotations.DefaultBackend = tc.defaultBackend
+
+			recorder := record.NewFakeRecorder(2)
+			nginx := &NGINXController{
+				cfg: &Configuration{
+					ListenPorts: &ngx_config.ListenPorts{
+						Default: 80,
+					},
+				},
+				store: fakeMCIStore{
+					mcis:     []*ingress.MultiClusterIngress{mci},
+					services: map[string]*corev1.Service{"example/derived-http-svc": service},
+				},
+				recorder: recorder,
+			}
+
+			upstreams, servers, notices := nginx.getBackendServersFromMCIs([]*ingress.MultiClusterIngress{mci})
+			if len(recorder.Events) != 0 {
+				t.Fatalf("expected the build to record no event")
+			}
+
+			for _, upstream := range upstreams {
+				if upstream.Name == "example-http-svc-80" && len(upstream.Endpoints) == 0 {
+					t.Errorf("expected the upstream %v to have endpoints", upstream.Name)
+				}
+			}
+
+			for _, server := range servers {
+				if server.Hostname != "example.com" {
+					continue
+				}
+
+				for _, location := range server.Locations {
+					if (location.Denied != nil) != tc.expectedDenied {
+						t.Errorf("expected denied %v for location %v but got %v", tc.expectedDenied, location.Path, location.Denied)
+					}
+
+					if location.IsDefBackend {
+						continue
+					}
+
+					if location.Backend != tc.expectedBackend {
+						t.Errorf("expected location backend %v but got %v", tc.expectedBackend, location.Backend)
+					}
+				}
+			}
+
+			// the next sync of the same multiclusteringress records no event
+			nginx.reportMCINotices(notices)
+			nginx.reportMCINotices(notices)
+
+			expectedEvents := 0
+			if tc.enabled {
+				expectedEvents = 1
+			}
+			if len(recorder.Events) != expectedEvents {
+				t.Errorf("expected %v maintenance mode events but got %v", expectedEvents, len(recorder.Events))
+			}
+		})
+	}
+}
+
+func TestMCIDisabled(t *testing.T) {
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "derived-http-svc",
+			Namespace: "example",
+		},
+		Spec: corev1.ServiceSpec{
+			ClusterIP: "10.0.0.1",
+			Ports: []corev1.ServicePort{
+				{Port: 80, TargetPort: intstr.FromInt(8080)},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name     string
+		disabled bool
+	}{
+		{"enabled", false},
+		{"disabled", true},
+		{"re-enabled", false},
+	}
+
+	// the same multiclusteringress goes through the cases in order
+	mci := newTestMCI("example", "example.com", "/", "http-svc", false)
+	mci.ParsedAnnotations.ServiceUpstream = true
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mci.ParsedAnnotations.Disabled = tc.disabled
+
+			recorder := record.NewFakeRecorder(2)
+			nginx := &NGINXController{
+				cfg: &Configuration{
+					ListenPorts: &ngx_config.ListenPorts{
+						Default: 80,
+					},
+				},
+				store: fakeMCIStore{
+					mcis:     []*ingress.MultiClusterIngress{mci},
+					services: map[string]*corev1.Service{"example/derived-http-svc": service},
+				},
+				recorder: recorder,
+			}
+
+			upstreams, servers, notices := nginx.getBackendServersFromMCIs([]*ingress.MultiClusterIngress{mci})
+			if len(recorder.Events) != 0 {
+				t.Fatalf("expected the build to record no event")
+			}
+
+			foundUpstream := false
+			for _, upstream := range upstreams {
+				if upstream.Name == "example-http-svc-80" {
+					foundUpstream = true
+				}
+			}
+			if foundUpstream == tc.disabled {
+				t.Errorf("expected upstream example-http-svc-80 present %v but got %v", !tc.disabled, foundUpstream)
+			}
+
+			foundServer := false
+			for _, server := range servers {
+				if server.Hostname == "example.com" {
+					foundServer = true
+				}
+			}
+			if foundServer == tc.disabled {
+				t.Errorf("expected server example.com present %v but got %v", !tc.disabled, foundServer)
+			}
+
+			// the next sync of the same disabled multiclusteringress records no event
+			nginx.reportMCINotices(notices)
+			nginx.reportMCINotices(notices)
+
+			expectedEvents := 0
+			if tc.disabled {
+				expectedEvents = 1
+			}
+			if len(recorder.Events) != expectedEvents {
+				t.Errorf("expected %v disabled events but got %v", expectedEvents, len(recorder.Events))
+			}
+		})
+	}
+}
+
+func TestGetStreamSnippetsFromMCIs(t *testing.T) {
+	newMCI := func(name, snippet string) *ingress.MultiClusterIngress {
+		mci := newTestMCI(name, name+".example.com", "/", "http-svc", false)
+		mci.ParsedAnnotations.StreamSnippet = snippet
+		return mci
+	}
+
+	udp := "server { listen 8000 udp; proxy_pass 127.0.0.1:9000; }"
+	tcp := "server { listen 8001; proxy_pass 127.0.0.1:9001; }"
+
+	mcis := []*ingress.MultiClusterIngress{
+		newMCI("a", tcp),
+		newMCI("b", udp),
+		newMCI("c", ""),
+		newMCI("d", tcp),
+	}
+
+	nginx := &NGINXController{}
+	expected := []string{udp, tcp}
+
+	if snippets := nginx.getStreamSnippetsFromMCIs(mcis); !reflect.DeepEqual(snippets, expected) {
+		t.Errorf("expected stream snippets %v but got %v", expected, snippets)
+	}
+
+	// the order of the multiclusteringresses must not change the result
+	reversed := []*ingress.MultiClusterIngress{mcis[3], mcis[2], mcis[1], mcis[0]}
+	if snippets := nginx.getStreamSnippetsFromMCIs(reversed); !reflect.DeepEqual(snippets, expected) {
+		t.Errorf("expected stream snippets %v but got %v", expected, snippets)
+	}
+}
+
+func TestWarnMissingBrotliModule(t *testing.T) {
+	moduleErr := fmt.Errorf(`dlopen() "/etc/nginx/modules/ngx_http_brotli_filter_module.so" failed`)
+
+	testCases := []struct {
+		name          string
+		enabled       bool
+		err           error
+		expectedEvent bool
+	}{
+		{"brotli enabled and module missing", true, moduleErr, true},
+		{"brotli disabled and module missing", false, moduleErr, false},
+		{"brotli enabled and unrelated error", true, fmt.Errorf(`unknown directive "foo"`), false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(1)
+			nginx := &NGINXController{recorder: recorder}
+
+			mci := newTestMCI("example", "example.com", "/", "http-svc", false)
+			mci.ParsedAnnotations.Brotli.Enabled = tc.enabled
+
+			nginx.warnMissingBrotliModule(mci, tc.err)
+
+			select {
+			case event := <-recorder.Events:
+				if !tc.expectedEvent {
+					t.Errorf("unexpected event %q", event)
+				}
+			default:
+				if tc.expectedEvent {
+					t.Errorf("expected a warning event")
+				}
+			}
+		})
+	}
+}
+
+func TestWarnGeoIPUnavailable(t *testing.T) {
+	testCases := []struct {
+		name          string
+		useGeoIP      bool
+		useGeoIP2     bool
+		editionFiles  []string
+		expectedEvent bool
+	}{
+		{"legacy GeoIP database", true, false, nil, false},
+		{"GeoIP2 country database", false, true, []string{"GeoLite2-Country.mmdb"}, false},
+		{"GeoIP2 without country database", false, true, []string{"GeoLite2-ASN.mmdb"}, true},
+		{"GeoIP disabled", false, false, nil, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mci := newTestMCI("example", "example.com", "/", "http-svc", false)
+			mci.ParsedAnnotations.GeoIPAccess.DenyCountries = []string{"CN"}
+
+			recorder := record.NewFakeRecorder(2)
+			nginx := &NGINXController{
+				cfg: &Configuration{
+					ListenPorts: &ngx_config.ListenPorts{
+						Default: 80,
+					},
+					MaxmindEditionFiles: &tc.editionFiles,
+				},
+				store: fakeMCIStore{
+					fakeIngressStore: fakeIngressStore{
+						configuration: ngx_config.Configuration{UseGeoIP: tc.useGeoIP, UseGeoIP2: tc.useGeoIP2},
+					},
+					mcis: []*ingress.MultiClusterIngress{mci},
+				},
+				recorder: recorder,
+			}
+
+			_, _, notices := nginx.getBackendServersFromMCIs([]*ingress.MultiClusterIngress{mci})
+			if len(recorder.Events) != 0 {
+				t.Fatalf("expected the build to record no event")
+			}
+
+			// the next sync of the same multiclusteringress records no event
+			nginx.reportMCINotices(notices)
+			nginx.reportMCINotices(notices)
+
+			expectedEvents := 0
+			if tc.expectedEvent {
+				expectedEvents = 1
+			}
+			if len(recorder.Events) != expectedEvents {
+				t.Errorf("expected %v warning events but got %v", expectedEvents, len(recorder.Events))
+			}
+		})
+	}
+}
+
+func TestCheckMCIInvalidGeoIPCountries(t *testing.T) {
+	nginx := &NGINXController{
+		cfg:             &Configuration{},
+		store:           fakeMCIStore{},
+		metricCollector: metric.DummyCollector{},
+	}
+
+	mci := newTestMCI("example", "example.com", "/", "http-svc", false)
+	mci.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix("geoip-allow-countries"): "US,XX",
+	})
+
+	if err := nginx.CheckMCI(&mci.MultiClusterIngress); err == nil {
+		t.Errorf("expected an error with an invalid geoip-allow-countries annotation")
+	}
+}
+
+func TestServedHosts(t *testing.T) {
+	nginx := &NGINXController{
+		runningConfig: &ingress.Configuration{
+			Servers: []*ingress.Server{
+				{Hostname: defServerName},
+				{Hostname: "foo.example.com", Aliases: []string{"www.foo.example.com"}},
+				{Hostname: "bar.example.com", Aliases: []string{"foo.example.com"}},
+			},
+		},
+	}
+
+	expected := []string{"bar.example.com", "foo.example.com", "www.foo.example.com"}
+	if hosts := nginx.ServedHosts(); !reflect.DeepEqual(hosts, expected) {
+		t.Errorf("expected hosts %v but returned %v", expected, hosts)
+	}
+}
+
+func TestExportServers(t *testing.T) {
+	pathExact := networking.PathTypeExact
+	pathPrefix := networking.PathTypePrefix
+
+	service := func(name string) *corev1.Service {
+		return &corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "example", Name: name}}
+	}
+
+	servers := func() []*ingress.Server {
+		return []*ingress.Server{
+			{
+				Hostname: defServerName,
+				Locations: []*ingress.Location{
+					{Path: "/", PathType: &pathPrefix, Backend: defUpstreamName, IsDefBackend: true},
+				},
+			},
+			{
+				Hostname: "foo.example.com",
+				Aliases:  []string{"www.foo.example.com", "foo.example.org"},
+				Locations: []*ingress.Location{
+					{Path: "/", PathType: &pathPrefix, Backend: "example-foo-80", Service: service("foo"), Port: intstr.FromInt(80)},
+					{Path: "/api", PathType: &pathPrefix, Backend: "example-api-http", Service: service("api"), Port: intstr.FromString("http")},
+					{Path: "/api", PathType: &pathExact, Backend: "example-api-http", Service: service("api"), Port: intstr.FromString("http")},
+				},
+			},
+			{
+				Hostname: "bar.example.com",
+				Locations: []*ingress.Location{
+					{Path: "/", PathType: &pathPrefix, Backend: "example-bar-8080", Service: service("bar"), Port: intstr.FromInt(8080)},
+				},
+			},
+		}
+	}
+
+	expected := `[
+  {
+    "hostname": "_",
+    "locations": [
+      {
+        "path": "/",
+        "pathType": "Prefix",
+        "backend": "upstream-default-backend"
+      }
+    ]
+  },
+  {
+    "hostname": "bar.example.com",
+    "locations": [
+      {
+        "path": "/",
+        "pathType": "Prefix",
+        "backend": "example-bar-8080",
+        "service": "example/bar",
+        "port": "8080"
+      }
+    ]
+  },
+  {
+    "hostname": "foo.example.com",
+    "aliases": [
+      "foo.example.org",
+      "www.foo.example.com"
+    ],
+    "locations": [
+      {
+        "path": "/",
+        "pathType": "Prefix",
+        "backend": "example-foo-80",
+        "service": "example/foo",
+        "port": "80"
+      },
+      {
+        "path": "/api",
+        "pathType": "Exact",
+        "backend": "example-api-http",
+        "service": "example/api",
+        "port": "http"
+      },
+      {
+        "path": "/api",
+        "pathType": "Prefix",
+        "backend": "example-api-http",
+        "service": "example/api",
+        "port": "http"
+      }
+    ]
+  }
+]`
+
+	nginx := &NGINXController{
+		runningConfig: &ingress.Configuration{
+			Servers: servers(),
+		},
+	}
+
+	out, err := nginx.ExportServers()
+	if err != nil {
+		t.Fatalf("unexpected error exporting servers: %v", err)
+	}
+
+	if string(out) != expected {
+		t.Errorf("expected servers\n%v\nbut returned\n%v", expected, string(out))
+	}
+
+	shuffled := servers()
+	for i, j := 0, len(shuffled)-1; i < j; i, j = i+1, j-1 {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	}
+	for _, server := range shuffled {
+		locations := server.Locations
+		for i, j := 0, len(locations)-1; i < j; i, j = i+1, j-1 {
+			locations[i], locations[j] = locations[j], locations[i]
+		}
+	}
+
+	shuffledOut, err := exportServers(shuffled)
+	if err != nil {
+		t.Fatalf("unexpected error exporting servers: %v", err)
+	}
+
+	if string(shuffledOut) != string(out) {
+		t.Errorf("expected the same output for the shuffled servers but returned\n%v", string(shuffledOut))
+	}
+}
+
+func TestCheckMCIInvalidSSLProtocols(t *testing.T) {
+	nginx := &NGINXController{
+		cfg:             &Configuration{},
+		store:           fakeMCIStore{},
+		metricCollector: metric.DummyCollector{},
+	}
+
+	mci := newTestMCI("example", "example.com", "/", "http-svc", false)
+	mci.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix("ssl-protocols"): "TLSv1.3 SSLv3",
+	})
+
+	if err := nginx.CheckMCI(&mci.MultiClusterIngress); err == nil {
+		t.Errorf("expected an error with an invalid ssl-protocols annotation")
+	}
+}
+
+func TestCheckMCIInvalidAccessLogSampleRate(t *testing.T) {
+	nginx := &NGINXController{
+		cfg:             &Configuration{},
+		store:           fakeMCIStore{},
+		metricCollector: metric.DummyCollector{},
+	}
+
+	mci := newTestMCI("example", "example.com", "/", "http-svc", false)
+	mci.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix("access-log-sample-rate"): "1.5",
+	})
+
+	if err := nginx.CheckMCI(&mci.MultiClusterIngress); err == nil {
+		t.Errorf("expected an error with an invalid access-log-sample-rate annotation")
+	}
+}
+
+func TestCheckMCIInvalidSubFilter(t *testing.T) {
+	nginx := &NGINXController{
+		cfg:             &Configuration{},
+		store:           fakeMCIStore{},
+		metricCollector: metric.DummyCollector{},
+	}
+
+	mci := newTestMCI("example", "example.com", "/", "http-svc", false)
+	mci.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix("sub-filter"): "http://old.example.com",
+	})
+
+	if err := nginx.CheckMCI(&mci.MultiClusterIngress); err == nil {
+		t.Errorf("expected an error with a sub-filter annotation without replacement")
+	}
+}
+
+func TestCheckMCIInvalidClientBodyInFileOnly(t *testing.T) {
+	nginx := &NGINXController{
+		cfg:             &Configuration{},
+		store:           fakeMCIStore{},
+		metricCollector: metric.DummyCollector{},
+	}
+
+	mci := newTestMCI("example", "example.com", "/", "http-svc", false)
+	mci.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix("client-body-in-file-only"): "always",
+	})
+
+	if err := nginx.CheckMCI(&mci.MultiClusterIngress); err == nil {
+		t.Errorf("expected an error with an invalid client-body-in-file-only annotation")
+	}
+}
+
+func TestSSLProtocolsByMCI(t *testing.T) {
+	mci := newTestMCI("example", "example.com", "/", "http-svc", false)
+	mci.ParsedAnnotations.SSLProtocols.SSLProtocols = "TLSv1.3"
+
+	nginx := &NGINXController{
+		cfg: &Configuration{
+			ListenPorts: &ngx_config.ListenPorts{
+				Default: 80,
+			},
+		},
+		store: fakeMCIStore{
+			mcis: []*ingress.MultiClusterIngress{mci},
+		},
+	}
+
+	_, servers, _ := nginx.getBackendServersFromMCIs([]*ingress.MultiClusterIngress{mci})
+
+	for _, server := range servers {
+		expected := ""
+		if server.Hostname == "example.com" {
+			expected = "TLSv1.3"
+		}
+
+		if server.SSLProtocols != expected {
+			t.Errorf("expected ssl protocols %q for server %v but got %q", expected, server.Hostname, server.SSLProtocols)
+		}
+	}
+}
+
+type mtlsConflictCollector struct {
+	metric.DummyCollector
+	conflicts map[string]int
+}
+
+func (c *mtlsConflictCollector) IncMTLSConflictCount(namespace, name string) {
+	c.conflicts[fmt.Sprintf("%v/%v", namespace, name)]++
+}
+
+func TestMCICertificateAuthConflict(t *testing.T) {
+	newCertificateAuthMCI := func(name, path, secret string) *ingress.MultiClusterIngress {
+		mci := newTestMCI(name, "example.com", path, "http-svc", false)
+		mci.ParsedAnnotations.CertificateAuth = authtls.Config{
+			AuthSSLCert: resolver.AuthSSLCert{
+				Secret:     secret,
+				CAFileName: fmt.Sprintf("/etc/ingress-controller/ssl/ca-%v.pem", name),
+			},
+			VerifyClient: "on",
+		}
+		return mci
+	}
+
+	first := newCertificateAuthMCI("first", "/", "example/ca-a")
+	same := newCertificateAuthMCI("same", "/same", "example/ca-a")
+	conflicting := newCertificateAuthMCI("conflicting", "/conflicting", "example/ca-b")
+	conflicting.Spec.Rules = append(conflicting.Spec.Rules, conflicting.Spec.Rules[0])
+	mcis := []*ingress.MultiClusterIngress{first, same, conflicting}
+
+	recorder := record.NewFakeRecorder(10)
+	mc := &mtlsConflictCollector{conflicts: map[string]int{}}
+	nginx := &NGINXController{
+		cfg: &Configuration{
+			ListenPorts: &ngx_config.ListenPorts{
+				Default: 80,
+			},
+		},
+		store: fakeMCIStore{
+			mcis: mcis,
+		},
+		recorder:        recorder,
+		metricCollector: mc,
+	}
+
+	_, servers, notices := nginx.getBackendServersFromMCIs(mcis)
+
+	for _, server := range servers {
+		if server.Hostname == "example.com" && server.CertificateAuth.Secret != "example/ca-a" {
+			t.Errorf("expected the CA secret of the first multiclusteringress but got %q", server.CertificateAuth.Secret)
+		}
+	}
+
+	if len(mc.conflicts) != 0 || len(recorder.Events) != 0 {
+		t.Fatalf("expected the build to report no conflict but got %v and %v events", mc.conflicts, len(recorder.Events))
+	}
+
+	// a sync finding the same conflict again does not report it
+	nginx.reportMCINotices(notices)
+	nginx.reportMCINotices(notices)
+
+	if len(mc.conflicts) != 1 || mc.conflicts["example/conflicting"] != 1 {
+		t.Errorf("expected one conflict for example/conflicting but got %v", mc.conflicts)
+	}
+
+	select {
+	case event := <-recorder.Events:
+		if !strings.Contains(event, "MutualTLSConflict") || !strings.Contains(event, "example/ca-b") {
+			t.Errorf("unexpected event %q", event)
+		}
+	default:
+		t.Errorf("expected a warning event")
+	}
+
+	select {
+	case event := <-recorder.Events:
+		t.Errorf("unexpected event %q", event)
+	default:
+	}
+}
+
+func TestReportMCINotices(t *testing.T) {
+	mci := newTestMCI("example", "example.com", "/", "http-svc", false)
+
+	var reported []string
+	notice := func(details ...string) mciNotice {
+		return newMCINotice(mci, func(n *NGINXController, mci *ingress.MultiClusterIngress) {
+			reported = append(reported, strings.Join(details, "/"))
+		}, details...)
+	}
+
+	nginx := &NGINXController{}
+	syncs := []struct {
+		notices  []mciNotice
+		expected []string
+	}{
+		{[]mciNotice{notice("a"), notice("a"), notice("b")}, []string{"a", "b"}},
+		{[]mciNotice{notice("a"), notice("b")}, nil},
+		{[]mciNotice{notice("b", "c")}, []string{"b/c"}},
+		{[]mciNotice{notice("a"), notice("b", "c")}, []string{"a"}},
+	}
+
+	for i, sync := range syncs {
+		reported = nil
+		nginx.reportMCINotices(sync.notices)
+		if !reflect.DeepEqual(reported, sync.expected) {
+			t.Errorf("expected the sync %v to report %v but got %v", i, sync.expected, reported)
+		}
+	}
+}
+
+func TestMCIProxySSLClientCertificate(t *testing.T) {
+	pemFileName := "/etc/ingress-controller/ssl/example-client-cert.pem"
+
+	withCert := newTestMCI("with-cert", "example.com", "/", "http-svc", false)
+	withCert.ParsedAnnotations.ProxySSL = proxyssl.Config{
+		AuthSSLCert: resolver.AuthSSLCert{
+			Secret:      "example/client-cert",
+			PemFileName: pemFileName,
+		},
+	}
+	withoutCert := newTestMCI("without-cert", "example.com", "/other", "other-svc", false)
+	mcis := []*ingress.MultiClusterIngress{withCert, withoutCert}
+
+	nginx := &NGINXController{
+		cfg: &Configuration{
+			ListenPorts: &ngx_config.ListenPorts{
+				Default: 80,
+			},
+		},
+		store: fakeMCIStore{
+			mcis: mcis,
+		},
+	}
+
+	_, servers, _ := nginx.getBackendServersFromMCIs(mcis)
+
+	for _, server := range servers {
+		if server.Hostname != "example.com" {
+			continue
+		}
+
+		if server.ProxySSL.PemFileName != pemFileName {
+			t.Errorf("expected server client certificate %q but got %q", pemFileName, server.ProxySSL.PemFileName)
+		}
+
+		for _, location := range server.Locations {
+			if location.Path == "/" && location.ProxySSL.PemFileName != pemFileName {
+				t.Errorf("expected location client certificate %q but got %q", pemFileName, location.ProxySSL.PemFileName)
+			}
+		}
+		return
+	}
+
+	t.Errorf("expected a server for example.com")
+}
+
+func TestMCIDebugHeader(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		t.Run(strconv.FormatBool(enabled), func(t *testing.T) {
+			mci := newTestMCI("served", "example.com", "/", "http-svc", false)
+
+			nginx := &NGINXController{
+				cfg: &Configuration{
+					ListenPorts: &ngx_config.ListenPorts{
+						Default: 80,
+					},
+					AddMCIDebugHeader: enabled,
+				},
+				store: fakeMCIStore{
+					mcis: []*ingress.MultiClusterIngress{mci},
+				},
+			}
+
+			_, servers, _ := nginx.getBackendServersFromMCIs([]*ingress.MultiClusterIngress{mci})
+
+			for _, server := range servers {
+				if server.Hostname != "example.com" {
+					continue
+				}
+
+				expected := ""
+				if enabled {
+					expected = "example/served"
+				}
+
+				for _, location := range server.Locations {
+					if location.ServedByMCI != expected {
+						t.Errorf("expected location %v to be served by %q but got %q", location.Path, expected, location.ServedByMCI)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestClientKeepaliveByMCI(t *testing.T) {
+	mci := newTestMCI("example", "example.com", "/", "http-svc", false)
+	mci.ParsedAnnotations.ClientKeepalive = clientkeepalive.Config{Requests: 500, Timeout: 30}
+
+	nginx := &NGINXController{
+		cfg: &Configuration{
+			ListenPorts: &ngx_config.ListenPorts{
+				Default: 80,
+			},
+		},
+		store: fakeMCIStore{
+			mcis: []*ingress.MultiClusterIngress{mci},
+		},
+	}
+
+	_, servers, _ := nginx.getBackendServersFromMCIs([]*ingress.MultiClusterIngress{mci})
+
+	for _, server := range servers {
+		expected := clientkeepalive.Config{}
+		if server.Hostname == "example.com" {
+			expected = clientkeepalive.Config{Requests: 500, Timeout: 30}
+		}
+
+		if !server.ClientKeepalive.Equal(&expected) {
+			t.Errorf("expected client keep-alive %+v for server %v but got %+v", expected, server.Hostname, server.ClientKeepalive)
+		}
+	}
+}
+
+func TestCheckMCIInvalidClientKeepalive(t *testing.T) {
+	nginx := &NGINXController{
+		cfg:             &Configuration{},
+		store:           fakeMCIStore{},
+		metricCollector: metric.DummyCollector{},
+	}
+
+	mci := newTestMCI("example", "example.com", "/", "http-svc", false)
+	mci.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix("client-keepalive-timeout"): "-30s",
+	})
+
+	if err := nginx.CheckMCI(&mci.MultiClusterIngress); err == nil {
+		t.Errorf("expected an error with an invalid client-keepalive-timeout annotation")
+	}
+}
+
+func TestInternalRedirectByMCI(t *testing.T) {
+	mci := newTestMCI("example", "example.com", "/", "http-svc", false)
+	mci.ParsedAnnotations.InternalRedirect = internalredirect.Config{
+		Enabled: true,
+		Locations: []internalredirect.Location{
+			{Path: "/", Target: "http://files.example.svc.cluster.local/"},
+			{Path: "/protected/", Target: "http://files.example.svc.cluster.local/"},
+		},
+	}
+
+	nginx := &NGINXController{
+		cfg: &Configuration{
+			ListenPorts: &ngx_config.ListenPorts{
+				Default: 80,
+			},
+		},
+		store: fakeMCIStore{
+			mcis: []*ingress.MultiClusterIngress{mci},
+		},
+	}
+
+	_, servers, _ := nginx.getBackendServersFromMCIs([]*ingress.MultiClusterIngress{mci})
+
+	for _, server := range servers {
+		expected := internalredirect.Config{}
+		if server.Hostname == "example.com" {
+			expected = internalredirect.Config{
+				Enabled: true,
+				Locations: []internalredirect.Location{
+					{Path: "/protected/", Target: "http://files.example.svc.cluster.local/"},
+				},
+			}
+		}
+
+		if !server.InternalRedirect.Equal(&expected) {
+			t.Errorf("expected internal redirect %+v for server %v but got %+v", expected, server.Hostname, server.InternalRedirect)
+		}
+	}
+}
+
+func TestCheckMCIInternalRedirectWithoutLocations(t *testing.T) {
+	nginx := &NGINXController{
+		cfg:             &Configuration{},
+		store:           fakeMCIStore{},
+		metricCollector: metric.DummyCollector{},
+	}
+
+	mci := newTestMCI("example", "example.com", "/", "http-svc", false)
+	mci.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix("enable-internal-redirect"): "true",
+	})
+
+	if err := nginx.CheckMCI(&mci.MultiClusterIngress); err == nil {
+		t.Errorf("expected an error with enable-internal-redirect and no internal-redirect-locations annotation")
+	}
+}
+
+func TestCheckMCIUndefinedProxyCacheZone(t *testing.T) {
+	nginx := &NGINXController{
+		cfg:             &Configuration{},
+		store:           fakeMCIStore{},
+		metricCollector: metric.DummyCollector{},
+	}
+
+	mci := newTestMCI("example", "example.com", "/", "http-svc", false)
+	mci.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix("enable-proxy-cache"): "true",
+		parser.GetAnnotationWithPrefix("proxy-cache-zone"):   "undefined",
+	})
+
+	if err := nginx.CheckMCI(&mci.MultiClusterIngress); err == nil {
+		t.Errorf("expected an error with a proxy-cache-zone not defined in proxy-cache-zones")
+	}
+}
+
+func TestCheckMCIInvalidPreloadLinks(t *testing.T) {
+	nginx := &NGINXController{
+		cfg:             &Configuration{},
+		store:           fakeMCIStore{},
+		metricCollector: metric.DummyCollector{},
+	}
+
+	mci := newTestMCI("example", "example.com", "/", "http-svc", false)
+	mci.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix("preload-links"): "/app.css>; rel=prefetch",
+	})
+
+	if err := nginx.CheckMCI(&mci.MultiClusterIngress); err == nil {
+		t.Errorf("expected an error with an invalid preload-links annotation")
+	}
+}
+
+func TestLocationApplyAnnotationsWebSocket(t *testing.T) {
+	testCases := []struct {
+		protocol  string
+		webSocket bool
+	}{
+		{"WS", true},
+		{"WSS", true},
+		{"HTTP", false},
+		{"HTTPS", false},
+	}
+
+	for _, tc := range testCases {
+		loc := &ingress.Location{}
+		locationApplyAnnotations(loc, &annotations.Ingress{BackendProtocol: tc.protocol})
+
+		if loc.WebSocket != tc.webSocket {
+			t.Errorf("expected WebSocket %v for backend protocol %v but got %v", tc.webSocket, tc.protocol, loc.WebSocket)
+		}
+	}
+}
+
+func TestServicePortName(t *testing.T) {
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "derived-http-svc",
+			Namespace: "example",
+		},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{
+				{Name: "http", Port: 80, TargetPort: intstr.FromInt(8080)},
+				{Name: "metrics", Port: 9090, TargetPort: intstr.FromInt(9090)},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name            string
+		portName        string
+		expectedBackend string
+	}{
+		{"named port override", "metrics", "example-http-svc-metrics"},
+		{"nonexistent port name", "admin", "example-http-svc-80"},
+		{"no override", "", "example-http-svc-80"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mci := newTestMCI("example", "example.com", "/", "http-svc", false)
+			mci.ParsedAnnotations.ServicePortName = tc.portName
+
+			nginx := &NGINXController{
+				cfg: &Configuration{
+					ListenPorts: &ngx_config.ListenPorts{
+						Default: 80,
+					},
+				},
+				store: fakeMCIStore{
+					mcis:     []*ingress.MultiClusterIngress{mci},
+					services: map[string]*corev1.Service{"example/derived-http-svc": service},
+				},
+			}
+
+			upstreams, servers, _ := nginx.getBackendServersFromMCIs([]*ingress.MultiClusterIngress{mci})
+
+			found := false
+			for _, upstream := range upstreams {
+				if upstream.Name == tc.expectedBackend {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("expected an upstream named %v", tc.expectedBackend)
+			}
+
+			for _, server := range servers {
+				if server.Hostname != "example.com" {
+					continue
+				}
+
+				if backend := server.Locations[0].Backend; backend != tc.expectedBackend {
+					t.Errorf("expected location backend %v but got %v", tc.expectedBackend, backend)
+				}
+			}
+
+			if port := mci.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Port; port.Number != 80 || port.Name != "" {
+				t.Errorf("expected the original multiclusteringress not to be modified but got port %v", port)
+			}
+		})
+	}
+}
+
+func TestSSELocation(t *testing.T) {
+	testCases := []struct {
+		name                string
+		sse                 bool
+		buffering           string
+		readTimeout         int
+		expectedBuffering   string
+		expectedReadTimeout int
+		expectedEvent       bool
+	}{
+		{"sse disabled", false, "on", 60, "on", 60, false},
+		{"sse enabled", true, "", 60, "off", sseReadTimeout, false},
+		{"sse keeps a longer read timeout", true, "", 7200, "off", 7200, false},
+		{"sse overrides proxy buffering", true, "on", 60, "off", sseReadTimeout, true},
+		{"sse with proxy buffering off", true, "off", 60, "off", sseReadTimeout, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mci := newTestMCI("example", "example.com", "/", "http-svc", false)
+			mci.ParsedAnnotations.SSE = tc.sse
+			mci.ParsedAnnotations.Proxy = proxy.Config{
+				ProxyBuffering:       "on",
+				ProxyMaxTempFileSize: "1024m",
+				ReadTimeout:          tc.readTimeout,
+			}
+			if tc.buffering != "" {
+				mci.SetAnnotations(map[string]string{
+					parser.GetAnnotationWithPrefix("proxy-buffering"): tc.buffering,
+				})
+			}
+
+			recorder := record.NewFakeRecorder(10)
+			nginx := &NGINXController{
+				cfg: &Configuration{
+					ListenPorts: &ngx_config.ListenPorts{
+						Default: 80,
+					},
+				},
+				store: fakeMCIStore{
+					mcis: []*ingress.MultiClusterIngress{mci},
+				},
+				recorder: recorder,
+			}
+
+			_, servers, notices := nginx.getBackendServersFromMCIs([]*ingress.MultiClusterIngress{mci})
+			if len(recorder.Events) != 0 {
+				t.Fatalf("expected the build to record no event")
+			}
+
+			var location *ingress.Location
+			for _, server := range servers {
+				if server.Hostname == "example.com" {
+					location = server.Locations[0]
+				}
+			}
+
+			if location == nil {
+				t.Fatalf("expected a location for server example.com")
+			}
+
+			if location.Proxy.ProxyBuffering != tc.expectedBuffering {
+				t.Errorf("expected proxy buffering %v but got %v", tc.expectedBuffering, location.Proxy.ProxyBuffering)
+			}
+
+			if location.Proxy.ReadTimeout != tc.expectedReadTimeout {
+				t.Errorf("expected read timeout %v but got %v", tc.expectedReadTimeout, location.Proxy.ReadTimeout)
+			}
+
+			if tc.sse && location.Proxy.ProxyMaxTempFileSize != "0" {
+				t.Errorf("expected proxy max temp file size 0 but got %v", location.Proxy.ProxyMaxTempFileSize)
+			}
+
+			// the next sync of the same multiclusteringress records no event
+			nginx.reportMCINotices(notices)
+			nginx.reportMCINotices(notices)
+
+			expectedEvents := 0
+			if tc.expectedEvent {
+				expectedEvents = 1
+			}
+			if len(recorder.Events) != expectedEvents {
+				t.Errorf("expected %v warning events but got %v", expectedEvents, len(recorder.Events))
+			}
+		})
+	}
+}
+
+func TestCheckMCIWithResult(t *testing.T) {
+	if err := file.CreateRequiredDirectories(); err != nil {
+		t.Fatal(err)
+	}
+
+	nginx := newNGINXController(t)
+	nginx.metricCollector = metric.DummyCollector{}
+	nginx.t = fakeTemplate{}
+	nginx.store = fakeMCIStore{}
+	nginx.command = testNginxTestCommand{
+		t:        t,
+		expected: "_,example.com",
+	}
+
+	mci := newTestMCI("example", "example.com", "/", "http-svc", false)
+
+	result, err := nginx.CheckMCIWithResult(&mci.MultiClusterIngress)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.TestedSize != 1 {
+		t.Errorf("expected tested size 1 but got %v", result.TestedSize)
+	}
+
+	if result.ContentLength != len("_,example.com") {
+		t.Errorf("expected content length %v but got %v", len("_,example.com"), result.ContentLength)
+	}
+
+	if result.RenderDuration < 0 || result.TestDuration < 0 {
+		t.Errorf("expected non-negative durations but got render %v and test %v", result.RenderDuration, result.TestDuration)
+	}
+}
+
+// slowNginxTestCommand takes delay to test the configuration, sending the
+// tested file to canceled when the context is done before
+type slowNginxTestCommand struct {
+	testNginxTestCommand
+	delay    time.Duration
+	canceled chan string
+}
+
+func (sntc slowNginxTestCommand) Test(ctx context.Context, cfg string) ([]byte, error) {
+	select {
+	case <-time.After(sntc.delay):
+		return sntc.testNginxTestCommand.Test(ctx, cfg)
+	case <-ctx.Done():
+		sntc.canceled <- cfg
+		return nil, ctx.Err()
+	}
+}
+
+type checkTimeoutCollector struct {
+	metric.DummyCollector
+	timeouts map[string]int
+}
+
+func (c *checkTimeoutCollector) IncCheckTimeoutCount(namespace, name string) {
+	c.timeouts[fmt.Sprintf("%v/%v", namespace, name)]++
+}
+
+func TestCheckMCIAdmissionTimeout(t *testing.T) {
+	if err := file.CreateRequiredDirectories(); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name             string
+		timeout          time.Duration
+		delay            time.Duration
+		expectedTimeouts int
+	}{
+		{"no timeout", 0, 100 * time.Millisecond, 0},
+		{"fast test", time.Second, 0, 0},
+		{"slow test", 50 * time.Millisecond, time.Second, 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mc := &checkTimeoutCollector{timeouts: map[string]int{}}
+			nginx := newNGINXController(t)
+			nginx.metricCollector = mc
+			nginx.t = fakeTemplate{}
+			nginx.store = fakeMCIStore{}
+			nginx.cfg.AdmissionTimeout = tc.timeout
+			nginx.command = slowNginxTestCommand{
+				testNginxTestCommand: testNginxTestCommand{
+					t:        t,
+					expected: "_,example.com",
+				},
+				delay:    tc.delay,
+				canceled: make(chan string, 1),
+			}
+
+			mci := newTestMCI("example", "example.com", "/", "http-svc", false)
+
+			start := time.Now()
+			err := nginx.CheckMCI(&mci.MultiClusterIngress)
+			if tc.expectedTimeouts == 0 && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tc.expectedTimeouts > 0 {
+				if err == nil || !strings.Contains(err.Error(), "admission timeout") {
+					t.Fatalf("expected a timeout error but got %v", err)
+				}
+
+				if elapsed := time.Since(start); elapsed >= tc.delay {
+					t.Errorf("expected the check to give up before the test finished but it took %v", elapsed)
+				}
+
+				select {
+				case cfg := <-nginx.command.(slowNginxTestCommand).canceled:
+					err := wait.PollImmediate(10*time.Millisecond, time.Second, func() (bool, error) {
+						_, err := os.Stat(cfg)
+						return os.IsNotExist(err), nil
+					})
+					if err != nil {
+						t.Errorf("expected the tested file %v to be removed", cfg)
+					}
+				case <-time.After(tc.delay):
+					t.Errorf("expected the test of the configuration to be canceled")
+				}
+			}
+
+			if timeouts := mc.timeouts["example/example"]; timeouts != tc.expectedTimeouts {
+				t.Errorf("expected %v timeouts but got %v", tc.expectedTimeouts, timeouts)
+			}
+		})
+	}
+}
+
+func TestCheckMCIUnwatchedNamespace(t *testing.T) {
+	if err := file.CreateRequiredDirectories(); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name            string
+		namespace       string
+		reject          bool
+		expectErr       bool
+		expectedIgnored bool
+	}{
+		{"watched namespace", "example", false, false, false},
+		{"unwatched namespace is ignored", "other", false, false, true},
+		{"unwatched namespace is rejected", "other", true, true, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			nginx := newNGINXController(t)
+			nginx.metricCollector = metric.DummyCollector{}
+			nginx.t = fakeTemplate{}
+			nginx.store = fakeMCIStore{}
+			nginx.cfg.Namespace = tc.namespace
+			nginx.cfg.RejectUnwatchedNamespace = tc.reject
+			nginx.command = testNginxTestCommand{
+				t:        t,
+				expected: "_,example.com",
+			}
+
+			mci := newTestMCI("example", "example.com", "/", "http-svc", false)
+
+			result, err := nginx.CheckMCIWithResult(&mci.MultiClusterIngress)
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("expected error %v but got %v", tc.expectErr, err)
+			}
+			if err != nil {
+				return
+			}
+
+			if result.Ignored != tc.expectedIgnored {
+				t.Errorf("expected ignored %v but got %v", tc.expectedIgnored, result.Ignored)
+			}
+		})
+	}
+}
+
+func TestCheckMCIDisabled(t *testing.T) {
+	if err := file.CreateRequiredDirectories(); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name            string
+		disabled        string
+		expectErr       bool
+		expectedIgnored bool
+	}{
+		{"invalid multiclusteringress", "", true, false},
+		{"invalid multiclusteringress not disabled", "false", true, false},
+		{"invalid multiclusteringress disabled", "true", false, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			nginx := newNGINXController(t)
+			nginx.metricCollector = metric.DummyCollector{}
+			nginx.t = fakeTemplate{}
+			nginx.store = fakeMCIStore{}
+			nginx.command = testNginxTestCommand{
+				t:        t,
+				expected: "_,example.com",
+			}
+
+			mci := newTestMCI("example", "example.com", "/", "http-svc", false)
+			mci.Annotations = map[string]string{
+				parser.GetAnnotationWithPrefix("ssl-passthrough"):           "true",
+				parser.GetAnnotationWithPrefix("ssl-passthrough-root-only"): "true",
+			}
+			if tc.disabled != "" {
+				mci.Annotations[parser.GetAnnotationWithPrefix("disabled")] = tc.disabled
+			}
+
+			apiPath := mci.Spec.Rules[0].HTTP.Paths[0]
+			apiPath.Path = "/api"
+			mci.Spec.Rules[0].HTTP.Paths = append(mci.Spec.Rules[0].HTTP.Paths, apiPath)
+
+			result, err := nginx.CheckMCIWithResult(&mci.MultiClusterIngress)
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("expected error %v but got %v", tc.expectErr, err)
+			}
+			if err != nil {
+				return
+			}
+
+			if result.Ignored != tc.expectedIgnored {
+				t.Errorf("expected ignored %v but got %v", tc.expectedIgnored, result.Ignored)
+			}
+		})
+	}
+}
+
+func TestCheckMCIStreamServices(t *testing.T) {
+	if err := file.CreateRequiredDirectories(); err != nil {
+		t.Fatal(err)
+	}
+
+	tcpServices := parser.GetAnnotationWithPrefix("tcp-services")
+	udpServices := parser.GetAnnotationWithPrefix("udp-services")
+
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		expectErr   bool
+	}{
+		{"free ports", map[string]string{tcpServices: "9100:dns:53", udpServices: "9000:dns:53"}, false},
+		{"invalid mapping", map[string]string{tcpServices: "9100:dns"}, true},
+		{"reserved port", map[string]string{tcpServices: "80:dns:53"}, true},
+		{"port of the configmap", map[string]string{tcpServices: "5353:dns:53"}, true},
+		{"port of another multiclusteringress", map[string]string{tcpServices: "9000:dns:53"}, true},
+	}
+
+	other := newTestMCI("other", "", "/", "http-svc", false)
+	other.Spec.Rules = nil
+	other.ParsedAnnotations.StreamServices = streamservices.Config{
+		TCP: map[int]string{9000: "example/dns:53"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			nginx := newNGINXController(t)
+			nginx.cfg.TCPConfigMapName = "example/tcp"
+			nginx.cfg.UDPConfigMapName = "example/udp"
+			nginx.metricCollector = metric.DummyCollector{}
+			nginx.t = fakeTemplate{}
+			nginx.store = fakeMCIStore{
+				mcis: []*ingress.MultiClusterIngress{other},
+				configMaps: map[string]*corev1.ConfigMap{
+					"example/tcp": {Data: map[string]string{"5353": "example/dns:53"}},
+				},
+			}
+			nginx.command = testNginxTestCommand{
+				t:        t,
+				expected: "_,example.com",
+			}
+
+			mci := newTestMCI("example", "example.com", "/", "http-svc", false)
+			mci.Annotations = tc.annotations
+
+			_, err := nginx.CheckMCIWithResult(&mci.MultiClusterIngress)
+			if tc.expectErr != (err != nil) {
+				t.Errorf("expected error %v but got %v", tc.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestCheckMCIAdmissionEvents(t *testing.T) {
+	if err := file.CreateRequiredDirectories(); err != nil {
+		t.Fatal(err)
+	}
+
+	disabled := parser.GetAnnotationWithPrefix("disabled")
+	sslCiphers := parser.GetAnnotationWithPrefix("ssl-ciphers")
+	invalidCiphers := "HIGH:!aNULL; ssl_protocols SSLv3"
+
+	testCases := []struct {
+		name          string
+		emit          bool
+		annotations   map[string]string
+		expectErr     bool
+		expectedEvent string
+	}{
+		{"events disabled", false, nil, false, ""},
+		{"accepted", true, nil, false, "Normal AdmissionAccepted"},
+		{"accepted without validation", true, map[string]string{disabled: "true", sslCiphers: invalidCiphers}, false, "Normal AdmissionAccepted"},
+		{"rejected", true, map[string]string{sslCiphers: invalidCiphers}, true, "Warning AdmissionRejected"},
+		{"rejected with events disabled", false, map[string]string{sslCiphers: invalidCiphers}, true, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(1)
+			nginx := newNGINXController(t)
+			nginx.metricCollector = metric.DummyCollector{}
+			nginx.t = fakeTemplate{}
+			nginx.store = fakeMCIStore{}
+			nginx.recorder = recorder
+			nginx.cfg.EmitAdmissionEvents = tc.emit
+			nginx.command = testNginxTestCommand{
+				t:        t,
+				expected: "_,example.com",
+			}
+
+			mci := newTestMCI("example", "example.com", "/", "http-svc", false)
+			mci.Annotations = tc.annotations
+
+			err := nginx.CheckMCI(&mci.MultiClusterIngress)
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("expected error %v but got %v", tc.expectErr, err)
+			}
+
+			select {
+			case event := <-recorder.Events:
+				if tc.expectedEvent == "" || !strings.HasPrefix(event, tc.expectedEvent) {
+					t.Errorf("expected event %q but got %q", tc.expectedEvent, event)
+				}
+			default:
+				if tc.expectedEvent != "" {
+					t.Errorf("expected event %q but none was recorded", tc.expectedEvent)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckMCICanaryPrimary(t *testing.T) {
+	if err := file.CreateRequiredDirectories(); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name      string
+		validate  bool
+		existing  []*ingress.MultiClusterIngress
+		expectErr bool
+		// servers of the configuration tested when the canary is accepted
+		expected string
+	}{
+		{"matching primary", true, []*ingress.MultiClusterIngress{
+			newTestMCI("primary", "example.com", "/", "http-svc", false),
+		}, false, "_,example.com"},
+		{"no primary", true, nil, true, ""},
+		{"primary with another path", true, []*ingress.MultiClusterIngress{
+			newTestMCI("primary", "example.com", "/other", "http-svc", false),
+		}, true, ""},
+		{"only another canary", true, []*ingress.MultiClusterIngress{
+			newTestMCI("other-canary", "example.com", "/", "http-svc", true),
+		}, true, ""},
+		// the canary backends without a primary are dropped
+		{"no primary without validation", false, nil, false, "_"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			nginx := newNGINXController(t)
+			nginx.metricCollector = metric.DummyCollector{}
+			nginx.t = fakeTemplate{}
+			nginx.store = fakeMCIStore{
+				mcis: tc.existing,
+			}
+			nginx.cfg.ValidateCanaryPrimary = tc.validate
+			nginx.command = testNginxTestCommand{
+				t:        t,
+				expected: tc.expected,
+			}
+
+			mci := newTestMCI("example-canary", "example.com", "/", "canary-svc", true)
+			mci.SetAnnotations(map[string]string{
+				parser.GetAnnotationWithPrefix("canary"):        "true",
+				parser.GetAnnotationWithPrefix("canary-weight"): "10",
+			})
+
+			err := nginx.CheckMCI(&mci.MultiClusterIngress)
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("expected error %v but got %v", tc.expectErr, err)
+			}
+			if err != nil && !strings.Contains(err.Error(), "example.com/") {
+				t.Errorf("expected the host/path pair in the error message: %v", err)
+			}
+		})
+	}
+}
+
+func TestCheckMCIBackendServices(t *testing.T) {
+	if err := file.CreateRequiredDirectories(); err != nil {
+		t.Fatal(err)
+	}
+
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "derived-http-svc",
+			Namespace: "example",
+		},
+	}
+
+	testCases := []struct {
+		name      string
+		validate  bool
+		services  map[string]*corev1.Service
+		expectErr bool
+	}{
+		{"existing service", true, map[string]*corev1.Service{"example/derived-http-svc": service}, false},
+		{"missing service", true, nil, true},
+		{"missing service without validation", false, nil, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			nginx := newNGINXController(t)
+			nginx.metricCollector = metric.DummyCollector{}
+			nginx.t = fakeTemplate{}
+			nginx.store = fakeMCIStore{
+				services: tc.services,
+			}
+			nginx.cfg.ValidateBackendServices = tc.validate
+			nginx.command = testNginxTestCommand{
+				t:        t,
+				expected: "_,example.com",
+			}
+
+			mci := newTestMCI("example", "example.com", "/", "http-svc", false)
+
+			err := nginx.CheckMCI(&mci.MultiClusterIngress)
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("expected error %v but got %v", tc.expectErr, err)
+			}
+			if err != nil && !strings.Contains(err.Error(), "example/http-svc") {
+				t.Errorf("expected the missing service in the error message: %v", err)
+			}
+		})
+	}
+}
+
+func TestCheckMCIMaxLocationsPerServer(t *testing.T) {
+	if err := file.CreateRequiredDirectories(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the existing multiclusteringress defines /a and the new one /b, both of
+	// type Prefix so each adds an exact and a prefix location, and the server
+	// keeps its default root location, for a total of five locations
+	testCases := []struct {
+		name      string
+		limit     int
+		expectErr bool
+	}{
+		{"no limit", 0, false},
+		{"below the limit", 6, false},
+		{"at the limit", 5, false},
+		{"above the limit", 4, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			nginx := newNGINXController(t)
+			nginx.metricCollector = metric.DummyCollector{}
+			nginx.t = fakeTemplate{}
+			nginx.store = fakeMCIStore{
+				mcis: []*ingress.MultiClusterIngress{
+					newTestMCI("existing", "example.com", "/a", "http-svc", false),
+				},
+			}
+			nginx.cfg.MaxLocationsPerServer = tc.limit
+			nginx.command = testNginxTestCommand{
+				t:        t,
+				expected: "_,example.com",
+			}
+
+			mci := newTestMCI("example", "example.com", "/b", "http-svc", false)
+
+			err := nginx.CheckMCI(&mci.MultiClusterIngress)
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("expected error %v but got %v", tc.expectErr, err)
+			}
+			if err != nil && !strings.Contains(err.Error(), `host "example.com" would have 5 locations`) {
+				t.Errorf("unexpected error message: %v", err)
+			}
+		})
+	}
+}
+
+func TestHostPorts(t *testing.T) {
+	mci := newTestMCI("example", "example.com", "/", "http-svc", false)
+
+	nginx := &NGINXController{
+		cfg: &Configuration{
+			ListenPorts: &ngx_config.ListenPorts{
+				Default: 8181,
+				HTTP:    80,
+				HTTPS:   443,
+			},
+			HostPortsConfigMapName: "default/host-ports",
+		},
+		store: fakeMCIStore{
+			mcis: []*ingress.MultiClusterIngress{mci},
+			configMaps: map[string]*corev1.ConfigMap{
+				"default/host-ports": {
+					Data: map[string]string{
+						"example.com": "8080:8443",
+					},
+				},
+			},
+		},
+	}
+
+	_, servers, _ := nginx.getBackendServersFromMCIs([]*ingress.MultiClusterIngress{mci})
+
+	for _, server := range servers {
+		expectedHTTP, expectedHTTPS := 0, 0
+		if server.Hostname == "example.com" {
+			expectedHTTP, expectedHTTPS = 8080, 8443
+		}
+
+		if server.HTTPPort != expectedHTTP || server.HTTPSPort != expectedHTTPS {
+			t.Errorf("expected ports %v:%v for server %v but got %v:%v", expectedHTTP, expectedHTTPS, server.Hostname, server.HTTPPort, server.HTTPSPort)
+		}
+	}
+}
+
+func TestGetHostPorts(t *testing.T) {
+	nginx := &NGINXController{
+		cfg: &Configuration{
+			ListenPorts: &ngx_config.ListenPorts{
+				Default:  8181,
+				Health:   10254,
+				HTTP:     80,
+				HTTPS:    443,
+				SSLProxy: 442,
+			},
+			HostPortsConfigMapName: "default/host-ports",
+		},
+		store: fakeMCIStore{
+			configMaps: map[string]*corev1.ConfigMap{
+				"default/host-ports": {
+					Data: map[string]string{
+						"a.example.com": "8080",
+						"b.example.com": ":8443",
+						"c.example.com": "8080:8443",
+						"d.example.com": "8443",
+						"e.example.com": "70000",
+						"f.example.com": "http",
+						"g.example.com": "10254",
+						"h.example.com": "443",
+						"i.example.com": "9000:9000",
+						"j.example.com": "",
+					},
+				},
+			},
+		},
+	}
+
+	expected := map[string]hostPorts{
+		"a.example.com": {HTTP: 8080},
+		"b.example.com": {HTTPS: 8443},
+		"c.example.com": {HTTP: 8080, HTTPS: 8443},
+	}
+
+	hostPorts := nginx.getHostPorts()
+	if !reflect.DeepEqual(hostPorts, expected) {
+		t.Errorf("expected %v but got %v", expected, hostPorts)
+	}
+}
+
+func TestMCIDefaultSSLCertificate(t *testing.T) {
+	fakeCert := &ingress.SSLCert{Name: "fake"}
+	fallbackCert := &ingress.SSLCert{Name: "fallback", Certificate: &x509.Certificate{}}
+
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		secret      string
+		expected    *ingress.SSLCert
+	}{
+		{"no annotation", nil, "", fakeCert},
+		{"valid reference", map[string]string{parser.GetAnnotationWithPrefix("default-ssl-certificate"): "example/fallback"}, "example/fallback", fallbackCert},
+		{"invalid reference", map[string]string{parser.GetAnnotationWithPrefix("default-ssl-certificate"): "fallback"}, "", fakeCert},
+		{"missing certificate", map[string]string{parser.GetAnnotationWithPrefix("default-ssl-certificate"): "example/missing"}, "example/missing", fakeCert},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mci := newTestMCI("example", "example.com", "/", "http-svc", false)
+			mci.Annotations = tc.annotations
+			mci.ParsedAnnotations.DefaultSSLCertificate.Secret = tc.secret
+			mci.Spec.TLS = []networking.IngressTLS{
+				{Hosts: []string{"example.com"}},
+			}
+
+			nginx := &NGINXController{
+				cfg: &Configuration{
+					ListenPorts: &ngx_config.ListenPorts{
+						Default: 80,
+					},
+					FakeCertificate: fakeCert,
+				},
+				store: fakeMCIStore{
+					mcis: []*ingress.MultiClusterIngress{mci},
+					sslCerts: map[string]*ingress.SSLCert{
+						"example/fallback": fallbackCert,
+					},
+				},
+			}
+
+			_, servers, _ := nginx.getBackendServersFromMCIs([]*ingress.MultiClusterIngress{mci})
+
+			for _, server := range servers {
+				if server.Hostname != "example.com" {
+					continue
+				}
+
+				if server.SSLCert != tc.expected {
+					t.Errorf("expected certificate %v but got %v", tc.expected.Name, server.SSLCert)
+				}
+			}
+		})
+	}
+}
+
+func TestCanaryPriority(t *testing.T) {
+	for _, priority := range []string{canary.PriorityHeader, canary.PriorityWeight} {
+		primary := newTestMCI("primary", "example.com", "/", "http-svc", false)
+		canaryMCI := newTestMCI("canary", "example.com", "/", "canary-svc", true)
+		canaryMCI.ParsedAnnotations.Canary.Weight = 20
+		canaryMCI.ParsedAnnotations.Canary.Priority = priority
+
+		mcis := []*ingress.MultiClusterIngress{primary, canaryMCI}
+
+		nginx := &NGINXController{
+			cfg: &Configuration{
+				ListenPorts: &ngx_config.ListenPorts{
+					Default: 80,
+				},
+			},
+			store: fakeMCIStore{
+				mcis: mcis,
+			},
+		}
+
+		upstreams, _, _ := nginx.getBackendServersFromMCIs(mcis)
+
+		found := false
+		for _, upstream := range upstreams {
+			if upstream.Name != "example-canary-svc-80" {
+				continue
+			}
+
+			found = true
+			if upstream.TrafficShapingPolicy.Priority != priority {
+				t.Errorf("expected priority %q but got %q", priority, upstream.TrafficShapingPolicy.Priority)
+			}
+		}
+
+		if !found {
+			t.Errorf("expected the canary upstream to be created")
+		}
+	}
+}
+
+func TestCanaryWeightedSplit(t *testing.T) {
+	testCases := []struct {
+		name     string
+		weights  map[string]int
+		expected map[string]int
+		failures int
+	}{
+		{
+			name:     "50/30/20 split",
+			weights:  map[string]int{"canary-a": 30, "canary-b": 20},
+			expected: map[string]int{"example-canary-a-svc-80": 30, "example-canary-b-svc-80": 20},
+		},
+		{
+			name:     "weights above the weight total",
+			weights:  map[string]int{"canary-a": 30, "canary-b": 80},
+			expected: map[string]int{"example-canary-a-svc-80": 30},
+			failures: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mcis := []*ingress.MultiClusterIngress{
+				newTestMCI("primary", "example.com", "/", "http-svc", false),
+			}
+			for _, name := range []string{"canary-a", "canary-b"} {
+				canaryMCI := newTestMCI(name, "example.com", "/", name+"-svc", true)
+				canaryMCI.ParsedAnnotations.Canary.Weight = tc.weights[name]
+				mcis = append(mcis, canaryMCI)
+			}
+
+			mc := &canaryMergeFailureCollector{failures: map[string]int{}}
+			nginx := &NGINXController{
+				cfg: &Configuration{
+					ListenPorts: &ngx_config.ListenPorts{
+						Default: 80,
+					},
+				},
+				store: fakeMCIStore{
+					mcis: mcis,
+				},
+				metricCollector: mc,
+			}
+
+			upstreams, _, notices := nginx.getBackendServersFromMCIs(mcis)
+			nginx.reportMCINotices(notices)
+
+			weights := map[string]int{}
+			var primary *ingress.Backend
+			for _, upstream := range upstreams {
+				if upstream.Name == "example-http-svc-80" {
+					primary = upstream
+				}
+				if upstream.NoServer {
+					weights[upstream.Name] = upstream.TrafficShapingPolicy.Weight
+				}
+			}
+
+			if primary == nil {
+				t.Fatalf("expected a primary upstream example-http-svc-80")
+			}
+
+			if !reflect.DeepEqual(weights, tc.expected) {
+				t.Errorf("expected alternative upstreams %v but got %v", tc.expected, weights)
+			}
+
+			alternatives := sets.NewString(primary.AlternativeBackends...)
+			if !alternatives.Equal(sets.StringKeySet(tc.expected)) {
+				t.Errorf("expected alternative backends %v but got %v", sets.StringKeySet(tc.expected).List(), primary.AlternativeBackends)
+			}
+
+			failures := 0
+			for _, count := range mc.failures {
+				failures += count
+			}
+			if failures != tc.failures {
+				t.Errorf("expected %v canary merge failures but got %v", tc.failures, failures)
+			}
+		})
+	}
+}
+
+func TestMCIBackendProxyProtocol(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		mci := newTestMCI("example", "example.com", "/", "http-svc", false)
+		mci.ParsedAnnotations.BackendProxyProtocol = enabled
+		mci.ParsedAnnotations.SSLPassthrough = true
+
+		mcis := []*ingress.MultiClusterIngress{mci}
+
+		nginx := &NGINXController{
+			cfg: &Configuration{
+				ListenPorts: &ngx_config.ListenPorts{
+					Default: 80,
+				},
+			},
+			store: fakeMCIStore{
+				mcis: mcis,
+			},
+		}
+
+		_, _, pcfg, _ := nginx.getConfigurationFromMCI(mcis)
+
+		found := false
+		for _, upstream := range pcfg.Backends {
+			if upstream.Name != "example-http-svc-80" {
+				continue
+			}
+
+			found = true
+			if upstream.ProxyProtocol != enabled {
+				t.Errorf("expected backend proxy protocol %v but got %v", enabled, upstream.ProxyProtocol)
+			}
+		}
+
+		if !found {
+			t.Errorf("expected the upstream to be created")
+		}
+
+		if len(pcfg.PassthroughBackends) != 1 {
+			t.Fatalf("expected 1 passthrough backend but got %d", len(pcfg.PassthroughBackends))
+		}
+		if pcfg.PassthroughBackends[0].ProxyProtocol != enabled {
+			t.Errorf("expected passthrough proxy protocol %v but got %v", enabled, pcfg.PassthroughBackends[0].ProxyProtocol)
+		}
+	}
+}
+
+func TestMCIHealthCheck(t *testing.T) {
+	hc := healthcheck.Config{Path: "/healthz", Interval: 5, Status: 204}
+
+	mci := newTestMCI("example", "example.com", "/", "http-svc", false)
+	mci.ParsedAnnotations.HealthCheck = hc
+
+	other := newTestMCI("other", "other.com", "/", "other-svc", false)
+
+	mcis := []*ingress.MultiClusterIngress{mci, other}
+
+	nginx := &NGINXController{
+		cfg: &Configuration{
+			ListenPorts: &ngx_config.ListenPorts{
+				Default: 80,
+			},
+		},
+		store: fakeMCIStore{
+			mcis: mcis,
+		},
+	}
+
+	upstreams, _, _ := nginx.getBackendServersFromMCIs(mcis)
+
+	expected := map[string]healthcheck.Config{
+		"example-http-svc-80":  hc,
+		"example-other-svc-80": {},
+	}
+
+	for _, upstream := range upstreams {
+		want, ok := expected[upstream.Name]
+		if !ok {
+			continue
+		}
+		delete(expected, upstream.Name)
+
+		if !(&upstream.HealthCheck).Equal(&want) {
+			t.Errorf("expected health check %v for upstream %v but got %v", want, upstream.Name, upstream.HealthCheck)
+		}
+	}
+
+	if len(expected) != 0 {
+		t.Errorf("expected upstreams %v to be created", expected)
+	}
+}
+
+func TestSortUpstreams(t *testing.T) {
+	newUpstreams := func() []*ingress.Backend {
+		return []*ingress.Backend{
+			{Name: "zz-canary", NoServer: true},
+			{Name: "b-primary", AlternativeBackends: []string{"zz-canary"}},
+			{Name: "a-canary", NoServer: true},
+			{Name: "c-primary", AlternativeBackends: []string{"a-canary", "zz-canary"}},
+			{Name: "orphan-canary", NoServer: true},
+			{Name: "upstream-default-backend"},
+		}
+	}
+
+	testCases := []struct {
+		name     string
+		group    bool
+		expected []string
+	}{
+		{"by name", false, []string{"a-canary", "b-primary", "c-primary", "orphan-canary", "upstream-default-backend", "zz-canary"}},
+		{"grouped", true, []string{"b-primary", "zz-canary", "c-primary", "a-canary", "orphan-canary", "upstream-default-backend"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			upstreams := newUpstreams()
+			sortUpstreams(upstreams, tc.group)
+
+			names := make([]string, 0, len(upstreams))
+			for _, upstream := range upstreams {
+				names = append(names, upstream.Name)
+			}
+
+			if !reflect.DeepEqual(names, tc.expected) {
+				t.Errorf("expected %v but got %v", tc.expected, names)
+			}
+		})
+	}
+}
+
+func TestGroupCanaryUpstreams(t *testing.T) {
+	primary := newTestMCI("primary", "example.com", "/", "http-svc", false)
+	canaryMCI := newTestMCI("canary", "example.com", "/", "a-canary-svc", true)
+	canaryMCI.ParsedAnnotations.Canary.Weight = 20
+	other := newTestMCI("other", "other.com", "/", "b-svc", false)
+
+	mcis := []*ingress.MultiClusterIngress{primary, canaryMCI, other}
+
+	for _, group := range []bool{false, true} {
+		nginx := &NGINXController{
+			cfg: &Configuration{
+				ListenPorts: &ngx_config.ListenPorts{
+					Default: 80,
+				},
+				GroupCanaryUpstreams: group,
+			},
+			store: fakeMCIStore{
+				mcis: mcis,
+			},
+		}
+
+		upstreams, _, _ := nginx.getBackendServersFromMCIs(mcis)
+
+		names := []string{}
+		for _, upstream := range upstreams {
+			if strings.HasPrefix(upstream.Name, "example-") {
+				names = append(names, upstream.Name)
+			}
+		}
+
+		expected := []string{"example-a-canary-svc-80", "example-b-svc-80", "example-http-svc-80"}
+		if group {
+			expected = []string{"example-b-svc-80", "example-http-svc-80", "example-a-canary-svc-80"}
+		}
+
+		if !reflect.DeepEqual(names, expected) {
+			t.Errorf("expected upstreams %v with grouping %v but got %v", expected, group, names)
+		}
+	}
+}
+
+func TestMCIDefaultBackendTimeouts(t *testing.T) {
+	testCases := []struct {
+		name            string
+		connectTimeout  int
+		readTimeout     int
+		expectedConnect int
+		expectedRead    int
+	}{
+		{"no override", 0, 0, 0, 0},
+		{"connect override", 2, 0, 2, 0},
+		{"both overrides", 2, 3, 2, 3},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mci := newTestMCI("example", "example.com", "/", "http-svc", false)
+			mci.ParsedAnnotations.Proxy.ConnectTimeout = 5
+			mci.ParsedAnnotations.Proxy.ReadTimeout = 60
+
+			nginx := &NGINXController{
+				cfg: &Configuration{
+					ListenPorts: &ngx_config.ListenPorts{
+						Default: 80,
+					},
+					DefaultBackendConnectTimeout: tc.connectTimeout,
+					DefaultBackendReadTimeout:    tc.readTimeout,
+				},
+				store: fakeMCIStore{
+					mcis: []*ingress.MultiClusterIngress{mci},
+				},
+			}
+
+			_, servers, _ := nginx.getBackendServersFromMCIs([]*ingress.MultiClusterIngress{mci})
+
+			for _, server := range servers {
+				for _, location := range server.Locations {
+					connect, read := 5, 60
+					if server.Hostname == defServerName {
+						connect, read = tc.expectedConnect, tc.expectedRead
+					}
+
+					if location.Proxy.ConnectTimeout != connect {
+						t.Errorf("expected connect timeout %v for location %v of server %v but got %v", connect, location.Path, server.Hostname, location.Proxy.ConnectTimeout)
+					}
+					if location.Proxy.ReadTimeout != read {
+						t.Errorf("expected read timeout %v for location %v of server %v but got %v", read, location.Path, server.Hostname, location.Proxy.ReadTimeout)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestCheckMCISSLPassthroughWithTLS(t *testing.T) {
+	if err := file.CreateRequiredDirectories(); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name        string
+		passthrough bool
+		tls         []networking.IngressTLS
+		expectErr   bool
+	}{
+		{"ssl-passthrough only", true, nil, false},
+		{"TLS secret only", false, []networking.IngressTLS{{Hosts: []string{"example.com"}, SecretName: "example-tls"}}, false},
+		{"ssl-passthrough and TLS without secret", true, []networking.IngressTLS{{Hosts: []string{"example.com"}}}, false},
+		{"ssl-passthrough and TLS secret of another host", true, []networking.IngressTLS{{Hosts: []string{"other.example.com"}, SecretName: "example-tls"}}, false},
+		{"ssl-passthrough and TLS secret", true, []networking.IngressTLS{{Hosts: []string{"example.com"}, SecretName: "example-tls"}}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			nginx := newNGINXController(t)
+			nginx.metricCollector = metric.DummyCollector{}
+			nginx.t = fakeTemplate{}
+			nginx.store = fakeMCIStore{}
+			nginx.command = testNginxTestCommand{
+				t:        t,
+				expected: "_,example.com",
+			}
+
+			mci := newTestMCI("example", "example.com", "/", "http-svc", false)
+			mci.Annotations = map[string]string{
+				parser.GetAnnotationWithPrefix("ssl-passthrough"): strconv.FormatBool(tc.passthrough),
+			}
+			mci.Spec.TLS = tc.tls
+
+			err := nginx.CheckMCI(&mci.MultiClusterIngress)
+			if tc.expectErr != (err != nil) {
+				t.Errorf("expected error %v but got %v", tc.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestCheckMCISSLPassthroughRootOnly(t *testing.T) {
+	if err := file.CreateRequiredDirectories(); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name      string
+		rootOnly  string
+		paths     []string
+		expectErr bool
+	}{
+		{"root path", "true", []string{"/"}, false},
+		{"extra paths", "true", []string{"/", "/api"}, true},
+		{"extra paths without the annotation", "", []string{"/", "/api"}, false},
+		{"extra paths with the annotation disabled", "false", []string{"/", "/api"}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			nginx := newNGINXController(t)
+			nginx.metricCollector = metric.DummyCollector{}
+			nginx.t = fakeTemplate{}
+			nginx.store = fakeMCIStore{}
+			nginx.command = testNginxTestCommand{
+				t:        t,
+				expected: "_,example.com",
+			}
+
+			mci := newTestMCI("example", "example.com", "/", "http-svc", false)
+			mci.Annotations = map[string]string{
+				parser.GetAnnotationWithPrefix("ssl-passthrough"): "true",
+			}
+			if tc.rootOnly != "" {
+				mci.Annotations[parser.GetAnnotationWithPrefix("ssl-passthrough-root-only")] = tc.rootOnly
+			}
+
+			rootPath := mci.Spec.Rules[0].HTTP.Paths[0]
+			mci.Spec.Rules[0].HTTP.Paths = nil
+			for _, p := range tc.paths {
+				path := rootPath
+				path.Path = p
+				mci.Spec.Rules[0].HTTP.Paths = append(mci.Spec.Rules[0].HTTP.Paths, path)
+			}
+
+			err := nginx.CheckMCI(&mci.MultiClusterIngress)
+			if tc.expectErr != (err != nil) {
+				t.Errorf("expected error %v but got %v", tc.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestCheckMCIStreamSnippet(t *testing.T) {
+	if err := file.CreateRequiredDirectories(); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name      string
+		snippet   string
+		expectErr bool
+	}{
+		{"stream server", "server { listen 8000; proxy_pass 127.0.0.1:9000; }", false},
+		{"stream upstream", "upstream dns { server 10.0.0.1:53; }\nserver { listen 5353 udp; proxy_pass dns; }", false},
+		{"directive in a comment", "# location / { }\nserver { listen 8000; proxy_pass 127.0.0.1:9000; }", false},
+		{"location block", "server { listen 8000; location / { return 200; } }", true},
+		{"server_name", "server {\n  listen 8000;\n  server_name example.com;\n}", true},
+		{"http proxy_pass", "server { listen 8000; proxy_pass http://127.0.0.1:9000; }", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			nginx := newNGINXController(t)
+			nginx.metricCollector = metric.DummyCollector{}
+			nginx.t = fakeTemplate{}
+			nginx.store = fakeMCIStore{
+				fakeIngressStore: fakeIngressStore{
+					configuration: ngx_config.Configuration{
+						AllowSnippetAnnotations: true,
+					},
+				},
+			}
+			nginx.command = testNginxTestCommand{
+				t:        t,
+				expected: "_,example.com",
+			}
+
+			mci := newTestMCI("example", "example.com", "/", "http-svc", false)
+			mci.Annotations = map[string]string{
+				parser.GetAnnotationWithPrefix("stream-snippet"): tc.snippet,
+			}
+
+			err := nginx.CheckMCI(&mci.MultiClusterIngress)
+			if tc.expectErr != (err != nil) {
+				t.Errorf("expected error %v but got %v", tc.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestMCINamespaceDefaultBackend(t *testing.T) {
+	defaultBackend := func(namespace string) *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "derived-default-backend",
+				Namespace: namespace,
+			},
+			Spec: corev1.ServiceSpec{
+				Type:         corev1.ServiceTypeExternalName,
+				ExternalName: "errors.example.org",
+				Ports: []corev1.ServicePort{
+					{Port: 80, TargetPort: intstr.FromInt(8080)},
+				},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name            string
+		flag            string
+		services        map[string]*corev1.Service
+		expectedBackend string
+	}{
+		{"flag not set", "", map[string]*corev1.Service{"example/derived-default-backend": defaultBackend("example")}, defUpstreamName},
+		{"namespace default present", "default-backend", map[string]*corev1.Service{"example/derived-default-backend": defaultBackend("example")}, "example-default-backend-80"},
+		{"namespace default absent", "default-backend", map[string]*corev1.Service{"other/derived-default-backend": defaultBackend("other")}, defUpstreamName},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mci := newTestMCI("example", "example.com", "/a", "http-svc", false)
+
+			nginx := &NGINXController{
+				cfg: &Configuration{
+					ListenPorts: &ngx_config.ListenPorts{
+						Default: 80,
+					},
+					NamespaceDefaultBackendService: tc.flag,
+				},
+				store: fakeMCIStore{
+					mcis:     []*ingress.MultiClusterIngress{mci},
+					services: tc.services,
+				},
+			}
+
+			upstreams, servers, _ := nginx.getBackendServersFromMCIs([]*ingress.MultiClusterIngress{mci})
+
+			found := false
+			for _, server := range servers {
+				if server.Hostname == defServerName {
+					if backend := server.Locations[0].Backend; backend != defUpstreamName {
+						t.Errorf("expected the catch-all server to keep backend %v but got %v", defUpstreamName, backend)
+					}
+					continue
+				}
+
+				for _, location := range server.Locations {
+					if location.Path != rootLocation {
+						continue
+					}
+
+					found = true
+					if location.Backend != tc.expectedBackend {
+						t.Errorf("expected root location backend %v but got %v", tc.expectedBackend, location.Backend)
+					}
+				}
+			}
+
+			if !found {
+				t.Fatalf("expected a root location for server example.com")
+			}
+
+			if tc.expectedBackend == defUpstreamName {
+				return
+			}
+
+			for _, upstream := range upstreams {
+				if upstream.Name == tc.expectedBackend && len(upstream.Endpoints) == 0 {
+					t.Errorf("expected upstream %v to have endpoints", upstream.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestMCIDefaultBackendUpstreams(t *testing.T) {
+	defaultBackend := func(namespace string) *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "derived-default-backend",
+				Namespace: namespace,
+			},
+			Spec: corev1.ServiceSpec{
+				Type:         corev1.ServiceTypeExternalName,
+				ExternalName: "errors.example.org",
+				Ports: []corev1.ServicePort{
+					{Port: 80, TargetPort: intstr.FromInt(8080)},
+				},
+			},
+		}
+	}
+
+	// a multiclusteringress with a default backend in the spec, and another
+	// one using the default backend of its namespace
+	withSpec := newTestMCI("with-spec", "a.example.com", "/a", "http-svc", false)
+	withSpec.Spec.DefaultBackend = &networking.IngressBackend{
+		Service: &networking.IngressServiceBackend{
+			Name: "default-backend",
+			Port: networking.ServiceBackendPort{Number: 80},
+		},
+	}
+
+	withNamespace := newTestMCI("with-namespace", "b.example.com", "/b", "http-svc", false)
+	withNamespace.Namespace = "other"
+
+	configuration := ngx_config.Configuration{}
+	configuration.LoadBalancing = "ewma"
+
+	nginx := &NGINXController{
+		cfg: &Configuration{
+			ListenPorts: &ngx_config.ListenPorts{
+				Default: 80,
+			},
+			NamespaceDefaultBackendService: "default-backend",
+		},
+		store: fakeMCIStore{
+			fakeIngressStore: fakeIngressStore{
+				configuration: configuration,
+			},
+			mcis: []*ingress.MultiClusterIngress{withSpec, withNamespace},
+			services: map[string]*corev1.Service{
+				"example/derived-default-backend": defaultBackend("example"),
+				"other/derived-default-backend":   defaultBackend("other"),
+			},
+		},
+	}
+
+	upstreams, _, _ := nginx.getBackendServersFromMCIs([]*ingress.MultiClusterIngress{withSpec, withNamespace})
+
+	byName := map[string]*ingress.Backend{}
+	for _, upstream := range upstreams {
+		byName[upstream.Name] = upstream
+	}
+
+	for _, name := range []string{"example-default-backend-80", "other-default-backend-80"} {
+		upstream, ok := byName[name]
+		if !ok {
+			t.Fatalf("expected an upstream %v", name)
+		}
+
+		if upstream.LoadBalancing != "ewma" {
+			t.Errorf("expected upstream %v to use the ewma load balancer but got %q", name, upstream.LoadBalancing)
+		}
+		if upstream.Port != intstr.FromInt(80) {
+			t.Errorf("expected upstream %v to use port 80 but got %v", name, upstream.Port.String())
+		}
+		if upstream.Service == nil {
+			t.Errorf("expected upstream %v to reference its service", name)
+		}
+		if len(upstream.Endpoints) == 0 {
+			t.Errorf("expected upstream %v to have endpoints", name)
+		}
+	}
+}
+
+func TestUpstreamNameForMCI(t *testing.T) {
+	testCases := []struct {
+		name     string
+		backend  *networking.IngressServiceBackend
+		expected string
+	}{
+		{"port number", &networking.IngressServiceBackend{Name: "http-svc", Port: networking.ServiceBackendPort{Number: 80}}, "example-http-svc-80"},
+		{"port name", &networking.IngressServiceBackend{Name: "http-svc", Port: networking.ServiceBackendPort{Name: "http"}}, "example-http-svc-http"},
+		{"no port", &networking.IngressServiceBackend{Name: "http-svc"}, "example-INVALID"},
+		{"no service", nil, "example-INVALID"},
+	}
+
+	for _, tc := range testCases {
+		if name := UpstreamNameForMCI("example", tc.backend); name != tc.expected {
+			t.Errorf("%v: expected %v but got %v", tc.name, tc.expected, name)
+		}
+	}
+
+	// the names must match the upstreams generated for the paths
+	mci := newTestMCI("example", "example.com", "/", "http-svc", false)
+	other := newTestMCI("other", "other.com", "/", "other-svc", false)
+	other.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Port = networking.ServiceBackendPort{Name: "http"}
+
+	mcis := []*ingress.MultiClusterIngress{mci, other}
+
+	nginx := &NGINXController{
+		cfg: &Configuration{
+			ListenPorts: &ngx_config.ListenPorts{
+				Default: 80,
+			},
+		},
+		store: fakeMCIStore{
+			mcis: mcis,
+		},
+	}
+
+	upstreams, _, _ := nginx.getBackendServersFromMCIs(mcis)
+
+	generated := sets.NewString()
+	for _, upstream := range upstreams {
+		generated.Insert(upstream.Name)
+	}
+
+	for _, m := range mcis {
+		for _, rule := range m.Spec.Rules {
+			for _, path := range rule.HTTP.Paths {
+				name := UpstreamNameForMCI(m.Namespace, path.Backend.Service)
+				if !generated.Has(name) {
+					t.Errorf("expected upstream %v to be generated, got %v", name, generated.List())
+				}
+			}
+		}
+	}
+}
+
+func TestMCIDisablePathRedirect(t *testing.T) {
+	implementationSpecific := networking.PathTypeImplementationSpecific
+
+	for _, enabled := range []bool{false, true} {
+		mci := newTestMCI("example", "example.com", "/user/", "http-svc", false)
+		mci.Spec.Rules[0].HTTP.Paths[0].PathType = &implementationSpecific
+		prefix := mci.Spec.Rules[0].HTTP.Paths[0]
+		prefix.Path = "/api/"
+		prefix.PathType = &pathTypePrefix
+		mci.Spec.Rules[0].HTTP.Paths = append(mci.Spec.Rules[0].HTTP.Paths, prefix)
+		mci.ParsedAnnotations.DisablePathRedirect = enabled
+
+		mcis := []*ingress.MultiClusterIngress{mci}
+
+		nginx := &NGINXController{
+			cfg: &Configuration{
+				ListenPorts: &ngx_config.ListenPorts{
+					Default: 80,
+				},
+			},
+			store: fakeMCIStore{
+				mcis: mcis,
+			},
+		}
+
+		_, servers, _, _ := nginx.getConfigurationFromMCI(mcis)
+
+		exact := map[string]int{}
+		for _, server := range servers {
+			if server.Hostname != "example.com" {
+				continue
+			}
+
+			for _, location := range server.Locations {
+				if pathTypeOrDefault(location.PathType) == pathTypeExact {
+					exact[location.Path]++
+				}
+			}
+		}
+
+		expected := 0
+		if enabled {
+			expected = 1
+		}
+		for _, path := range []string{"/user", "/api"} {
+			if exact[path] != expected {
+				t.Errorf("expected %v exact location %v with disable-path-redirect %v but got %v", expected, path, enabled, exact[path])
+			}
+		}
+	}
+}
+
+func TestMCIChecksum(t *testing.T) {
+	first := newTestMCI("first", "first.example.com", "/", "http-svc", false)
+	second := newTestMCI("second", "second.example.com", "/", "http-svc", false)
+
+	checksum := mciChecksum([]*ingress.MultiClusterIngress{first, second})
+
+	if mciChecksum([]*ingress.MultiClusterIngress{second, first}) != checksum {
+		t.Errorf("expected the checksum to not depend on the order of the multiclusteringresses")
+	}
+
+	second.ParsedAnnotations.SSE = true
+	if mciChecksum([]*ingress.MultiClusterIngress{first, second}) != checksum {
+		t.Errorf("expected the checksum to only depend on the spec and annotations")
+	}
+
+	second.Annotations = map[string]string{"nginx.ingress.kubernetes.io/enable-sse": "true"}
+	annotated := mciChecksum([]*ingress.MultiClusterIngress{first, second})
+	if annotated == checksum {
+		t.Errorf("expected the checksum to change with the annotations")
+	}
+
+	second.Spec.Rules[0].Host = "other.example.com"
+	if mciChecksum([]*ingress.MultiClusterIngress{first, second}) == annotated {
+		t.Errorf("expected the checksum to change with the spec")
+	}
+
+	if mciChecksum([]*ingress.MultiClusterIngress{first}) == checksum {
+		t.Errorf("expected the checksum to change when a multiclusteringress is removed")
+	}
+}
+
+func TestMCIRouteByHeader(t *testing.T) {
+	service := func(name string) *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "example",
+			},
+			Spec: corev1.ServiceSpec{
+				Type:         corev1.ServiceTypeExternalName,
+				ExternalName: name + ".example.org",
+				Ports: []corev1.ServicePort{
+					{Port: 80, TargetPort: intstr.FromInt(8080)},
+				},
+			},
+		}
+	}
+
+	const alternateName = "example-http-svc-80-route-by-header-http-svc-b"
+
+	for _, enabled := range []bool{false, true} {
+		mci := newTestMCI("example", "example.com", "/", "http-svc", false)
+		if enabled {
+			mci.ParsedAnnotations.RouteByHeader = routebyheader.Config{
+				Header:      "X-Variant",
+				HeaderValue: "b",
+				Service:     "http-svc-b",
+			}
+		}
+
+		nginx := &NGINXController{
+			cfg: &Configuration{
+				ListenPorts: &ngx_config.ListenPorts{
+					Default: 80,
+				},
+			},
+			store: fakeMCIStore{
+				mcis: []*ingress.MultiClusterIngress{mci},
+				services: map[string]*corev1.Service{
+					"example/derived-http-svc":   service("derived-http-svc"),
+					"example/derived-http-svc-b": service("derived-http-svc-b"),
+				},
+			},
+		}
+
+		upstreams, servers, _ := nginx.getBackendServersFromMCIs([]*ingress.MultiClusterIngress{mci})
+
+		var primary, alternate *ingress.Backend
+		for _, upstream := range upstreams {
+			switch upstream.Name {
+			case "example-http-svc-80":
+				primary = upstream
+			case alternateName:
+				alternate = upstream
+			}
+		}
+
+		if primary == nil {
+			t.Fatalf("expected an upstream example-http-svc-80")
+		}
+
+		if !enabled {
+			if alternate != nil || len(primary.AlternativeBackends) != 0 {
+				t.Errorf("expected no alternate upstream without route-by-header")
+			}
+			continue
+		}
+
+		if alternate == nil {
+			t.Fatalf("expected an upstream %v", alternateName)
+		}
+
+		if !reflect.DeepEqual(primary.AlternativeBackends, []string{alternateName}) {
+			t.Errorf("expected the alternative backends of the primary upstream to be %v but got %v", alternateName, primary.AlternativeBackends)
+		}
+
+		if !alternate.NoServer {
+			t.Errorf("expected the alternate upstream to not be referenced by a server")
+		}
+
+		expectedPolicy := ingress.TrafficShapingPolicy{Header: "X-Variant", HeaderValue: "b"}
+		if !alternate.TrafficShapingPolicy.Equal(expectedPolicy) {
+			t.Errorf("expected traffic shaping policy %v but got %v", expectedPolicy, alternate.TrafficShapingPolicy)
+		}
+
+		if alternate.Service == nil || alternate.Service.Name != "derived-http-svc-b" {
+			t.Errorf("expected the alternate upstream to use the service derived-http-svc-b but got %v", alternate.Service)
+		}
+
+		if len(alternate.Endpoints) != 1 || alternate.Endpoints[0].Address != "derived-http-svc-b.example.org" {
+			t.Errorf("expected the endpoints of the alternate service but got %v", alternate.Endpoints)
+		}
+
+		for _, server := range servers {
+			for _, location := range server.Locations {
+				if location.Backend == alternateName {
+					t.Errorf("expected no location to use the alternate upstream but %v%v does", server.Hostname, location.Path)
+				}
+			}
+		}
+	}
+}
+
+func TestMCIPassiveHealthCheck(t *testing.T) {
+	service := func(name string) *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "example",
+			},
+			Spec: corev1.ServiceSpec{
+				Type:         corev1.ServiceTypeExternalName,
+				ExternalName: name + ".example.org",
+				Ports: []corev1.ServicePort{
+					{Port: 80, TargetPort: intstr.FromInt(8080)},
+				},
+			},
+		}
+	}
+
+	phc := passivehealthcheck.Config{MaxFails: 3, FailTimeout: 30}
+
+	mci := newTestMCI("example", "example.com", "/", "http-svc", false)
+	mci.ParsedAnnotations.PassiveHealthCheck = phc
+
+	other := newTestMCI("other", "other.com", "/", "other-svc", false)
+
+	mcis := []*ingress.MultiClusterIngress{mci, other}
+
+	nginx := &NGINXController{
+		cfg: &Configuration{
+			ListenPorts: &ngx_config.ListenPorts{
+				Default: 80,
+			},
+		},
+		store: fakeMCIStore{
+			mcis: mcis,
+			services: map[string]*corev1.Service{
+				"example/derived-http-svc":  service("derived-http-svc"),
+				"example/derived-other-svc": service("derived-other-svc"),
+			},
+		},
+	}
+
+	upstreams, _, _ := nginx.getBackendServersFromMCIs(mcis)
+
+	expected := map[string]passivehealthcheck.Config{
+		"example-http-svc-80":  phc,
+		"example-other-svc-80": {},
+	}
+
+	for _, upstream := range upstreams {
+		want, ok := expected[upstream.Name]
+		if !ok {
+			continue
+		}
+		delete(expected, upstream.Name)
+
+		if !(&upstream.PassiveHealthCheck).Equal(&want) {
+			t.Errorf("expected passive health check %v for upstream %v but got %v", want, upstream.Name, upstream.PassiveHealthCheck)
+		}
+
+		if len(upstream.Endpoints) == 0 {
+			t.Errorf("expected endpoints for upstream %v", upstream.Name)
+		}
+
+		for _, endpoint := range upstream.Endpoints {
+			if endpoint.MaxFails != want.MaxFails || endpoint.FailTimeout != want.FailTimeout {
+				t.Errorf("expected endpoint %v of upstream %v to use max fails %v and fail timeout %v but got %v and %v",
+					endpoint.Address, upstream.Name, want.MaxFails, want.FailTimeout, endpoint.MaxFails, endpoint.FailTimeout)
+			}
+		}
+	}
+
+	if len(expected) != 0 {
+		t.Errorf("expected upstreams %v to be created", expected)
+	}
+}
+
+func TestMCIEWMADecay(t *testing.T) {
+	service := func(name string) *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "example",
+			},
+			Spec: corev1.ServiceSpec{
+				Type:         corev1.ServiceTypeExternalName,
+				ExternalName: name + ".example.org",
+				Ports: []corev1.ServicePort{
+					{Port: 80, TargetPort: intstr.FromInt(8080)},
+				},
+			},
+		}
+	}
+
+	ewma := newTestMCI("ewma", "ewma.com", "/", "ewma-svc", false)
+	ewma.ParsedAnnotations.LoadBalancing = "ewma"
+	ewma.ParsedAnnotations.EWMADecay = 30
+
+	roundRobin := newTestMCI("round-robin", "round-robin.com", "/", "round-robin-svc", false)
+	roundRobin.ParsedAnnotations.LoadBalancing = "round_robin"
+	roundRobin.ParsedAnnotations.EWMADecay = 30
+
+	ewmaDefault := newTestMCI("ewma-default", "ewma-default.com", "/", "ewma-default-svc", false)
+	ewmaDefault.ParsedAnnotations.LoadBalancing = "ewma"
+
+	mcis := []*ingress.MultiClusterIngress{ewma, roundRobin, ewmaDefault}
+
+	nginx := &NGINXController{
+		cfg: &Configuration{
+			ListenPorts: &ngx_config.ListenPorts{
+				Default: 80,
+			},
+		},
+		store: fakeMCIStore{
+			mcis: mcis,
+			services: map[string]*corev1.Service{
+				"example/derived-ewma-svc":         service("derived-ewma-svc"),
+				"example/derived-round-robin-svc":  service("derived-round-robin-svc"),
+				"example/derived-ewma-default-svc": service("derived-ewma-default-svc"),
+			},
+		},
+	}
+
+	upstreams, _, _ := nginx.getBackendServersFromMCIs(mcis)
+
+	expected := map[string]float64{
+		"example-ewma-svc-80":         30,
+		"example-round-robin-svc-80":  0,
+		"example-ewma-default-svc-80": 0,
+	}
+
+	for _, upstream := range upstreams {
+		want, ok := expected[upstream.Name]
+		if !ok {
+			continue
+		}
+		delete(expected, upstream.Name)
+
+		if upstream.EWMADecay != want {
+			t.Errorf("expected EWMA decay %v for upstream %v but got %v", want, upstream.Name, upstream.EWMADecay)
+		}
+	}
+
+	if len(expected) != 0 {
+		t.Errorf("expected upstreams %v to be created", expected)
+	}
+}
+
+func TestMCIMisdirectedRequestCheck(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		mci := newTestMCI("example", "example.com", "/", "http-svc", false)
+		passthrough := newTestMCI("passthrough", "passthrough.com", "/", "passthrough-svc", false)
+		passthrough.ParsedAnnotations.SSLPassthrough = true
+
+		mcis := []*ingress.MultiClusterIngress{mci, passthrough}
+
+		nginx := &NGINXController{
+			cfg: &Configuration{
+				ListenPorts: &ngx_config.ListenPorts{
+					Default: 80,
+				},
+				EnableMisdirectedRequestCheck: enabled,
+			},
+			store: fakeMCIStore{
+				mcis: mcis,
+			},
+		}
+
+		_, servers, _ := nginx.getBackendServersFromMCIs(mcis)
+
+		expected := map[string]bool{
+			defServerName:     false,
+			"example.com":     enabled,
+			"passthrough.com": false,
+		}
+
+		for _, server := range servers {
+			want, ok := expected[server.Hostname]
+			if !ok {
+				continue
+			}
+			delete(expected, server.Hostname)
+
+			if server.MisdirectedRequestCheck != want {
+				t.Errorf("expected misdirected request check %v for server %v with the flag %v but got %v",
+					want, server.Hostname, enabled, server.MisdirectedRequestCheck)
+			}
+		}
+
+		if len(expected) != 0 {
+			t.Errorf("expected servers %v to be created", expected)
+		}
+	}
+}
+
+func TestMCIUpstreamDNSResolver(t *testing.T) {
+	service := func(name, externalName string) *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "example",
+			},
+			Spec: corev1.ServiceSpec{
+				Type:         corev1.ServiceTypeExternalName,
+				ExternalName: externalName,
+				Ports: []corev1.ServicePort{
+					{Port: 80, TargetPort: intstr.FromInt(8080)},
+				},
+			},
+		}
+	}
+
+	resolvers := []string{"10.96.0.10:53", "[fd00::10]:53"}
+
+	for _, enabled := range []bool{false, true} {
+		external := newTestMCI("external", "external.com", "/", "external-svc", false)
+		address := newTestMCI("address", "address.com", "/", "address-svc", false)
+		mcis := []*ingress.MultiClusterIngress{external, address}
+
+		cfg := &Configuration{
+			ListenPorts: &ngx_config.ListenPorts{
+				Default: 80,
+			},
+		}
+		if enabled {
+			cfg.UpstreamDNSResolver = resolvers
+			cfg.UpstreamDNSValid = 10 * time.Second
+		}
+
+		nginx := &NGINXController{
+			cfg: cfg,
+			store: fakeMCIStore{
+				mcis: mcis,
+				services: map[string]*corev1.Service{
+					"example/derived-external-svc": service("derived-external-svc", "api.example.org."),
+					"example/derived-address-svc":  service("derived-address-svc", "10.0.0.1"),
+				},
+			},
+		}
+
+		_, servers, _ := nginx.getBackendServersFromMCIs(mcis)
+
+		expected := map[string]*ingress.ExternalUpstream{
+			"external.com": nil,
+			"address.com":  nil,
+		}
+		if enabled {
+			expected["external.com"] = &ingress.ExternalUpstream{
+				Host:      "api.example.org",
+				Port:      "8080",
+				Resolvers: resolvers,
+				Valid:     10,
+			}
+		}
+
+		for _, server := range servers {
+			want, ok := expected[server.Hostname]
+			if !ok {
+				continue
+			}
+			delete(expected, server.Hostname)
+
+			for _, location := range server.Locations {
+				if !location.ExternalUpstream.Equal(want) {
+					t.Errorf("expected external upstream %+v for location %v of server %v with resolvers %v but got %+v",
+						want, location.Path, server.Hostname, enabled, location.ExternalUpstream)
+				}
+			}
+		}
+
+		if len(expected) != 0 {
+			t.Errorf("expected servers %v to be created", expected)
+		}
+	}
+}
+
+func TestTopologyAwareUpstreams(t *testing.T) {
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "derived-http-svc",
+			Namespace: "example",
+		},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{
+				{Port: 80, TargetPort: intstr.FromInt(8080)},
+			},
+		},
+	}
+
+	endpoint := func(address string, zones ...string) discoveryv1.Endpoint {
+		endpoint := discoveryv1.Endpoint{Addresses: []string{address}}
+		if len(zones) > 0 {
+			endpoint.Hints = &discoveryv1.EndpointHints{}
+			for _, zone := range zones {
+				endpoint.Hints.ForZones = append(endpoint.Hints.ForZones, discoveryv1.ForZone{Name: zone})
+			}
+		}
+		return endpoint
+	}
+
+	endpointSlice := func(endpoints ...discoveryv1.Endpoint) []*discoveryv1.EndpointSlice {
+		protocol := corev1.ProtocolTCP
+		port := int32(8080)
+		name := ""
+		return []*discoveryv1.EndpointSlice{{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "derived-http-svc-abc",
+				Namespace: "example",
+			},
+			Ports: []discoveryv1.EndpointPort{
+				{Protocol: &protocol, Port: &port, Name: &name},
+			},
+			Endpoints: endpoints,
+		}}
+	}
+
+	testCases := []struct {
+		name          string
+		zone          string
+		topologyAware bool
+		endpoints     []discoveryv1.Endpoint
+		expected      []string
+	}{
+		{"local zone endpoints", "zone-a", true,
+			[]discoveryv1.Endpoint{endpoint("10.0.0.1", "zone-a"), endpoint("10.0.0.2", "zone-b")},
+			[]string{"10.0.0.1"}},
+		{"no local zone endpoint", "zone-a", true,
+			[]discoveryv1.Endpoint{endpoint("10.0.0.1", "zone-b"), endpoint("10.0.0.2", "zone-b")},
+			[]string{"10.0.0.1", "10.0.0.2"}},
+		{"endpoints without hints", "zone-a", true,
+			[]discoveryv1.Endpoint{endpoint("10.0.0.1"), endpoint("10.0.0.2")},
+			[]string{"10.0.0.1", "10.0.0.2"}},
+		{"annotation not set", "zone-a", false,
+			[]discoveryv1.Endpoint{endpoint("10.0.0.1", "zone-a"), endpoint("10.0.0.2", "zone-b")},
+			[]string{"10.0.0.1", "10.0.0.2"}},
+		{"zone of the controller not set", "", true,
+			[]discoveryv1.Endpoint{endpoint("10.0.0.1", "zone-a"), endpoint("10.0.0.2", "zone-b")},
+			[]string{"10.0.0.1", "10.0.0.2"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mci := newTestMCI("example", "example.com", "/", "http-svc", false)
+			mci.ParsedAnnotations.TopologyAware = tc.topologyAware
+
+			nginx := &NGINXController{
+				cfg: &Configuration{
+					ListenPorts: &ngx_config.ListenPorts{
+						Default: 80,
+					},
+					TopologyZone:      tc.zone,
+					UseEndpointSlices: true,
+				},
+				store: fakeMCIStore{
+					mcis:     []*ingress.MultiClusterIngress{mci},
+					services: map[string]*corev1.Service{"example/derived-http-svc": service},
+					endpointSlices: map[string][]*discoveryv1.EndpointSlice{
+						"example/derived-http-svc": endpointSlice(tc.endpoints...),
+					},
+				},
+			}
+
+			upstreams, _, _ := nginx.getBackendServersFromMCIs([]*ingress.MultiClusterIngress{mci})
+
+			var addresses []string
+			for _, upstream := range upstreams {
+				if upstream.Name != "example-http-svc-80" {
+					continue
+				}
+				for _, endpoint := range upstream.Endpoints {
+					addresses = append(addresses, endpoint.Address)
+				}
+			}
+
+			if !reflect.DeepEqual(addresses, tc.expected) {
+				t.Errorf("expected the endpoints %v but got %v", tc.expected, addresses)
+			}
+		})
+	}
+}
+
+func TestTerminatingServingUpstreams(t *testing.T) {
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "derived-http-svc",
+			Namespace: "example",
+		},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{
+				{Port: 80, TargetPort: intstr.FromInt(8080)},
+			},
+		},
+	}
+
+	endpoint := func(address string, ready, serving, terminating bool) discoveryv1.Endpoint {
+		return discoveryv1.Endpoint{
+			Addresses: []string{address},
+			Conditions: discoveryv1.EndpointConditions{
+				Ready:       &ready,
+				Serving:     &serving,
+				Terminating: &terminating,
+			},
+		}
+	}
+
+	protocol := corev1.ProtocolTCP
+	port := int32(8080)
+	name := ""
+	endpointSlices := []*discoveryv1.EndpointSlice{{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "derived-http-svc-abc",
+			Namespace: "example",
+		},
+		Ports: []discoveryv1.EndpointPort{
+			{Protocol: &protocol, Port: &port, Name: &name},
+		},
+		Endpoints: []discoveryv1.Endpoint{
+			endpoint("10.0.0.1", true, true, false),
+			endpoint("10.0.0.2", false, true, true),
+			endpoint("10.0.0.3", false, false, true),
+		},
+	}}
+
+	testCases := []struct {
+		name                      string
+		includeTerminatingServing bool
+		expected                  []string
+	}{
+		{"ready endpoints only", false, []string{"10.0.0.1"}},
+		{"terminating serving endpoints", true, []string{"10.0.0.1", "10.0.0.2"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mci := newTestMCI("example", "example.com", "/", "http-svc", false)
+
+			nginx := &NGINXController{
+				cfg: &Configuration{
+					ListenPorts: &ngx_config.ListenPorts{
+						Default: 80,
+					},
+					UseEndpointSlices:         true,
+					IncludeTerminatingServing: tc.includeTerminatingServing,
+				},
+				store: fakeMCIStore{
+					mcis:     []*ingress.MultiClusterIngress{mci},
+					services: map[string]*corev1.Service{"example/derived-http-svc": service},
+					endpointSlices: map[string][]*discoveryv1.EndpointSlice{
+						"example/derived-http-svc": endpointSlices,
+					},
+				},
+			}
+
+			upstreams, _, _ := nginx.getBackendServersFromMCIs([]*ingress.MultiClusterIngress{mci})
+
+			var addresses []string
+			for _, upstream := range upstreams {
+				if upstream.Name != "example-http-svc-80" {
+					continue
+				}
+				for _, endpoint := range upstream.Endpoints {
+					addresses = append(addresses, endpoint.Address)
+					if endpoint.Address == "10.0.0.2" && (endpoint.Conditions == nil || !endpoint.Conditions.Terminating) {
+						t.Errorf("expected the endpoint %v to be terminating", endpoint.Address)
+					}
+				}
+			}
+
+			if !reflect.DeepEqual(addresses, tc.expected) {
+				t.Errorf("expected the endpoints %v but got %v", tc.expected, addresses)
+			}
+		})
+	}
+}
+
+func TestMCIBuildMetrics(t *testing.T) {
+	first := newTestMCI("first", "example.com", "/", "http-svc", false)
+	second := newTestMCI("second", "example.com", "/second", "http-svc", false)
+	shared := newTestMCI("shared", "example.com", "/team", "http-svc", false)
+	shared.Namespace = "team-a"
+	own := newTestMCI("own", "team-a.example.com", "/", "http-svc", false)
+	own.Namespace = "team-a"
+	mcis := []*ingress.MultiClusterIngress{first, second, shared, own}
+
+	service := func(namespace string) *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "derived-http-svc"},
+			Spec: corev1.ServiceSpec{
+				Ports: []corev1.ServicePort{{Port: 80, TargetPort: intstr.FromInt(8080)}},
+			},
+		}
+	}
+
+	nginx := &NGINXController{
+		cfg: &Configuration{
+			ListenPorts: &ngx_config.ListenPorts{
+				Default: 80,
+			},
+		},
+		store: fakeMCIStore{
+			mcis: mcis,
+			services: map[string]*corev1.Service{
+				"example/derived-http-svc": service("example"),
+				"team-a/derived-http-svc":  service("team-a"),
+			},
+		},
+		metricCollector: metric.DummyCollector{},
+	}
+
+	upstreams, servers, _ := nginx.getBackendServersFromMCIs(mcis)
+	upstreamsByNamespace, serversByNamespace, locationsByNamespace := mciBuildMetrics(upstreams, servers)
+
+	expectedUpstreams := map[string]int{"example": 1, "team-a": 1}
+	if !reflect.DeepEqual(upstreamsByNamespace, expectedUpstreams) {
+		t.Errorf("expected the upstreams %v but got %v", expectedUpstreams, upstreamsByNamespace)
+	}
+
+	expectedServers := map[string]int{"example": 1, "team-a": 2}
+	if !reflect.DeepEqual(serversByNamespace, expectedServers) {
+		t.Errorf("expected the servers %v but got %v", expectedServers, serversByNamespace)
+	}
+
+	expectedLocations := map[string]int{"example": 2, "team-a": 2}
+	if !reflect.DeepEqual(locationsByNamespace, expectedLocations) {
+		t.Errorf("expected the locations %v but got %v", expectedLocations, locationsByNamespace)
+	}
+}
+
+func TestScale(t *testing.T) {
+	first := newTestMCI("first", "example.com", "/", "http-svc", false)
+	second := newTestMCI("second", "example.com", "/second", "http-svc", false)
+	other := newTestMCI("other", "other.example.com", "/", "other-svc", false)
+	mcis := []*ingress.MultiClusterIngress{first, second, other}
+
+	nginx := &NGINXController{
+		cfg: &Configuration{
+			ListenPorts: &ngx_config.ListenPorts{
+				Default: 80,
+			},
+		},
+		store: fakeMCIStore{
+			mcis: mcis,
+		},
+		metricCollector: metric.DummyCollector{},
+		runningConfig:   &ingress.Configuration{},
+	}
+
+	if servers, upstreams, locations := nginx.Scale(); servers != 0 || upstreams != 0 || locations != 0 {
+		t.Errorf("expected an empty scale before the first sync but got %v servers, %v upstreams and %v locations",
+			servers, upstreams, locations)
+	}
+
+	nginx.runningConfig.Backends, nginx.runningConfig.Servers, _ = nginx.getBackendServersFromMCIs(mcis)
+
+	// the catch-all server and the default upstream are part of the configuration
+	servers, upstreams, locations := nginx.Scale()
+	if servers != 3 {
+		t.Errorf("expected 3 servers but got %v", servers)
+	}
+	if upstreams != 3 {
+		t.Errorf("expected 3 upstreams but got %v", upstreams)
+	}
+	if locations != 4 {
+		t.Errorf("expected 4 locations but got %v", locations)
+	}
+}
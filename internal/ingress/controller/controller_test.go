@@ -130,7 +130,7 @@ func (ntc testNginxTestCommand) ExecCommand(args ...string) *exec.Cmd {
 	return nil
 }
 
-func (ntc testNginxTestCommand) Test(cfg string) ([]byte, error) {
+func (ntc testNginxTestCommand) Test(ctx context.Context, cfg string) ([]byte, error) {
 	fd, err := os.Open(cfg)
 	if err != nil {
 		ntc.t.Errorf("could not read generated nginx configuration: %v", err.Error())
@@ -33,7 +33,8 @@ import (
 )
 
 // getEndpointsByEps returns a slice of ingress.Endpoint for a given service/target port combination.
-func getEndpointsByEps(svc *corev1.Service, svcPort *corev1.ServicePort, proto corev1.Protocol,
+// The endpoints terminating but still serving are only included with includeTerminatingServing.
+func getEndpointsByEps(svc *corev1.Service, svcPort *corev1.ServicePort, proto corev1.Protocol, includeTerminatingServing bool,
 	getServiceEndpointSlices func(string) ([]*discoveryv1.EndpointSlice, error)) []ingress.Endpoint {
 
 	upsServers := make([]ingress.Endpoint, 0)
@@ -105,15 +106,25 @@ func getEndpointsByEps(svc *corev1.Service, svcPort *corev1.ServicePort, proto c
 			}
 
 			for _, endpoint := range endpointSlice.Endpoints {
+				conditions := getEndpointConditions(endpoint.Conditions)
+				if !isUsableEndpoint(conditions, includeTerminatingServing) {
+					continue
+				}
+
 				for _, address := range endpoint.Addresses {
 					epStr := net.JoinHostPort(address, strconv.Itoa(int(targetPort)))
 					if _, exist := processedUpstreamServers[epStr]; exist {
 						continue
 					}
 					upServer := ingress.Endpoint{
-						Address: address,
-						Port:    fmt.Sprintf("%v", targetPort),
-						Target:  endpoint.TargetRef,
+						Address:    address,
+						Port:       fmt.Sprintf("%v", targetPort),
+						Target:     endpoint.TargetRef,
+						ForZones:   getEndpointForZones(endpoint.Hints),
+						Conditions: conditions,
+					}
+					if endpoint.Zone != nil {
+						upServer.Zone = *endpoint.Zone
 					}
 					upsServers = append(upsServers, upServer)
 					processedUpstreamServers[epStr] = struct{}{}
@@ -125,3 +136,94 @@ func getEndpointsByEps(svc *corev1.Service, svcPort *corev1.ServicePort, proto c
 	klog.V(3).Infof("Endpoints found for Service %q: %+v", svcKey, upsServers)
 	return upsServers
 }
+
+// getEndpointConditions returns the conditions of an endpoint of an EndpointSlice.
+// Unknown conditions default to the ones of a running endpoint: a nil ready
+// condition is ready, and a nil serving condition is the ready one.
+func getEndpointConditions(conditions discoveryv1.EndpointConditions) *ingress.EndpointConditions {
+	endpointConditions := &ingress.EndpointConditions{
+		Ready: conditions.Ready == nil || *conditions.Ready,
+	}
+
+	endpointConditions.Serving = endpointConditions.Ready
+	if conditions.Serving != nil {
+		endpointConditions.Serving = *conditions.Serving
+	}
+
+	if conditions.Terminating != nil {
+		endpointConditions.Terminating = *conditions.Terminating
+	}
+
+	return endpointConditions
+}
+
+// isUsableEndpoint returns true when the endpoint can receive traffic: when it is
+// ready, like the addresses of the Endpoints, or, with includeTerminatingServing,
+// when it is terminating but still serving, to finish the in-flight requests
+// during a rollout.
+func isUsableEndpoint(conditions *ingress.EndpointConditions, includeTerminatingServing bool) bool {
+	if conditions.Ready {
+		return true
+	}
+
+	return includeTerminatingServing && conditions.Terminating && conditions.Serving
+}
+
+// getEndpointForZones returns the zones of the topology aware hints of an endpoint
+func getEndpointForZones(hints *discoveryv1.EndpointHints) []string {
+	if hints == nil || len(hints.ForZones) == 0 {
+		return nil
+	}
+
+	zones := make([]string, 0, len(hints.ForZones))
+	for _, forZone := range hints.ForZones {
+		zones = append(zones, forZone.Name)
+	}
+
+	return zones
+}
+
+// getZoneEndpoints returns the endpoints of a service hinted for the given zone by
+// the topology aware hints of its EndpointSlices, or all the endpoints when none is
+// hinted for the zone.
+func getZoneEndpoints(svcKey, zone string, endps []ingress.Endpoint,
+	getServiceEndpointSlices func(string) ([]*discoveryv1.EndpointSlice, error)) []ingress.Endpoint {
+
+	endpointSlices, err := getServiceEndpointSlices(svcKey)
+	if err != nil {
+		return endps
+	}
+
+	zoneAddresses := make(map[string]struct{})
+	for _, endpointSlice := range endpointSlices {
+		for _, endpoint := range endpointSlice.Endpoints {
+			if endpoint.Hints == nil {
+				continue
+			}
+
+			for _, forZone := range endpoint.Hints.ForZones {
+				if forZone.Name != zone {
+					continue
+				}
+
+				for _, address := range endpoint.Addresses {
+					zoneAddresses[address] = struct{}{}
+				}
+			}
+		}
+	}
+
+	zoneEndps := make([]ingress.Endpoint, 0)
+	for _, endp := range endps {
+		if _, ok := zoneAddresses[endp.Address]; ok {
+			zoneEndps = append(zoneEndps, endp)
+		}
+	}
+
+	if len(zoneEndps) == 0 {
+		klog.V(3).Infof("No Endpoint of Service %q hinted for zone %q, using all of them", svcKey, zone)
+		return endps
+	}
+
+	return zoneEndps
+}
@@ -0,0 +1,238 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"k8s.io/ingress-nginx/internal/ingress"
+	ngx_config "k8s.io/ingress-nginx/internal/ingress/controller/config"
+)
+
+func newEndpointSliceTestService() *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "http-svc",
+			Namespace: "example",
+		},
+		Spec: corev1.ServiceSpec{
+			Type: corev1.ServiceTypeClusterIP,
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "http",
+					Port:       80,
+					TargetPort: intstr.FromInt(8080),
+					Protocol:   corev1.ProtocolTCP,
+				},
+			},
+		},
+	}
+}
+
+// newEndpointSliceTestEndpoints returns the Endpoints and the EndpointSlices
+// of the same service, with two ready addresses and a not ready one
+func newEndpointSliceTestEndpoints() (*corev1.Endpoints, []*discoveryv1.EndpointSlice) {
+	endpoints := &corev1.Endpoints{
+		Subsets: []corev1.EndpointSubset{
+			{
+				Addresses: []corev1.EndpointAddress{
+					{IP: "10.0.0.1"},
+					{IP: "10.0.0.2"},
+				},
+				NotReadyAddresses: []corev1.EndpointAddress{
+					{IP: "10.0.0.3"},
+				},
+				Ports: []corev1.EndpointPort{
+					{Name: "http", Port: 8080, Protocol: corev1.ProtocolTCP},
+				},
+			},
+		},
+	}
+
+	ready, notReady := true, false
+	zoneA, zoneB := "zone-a", "zone-b"
+	slice := func(name string, endpoints ...discoveryv1.Endpoint) *discoveryv1.EndpointSlice {
+		portName, port, protocol := "http", int32(8080), corev1.ProtocolTCP
+		return &discoveryv1.EndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "example",
+			},
+			Ports: []discoveryv1.EndpointPort{
+				{Name: &portName, Port: &port, Protocol: &protocol},
+			},
+			Endpoints: endpoints,
+		}
+	}
+
+	endpointSlices := []*discoveryv1.EndpointSlice{
+		slice("http-svc-abc", discoveryv1.Endpoint{
+			Addresses:  []string{"10.0.0.1"},
+			Conditions: discoveryv1.EndpointConditions{Ready: &ready},
+			Zone:       &zoneA,
+			Hints: &discoveryv1.EndpointHints{
+				ForZones: []discoveryv1.ForZone{{Name: zoneA}},
+			},
+		}),
+		slice("http-svc-def", discoveryv1.Endpoint{
+			Addresses: []string{"10.0.0.2"},
+			Zone:      &zoneB,
+		}, discoveryv1.Endpoint{
+			Addresses:  []string{"10.0.0.3"},
+			Conditions: discoveryv1.EndpointConditions{Ready: &notReady},
+			Zone:       &zoneB,
+		}),
+	}
+
+	return endpoints, endpointSlices
+}
+
+func endpointAddresses(endpoints []ingress.Endpoint) []string {
+	addresses := make([]string, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		addresses = append(addresses, fmt.Sprintf("%v:%v", endpoint.Address, endpoint.Port))
+	}
+	sort.Strings(addresses)
+	return addresses
+}
+
+func TestGetEndpointsByEpsMatchesEndpoints(t *testing.T) {
+	svc := newEndpointSliceTestService()
+	endpoints, endpointSlices := newEndpointSliceTestEndpoints()
+
+	legacy := getEndpoints(svc, &svc.Spec.Ports[0], corev1.ProtocolTCP, func(string) (*corev1.Endpoints, error) {
+		return endpoints, nil
+	})
+	bySlices := getEndpointsByEps(svc, &svc.Spec.Ports[0], corev1.ProtocolTCP, false, func(string) ([]*discoveryv1.EndpointSlice, error) {
+		return endpointSlices, nil
+	})
+
+	expected := []string{"10.0.0.1:8080", "10.0.0.2:8080"}
+	if addresses := endpointAddresses(legacy); !reflect.DeepEqual(addresses, expected) {
+		t.Errorf("expected the endpoints %v from the Endpoints but got %v", expected, addresses)
+	}
+	if addresses := endpointAddresses(bySlices); !reflect.DeepEqual(addresses, expected) {
+		t.Errorf("expected the endpoints %v from the EndpointSlices but got %v", expected, addresses)
+	}
+
+	for _, endpoint := range legacy {
+		if endpoint.Conditions != nil || endpoint.Zone != "" || endpoint.ForZones != nil {
+			t.Errorf("expected no conditions, zone or hints for the endpoint %v of the Endpoints", endpoint.Address)
+		}
+	}
+
+	expectedSlices := map[string]ingress.Endpoint{
+		"10.0.0.1": {
+			Zone:       "zone-a",
+			ForZones:   []string{"zone-a"},
+			Conditions: &ingress.EndpointConditions{Ready: true, Serving: true},
+		},
+		"10.0.0.2": {
+			Zone:       "zone-b",
+			Conditions: &ingress.EndpointConditions{Ready: true, Serving: true},
+		},
+	}
+	for _, endpoint := range bySlices {
+		expectedEndpoint := expectedSlices[endpoint.Address]
+		if endpoint.Zone != expectedEndpoint.Zone {
+			t.Errorf("expected zone %q for the endpoint %v but got %q", expectedEndpoint.Zone, endpoint.Address, endpoint.Zone)
+		}
+		if !reflect.DeepEqual(endpoint.ForZones, expectedEndpoint.ForZones) {
+			t.Errorf("expected hints %v for the endpoint %v but got %v", expectedEndpoint.ForZones, endpoint.Address, endpoint.ForZones)
+		}
+		if !reflect.DeepEqual(endpoint.Conditions, expectedEndpoint.Conditions) {
+			t.Errorf("expected conditions %+v for the endpoint %v but got %+v", expectedEndpoint.Conditions, endpoint.Address, endpoint.Conditions)
+		}
+	}
+}
+
+func TestGetEndpointConditions(t *testing.T) {
+	yes, no := true, false
+
+	testCases := []struct {
+		name       string
+		conditions discoveryv1.EndpointConditions
+		expected   ingress.EndpointConditions
+	}{
+		{"unknown conditions", discoveryv1.EndpointConditions{}, ingress.EndpointConditions{Ready: true, Serving: true}},
+		{"ready", discoveryv1.EndpointConditions{Ready: &yes}, ingress.EndpointConditions{Ready: true, Serving: true}},
+		{"not ready", discoveryv1.EndpointConditions{Ready: &no}, ingress.EndpointConditions{}},
+		{"terminating and serving", discoveryv1.EndpointConditions{Ready: &no, Serving: &yes, Terminating: &yes},
+			ingress.EndpointConditions{Serving: true, Terminating: true}},
+		{"terminating", discoveryv1.EndpointConditions{Ready: &no, Serving: &no, Terminating: &yes},
+			ingress.EndpointConditions{Terminating: true}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if conditions := getEndpointConditions(tc.conditions); *conditions != tc.expected {
+				t.Errorf("expected %+v but got %+v", tc.expected, *conditions)
+			}
+		})
+	}
+}
+
+func TestResolveEndpoints(t *testing.T) {
+	svc := newEndpointSliceTestService()
+	endpoints, endpointSlices := newEndpointSliceTestEndpoints()
+	// the EndpointSlices have one less address than the Endpoints to tell them apart
+	endpointSlices = endpointSlices[:1]
+
+	testCases := []struct {
+		name              string
+		useEndpointSlices bool
+		endpointSlices    map[string][]*discoveryv1.EndpointSlice
+		expected          []string
+	}{
+		{"endpointslices", true, map[string][]*discoveryv1.EndpointSlice{"example/http-svc": endpointSlices},
+			[]string{"10.0.0.1:8080"}},
+		{"endpoints", false, map[string][]*discoveryv1.EndpointSlice{"example/http-svc": endpointSlices},
+			[]string{"10.0.0.1:8080", "10.0.0.2:8080"}},
+		{"service without endpointslice", true, nil,
+			[]string{"10.0.0.1:8080", "10.0.0.2:8080"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			nginx := &NGINXController{
+				cfg: &Configuration{
+					ListenPorts: &ngx_config.ListenPorts{
+						Default: 80,
+					},
+					UseEndpointSlices: tc.useEndpointSlices,
+				},
+				store: fakeMCIStore{
+					endpoints:      map[string]*corev1.Endpoints{"example/http-svc": endpoints},
+					endpointSlices: tc.endpointSlices,
+				},
+			}
+
+			result := nginx.resolveEndpoints(svc, &svc.Spec.Ports[0], corev1.ProtocolTCP)
+			if addresses := endpointAddresses(result); !reflect.DeepEqual(addresses, tc.expected) {
+				t.Errorf("expected the endpoints %v but got %v", tc.expected, addresses)
+			}
+		})
+	}
+}
@@ -18,6 +18,7 @@ package controller
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	networking "k8s.io/api/networking/v1"
@@ -29,6 +30,33 @@ var (
 	pathTypePrefix = networking.PathTypePrefix
 )
 
+const (
+	// LocationTiebreakReverse orders locations with paths of equal length in
+	// reverse alphabetical order
+	LocationTiebreakReverse = "reverse"
+	// LocationTiebreakForward orders locations with paths of equal length in
+	// alphabetical order
+	LocationTiebreakForward = "forward"
+)
+
+// sortLocations orders locations by path length, longest first. Paths of equal
+// length are ordered alphabetically or in reverse depending on tiebreak.
+// NGINX picks the longest matching prefix location regardless of the order in
+// the configuration file, so the tiebreak only changes which regular expression
+// location is evaluated first when use-regex is enabled.
+func sortLocations(locations []*ingress.Location, tiebreak string) {
+	sort.SliceStable(locations, func(i, j int) bool {
+		if tiebreak == LocationTiebreakForward {
+			return locations[i].Path < locations[j].Path
+		}
+		return locations[i].Path > locations[j].Path
+	})
+
+	sort.SliceStable(locations, func(i, j int) bool {
+		return len(locations[i].Path) > len(locations[j].Path)
+	})
+}
+
 // updateServerLocations inspects the generated locations configuration for a server
 // normalizing the path and adding an additional exact location when is possible
 func updateServerLocations(locations []*ingress.Location) []*ingress.Location {
@@ -84,7 +112,56 @@ func updateServerLocations(locations []*ingress.Location) []*ingress.Location {
 		newLocations = append(newLocations, exactLocation)
 	}
 
-	return newLocations
+	return addPathRedirectLocations(newLocations)
+}
+
+// addPathRedirectLocations adds an exact location without the trailing slash for
+// the locations ending with one and using the disable-path-redirect annotation.
+// NGINX otherwise answers a request for the path without the slash with a 301
+// redirect to the location.
+func addPathRedirectLocations(locations []*ingress.Location) []*ingress.Location {
+	exactLocations := map[string]bool{}
+	for _, location := range locations {
+		if pathTypeOrDefault(location.PathType) == pathTypeExact {
+			exactLocations[location.Path] = true
+		}
+	}
+
+	for _, location := range locations {
+		if !location.DisablePathRedirect || location.Rewrite.UseRegex {
+			continue
+		}
+
+		if location.Path == rootLocation || !strings.HasSuffix(location.Path, "/") ||
+			pathTypeOrDefault(location.PathType) == pathTypeExact {
+			continue
+		}
+
+		path := strings.TrimSuffix(location.Path, "/")
+		if exactLocations[path] {
+			continue
+		}
+
+		var el ingress.Location = *location
+		exactLocation := &el
+		exactLocation.Path = path
+		exactLocation.PathType = &pathTypeExact
+		exactLocations[path] = true
+
+		locations = append(locations, exactLocation)
+	}
+
+	return locations
+}
+
+// pathTypeOrDefault returns the path type, treating a nil PathType as Prefix,
+// the same default applied by karmada.SetDefaultNGINXPathType
+func pathTypeOrDefault(pathType *networking.PathType) networking.PathType {
+	if pathType == nil {
+		return pathTypePrefix
+	}
+
+	return *pathType
 }
 
 func normalizePrefixPath(path string) string {
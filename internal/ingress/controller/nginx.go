@@ -18,6 +18,7 @@ package controller
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"errors"
@@ -70,6 +71,10 @@ const (
 
 // NewNGINXController creates a new NGINX Ingress controller.
 func NewNGINXController(config *Configuration, mc metric.Collector) *NGINXController {
+	if mc == nil {
+		mc = metric.DummyCollector{}
+	}
+
 	eventBroadcaster := record.NewBroadcaster()
 	eventBroadcaster.StartLogging(klog.Infof)
 	eventBroadcaster.StartRecordingToSink(&v1core.EventSinkImpl{
@@ -137,6 +142,7 @@ func NewNGINXController(config *Configuration, mc metric.Collector) *NGINXContro
 		config.IngressClassConfiguration)
 
 	n.syncQueue = task.NewTaskQueue(n.syncIngress)
+	n.syncQueue.OnSync(n.metricCollector.ObserveCoalescedUpdates)
 
 	if config.UpdateStatus {
 		n.syncStatus = status.NewStatusSyncer(status.Config{
@@ -234,6 +240,13 @@ type NGINXController struct {
 	// runningConfig contains the running configuration in the Backend
 	runningConfig *ingress.Configuration
 
+	// mciChecksum is the hash of the specs and annotations of the
+	// multiclusteringresses seen in the last sync
+	mciChecksum uint64
+
+	// reportedMCINotices are the keys of the notices reported by the last sync
+	reportedMCINotices sets.String
+
 	t ngx_template.Writer
 
 	resolver []net.IP
@@ -463,12 +476,11 @@ func (n NGINXController) generateTemplate(cfg ngx_config.Configuration, ingressC
 				}
 			}
 
-			// TODO: Allow PassthroughBackends to specify they support proxy-protocol
 			servers = append(servers, &TCPServer{
 				Hostname:      pb.Hostname,
 				IP:            svc.Spec.ClusterIP,
 				Port:          port,
-				ProxyProtocol: false,
+				ProxyProtocol: pb.ProxyProtocol,
 			})
 		}
 
@@ -612,8 +624,9 @@ func (n NGINXController) generateTemplate(cfg ngx_config.Configuration, ingressC
 }
 
 // testTemplate checks if the NGINX configuration inside the byte array is valid
-// running the command "nginx -t" using a temporal file.
-func (n NGINXController) testTemplate(cfg []byte) error {
+// running the command "nginx -t" using a temporal file. The command is killed
+// when the context is done.
+func (n NGINXController) testTemplate(ctx context.Context, cfg []byte) error {
 	if len(cfg) == 0 {
 		return fmt.Errorf("invalid NGINX configuration (empty)")
 	}
@@ -621,12 +634,13 @@ func (n NGINXController) testTemplate(cfg []byte) error {
 	if err != nil {
 		return err
 	}
+	defer os.Remove(tmpfile.Name())
 	defer tmpfile.Close()
 	err = os.WriteFile(tmpfile.Name(), cfg, file.ReadWriteByUser)
 	if err != nil {
 		return err
 	}
-	out, err := n.command.Test(tmpfile.Name())
+	out, err := n.command.Test(ctx, tmpfile.Name())
 	if err != nil {
 		// this error is different from the rest because it must be clear why nginx is not working
 		oe := fmt.Sprintf(`
@@ -639,7 +653,6 @@ Error: %v
 		return errors.New(oe)
 	}
 
-	os.Remove(tmpfile.Name())
 	return nil
 }
 
@@ -661,7 +674,7 @@ func (n *NGINXController) OnUpdate(ingressCfg ingress.Configuration) error {
 		return err
 	}
 
-	err = n.testTemplate(content)
+	err = n.testTemplate(context.Background(), content)
 	if err != nil {
 		return err
 	}
@@ -943,13 +956,16 @@ func configureBackends(rawBackends []*ingress.Backend) error {
 			NoServer:             backend.NoServer,
 			TrafficShapingPolicy: backend.TrafficShapingPolicy,
 			AlternativeBackends:  backend.AlternativeBackends,
+			HealthCheck:          backend.HealthCheck,
 		}
 
 		var endpoints []ingress.Endpoint
 		for _, endpoint := range backend.Endpoints {
 			endpoints = append(endpoints, ingress.Endpoint{
-				Address: endpoint.Address,
-				Port:    endpoint.Port,
+				Address:     endpoint.Address,
+				Port:        endpoint.Port,
+				MaxFails:    endpoint.MaxFails,
+				FailTimeout: endpoint.FailTimeout,
 			})
 		}
 
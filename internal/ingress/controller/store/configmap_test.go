@@ -0,0 +1,76 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+// the store is the resolver used by the annotation parsers
+var _ resolver.Resolver = &k8sStore{}
+
+func newConfigMapStore(t *testing.T) *k8sStore {
+	t.Helper()
+
+	return &k8sStore{
+		listers: &Lister{
+			ConfigMap: ConfigMapLister{Store: cache.NewStore(cache.MetaNamespaceKeyFunc)},
+		},
+	}
+}
+
+func TestGetConfigMap(t *testing.T) {
+	t.Run("the configmap does not exist", func(t *testing.T) {
+		s := newConfigMapStore(t)
+
+		_, err := s.GetConfigMap("namespace/configmap")
+		if err == nil {
+			t.Fatal("expected an error but nothing has been returned")
+		}
+
+		if _, ok := err.(NotExistsError); !ok {
+			t.Errorf("expected NotExistsError, got %v", err)
+		}
+	})
+
+	t.Run("the configmap exists", func(t *testing.T) {
+		s := newConfigMapStore(t)
+
+		configMap := &apiv1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "namespace", Name: "configmap"},
+			Data:       map[string]string{"X-Tenant": "example"},
+		}
+		if err := s.listers.ConfigMap.Add(configMap); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+
+		cm, err := s.GetConfigMap("namespace/configmap")
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+
+		if cm.Data["X-Tenant"] != "example" {
+			t.Errorf("expected the configmap data to be returned but got %v", cm.Data)
+		}
+	})
+}
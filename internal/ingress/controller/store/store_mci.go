@@ -154,6 +154,7 @@ func (s *k8sStore) updateSecretMCIMap(mci *karmadanetwork.MultiClusterIngress) {
 	secretAnnotations := []string{
 		"auth-secret",
 		"auth-tls-secret",
+		"default-ssl-certificate",
 		"proxy-ssl-secret",
 		"secure-verify-ca-secret",
 	}
@@ -17,9 +17,16 @@ limitations under the License.
 package store
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/base64"
+	"encoding/pem"
 	"fmt"
+	"math/big"
 	"os"
 	"path/filepath"
 	"sync"
@@ -39,6 +46,7 @@ import (
 	"k8s.io/client-go/tools/cache"
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
 
+	"k8s.io/ingress-nginx/internal/file"
 	"k8s.io/ingress-nginx/internal/ingress"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
 	"k8s.io/ingress-nginx/internal/ingress/controller/ingressclass"
@@ -1387,6 +1395,7 @@ func newStore(t *testing.T) *k8sStore {
 			IngressClass:          IngressClassLister{cache.NewStore(cache.MetaNamespaceKeyFunc)},
 			Ingress:               IngressLister{cache.NewStore(cache.MetaNamespaceKeyFunc)},
 			IngressWithAnnotation: IngressWithAnnotationsLister{cache.NewStore(cache.DeletionHandlingMetaNamespaceKeyFunc)},
+			Secret:                SecretLister{cache.NewStore(cache.MetaNamespaceKeyFunc)},
 		},
 		sslStore:         NewSSLCertTracker(),
 		updateCh:         channels.NewRingChannel(10),
@@ -1615,3 +1624,69 @@ func TestWriteSSLSessionTicketKey(t *testing.T) {
 		}
 	}
 }
+
+func TestGetAuthCertificateWithoutCA(t *testing.T) {
+	s := newStore(t)
+
+	cert, key := generateTestKeyPair(t, "client.example.com")
+	s.listers.Secret.Add(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "client-cert",
+			Namespace: "testns",
+		},
+		Data: map[string][]byte{
+			v1.TLSCertKey:       cert,
+			v1.TLSPrivateKeyKey: key,
+		},
+	})
+
+	authCert, err := s.GetAuthCertificate("testns/client-cert")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.Remove(authCert.PemFileName)
+
+	if authCert.CAFileName != "" {
+		t.Errorf("expected no CA file but got %v", authCert.CAFileName)
+	}
+
+	expected := filepath.Join(file.DefaultSSLDirectory, "testns-client-cert.pem")
+	if authCert.PemFileName != expected {
+		t.Fatalf("expected PEM file %v but got %v", expected, authCert.PemFileName)
+	}
+
+	content, err := os.ReadFile(authCert.PemFileName)
+	if err != nil {
+		t.Fatalf("unexpected error reading PEM file: %v", err)
+	}
+	if !bytes.Contains(content, cert) || !bytes.Contains(content, key) {
+		t.Errorf("expected PEM file to contain the certificate and the key")
+	}
+}
+
+func generateTestKeyPair(t *testing.T, host string) ([]byte, []byte) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("unexpected error generating key: %v", err)
+	}
+
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: host},
+		DNSNames:     []string{host},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &priv.PublicKey, priv)
+	if err != nil {
+		t.Fatalf("unexpected error creating certificate: %v", err)
+	}
+
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	key := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(priv)})
+
+	return cert, key
+}
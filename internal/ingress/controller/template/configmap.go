@@ -17,6 +17,8 @@ limitations under the License.
 package template
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"net"
 	"regexp"
@@ -64,11 +66,15 @@ const (
 	globalAuthCacheDuration       = "global-auth-cache-duration"
 	luaSharedDictsKey             = "lua-shared-dicts"
 	plugins                       = "plugins"
+	proxyCacheZones               = "proxy-cache-zones"
+	defaultJSONResponses          = "default-json-responses"
 )
 
 var (
 	validRedirectCodes    = sets.NewInt([]int{301, 302, 307, 308}...)
 	dictSizeRegex         = regexp.MustCompile(`^(\d+)([kKmM])?$`)
+	cacheZoneNameRegex    = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+	cacheZoneSizeRegex    = regexp.MustCompile(`^\d+[kKmM]$`)
 	defaultLuaSharedDicts = map[string]int{
 		"configuration_data":            20480,
 		"certificate_data":              20480,
@@ -144,6 +150,49 @@ func ReadConfig(src map[string]string) config.Configuration {
 		}
 	}
 
+	cacheZones := make(map[string]string)
+	if val, ok := conf[proxyCacheZones]; ok {
+		delete(conf, proxyCacheZones)
+		for _, v := range splitAndTrimSpace(val, ",") {
+			v = strings.Replace(v, " ", "", -1)
+			results := strings.SplitN(v, ":", 2)
+			if len(results) != 2 || !cacheZoneNameRegex.MatchString(results[0]) || results[0] == "auth_cache" {
+				klog.Errorf("Ignoring poorly formatted proxy cache zone %v", v)
+				continue
+			}
+			if !cacheZoneSizeRegex.MatchString(results[1]) {
+				klog.Errorf("Ignoring poorly formatted size %v for proxy cache zone %v", results[1], results[0])
+				continue
+			}
+
+			cacheZones[results[0]] = strings.ToLower(results[1])
+		}
+	}
+
+	jsonResponses := make(map[int]string)
+	if val, ok := conf[defaultJSONResponses]; ok {
+		delete(conf, defaultJSONResponses)
+		bodies := map[string]json.RawMessage{}
+		if err := json.Unmarshal([]byte(val), &bodies); err != nil {
+			klog.Errorf("Ignoring %v, it is not a JSON object of bodies indexed by status code: %v", defaultJSONResponses, err)
+		}
+		for k, body := range bodies {
+			code, err := strconv.Atoi(k)
+			if err != nil || code < 400 || code > 599 {
+				klog.Errorf("Ignoring the default JSON response of %q, it is not a 4xx or 5xx status code", k)
+				continue
+			}
+
+			compact := &bytes.Buffer{}
+			if err := json.Compact(compact, body); err != nil {
+				klog.Errorf("Ignoring the default JSON response of %v: %v", code, err)
+				continue
+			}
+
+			jsonResponses[code] = compact.String()
+		}
+	}
+
 	if val, ok := conf[customHTTPErrors]; ok {
 		delete(conf, customHTTPErrors)
 		for _, i := range splitAndTrimSpace(val, ",") {
@@ -375,6 +424,8 @@ func ReadConfig(src map[string]string) config.Configuration {
 	to.ProxyStreamResponses = streamResponses
 	to.DisableIpv6DNS = !ing_net.IsIPv6Enabled()
 	to.LuaSharedDicts = luaSharedDicts
+	to.ProxyCacheZones = cacheZones
+	to.DefaultJSONResponses = jsonResponses
 
 	config := &mapstructure.DecoderConfig{
 		Metadata:         nil,
@@ -388,6 +388,73 @@ func TestLuaSharedDictsParsing(t *testing.T) {
 	}
 }
 
+func TestProxyCacheZonesParsing(t *testing.T) {
+	testsCases := []struct {
+		name   string
+		entry  map[string]string
+		expect map[string]string
+	}{
+		{
+			name:   "no zones configured when proxy-cache-zones is not set",
+			entry:  make(map[string]string),
+			expect: map[string]string{},
+		},
+		{
+			name:   "custom zones",
+			entry:  map[string]string{"proxy-cache-zones": "api: 10m, static:512K"},
+			expect: map[string]string{"api": "10m", "static": "512k"},
+		},
+		{
+			name:   "invalid zones should be ignored",
+			entry:  map[string]string{"proxy-cache-zones": "api:10m, no_unit:10, bad-name:1m, auth_cache:1m, missing_size"},
+			expect: map[string]string{"api": "10m"},
+		},
+	}
+
+	for _, tc := range testsCases {
+		cfg := ReadConfig(tc.entry)
+		if !reflect.DeepEqual(cfg.ProxyCacheZones, tc.expect) {
+			t.Errorf("Testing %v. Expected \"%v\" but \"%v\" was returned", tc.name, tc.expect, cfg.ProxyCacheZones)
+		}
+	}
+}
+
+func TestDefaultJSONResponsesParsing(t *testing.T) {
+	testsCases := []struct {
+		name   string
+		entry  map[string]string
+		expect map[int]string
+	}{
+		{
+			name:   "no responses configured when default-json-responses is not set",
+			entry:  make(map[string]string),
+			expect: map[int]string{},
+		},
+		{
+			name:   "compacted responses",
+			entry:  map[string]string{"default-json-responses": `{"404": {"error": "not found"}, "503": {"error": "unavailable", "retry": true}}`},
+			expect: map[int]string{404: `{"error":"not found"}`, 503: `{"error":"unavailable","retry":true}`},
+		},
+		{
+			name:   "invalid status codes should be ignored",
+			entry:  map[string]string{"default-json-responses": `{"404": {"error": "not found"}, "200": {}, "teapot": {}}`},
+			expect: map[int]string{404: `{"error":"not found"}`},
+		},
+		{
+			name:   "invalid JSON should be ignored",
+			entry:  map[string]string{"default-json-responses": `{"404": {"error": not found}}`},
+			expect: map[int]string{},
+		},
+	}
+
+	for _, tc := range testsCases {
+		cfg := ReadConfig(tc.entry)
+		if !reflect.DeepEqual(cfg.DefaultJSONResponses, tc.expect) {
+			t.Errorf("Testing %v. Expected \"%v\" but \"%v\" was returned", tc.name, tc.expect, cfg.DefaultJSONResponses)
+		}
+	}
+}
+
 func TestSplitAndTrimSpace(t *testing.T) {
 	testsCases := []struct {
 		name   string
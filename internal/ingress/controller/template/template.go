@@ -24,10 +24,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"math/rand" // #nosec
 	"net"
 	"net/url"
 	"os"
+	"path"
 	"reflect"
 	"regexp"
 	"sort"
@@ -36,13 +38,17 @@ import (
 	text_template "text/template"
 	"time"
 
+	apiv1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
 
 	"k8s.io/ingress-nginx/internal/ingress"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/backendscheme"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/defaultbackend"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/influxdb"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/ratelimit"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/requestid"
 	"k8s.io/ingress-nginx/internal/ingress/controller/config"
 	ing_net "k8s.io/ingress-nginx/internal/net"
 )
@@ -221,6 +227,7 @@ var (
 			return true
 		},
 		"escapeLiteralDollar":             escapeLiteralDollar,
+		"buildDefaultResponse":            buildDefaultResponse,
 		"buildLuaSharedDictionaries":      buildLuaSharedDictionaries,
 		"luaConfigurationRequestBodySize": luaConfigurationRequestBodySize,
 		"buildLocation":                   buildLocation,
@@ -232,6 +239,8 @@ var (
 		"filterRateLimits":                filterRateLimits,
 		"buildRateLimitZones":             buildRateLimitZones,
 		"buildRateLimit":                  buildRateLimit,
+		"filterAccessLogSamples":          filterAccessLogSamples,
+		"accessLogSampleID":               accessLogSampleID,
 		"configForLua":                    configForLua,
 		"locationConfigForLua":            locationConfigForLua,
 		"buildResolvers":                  buildResolvers,
@@ -256,10 +265,15 @@ var (
 		},
 		"isValidByteSize":                    isValidByteSize,
 		"buildForwardedFor":                  buildForwardedFor,
+		"buildHeaderVariable":                buildHeaderVariable,
+		"buildGeoIPAccess":                   buildGeoIPAccess,
+		"buildPreloadLinks":                  buildPreloadLinks,
 		"buildAuthSignURL":                   buildAuthSignURL,
 		"buildAuthSignURLLocation":           buildAuthSignURLLocation,
 		"buildOpentracing":                   buildOpentracing,
 		"proxySetHeader":                     proxySetHeader,
+		"buildRequestID":                     buildRequestID,
+		"buildRequestIDHeaders":              buildRequestIDHeaders,
 		"buildInfluxDB":                      buildInfluxDB,
 		"enforceRegexModifier":               enforceRegexModifier,
 		"buildCustomErrorDeps":               buildCustomErrorDeps,
@@ -273,6 +287,7 @@ var (
 		"buildMirrorLocations":               buildMirrorLocations,
 		"shouldLoadAuthDigestModule":         shouldLoadAuthDigestModule,
 		"shouldLoadInfluxDBModule":           shouldLoadInfluxDBModule,
+		"shouldLoadBrotliModule":             shouldLoadBrotliModule,
 		"buildServerName":                    buildServerName,
 		"buildCorsOriginRegex":               buildCorsOriginRegex,
 	}
@@ -306,6 +321,24 @@ func formatIP(input string) string {
 	return fmt.Sprintf("[%s]", input)
 }
 
+// buildDefaultResponse returns the directives returning the body of the
+// default-json-responses configured for the status code, if any
+func buildDefaultResponse(c interface{}, code int) string {
+	cfg, ok := c.(config.Configuration)
+	if !ok {
+		klog.Errorf("expected a 'config.Configuration' type but %T was returned", c)
+		return ""
+	}
+
+	body, ok := cfg.DefaultJSONResponses[code]
+	if !ok {
+		return ""
+	}
+
+	body = strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(body)
+	return fmt.Sprintf("default_type %q;\nreturn %v '%v';", cfg.DefaultResponseContentType, code, escapeLiteralDollar(body))
+}
+
 func quote(input interface{}) string {
 	var inputStr string
 	switch input := input.(type) {
@@ -627,7 +660,7 @@ func buildProxyPass(host string, b interface{}, loc interface{}) string {
 	switch location.BackendProtocol {
 	case "AUTO_HTTP":
 		proto = "$scheme://"
-	case "HTTPS":
+	case "HTTPS", "WSS":
 		proto = "https://"
 	case "GRPC":
 		proto = "grpc://"
@@ -659,14 +692,30 @@ func buildProxyPass(host string, b interface{}, loc interface{}) string {
 		}
 	}
 
+	// the backend-scheme annotation takes precedence over the backend protocol
+	if scheme := locationBackendScheme(location); scheme != "" {
+		proto = scheme + "://"
+		proxyPass = "proxy_pass"
+		if backendscheme.IsGRPC(scheme) {
+			proxyPass = "grpc_pass"
+		}
+	}
+
 	// TODO: add support for custom protocols
 	if location.Backend == "upstream-default-backend" {
 		proto = "http://"
 		proxyPass = "proxy_pass"
 	}
 
+	// the upstream is resolved by NGINX at request time, which requires a variable
+	var externalUpstream string
+	if location.ExternalUpstream != nil && location.Backend != "upstream-default-backend" {
+		externalUpstream = buildExternalUpstream(location.ExternalUpstream)
+		upstreamName = "$external_upstream"
+	}
+
 	// defProxyPass returns the default proxy_pass, just the name of the upstream
-	defProxyPass := fmt.Sprintf("%v %s%s;", proxyPass, proto, upstreamName)
+	defProxyPass := fmt.Sprintf("%v%v %s%s;", externalUpstream, proxyPass, proto, upstreamName)
 
 	// if the path in the ingress rule is equals to the target: no special rewrite
 	if path == location.Rewrite.Target {
@@ -681,14 +730,40 @@ func buildProxyPass(host string, b interface{}, loc interface{}) string {
 		}
 
 		return fmt.Sprintf(`
-rewrite "(?i)%s" %s break;
-%v%v %s%s;`, path, location.Rewrite.Target, xForwardedPrefix, proxyPass, proto, upstreamName)
+%vrewrite "(?i)%s" %s break;
+%v%v %s%s;`, externalUpstream, path, location.Rewrite.Target, xForwardedPrefix, proxyPass, proto, upstreamName)
 	}
 
 	// default proxy_pass
 	return defProxyPass
 }
 
+// locationBackendScheme returns the scheme of the backend-scheme annotation
+// unless the backend protocol does not use one
+func locationBackendScheme(location *ingress.Location) string {
+	if location.BackendProtocol == "AJP" || location.BackendProtocol == "FCGI" {
+		return ""
+	}
+
+	return location.BackendScheme
+}
+
+// isGRPCLocation returns true if the location proxies the requests with grpc_pass
+func isGRPCLocation(location *ingress.Location) bool {
+	if scheme := locationBackendScheme(location); scheme != "" {
+		return backendscheme.IsGRPC(scheme)
+	}
+
+	return location.BackendProtocol == "GRPC" || location.BackendProtocol == "GRPCS"
+}
+
+// buildExternalUpstream returns the resolver directive and the variable used
+// by proxy_pass for an upstream NGINX must resolve at request time
+func buildExternalUpstream(eu *ingress.ExternalUpstream) string {
+	return fmt.Sprintf("resolver %v valid=%vs;\nset $external_upstream \"%v\";\n",
+		strings.Join(eu.Resolvers, " "), eu.Valid, net.JoinHostPort(eu.Host, eu.Port))
+}
+
 func filterRateLimits(input interface{}) []ratelimit.Config {
 	ratelimits := []ratelimit.Config{}
 	found := sets.String{}
@@ -709,6 +784,74 @@ func filterRateLimits(input interface{}) []ratelimit.Config {
 	return ratelimits
 }
 
+// accessLogSample describes a fraction of the requests written to the access log
+type accessLogSample struct {
+	// ID is the suffix of the variables of the sample, the rate in basis points
+	ID string
+	// Percent is the rate as expected by split_clients
+	Percent string
+}
+
+// accessLogSampleID returns the suffix of the variables used to sample the
+// access log at the given rate, or an empty string when every request is logged
+func accessLogSampleID(input interface{}) string {
+	rate, ok := input.(float64)
+	if !ok {
+		klog.Errorf("expected a 'float64' type but %T was returned", input)
+		return ""
+	}
+
+	if rate <= 0 {
+		return ""
+	}
+
+	// split_clients accepts up to two decimals in the percentages
+	bp := int(math.Round(rate * 10000))
+	if bp < 1 {
+		bp = 1
+	}
+	if bp >= 10000 {
+		return ""
+	}
+
+	return strconv.Itoa(bp)
+}
+
+// filterAccessLogSamples returns the distinct access log sample rates of the
+// locations, each one requiring its own split_clients block
+func filterAccessLogSamples(input interface{}) []accessLogSample {
+	samples := []accessLogSample{}
+	found := sets.String{}
+
+	servers, ok := input.([]*ingress.Server)
+	if !ok {
+		klog.Errorf("expected a '[]*ingress.Server' type but %T was returned", input)
+		return samples
+	}
+
+	for _, server := range servers {
+		for _, loc := range server.Locations {
+			if !loc.Logs.Access {
+				continue
+			}
+
+			id := accessLogSampleID(loc.Logs.SampleRate)
+			if id == "" || found.Has(id) {
+				continue
+			}
+			found.Insert(id)
+
+			bp, _ := strconv.Atoi(id)
+			samples = append(samples, accessLogSample{
+				ID:      id,
+				Percent: fmt.Sprintf("%d.%02d%%", bp/100, bp%100),
+			})
+		}
+	}
+
+	return samples
+}
+
 // buildRateLimitZones produces an array of limit_conn_zone in order to allow
 // rate limiting of request. Each Ingress rule could have up to three zones, one
 // for connection limit by IP address, one for limiting requests per minute, and
@@ -790,6 +933,18 @@ func buildRateLimit(input interface{}) []string {
 		limits = append(limits, limit)
 	}
 
+	if loc.RateLimit.ConnStatusCode > 0 && loc.RateLimit.Connections.Limit > 0 {
+		limit := fmt.Sprintf("limit_conn_status %v;",
+			loc.RateLimit.ConnStatusCode)
+		limits = append(limits, limit)
+	}
+
+	if loc.RateLimit.ReqStatusCode > 0 && (loc.RateLimit.RPS.Limit > 0 || loc.RateLimit.RPM.Limit > 0) {
+		limit := fmt.Sprintf("limit_req_status %v;",
+			loc.RateLimit.ReqStatusCode)
+		limits = append(limits, limit)
+	}
+
 	if loc.RateLimit.LimitRateAfter > 0 {
 		limit := fmt.Sprintf("limit_rate_after %vk;",
 			loc.RateLimit.LimitRateAfter)
@@ -1049,6 +1204,116 @@ func getIngressInformation(i, h, p interface{}) *ingressInformation {
 }
 
 func buildForwardedFor(input interface{}) string {
+	return buildHeaderVariable(input)
+}
+
+// preloadDestinations contains the destination of the preloaded assets by
+// extension, as browsers ignore the preload links without it
+var preloadDestinations = map[string]string{
+	".css":   "style",
+	".js":    "script",
+	".mjs":   "script",
+	".woff":  "font",
+	".woff2": "font",
+	".ttf":   "font",
+	".otf":   "font",
+	".png":   "image",
+	".jpg":   "image",
+	".jpeg":  "image",
+	".gif":   "image",
+	".svg":   "image",
+	".webp":  "image",
+	".avif":  "image",
+}
+
+// buildPreloadLinks returns the quoted value of the Link header announcing
+// the assets to preload
+func buildPreloadLinks(input interface{}) string {
+	assets, ok := input.([]string)
+	if !ok {
+		klog.Errorf("expected a '[]string' type but %T was returned", input)
+		return ""
+	}
+
+	links := make([]string, 0, len(assets))
+	for _, asset := range assets {
+		link := fmt.Sprintf("<%v>; rel=preload", asset)
+		if as, ok := preloadDestinations[strings.ToLower(path.Ext(asset))]; ok {
+			link = fmt.Sprintf("%v; as=%v", link, as)
+			// fonts are always fetched in CORS mode
+			if as == "font" {
+				link = fmt.Sprintf("%v; crossorigin", link)
+			}
+		}
+		links = append(links, link)
+	}
+
+	return fmt.Sprintf("%q", strings.Join(links, ", "))
+}
+
+// GeoIPCountryVariable returns the NGINX variable holding the country code of
+// the client, or an empty string when no GeoIP database provides it
+func GeoIPCountryVariable(cfg config.Configuration, files *[]string) string {
+	if cfg.UseGeoIP2 && files != nil {
+		editions := sets.NewString(*files...)
+		if editions.Has("GeoLite2-Country.mmdb") || editions.Has("GeoIP2-Country.mmdb") {
+			return "$geoip2_country_code"
+		}
+		if editions.Has("GeoLite2-City.mmdb") || editions.Has("GeoIP2-City.mmdb") {
+			return "$geoip2_city_country_code"
+		}
+	}
+
+	if cfg.UseGeoIP {
+		return "$geoip_country_code"
+	}
+
+	return ""
+}
+
+// buildGeoIPAccess returns the directives denying the access to the location
+// by country. Without GeoIP database the country of every client is unknown,
+// so an allow list denies every request and a deny list none.
+func buildGeoIPAccess(c, f, l interface{}) string {
+	cfg, ok := c.(config.Configuration)
+	if !ok {
+		klog.Errorf("expected a 'config.Configuration' type but %T was returned", c)
+		return ""
+	}
+
+	files, ok := f.(*[]string)
+	if !ok {
+		klog.Errorf("expected a '*[]string' type but %T was returned", f)
+		return ""
+	}
+
+	location, ok := l.(*ingress.Location)
+	if !ok {
+		klog.Errorf("expected an '*ingress.Location' type but %T was returned", l)
+		return ""
+	}
+
+	access := location.GeoIPAccess
+	if !access.Enabled() {
+		return ""
+	}
+
+	variable := GeoIPCountryVariable(cfg, files)
+	if len(access.AllowCountries) > 0 {
+		if variable == "" {
+			return "return 403;"
+		}
+		return fmt.Sprintf("if (%v !~ \"^(%v)$\") {\n    return 403;\n}", variable, strings.Join(access.AllowCountries, "|"))
+	}
+
+	if variable == "" {
+		return ""
+	}
+	return fmt.Sprintf("if (%v ~ \"^(%v)$\") {\n    return 403;\n}", variable, strings.Join(access.DenyCountries, "|"))
+}
+
+// buildHeaderVariable returns the NGINX variable holding the request header
+func buildHeaderVariable(input interface{}) string {
 	s, ok := input.(string)
 	if !ok {
 		klog.Errorf("expected a 'string' type but %T was returned", input)
@@ -1168,30 +1433,136 @@ func proxySetHeader(loc interface{}) string {
 		return "proxy_set_header"
 	}
 
-	if location.BackendProtocol == "GRPC" || location.BackendProtocol == "GRPCS" {
+	if isGRPCLocation(location) {
 		return "grpc_set_header"
 	}
 
 	return "proxy_set_header"
 }
 
+// buildRequestIDHeaders returns the variable suffixes of the custom request ID
+// headers of the locations, used to declare one $req_id_<suffix> map each.
+func buildRequestIDHeaders(input interface{}) []string {
+	headers := sets.String{}
+
+	servers, ok := input.([]*ingress.Server)
+	if !ok {
+		klog.Errorf("expected a '[]*ingress.Server' type but %T was returned", input)
+		return headers.List()
+	}
+
+	for _, server := range servers {
+		for _, loc := range server.Locations {
+			if !loc.RequestID.Enabled || strings.EqualFold(loc.RequestID.Header, requestid.DefaultHeader) {
+				continue
+			}
+
+			headers.Insert(requestIDHeaderVariable(loc.RequestID.Header))
+		}
+	}
+
+	return headers.List()
+}
+
+// requestIDHeaderVariable returns the suffix of the NGINX variable of a
+// request header, e.g. x_correlation_id for X-Correlation-ID
+func requestIDHeaderVariable(header string) string {
+	return strings.ToLower(strings.ReplaceAll(header, "-", "_"))
+}
+
+// buildRequestID returns the directives used to propagate the request ID to the
+// upstream server. A custom request ID header keeps the value sent by the client,
+// or gets a generated one. When opentracing is active, the trace ID of the
+// configured tracer is reused.
+func buildRequestID(c interface{}, loc interface{}) string {
+	cfg, ok := c.(config.Configuration)
+	if !ok {
+		klog.Errorf("expected a 'config.Configuration' type but %T was returned", c)
+		return ""
+	}
+
+	location, ok := loc.(*ingress.Location)
+	if !ok {
+		klog.Errorf("expected a '*ingress.Location' type but %T was returned", loc)
+		return ""
+	}
+
+	setHeader := proxySetHeader(location)
+	defaultHeader := fmt.Sprintf("%v %v $req_id;", setHeader, requestid.DefaultHeader)
+
+	if !location.RequestID.Enabled {
+		return defaultHeader
+	}
+
+	value := "$req_id"
+	if !strings.EqualFold(location.RequestID.Header, requestid.DefaultHeader) {
+		value = "$req_id_" + requestIDHeaderVariable(location.RequestID.Header)
+	}
+
+	if isOpentracingEnabledForLocation(cfg, location) {
+		if traceID := opentracingTraceIDVariable(cfg); traceID != "" {
+			value = traceID
+		}
+	}
+
+	header := fmt.Sprintf("%v %v %v;", setHeader, location.RequestID.Header, value)
+	if strings.EqualFold(location.RequestID.Header, requestid.DefaultHeader) {
+		return header
+	}
+
+	return fmt.Sprintf("%v\n%v", defaultHeader, header)
+}
+
+func isOpentracingEnabledForLocation(cfg config.Configuration, location *ingress.Location) bool {
+	if location.Opentracing.Set {
+		return location.Opentracing.Enabled
+	}
+
+	return cfg.EnableOpentracing
+}
+
+// opentracingTraceIDVariable returns the NGINX variable containing the trace ID
+// propagated by the configured opentracing tracer. The uber-trace-id header of
+// Jaeger is formatted as trace-id:span-id:parent-span-id:flags, the trace ID is
+// extracted by the $jaeger_trace_id map of the template.
+func opentracingTraceIDVariable(cfg config.Configuration) string {
+	if cfg.DatadogCollectorHost != "" {
+		return "$opentracing_context_x_datadog_trace_id"
+	} else if cfg.ZipkinCollectorHost != "" {
+		return "$opentracing_context_x_b3_traceid"
+	} else if cfg.JaegerCollectorHost != "" || cfg.JaegerEndpoint != "" {
+		return "$jaeger_trace_id"
+	}
+
+	return ""
+}
+
 // buildCustomErrorDeps is a utility function returning a struct wrapper with
-// the data required to build the 'CUSTOM_ERRORS' template
-func buildCustomErrorDeps(upstreamName string, errorCodes []int, enableMetrics bool) interface{} {
+// the data required to build the 'CUSTOM_ERRORS' template. The backend protocol
+// and the upstream vhost are only set for an external default backend.
+func buildCustomErrorDeps(upstreamName string, errorCodes []int, enableMetrics bool, backendProtocol, upstreamVhost string) interface{} {
 	return struct {
-		UpstreamName  string
-		ErrorCodes    []int
-		EnableMetrics bool
+		UpstreamName    string
+		ErrorCodes      []int
+		EnableMetrics   bool
+		BackendProtocol string
+		UpstreamVhost   string
 	}{
-		UpstreamName:  upstreamName,
-		ErrorCodes:    errorCodes,
-		EnableMetrics: enableMetrics,
+		UpstreamName:    upstreamName,
+		ErrorCodes:      errorCodes,
+		EnableMetrics:   enableMetrics,
+		BackendProtocol: backendProtocol,
+		UpstreamVhost:   upstreamVhost,
 	}
 }
 
 type errorLocation struct {
 	UpstreamName string
 	Codes        []int
+	// BackendProtocol and UpstreamVhost are the protocol and the host of
+	// the default backend synthesized from the default-backend-url annotation
+	BackendProtocol string
+	UpstreamVhost   string
 }
 
 // buildCustomErrorLocationsPerServer is a utility function which will collect all
@@ -1208,8 +1579,12 @@ func buildCustomErrorLocationsPerServer(input interface{}) []errorLocation {
 	}
 
 	codesMap := make(map[string]map[int]bool)
+	externalBackends := make(map[string]*apiv1.Service)
 	for _, loc := range server.Locations {
 		backendUpstream := loc.DefaultBackendUpstreamName
+		if _, _, ok := defaultbackend.ExternalBackend(loc.DefaultBackend); ok {
+			externalBackends[backendUpstream] = loc.DefaultBackend
+		}
 
 		var dedupedCodes map[int]bool
 		if existingMap, ok := codesMap[backendUpstream]; ok {
@@ -1232,9 +1607,12 @@ func buildCustomErrorLocationsPerServer(input interface{}) []errorLocation {
 			codesForUpstream = append(codesForUpstream, code)
 		}
 		sort.Ints(codesForUpstream)
+		host, protocol, _ := defaultbackend.ExternalBackend(externalBackends[upstream])
 		errorLocations = append(errorLocations, errorLocation{
-			UpstreamName: upstream,
-			Codes:        codesForUpstream,
+			UpstreamName:    upstream,
+			Codes:           codesForUpstream,
+			BackendProtocol: protocol,
+			UpstreamVhost:   host,
 		})
 	}
 
@@ -1250,7 +1628,7 @@ func opentracingPropagateContext(location *ingress.Location) string {
 		return ""
 	}
 
-	if location.BackendProtocol == "GRPC" || location.BackendProtocol == "GRPCS" {
+	if isGRPCLocation(location) {
 		return "opentracing_grpc_propagate_context;"
 	}
 
@@ -1300,9 +1678,8 @@ func buildHTTPListener(t interface{}, s interface{}) string {
 		return ""
 	}
 
-	hostname, ok := s.(string)
+	hostname, httpPort, _, ok := listenServer(s)
 	if !ok {
-		klog.Errorf("expected a 'string' type but %T was returned", s)
 		return ""
 	}
 
@@ -1312,8 +1689,7 @@ func buildHTTPListener(t interface{}, s interface{}) string {
 	}
 
 	co := commonListenOptions(tc, hostname)
-
-	out = append(out, httpListener(addrV4, co, tc)...)
+	out = append(out, httpListener(addrV4, co, tc, httpPort)...)
 
 	if !tc.IsIPV6Enabled {
 		return strings.Join(out, "\n")
@@ -1324,7 +1700,7 @@ func buildHTTPListener(t interface{}, s interface{}) string {
 		addrV6 = tc.Cfg.BindAddressIpv6
 	}
 
-	out = append(out, httpListener(addrV6, co, tc)...)
+	out = append(out, httpListener(addrV6, co, tc, httpPort)...)
 
 	return strings.Join(out, "\n")
 }
@@ -1338,9 +1714,8 @@ func buildHTTPSListener(t interface{}, s interface{}) string {
 		return ""
 	}
 
-	hostname, ok := s.(string)
+	hostname, _, httpsPort, ok := listenServer(s)
 	if !ok {
-		klog.Errorf("expected a 'string' type but %T was returned", s)
 		return ""
 	}
 
@@ -1351,7 +1726,7 @@ func buildHTTPSListener(t interface{}, s interface{}) string {
 		addrV4 = tc.Cfg.BindAddressIpv4
 	}
 
-	out = append(out, httpsListener(addrV4, co, tc)...)
+	out = append(out, httpsListener(addrV4, co, tc, httpsPort)...)
 
 	if !tc.IsIPV6Enabled {
 		return strings.Join(out, "\n")
@@ -1362,7 +1737,7 @@ func buildHTTPSListener(t interface{}, s interface{}) string {
 		addrV6 = tc.Cfg.BindAddressIpv6
 	}
 
-	out = append(out, httpsListener(addrV6, co, tc)...)
+	out = append(out, httpsListener(addrV6, co, tc, httpsPort)...)
 
 	return strings.Join(out, "\n")
 }
@@ -1391,15 +1766,34 @@ func commonListenOptions(template config.TemplateConfig, hostname string) string
 	return strings.Join(out, " ")
 }
 
-func httpListener(addresses []string, co string, tc config.TemplateConfig) []string {
+// listenServer returns the hostname and the custom HTTP and HTTPS ports of a
+// server, or of the redirect server with the given hostname, which always uses
+// the default ports. Zero means the default port applies.
+func listenServer(s interface{}) (string, int, int, bool) {
+	switch server := s.(type) {
+	case *ingress.Server:
+		return server.Hostname, server.HTTPPort, server.HTTPSPort, true
+	case string:
+		return server, 0, 0, true
+	}
+
+	klog.Errorf("expected an '*ingress.Server' or 'string' type but %T was returned", s)
+	return "", 0, 0, false
+}
+
+func httpListener(addresses []string, co string, tc config.TemplateConfig, port int) []string {
+	if port == 0 {
+		port = tc.ListenPorts.HTTP
+	}
+
 	out := make([]string, 0)
 	for _, address := range addresses {
 		lo := []string{"listen"}
 
 		if address == "" {
-			lo = append(lo, fmt.Sprintf("%v", tc.ListenPorts.HTTP))
+			lo = append(lo, fmt.Sprintf("%v", port))
 		} else {
-			lo = append(lo, fmt.Sprintf("%v:%v", address, tc.ListenPorts.HTTP))
+			lo = append(lo, fmt.Sprintf("%v:%v", address, port))
 		}
 
 		lo = append(lo, co)
@@ -1410,12 +1804,19 @@ func httpListener(addresses []string, co string, tc config.TemplateConfig) []str
 	return out
 }
 
-func httpsListener(addresses []string, co string, tc config.TemplateConfig) []string {
+func httpsListener(addresses []string, co string, tc config.TemplateConfig, port int) []string {
 	out := make([]string, 0)
 	for _, address := range addresses {
 		lo := []string{"listen"}
 
-		if tc.IsSSLPassthroughEnabled {
+		if port != 0 {
+			// custom ports are not behind the SSL passthrough proxy
+			if address == "" {
+				lo = append(lo, fmt.Sprintf("%v", port))
+			} else {
+				lo = append(lo, fmt.Sprintf("%v:%v", address, port))
+			}
+		} else if tc.IsSSLPassthroughEnabled {
 			if address == "" {
 				lo = append(lo, fmt.Sprintf("%v", tc.ListenPorts.SSLProxy))
 			} else {
@@ -1701,3 +2102,34 @@ func buildCorsOriginRegex(corsOrigins []string) string {
 	originsRegex = originsRegex + ")$ ) { set $cors 'true'; }"
 	return originsRegex
 }
+
+// shouldLoadBrotliModule determines whether or not the brotli modules need to be loaded.
+// First, it checks if `enable-brotli` is set in the ConfigMap. If it is not, it iterates over all locations to
+// check if brotli is enabled by the annotation `nginx.ingress.kubernetes.io/enable-brotli`.
+func shouldLoadBrotliModule(c interface{}, s interface{}) bool {
+	cfg, ok := c.(config.Configuration)
+	if !ok {
+		klog.Errorf("expected a 'config.Configuration' type but %T was returned", c)
+		return false
+	}
+
+	servers, ok := s.([]*ingress.Server)
+	if !ok {
+		klog.Errorf("expected an '[]*ingress.Server' type but %T was returned", s)
+		return false
+	}
+
+	if cfg.EnableBrotli {
+		return true
+	}
+
+	for _, server := range servers {
+		for _, location := range server.Locations {
+			if location.Brotli.Enabled {
+				return true
+			}
+		}
+	}
+
+	return false
+}
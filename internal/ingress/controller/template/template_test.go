@@ -25,6 +25,7 @@ import (
 	"path"
 	"path/filepath"
 	"reflect"
+	"regexp"
 	"strings"
 	"testing"
 
@@ -35,13 +36,31 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"k8s.io/ingress-nginx/internal/ingress"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/absoluteredirect"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/authreq"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/authtls"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/brotli"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/charset"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/clientcert"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/clientkeepalive"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/cors"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/defaultbackend"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/geoipaccess"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/grpctimeout"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/influxdb"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/internalredirect"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/log"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/modsecurity"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/opentracing"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/proxycache"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/proxysetheaders"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/ratelimit"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/requestid"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/rewrite"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/securityheaders"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/subfilter"
 	"k8s.io/ingress-nginx/internal/ingress/controller/config"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
 	"k8s.io/ingress-nginx/internal/nginx"
 )
 
@@ -366,6 +385,58 @@ func TestBuildProxyPass(t *testing.T) {
 	}
 }
 
+func TestBuildProxyPassExternalUpstream(t *testing.T) {
+	externalUpstream := &ingress.ExternalUpstream{
+		Host:      "api.example.org",
+		Port:      "443",
+		Resolvers: []string{"10.96.0.10:53", "[fd00::10]:53"},
+		Valid:     30,
+	}
+
+	resolver := `resolver 10.96.0.10:53 [fd00::10]:53 valid=30s;
+set $external_upstream "api.example.org:443";
+`
+
+	testCases := map[string]struct {
+		Target    string
+		Backend   string
+		ProxyPass string
+	}{
+		"proxy_pass using a variable": {
+			Backend:   "upstream-name",
+			ProxyPass: resolver + "proxy_pass https://$external_upstream;",
+		},
+		"rewrite with proxy_pass using a variable": {
+			Target:  "/new",
+			Backend: "upstream-name",
+			ProxyPass: `
+` + resolver + `rewrite "(?i)/" /new break;
+proxy_pass https://$external_upstream;`,
+		},
+		"default backend ignores the external upstream": {
+			Backend:   "upstream-default-backend",
+			ProxyPass: "proxy_pass http://upstream_balancer;",
+		},
+	}
+
+	for k, tc := range testCases {
+		loc := &ingress.Location{
+			Path:             "/",
+			Rewrite:          rewrite.Config{Target: tc.Target},
+			Backend:          tc.Backend,
+			BackendProtocol:  "HTTPS",
+			ExternalUpstream: externalUpstream,
+		}
+
+		backends := []*ingress.Backend{{Name: tc.Backend}}
+
+		pp := buildProxyPass("example.com", backends, loc)
+		if pp != tc.ProxyPass {
+			t.Errorf("%s: expected \n'%v'\nbut returned \n'%v'", k, tc.ProxyPass, pp)
+		}
+	}
+}
+
 func TestBuildProxyPassAutoHttp(t *testing.T) {
 	defaultBackend := "upstream-name"
 	defaultHost := "example.com"
@@ -686,6 +757,44 @@ func TestBuildForwardedFor(t *testing.T) {
 	}
 }
 
+func TestBuildGeoIPAccess(t *testing.T) {
+	countryDB := &[]string{"GeoLite2-Country.mmdb"}
+	cityDB := &[]string{"GeoIP2-City.mmdb"}
+	noDB := &[]string{}
+
+	allow := &ingress.Location{GeoIPAccess: geoipaccess.Config{AllowCountries: []string{"CA", "US"}}}
+	deny := &ingress.Location{GeoIPAccess: geoipaccess.Config{DenyCountries: []string{"CN"}}}
+
+	testCases := []struct {
+		name     string
+		cfg      config.Configuration
+		files    *[]string
+		location *ingress.Location
+		expected string
+	}{
+		{"no geoip annotations", config.Configuration{UseGeoIP2: true}, countryDB, &ingress.Location{}, ""},
+		{"allow with GeoIP2 country database", config.Configuration{UseGeoIP2: true}, countryDB, allow,
+			"if ($geoip2_country_code !~ \"^(CA|US)$\") {\n    return 403;\n}"},
+		{"deny with GeoIP2 city database", config.Configuration{UseGeoIP2: true}, cityDB, deny,
+			"if ($geoip2_city_country_code ~ \"^(CN)$\") {\n    return 403;\n}"},
+		{"deny with legacy GeoIP database", config.Configuration{UseGeoIP: true}, noDB, deny,
+			"if ($geoip_country_code ~ \"^(CN)$\") {\n    return 403;\n}"},
+		{"allow without database", config.Configuration{}, noDB, allow, "return 403;"},
+		{"deny without database", config.Configuration{}, noDB, deny, ""},
+	}
+
+	for _, tc := range testCases {
+		actual := buildGeoIPAccess(tc.cfg, tc.files, tc.location)
+		if actual != tc.expected {
+			t.Errorf("%v: expected '%v' but returned '%v'", tc.name, tc.expected, actual)
+		}
+	}
+
+	if actual := buildGeoIPAccess(config.Configuration{}, noDB, &ingress.Ingress{}); actual != "" {
+		t.Errorf("expected an empty string with an invalid location but returned '%v'", actual)
+	}
+}
+
 func TestBuildResolvers(t *testing.T) {
 	ipOne := net.ParseIP("192.0.0.1")
 	ipTwo := net.ParseIP("2001:db8:1234:0000:0000:0000:0000:0000")
@@ -816,6 +925,33 @@ func TestBuildRateLimit(t *testing.T) {
 	}
 }
 
+func TestBuildRateLimitStatusCodes(t *testing.T) {
+	loc := &ingress.Location{}
+	loc.RateLimit.ReqStatusCode = 429
+	loc.RateLimit.ConnStatusCode = 429
+
+	if limits := buildRateLimit(loc); len(limits) != 0 {
+		t.Errorf("expected no status codes without limits but returned '%v'", limits)
+	}
+
+	loc.RateLimit.Connections.Name = "con"
+	loc.RateLimit.Connections.Limit = 1
+	loc.RateLimit.RPS.Name = "rps"
+	loc.RateLimit.RPS.Limit = 1
+	loc.RateLimit.RPS.Burst = 1
+
+	expected := []string{
+		"limit_conn con 1;",
+		"limit_req zone=rps burst=1 nodelay;",
+		"limit_conn_status 429;",
+		"limit_req_status 429;",
+	}
+
+	if limits := buildRateLimit(loc); !reflect.DeepEqual(expected, limits) {
+		t.Errorf("Expected '%v' but returned '%v'", expected, limits)
+	}
+}
+
 // TODO: Needs more tests
 func TestBuildRateLimitZones(t *testing.T) {
 	invalidType := &ingress.Ingress{}
@@ -1403,6 +1539,34 @@ func TestBuildCustomErrorLocationsPerServer(t *testing.T) {
 				},
 			},
 		},
+		{ // External default backend from the default-backend-url annotation
+			&ingress.Server{Locations: []*ingress.Location{
+				{
+					DefaultBackendUpstreamName: "custom-default-backend-test-default-backend-url",
+					DefaultBackend: &apiv1.Service{
+						ObjectMeta: metav1.ObjectMeta{
+							Annotations: map[string]string{
+								defaultbackend.ExternalURLAnnotation: "https://maintenance.example.org",
+							},
+						},
+						Spec: apiv1.ServiceSpec{
+							Type:         apiv1.ServiceTypeExternalName,
+							ExternalName: "maintenance.example.org",
+							Ports:        []apiv1.ServicePort{{Name: "https", Port: 443}},
+						},
+					},
+					CustomHTTPErrors: []int{503},
+				},
+			}},
+			[]errorLocation{
+				{
+					UpstreamName:    "custom-default-backend-test-default-backend-url",
+					Codes:           []int{503},
+					BackendProtocol: "HTTPS",
+					UpstreamVhost:   "maintenance.example.org",
+				},
+			},
+		},
 	}
 
 	for _, c := range testCases {
@@ -1451,6 +1615,106 @@ func TestProxySetHeader(t *testing.T) {
 	}
 }
 
+func TestBuildRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      config.Configuration
+		loc      *ingress.Location
+		expected string
+	}{
+		{
+			name:     "request ID not enabled",
+			loc:      &ingress.Location{},
+			expected: "proxy_set_header X-Request-ID $req_id;",
+		},
+		{
+			name: "default header",
+			loc: &ingress.Location{
+				RequestID: requestid.Config{Enabled: true, Header: "X-Request-ID"},
+			},
+			expected: "proxy_set_header X-Request-ID $req_id;",
+		},
+		{
+			name: "custom header",
+			loc: &ingress.Location{
+				RequestID: requestid.Config{Enabled: true, Header: "X-Correlation-ID"},
+			},
+			expected: "proxy_set_header X-Request-ID $req_id;\nproxy_set_header X-Correlation-ID $req_id_x_correlation_id;",
+		},
+		{
+			name: "custom header with gRPC backend",
+			loc: &ingress.Location{
+				BackendProtocol: "GRPC",
+				RequestID:       requestid.Config{Enabled: true, Header: "X-Correlation-ID"},
+			},
+			expected: "grpc_set_header X-Request-ID $req_id;\ngrpc_set_header X-Correlation-ID $req_id_x_correlation_id;",
+		},
+		{
+			name: "custom header reusing the opentracing trace ID",
+			cfg:  config.Configuration{EnableOpentracing: true, ZipkinCollectorHost: "zipkin.svc"},
+			loc: &ingress.Location{
+				RequestID: requestid.Config{Enabled: true, Header: "X-Correlation-ID"},
+			},
+			expected: "proxy_set_header X-Request-ID $req_id;\nproxy_set_header X-Correlation-ID $opentracing_context_x_b3_traceid;",
+		},
+		{
+			name: "custom header reusing the jaeger trace ID",
+			cfg:  config.Configuration{EnableOpentracing: true, JaegerCollectorHost: "jaeger.svc"},
+			loc: &ingress.Location{
+				RequestID: requestid.Config{Enabled: true, Header: "X-Correlation-ID"},
+			},
+			expected: "proxy_set_header X-Request-ID $req_id;\nproxy_set_header X-Correlation-ID $jaeger_trace_id;",
+		},
+		{
+			name: "opentracing disabled in the location",
+			cfg:  config.Configuration{EnableOpentracing: true, JaegerCollectorHost: "jaeger.svc"},
+			loc: &ingress.Location{
+				Opentracing: opentracing.Config{Set: true, Enabled: false},
+				RequestID:   requestid.Config{Enabled: true, Header: "X-Request-ID"},
+			},
+			expected: "proxy_set_header X-Request-ID $req_id;",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buildRequestID(tt.cfg, tt.loc); got != tt.expected {
+				t.Errorf("buildRequestID() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+
+	if got := buildRequestID(config.Configuration{}, &ingress.Ingress{}); got != "" {
+		t.Errorf("buildRequestID() with an invalid type = %v, expected an empty string", got)
+	}
+}
+
+func TestBuildRequestIDHeaders(t *testing.T) {
+	servers := []*ingress.Server{
+		{
+			Locations: []*ingress.Location{
+				{RequestID: requestid.Config{Enabled: true, Header: "X-Correlation-ID"}},
+				{RequestID: requestid.Config{Enabled: true, Header: "x-correlation-id"}},
+				{RequestID: requestid.Config{Enabled: true, Header: "X-Request-ID"}},
+				{RequestID: requestid.Config{Enabled: false, Header: "X-Trace"}},
+			},
+		},
+		{
+			Locations: []*ingress.Location{
+				{RequestID: requestid.Config{Enabled: true, Header: "Client_Request_ID"}},
+			},
+		},
+	}
+
+	expected := []string{"client_request_id", "x_correlation_id"}
+	if got := buildRequestIDHeaders(servers); !reflect.DeepEqual(got, expected) {
+		t.Errorf("buildRequestIDHeaders() = %v, expected %v", got, expected)
+	}
+
+	if got := buildRequestIDHeaders(&ingress.Ingress{}); len(got) != 0 {
+		t.Errorf("buildRequestIDHeaders() with an invalid type = %v, expected an empty list", got)
+	}
+}
+
 func TestBuildInfluxDB(t *testing.T) {
 	invalidType := &ingress.Ingress{}
 	expected := ""
@@ -1941,3 +2205,1143 @@ func TestCleanConf(t *testing.T) {
 		t.Errorf("cleanConf result don't match with expected: %s", diff)
 	}
 }
+
+func TestShouldLoadBrotliModule(t *testing.T) {
+	if shouldLoadBrotliModule(config.Configuration{}, []*ingress.Server{}) {
+		t.Errorf("expected brotli module not to be loaded")
+	}
+
+	if !shouldLoadBrotliModule(config.Configuration{EnableBrotli: true}, []*ingress.Server{}) {
+		t.Errorf("expected brotli module to be loaded when enabled globally")
+	}
+
+	servers := []*ingress.Server{
+		{
+			Locations: []*ingress.Location{
+				{
+					Brotli: brotli.Config{
+						Enabled: true,
+					},
+				},
+			},
+		},
+	}
+
+	if !shouldLoadBrotliModule(config.Configuration{}, servers) {
+		t.Errorf("expected brotli module to be loaded when enabled in a location")
+	}
+}
+
+func TestBuildProxyPassWebSocket(t *testing.T) {
+	backends := []*ingress.Backend{{Name: "upstream-name"}}
+
+	testCases := map[string]string{
+		"WS":  "proxy_pass http://upstream_balancer;",
+		"WSS": "proxy_pass https://upstream_balancer;",
+	}
+
+	for proto, expected := range testCases {
+		loc := &ingress.Location{
+			Path:            "/",
+			PathType:        &pathPrefix,
+			Backend:         "upstream-name",
+			BackendProtocol: proto,
+			WebSocket:       true,
+		}
+
+		if pp := buildProxyPass("example.com", backends, loc); strings.TrimSpace(pp) != expected {
+			t.Errorf("%v: expected %q but returned %q", proto, expected, pp)
+		}
+	}
+}
+
+func TestBuildProxyPassBackendScheme(t *testing.T) {
+	backends := []*ingress.Backend{{Name: "upstream-name"}}
+
+	testCases := []struct {
+		protocol       string
+		scheme         string
+		expected       string
+		expectedHeader string
+	}{
+		{"HTTP", "http", "proxy_pass http://upstream_balancer;", "proxy_set_header"},
+		{"HTTP", "https", "proxy_pass https://upstream_balancer;", "proxy_set_header"},
+		{"HTTP", "grpc", "grpc_pass grpc://upstream_balancer;", "grpc_set_header"},
+		{"HTTP", "grpcs", "grpc_pass grpcs://upstream_balancer;", "grpc_set_header"},
+		{"HTTPS", "http", "proxy_pass http://upstream_balancer;", "proxy_set_header"},
+		{"GRPCS", "grpc", "grpc_pass grpc://upstream_balancer;", "grpc_set_header"},
+		{"GRPC", "https", "proxy_pass https://upstream_balancer;", "proxy_set_header"},
+		{"AUTO_HTTP", "https", "proxy_pass https://upstream_balancer;", "proxy_set_header"},
+		// the backend protocol applies without the annotation
+		{"GRPCS", "", "grpc_pass grpcs://upstream_balancer;", "grpc_set_header"},
+		// protocols without a scheme ignore the annotation
+		{"FCGI", "https", "fastcgi_pass upstream_balancer;", "proxy_set_header"},
+	}
+
+	for _, tc := range testCases {
+		loc := &ingress.Location{
+			Path:            "/",
+			PathType:        &pathPrefix,
+			Backend:         "upstream-name",
+			BackendProtocol: tc.protocol,
+			BackendScheme:   tc.scheme,
+		}
+
+		if pp := buildProxyPass("example.com", backends, loc); strings.TrimSpace(pp) != tc.expected {
+			t.Errorf("%v/%v: expected %q but returned %q", tc.protocol, tc.scheme, tc.expected, pp)
+		}
+
+		if header := proxySetHeader(loc); header != tc.expectedHeader {
+			t.Errorf("%v/%v: expected %q but returned %q", tc.protocol, tc.scheme, tc.expectedHeader, header)
+		}
+	}
+}
+
+func TestBuildDefaultResponse(t *testing.T) {
+	cfg := config.NewDefault()
+	cfg.DefaultJSONResponses = map[int]string{
+		404: `{"error":"not found"}`,
+		503: `{"error":"it's \"down\"","cost":"$5"}`,
+	}
+
+	testCases := []struct {
+		code     int
+		expected string
+	}{
+		{404, "default_type \"application/json\";\nreturn 404 '{\"error\":\"not found\"}';"},
+		{503, "default_type \"application/json\";\nreturn 503 '{\"error\":\"it\\'s \\\\\"down\\\\\"\",\"cost\":\"${literal_dollar}5\"}';"},
+		{502, ""},
+	}
+
+	for _, tc := range testCases {
+		if response := buildDefaultResponse(cfg, tc.code); response != tc.expected {
+			t.Errorf("%v: expected %q but returned %q", tc.code, tc.expected, response)
+		}
+	}
+}
+
+func TestTemplateDefaultJSONResponses(t *testing.T) {
+	pwd, _ := os.Getwd()
+	data, err := os.ReadFile(path.Join(pwd, "../../../../test/data/config.json"))
+	if err != nil {
+		t.Fatalf("unexpected error reading json file: %v", err)
+	}
+
+	ngxTpl, err := NewTemplate(nginx.TemplatePath)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	var dat config.TemplateConfig
+	if err := jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(data, &dat); err != nil {
+		t.Fatalf("unexpected error unmarshalling json: %v", err)
+	}
+	if dat.ListenPorts == nil {
+		dat.ListenPorts = &config.ListenPorts{}
+	}
+	dat.Cfg.DefaultSSLCertificate = &ingress.SSLCert{}
+	dat.Cfg.DefaultResponseContentType = "application/problem+json"
+	dat.Cfg.DefaultJSONResponses = map[int]string{
+		404: `{"title":"not found"}`,
+		503: `{"title":"unavailable"}`,
+	}
+
+	rt, err := ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	for _, expected := range []string{
+		// the default backend returns the body of unmatched routes
+		"default_type \"application/problem+json\";",
+		"return 404 '{\"title\":\"not found\"}';",
+		"error_page 503 @default_response_503;",
+		"location @default_response_503 {",
+		"return 503 '{\"title\":\"unavailable\"}';",
+	} {
+		if !strings.Contains(string(rt), expected) {
+			t.Errorf("expected %q in the configuration", expected)
+		}
+	}
+
+	if strings.Contains(string(rt), "return 404;") {
+		t.Errorf("expected the default backend to return the configured body instead of an empty 404")
+	}
+}
+
+func TestTemplateCorsVaryOrigin(t *testing.T) {
+	pwd, _ := os.Getwd()
+	data, err := os.ReadFile(path.Join(pwd, "../../../../test/data/config.json"))
+	if err != nil {
+		t.Fatalf("unexpected error reading json file: %v", err)
+	}
+
+	ngxTpl, err := NewTemplate(nginx.TemplatePath)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	for _, varyOrigin := range []bool{true, false} {
+		var dat config.TemplateConfig
+		if err := jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(data, &dat); err != nil {
+			t.Fatalf("unexpected error unmarshalling json: %v", err)
+		}
+		if dat.ListenPorts == nil {
+			dat.ListenPorts = &config.ListenPorts{}
+		}
+		dat.Cfg.DefaultSSLCertificate = &ingress.SSLCert{}
+
+		for _, server := range dat.Servers {
+			for _, location := range server.Locations {
+				location.CorsConfig = cors.Config{
+					CorsEnabled:     true,
+					CorsAllowOrigin: []string{"*"},
+					CorsVaryOrigin:  varyOrigin,
+				}
+			}
+		}
+
+		rt, err := ngxTpl.Write(dat)
+		if err != nil {
+			t.Fatalf("invalid NGINX template: %v", err)
+		}
+
+		if strings.Contains(string(rt), "add_header Vary Origin always;") != varyOrigin {
+			t.Errorf("expected Vary: Origin to be emitted %v", varyOrigin)
+		}
+	}
+}
+
+func TestTemplateServerTokens(t *testing.T) {
+	pwd, _ := os.Getwd()
+	data, err := os.ReadFile(path.Join(pwd, "../../../../test/data/config.json"))
+	if err != nil {
+		t.Fatalf("unexpected error reading json file: %v", err)
+	}
+
+	ngxTpl, err := NewTemplate(nginx.TemplatePath)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	var dat config.TemplateConfig
+	if err := jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(data, &dat); err != nil {
+		t.Fatalf("unexpected error unmarshalling json: %v", err)
+	}
+	if dat.ListenPorts == nil {
+		dat.ListenPorts = &config.ListenPorts{}
+	}
+	dat.Cfg.DefaultSSLCertificate = &ingress.SSLCert{}
+	dat.Cfg.ShowServerTokens = true
+
+	for _, server := range dat.Servers {
+		server.ServerTokens = "off"
+	}
+
+	rt, err := ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	if !strings.Contains(string(rt), "server_tokens                           off;") {
+		t.Errorf("expected server_tokens off in the server")
+	}
+
+	// server_tokens off only hides the version, the Server header is kept
+	if regexp.MustCompile(`more_clear_headers\s+Server;`).Match(rt) {
+		t.Errorf("expected the Server header to be kept")
+	}
+}
+
+func TestBuildListenersCustomPorts(t *testing.T) {
+	tc := config.TemplateConfig{
+		ListenPorts: &config.ListenPorts{
+			HTTP:     80,
+			HTTPS:    443,
+			SSLProxy: 442,
+		},
+		IsSSLPassthroughEnabled: true,
+	}
+
+	testCases := []struct {
+		name          string
+		server        interface{}
+		expectedHTTP  string
+		expectedHTTPS string
+	}{
+		{"custom ports", &ingress.Server{Hostname: "example.com", HTTPPort: 8080, HTTPSPort: 8443}, "listen 8080  ;", "listen 8443  ssl ;"},
+		{"default ports", &ingress.Server{Hostname: "other.example.com"}, "listen 80  ;", "listen 442 proxy_protocol  ssl ;"},
+		{"redirect server", "www.example.com", "listen 80  ;", "listen 442 proxy_protocol  ssl ;"},
+		{"invalid type", 8080, "", ""},
+	}
+
+	for _, testCase := range testCases {
+		if actual := buildHTTPListener(tc, testCase.server); actual != testCase.expectedHTTP {
+			t.Errorf("%v: expected HTTP listener %q but got %q", testCase.name, testCase.expectedHTTP, actual)
+		}
+		if actual := buildHTTPSListener(tc, testCase.server); actual != testCase.expectedHTTPS {
+			t.Errorf("%v: expected HTTPS listener %q but got %q", testCase.name, testCase.expectedHTTPS, actual)
+		}
+	}
+}
+
+func TestTemplateLocationProxySetHeaders(t *testing.T) {
+	pwd, _ := os.Getwd()
+	data, err := os.ReadFile(path.Join(pwd, "../../../../test/data/config.json"))
+	if err != nil {
+		t.Fatalf("unexpected error reading json file: %v", err)
+	}
+
+	ngxTpl, err := NewTemplate(nginx.TemplatePath)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	var dat config.TemplateConfig
+	if err := jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(data, &dat); err != nil {
+		t.Fatalf("unexpected error unmarshalling json: %v", err)
+	}
+	if dat.ListenPorts == nil {
+		dat.ListenPorts = &config.ListenPorts{}
+	}
+	dat.Cfg.DefaultSSLCertificate = &ingress.SSLCert{}
+
+	for _, server := range dat.Servers {
+		for _, location := range server.Locations {
+			location.ProxySetHeaders = proxysetheaders.Config{
+				Headers: map[string]string{"X-Tenant": "example"},
+			}
+		}
+	}
+
+	rt, err := ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	if !strings.Contains(string(rt), `proxy_set_header X-Tenant                    "example";`) {
+		t.Errorf("expected the location headers to be set in the requests to the upstream")
+	}
+}
+
+func TestTemplateLocationCharset(t *testing.T) {
+	pwd, _ := os.Getwd()
+	data, err := os.ReadFile(path.Join(pwd, "../../../../test/data/config.json"))
+	if err != nil {
+		t.Fatalf("unexpected error reading json file: %v", err)
+	}
+
+	ngxTpl, err := NewTemplate(nginx.TemplatePath)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	var dat config.TemplateConfig
+	if err := jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(data, &dat); err != nil {
+		t.Fatalf("unexpected error unmarshalling json: %v", err)
+	}
+	if dat.ListenPorts == nil {
+		dat.ListenPorts = &config.ListenPorts{}
+	}
+	dat.Cfg.DefaultSSLCertificate = &ingress.SSLCert{}
+
+	for _, server := range dat.Servers {
+		for _, location := range server.Locations {
+			location.Charset = charset.Config{
+				Charset: "utf-8",
+				Types:   "text/css application/json",
+			}
+		}
+	}
+
+	rt, err := ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	if !strings.Contains(string(rt), "charset                                 utf-8;") {
+		t.Errorf("expected the charset to be set in the locations")
+	}
+	if !strings.Contains(string(rt), "charset_types                           text/css application/json;") {
+		t.Errorf("expected the charset types to be set in the locations")
+	}
+}
+
+func TestTemplateLocationSubFilter(t *testing.T) {
+	pwd, _ := os.Getwd()
+	data, err := os.ReadFile(path.Join(pwd, "../../../../test/data/config.json"))
+	if err != nil {
+		t.Fatalf("unexpected error reading json file: %v", err)
+	}
+
+	ngxTpl, err := NewTemplate(nginx.TemplatePath)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	var dat config.TemplateConfig
+	if err := jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(data, &dat); err != nil {
+		t.Fatalf("unexpected error unmarshalling json: %v", err)
+	}
+	if dat.ListenPorts == nil {
+		dat.ListenPorts = &config.ListenPorts{}
+	}
+	dat.Cfg.DefaultSSLCertificate = &ingress.SSLCert{}
+
+	for _, server := range dat.Servers {
+		for _, location := range server.Locations {
+			location.SubFilter = subfilter.Config{
+				From:  "http://old.example.com",
+				To:    "https://new.example.com",
+				Types: "text/css",
+			}
+		}
+	}
+
+	rt, err := ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	if !strings.Contains(string(rt), `sub_filter                              "http://old.example.com" "https://new.example.com";`) {
+		t.Errorf("expected the sub_filter to be set in the locations")
+	}
+	if !strings.Contains(string(rt), "sub_filter_once                         off;") {
+		t.Errorf("expected every occurrence to be replaced")
+	}
+	if !strings.Contains(string(rt), "sub_filter_types                        text/css;") {
+		t.Errorf("expected the sub_filter types to be set in the locations")
+	}
+	if !strings.Contains(string(rt), `proxy_set_header Accept-Encoding        "";`) {
+		t.Errorf("expected uncompressed responses to be requested from the upstream")
+	}
+}
+
+func TestTemplateLocationServedByMCI(t *testing.T) {
+	pwd, _ := os.Getwd()
+	data, err := os.ReadFile(path.Join(pwd, "../../../../test/data/config.json"))
+	if err != nil {
+		t.Fatalf("unexpected error reading json file: %v", err)
+	}
+
+	ngxTpl, err := NewTemplate(nginx.TemplatePath)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	var dat config.TemplateConfig
+	if err := jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(data, &dat); err != nil {
+		t.Fatalf("unexpected error unmarshalling json: %v", err)
+	}
+	if dat.ListenPorts == nil {
+		dat.ListenPorts = &config.ListenPorts{}
+	}
+	dat.Cfg.DefaultSSLCertificate = &ingress.SSLCert{}
+
+	rt, err := ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+	if strings.Contains(string(rt), "X-Served-By-MCI") {
+		t.Errorf("expected no X-Served-By-MCI header by default")
+	}
+
+	for _, server := range dat.Servers {
+		for _, location := range server.Locations {
+			location.ServedByMCI = "example/served"
+		}
+	}
+
+	rt, err = ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+	if !strings.Contains(string(rt), `add_header X-Served-By-MCI "example/served" always;`) {
+		t.Errorf("expected the X-Served-By-MCI header to be added in the locations")
+	}
+}
+
+func TestTemplateServerClientKeepalive(t *testing.T) {
+	pwd, _ := os.Getwd()
+	data, err := os.ReadFile(path.Join(pwd, "../../../../test/data/config.json"))
+	if err != nil {
+		t.Fatalf("unexpected error reading json file: %v", err)
+	}
+
+	ngxTpl, err := NewTemplate(nginx.TemplatePath)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	var dat config.TemplateConfig
+	if err := jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(data, &dat); err != nil {
+		t.Fatalf("unexpected error unmarshalling json: %v", err)
+	}
+	if dat.ListenPorts == nil {
+		dat.ListenPorts = &config.ListenPorts{}
+	}
+	dat.Cfg.DefaultSSLCertificate = &ingress.SSLCert{}
+
+	for _, server := range dat.Servers {
+		server.ClientKeepalive = clientkeepalive.Config{Requests: 500, Timeout: 30}
+	}
+
+	rt, err := ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	if !strings.Contains(string(rt), "keepalive_requests                      500;") {
+		t.Errorf("expected the keep-alive requests to be set in the servers")
+	}
+	if !strings.Contains(string(rt), "keepalive_timeout                       30s;") {
+		t.Errorf("expected the keep-alive timeout to be set in the servers")
+	}
+}
+
+func TestTemplateServerSecurityHeaders(t *testing.T) {
+	pwd, _ := os.Getwd()
+	data, err := os.ReadFile(path.Join(pwd, "../../../../test/data/config.json"))
+	if err != nil {
+		t.Fatalf("unexpected error reading json file: %v", err)
+	}
+
+	ngxTpl, err := NewTemplate(nginx.TemplatePath)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	var dat config.TemplateConfig
+	if err := jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(data, &dat); err != nil {
+		t.Fatalf("unexpected error unmarshalling json: %v", err)
+	}
+	if dat.ListenPorts == nil {
+		dat.ListenPorts = &config.ListenPorts{}
+	}
+	dat.Cfg.DefaultSSLCertificate = &ingress.SSLCert{}
+
+	for _, server := range dat.Servers {
+		server.SecurityHeaders = securityheaders.Config{
+			Preset: "strict",
+			Headers: map[string]string{
+				"Strict-Transport-Security": "max-age=63072000; includeSubDomains; preload",
+				"X-Frame-Options":           "DENY",
+			},
+		}
+	}
+
+	rt, err := ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	expected := []string{
+		`more_set_headers                        "Strict-Transport-Security: max-age=63072000; includeSubDomains; preload";`,
+		`more_set_headers                        "X-Frame-Options: DENY";`,
+	}
+	for _, directive := range expected {
+		if !strings.Contains(string(rt), directive) {
+			t.Errorf("expected %q in the configuration", directive)
+		}
+	}
+}
+
+func TestTemplateServerInternalRedirect(t *testing.T) {
+	pwd, _ := os.Getwd()
+	data, err := os.ReadFile(path.Join(pwd, "../../../../test/data/config.json"))
+	if err != nil {
+		t.Fatalf("unexpected error reading json file: %v", err)
+	}
+
+	ngxTpl, err := NewTemplate(nginx.TemplatePath)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	var dat config.TemplateConfig
+	if err := jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(data, &dat); err != nil {
+		t.Fatalf("unexpected error unmarshalling json: %v", err)
+	}
+	if dat.ListenPorts == nil {
+		dat.ListenPorts = &config.ListenPorts{}
+	}
+	dat.Cfg.DefaultSSLCertificate = &ingress.SSLCert{}
+
+	for _, server := range dat.Servers {
+		server.InternalRedirect = internalredirect.Config{
+			Enabled: true,
+			Locations: []internalredirect.Location{
+				{Path: "/protected/", Target: "http://files.default.svc.cluster.local", URI: "/files/"},
+				{Path: "/archives/", Target: "http://10.0.0.1:8080"},
+			},
+		}
+	}
+
+	rt, err := ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	re := regexp.MustCompile(`location /protected/ \{\s+internal;\s+set \$target http://files.default.svc.cluster.local;\s+rewrite \^/protected/\(\.\*\)\$ /files/\$1 break;\s+proxy_pass \$target;\s+\}`)
+	if !re.Match(rt) {
+		t.Errorf("expected an internal location for /protected/ proxying to a variable")
+	}
+
+	re = regexp.MustCompile(`location /archives/ \{\s+internal;\s+set \$target http://10.0.0.1:8080;\s+proxy_pass \$target;\s+\}`)
+	if !re.Match(rt) {
+		t.Errorf("expected an internal location for /archives/ keeping the URI of the requests")
+	}
+}
+
+func TestTemplateLocationProxyCache(t *testing.T) {
+	pwd, _ := os.Getwd()
+	data, err := os.ReadFile(path.Join(pwd, "../../../../test/data/config.json"))
+	if err != nil {
+		t.Fatalf("unexpected error reading json file: %v", err)
+	}
+
+	ngxTpl, err := NewTemplate(nginx.TemplatePath)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	var dat config.TemplateConfig
+	if err := jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(data, &dat); err != nil {
+		t.Fatalf("unexpected error unmarshalling json: %v", err)
+	}
+	if dat.ListenPorts == nil {
+		dat.ListenPorts = &config.ListenPorts{}
+	}
+	dat.Cfg.DefaultSSLCertificate = &ingress.SSLCert{}
+	dat.Cfg.ProxyCacheZones = map[string]string{"api": "10m"}
+
+	for _, server := range dat.Servers {
+		for _, location := range server.Locations {
+			location.ProxyCache = proxycache.Config{
+				Enabled: true,
+				Zone:    "api",
+				Valid:   []string{"200 302 10m", "404 1m"},
+				Key:     "$scheme$host$request_uri",
+			}
+		}
+	}
+
+	rt, err := ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	expected := []string{
+		"proxy_cache_path /tmp/nginx-cache-api levels=1:2 keys_zone=api:10m inactive=60m use_temp_path=off;",
+		"proxy_cache                             api;",
+		`proxy_cache_key                         "$scheme$host$request_uri";`,
+		"proxy_cache_valid                       200 302 10m;",
+		"proxy_cache_valid                       404 1m;",
+	}
+	for _, directive := range expected {
+		if !strings.Contains(string(rt), directive) {
+			t.Errorf("expected %q in the configuration", directive)
+		}
+	}
+}
+
+func TestTemplateLocationGRPCTimeout(t *testing.T) {
+	pwd, _ := os.Getwd()
+	data, err := os.ReadFile(path.Join(pwd, "../../../../test/data/config.json"))
+	if err != nil {
+		t.Fatalf("unexpected error reading json file: %v", err)
+	}
+
+	ngxTpl, err := NewTemplate(nginx.TemplatePath)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	testCases := []struct {
+		protocol string
+		expected bool
+	}{
+		{"GRPC", true},
+		{"GRPCS", true},
+		{"HTTP", false},
+	}
+
+	for _, tc := range testCases {
+		var dat config.TemplateConfig
+		if err := jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(data, &dat); err != nil {
+			t.Fatalf("unexpected error unmarshalling json: %v", err)
+		}
+		if dat.ListenPorts == nil {
+			dat.ListenPorts = &config.ListenPorts{}
+		}
+		dat.Cfg.DefaultSSLCertificate = &ingress.SSLCert{}
+
+		for _, server := range dat.Servers {
+			for _, location := range server.Locations {
+				location.BackendProtocol = tc.protocol
+				location.GRPCTimeout = grpctimeout.Config{ReadTimeout: 3600, SendTimeout: 120}
+			}
+		}
+
+		rt, err := ngxTpl.Write(dat)
+		if err != nil {
+			t.Fatalf("invalid NGINX template: %v", err)
+		}
+
+		for _, directive := range []string{
+			"grpc_read_timeout                       3600s;",
+			"grpc_send_timeout                       120s;",
+		} {
+			if strings.Contains(string(rt), directive) != tc.expected {
+				t.Errorf("expected %q in the configuration of the %v backends: %v", directive, tc.protocol, tc.expected)
+			}
+		}
+	}
+}
+
+func TestTemplateLocationPreloadLinks(t *testing.T) {
+	pwd, _ := os.Getwd()
+	data, err := os.ReadFile(path.Join(pwd, "../../../../test/data/config.json"))
+	if err != nil {
+		t.Fatalf("unexpected error reading json file: %v", err)
+	}
+
+	ngxTpl, err := NewTemplate(nginx.TemplatePath)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	var dat config.TemplateConfig
+	if err := jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(data, &dat); err != nil {
+		t.Fatalf("unexpected error unmarshalling json: %v", err)
+	}
+	if dat.ListenPorts == nil {
+		dat.ListenPorts = &config.ListenPorts{}
+	}
+	dat.Cfg.DefaultSSLCertificate = &ingress.SSLCert{}
+
+	for _, server := range dat.Servers {
+		for _, location := range server.Locations {
+			location.HTTP2PushPreload = true
+			location.PreloadLinks = []string{"/css/app.css", "/js/app.js", "/fonts/main.woff2", "/data.json"}
+		}
+	}
+
+	rt, err := ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	expected := `add_header Link "</css/app.css>; rel=preload; as=style, </js/app.js>; rel=preload; as=script, </fonts/main.woff2>; rel=preload; as=font; crossorigin, </data.json>; rel=preload" always;`
+	if !strings.Contains(string(rt), expected) {
+		t.Errorf("expected the Link headers of the preloaded assets")
+	}
+
+	if strings.Contains(string(rt), "http2_push_preload on;") {
+		t.Errorf("expected the preload links to replace HTTP/2 push")
+	}
+}
+
+func TestTemplateLocationAccessLogSampling(t *testing.T) {
+	pwd, _ := os.Getwd()
+	data, err := os.ReadFile(path.Join(pwd, "../../../../test/data/config.json"))
+	if err != nil {
+		t.Fatalf("unexpected error reading json file: %v", err)
+	}
+
+	ngxTpl, err := NewTemplate(nginx.TemplatePath)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	var dat config.TemplateConfig
+	if err := jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(data, &dat); err != nil {
+		t.Fatalf("unexpected error unmarshalling json: %v", err)
+	}
+	if dat.ListenPorts == nil {
+		dat.ListenPorts = &config.ListenPorts{}
+	}
+	dat.Cfg.DefaultSSLCertificate = &ingress.SSLCert{}
+	dat.Cfg.DisableAccessLog = false
+	dat.Cfg.DisableHTTPAccessLog = false
+
+	for _, server := range dat.Servers {
+		for _, location := range server.Locations {
+			location.Logs = log.Config{
+				Access:     true,
+				SampleRate: 0.25,
+			}
+		}
+	}
+
+	rt, err := ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	if !strings.Contains(string(rt), "split_clients $request_id $access_log_sampled_2500") {
+		t.Errorf("expected a split_clients block for the sample rate")
+	}
+	if !strings.Contains(string(rt), "25.00% 1;") {
+		t.Errorf("expected the sample rate as a percentage")
+	}
+	if !strings.Contains(string(rt), "upstreaminfo "+dat.Cfg.AccessLogParams+" if=$loggable_2500;") {
+		t.Errorf("expected the locations to log the sampled requests")
+	}
+}
+
+func TestTemplateLocationRequestBodySizeLog(t *testing.T) {
+	pwd, _ := os.Getwd()
+	data, err := os.ReadFile(path.Join(pwd, "../../../../test/data/config.json"))
+	if err != nil {
+		t.Fatalf("unexpected error reading json file: %v", err)
+	}
+
+	ngxTpl, err := NewTemplate(nginx.TemplatePath)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	var dat config.TemplateConfig
+	if err := jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(data, &dat); err != nil {
+		t.Fatalf("unexpected error unmarshalling json: %v", err)
+	}
+	if dat.ListenPorts == nil {
+		dat.ListenPorts = &config.ListenPorts{}
+	}
+	dat.Cfg.DefaultSSLCertificate = &ingress.SSLCert{}
+	dat.Cfg.LogFormatUpstream = `$remote_addr [$time_local] "$request" $status [$request_body_size]`
+
+	declared := regexp.MustCompile(`map \$request_uri \$request_body_size \{\s+default "";\s+\}`)
+
+	rt, err := ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+	if strings.Contains(string(rt), "set $request_body_size") {
+		t.Errorf("expected no $request_body_size without the annotation")
+	}
+	if !declared.Match(rt) {
+		t.Errorf("expected $request_body_size to be declared without the annotation")
+	}
+	if !strings.Contains(string(rt), "[$request_body_size]';") {
+		t.Errorf("expected the log format to use $request_body_size")
+	}
+
+	for _, server := range dat.Servers {
+		for _, location := range server.Locations {
+			location.Logs = log.Config{
+				Access:          true,
+				SampleRate:      1,
+				RequestBodySize: true,
+			}
+		}
+	}
+
+	rt, err = ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+	if !strings.Contains(string(rt), `set $request_body_size "$request_length $content_length";`) {
+		t.Errorf("expected the locations to set $request_body_size")
+	}
+	if !declared.Match(rt) {
+		t.Errorf("expected $request_body_size to be declared with the annotation")
+	}
+}
+
+func TestTemplateLocationHTTP10Upstream(t *testing.T) {
+	pwd, _ := os.Getwd()
+	data, err := os.ReadFile(path.Join(pwd, "../../../../test/data/config.json"))
+	if err != nil {
+		t.Fatalf("unexpected error reading json file: %v", err)
+	}
+
+	ngxTpl, err := NewTemplate(nginx.TemplatePath)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	var dat config.TemplateConfig
+	if err := jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(data, &dat); err != nil {
+		t.Fatalf("unexpected error unmarshalling json: %v", err)
+	}
+	if dat.ListenPorts == nil {
+		dat.ListenPorts = &config.ListenPorts{}
+	}
+	dat.Cfg.DefaultSSLCertificate = &ingress.SSLCert{}
+
+	connectionClose := regexp.MustCompile(`proxy_set_header\s+Connection\s+"close";`)
+	httpVersion := regexp.MustCompile(`proxy_http_version\s+1\.0;`)
+
+	rt, err := ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+	if connectionClose.Match(rt) || httpVersion.Match(rt) {
+		t.Errorf("expected no HTTP/1.0 upstream without the annotation")
+	}
+
+	for _, server := range dat.Servers {
+		for _, location := range server.Locations {
+			location.Proxy.ProxyHTTPVersion = "1.0"
+			location.Proxy.ConnectionClose = true
+		}
+	}
+
+	rt, err = ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+	if !httpVersion.Match(rt) {
+		t.Errorf("expected the locations to use proxy_http_version 1.0")
+	}
+	if !connectionClose.Match(rt) {
+		t.Errorf("expected the locations to send Connection: close")
+	}
+	if regexp.MustCompile(`Connection\s+\$connection_upgrade;`).Match(rt) {
+		t.Errorf("expected no $connection_upgrade header with HTTP/1.0")
+	}
+}
+
+func TestTemplateLocationAbsoluteRedirect(t *testing.T) {
+	pwd, _ := os.Getwd()
+	data, err := os.ReadFile(path.Join(pwd, "../../../../test/data/config.json"))
+	if err != nil {
+		t.Fatalf("unexpected error reading json file: %v", err)
+	}
+
+	ngxTpl, err := NewTemplate(nginx.TemplatePath)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	testCases := []struct {
+		value    string
+		expected string
+	}{
+		{"", ""},
+		{"on", "absolute_redirect on;"},
+		{"off", "absolute_redirect off;"},
+	}
+
+	for _, tc := range testCases {
+		var dat config.TemplateConfig
+		if err := jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(data, &dat); err != nil {
+			t.Fatalf("unexpected error unmarshalling json: %v", err)
+		}
+		if dat.ListenPorts == nil {
+			dat.ListenPorts = &config.ListenPorts{}
+		}
+		dat.Cfg.DefaultSSLCertificate = &ingress.SSLCert{}
+
+		for _, server := range dat.Servers {
+			for _, location := range server.Locations {
+				location.AbsoluteRedirect = absoluteredirect.Config{AbsoluteRedirect: tc.value}
+			}
+		}
+
+		rt, err := ngxTpl.Write(dat)
+		if err != nil {
+			t.Fatalf("invalid NGINX template: %v", err)
+		}
+
+		if tc.expected == "" {
+			if strings.Contains(string(rt), "absolute_redirect") {
+				t.Errorf("expected no absolute_redirect without the annotation")
+			}
+			continue
+		}
+		if !strings.Contains(string(rt), tc.expected) {
+			t.Errorf("expected the locations to contain %q", tc.expected)
+		}
+	}
+}
+
+func TestAccessLogSampleID(t *testing.T) {
+	testCases := map[float64]string{
+		0:       "",
+		0.00001: "1",
+		0.005:   "50",
+		0.25:    "2500",
+		0.99999: "",
+		1:       "",
+	}
+
+	for rate, expected := range testCases {
+		if id := accessLogSampleID(rate); id != expected {
+			t.Errorf("expected id %q for rate %v but returned %q", expected, rate, id)
+		}
+	}
+}
+
+func TestTemplateLocationClientCert(t *testing.T) {
+	pwd, _ := os.Getwd()
+	data, err := os.ReadFile(path.Join(pwd, "../../../../test/data/config.json"))
+	if err != nil {
+		t.Fatalf("unexpected error reading json file: %v", err)
+	}
+
+	ngxTpl, err := NewTemplate(nginx.TemplatePath)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	var dat config.TemplateConfig
+	if err := jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(data, &dat); err != nil {
+		t.Fatalf("unexpected error unmarshalling json: %v", err)
+	}
+	if dat.ListenPorts == nil {
+		dat.ListenPorts = &config.ListenPorts{}
+	}
+	dat.Cfg.DefaultSSLCertificate = &ingress.SSLCert{}
+
+	server := &ingress.Server{
+		Hostname: "mtls.example.com",
+		CertificateAuth: authtls.Config{
+			AuthSSLCert: resolver.AuthSSLCert{
+				CAFileName: "/etc/ingress-controller/ssl/ca-example-mtls.pem",
+			},
+			VerifyClient:    "on",
+			ValidationDepth: 1,
+		},
+		Locations: []*ingress.Location{
+			{
+				Path:     "/",
+				PathType: &pathPrefix,
+				Backend:  "example-mtls-80",
+				ClientCert: clientcert.Config{
+					Enabled: true,
+					Headers: []clientcert.Header{
+						{Name: "ssl-client-fingerprint", Variable: "$ssl_client_fingerprint"},
+					},
+				},
+			},
+		},
+	}
+	dat.Servers = append(dat.Servers, server)
+
+	rt, err := ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	block := string(rt)[strings.Index(string(rt), "server_name mtls.example.com"):]
+	if !strings.Contains(block, "ssl-client-fingerprint $ssl_client_fingerprint;") {
+		t.Errorf("expected the selected client certificate header to be sent to the upstream")
+	}
+	if !strings.Contains(block, "ssl-client-verify      $ssl_client_verify;") {
+		t.Errorf("expected the client certificate verification result to be sent to the upstream")
+	}
+	if strings.Contains(block, "$ssl_client_s_dn") || strings.Contains(block, "$ssl_client_escaped_cert") {
+		t.Errorf("expected only the selected client certificate headers to be sent to the upstream")
+	}
+}
+
+func TestTemplateRateLimitExemptHeader(t *testing.T) {
+	pwd, _ := os.Getwd()
+	data, err := os.ReadFile(path.Join(pwd, "../../../../test/data/config.json"))
+	if err != nil {
+		t.Fatalf("unexpected error reading json file: %v", err)
+	}
+
+	ngxTpl, err := NewTemplate(nginx.TemplatePath)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	var dat config.TemplateConfig
+	if err := jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(data, &dat); err != nil {
+		t.Fatalf("unexpected error unmarshalling json: %v", err)
+	}
+	if dat.ListenPorts == nil {
+		dat.ListenPorts = &config.ListenPorts{}
+	}
+	dat.Cfg.DefaultSSLCertificate = &ingress.SSLCert{}
+	dat.Cfg.LimitConnZoneVariable = "$binary_remote_addr"
+
+	server := &ingress.Server{
+		Hostname: "limited.example.com",
+		Locations: []*ingress.Location{
+			{
+				Path:     "/",
+				PathType: &pathPrefix,
+				Backend:  "example-limited-80",
+				RateLimit: ratelimit.Config{
+					RPS: ratelimit.Zone{
+						Name:       "example_limited_rps",
+						Limit:      10,
+						Burst:      50,
+						SharedSize: 5,
+					},
+					Name:              "example_limited",
+					ID:                "ZXhhbXBsZV9saW1pdGVk",
+					ExemptHeader:      "X-Internal-Caller",
+					ExemptHeaderValue: "trusted",
+				},
+			},
+		},
+	}
+	dat.Servers = append(dat.Servers, server)
+
+	rt, err := ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	for _, expected := range []string{
+		"map $http_x_internal_caller $exempt_ZXhhbXBsZV9saW1pdGVk {",
+		`"trusted" 1;`,
+	} {
+		if !strings.Contains(string(rt), expected) {
+			t.Errorf("expected the exempt header to be mapped with %q", expected)
+		}
+	}
+
+	// an empty key skips limit_req and limit_conn
+	for _, expected := range []string{
+		"map $whitelist_ZXhhbXBsZV9saW1pdGVk$exempt_ZXhhbXBsZV9saW1pdGVk $limit_ZXhhbXBsZV9saW1pdGVk {",
+		"00 $binary_remote_addr;",
+	} {
+		if !strings.Contains(string(rt), expected) {
+			t.Errorf("expected exempt requests to use an empty limit key with %q", expected)
+		}
+	}
+}
+
+func TestTemplateMisdirectedRequestCheck(t *testing.T) {
+	pwd, _ := os.Getwd()
+	data, err := os.ReadFile(path.Join(pwd, "../../../../test/data/config.json"))
+	if err != nil {
+		t.Fatalf("unexpected error reading json file: %v", err)
+	}
+
+	ngxTpl, err := NewTemplate(nginx.TemplatePath)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	for _, enabled := range []bool{false, true} {
+		var dat config.TemplateConfig
+		if err := jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(data, &dat); err != nil {
+			t.Fatalf("unexpected error unmarshalling json: %v", err)
+		}
+		if dat.ListenPorts == nil {
+			dat.ListenPorts = &config.ListenPorts{}
+		}
+		dat.Cfg.DefaultSSLCertificate = &ingress.SSLCert{}
+
+		for _, server := range dat.Servers {
+			server.MisdirectedRequestCheck = enabled
+		}
+
+		rt, err := ngxTpl.Write(dat)
+		if err != nil {
+			t.Fatalf("invalid NGINX template: %v", err)
+		}
+
+		if strings.Contains(string(rt), "return 421;") != enabled {
+			t.Errorf("expected the 421 response to be configured only when the check is enabled (enabled %v)", enabled)
+		}
+	}
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package controller
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
@@ -59,6 +60,13 @@ func upstreamName(namespace string, service *networking.IngressServiceBackend) s
 	return fmt.Sprintf("%s-INVALID", namespace)
 }
 
+// UpstreamNameForMCI returns the name of the upstream generated for a service
+// backend of a multiclusteringress in the given namespace, so external tools can
+// correlate the backends of the configuration with the multiclusteringress paths
+func UpstreamNameForMCI(namespace string, svc *networking.IngressServiceBackend) string {
+	return upstreamName(namespace, svc)
+}
+
 // upstreamServiceNameAndPort verifies if service is not nil, and then return the
 // correct serviceName and Port
 func upstreamServiceNameAndPort(service *networking.IngressServiceBackend) (string, intstr.IntOrString) {
@@ -106,7 +114,7 @@ const (
 // command like reload or test configuration
 type NginxExecTester interface {
 	ExecCommand(args ...string) *exec.Cmd
-	Test(cfg string) ([]byte, error)
+	Test(ctx context.Context, cfg string) ([]byte, error)
 }
 
 // NginxCommand stores context around a given nginx executable path
@@ -138,9 +146,10 @@ func (nc NginxCommand) ExecCommand(args ...string) *exec.Cmd {
 	return exec.Command(nc.Binary, cmdArgs...)
 }
 
-// Test checks if config file is a syntax valid nginx configuration
-func (nc NginxCommand) Test(cfg string) ([]byte, error) {
-	return exec.Command(nc.Binary, "-c", cfg, "-t").CombinedOutput()
+// Test checks if config file is a syntax valid nginx configuration,
+// killing the nginx process when the context is done
+func (nc NginxCommand) Test(ctx context.Context, cfg string) ([]byte, error) {
+	return exec.CommandContext(ctx, nc.Binary, "-c", cfg, "-t").CombinedOutput()
 }
 
 // getSysctl returns the value for the specified sysctl setting
@@ -165,4 +165,13 @@ type Backend struct {
 	// By default, the NGINX ingress controller uses a list of all endpoints (Pod IP/port) in the NGINX upstream configuration.
 	// It disables that behavior and instead uses a single upstream in NGINX, the service's Cluster IP and port.
 	ServiceUpstream bool `json:"service-upstream"`
+
+	// Allows the default-backend-url annotation to point to plain http URLs.
+	// By default only https URLs are accepted.
+	AllowHTTPDefaultBackendURL bool `json:"allow-http-default-backend-url"`
+
+	// ProxyCacheZones contains the size of the cache zones the proxy-cache-zone
+	// annotation can use, indexed by name
+	// http://nginx.org/en/docs/http/ngx_http_proxy_module.html#proxy_cache_path
+	ProxyCacheZones map[string]string `json:"proxy-cache-zones"`
 }
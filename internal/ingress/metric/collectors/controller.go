@@ -18,6 +18,7 @@ package collectors
 
 import (
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -30,6 +31,8 @@ import (
 var (
 	operation        = []string{"controller_namespace", "controller_class", "controller_pod"}
 	ingressOperation = []string{"controller_namespace", "controller_class", "controller_pod", "namespace", "ingress"}
+	mciOperation     = []string{"controller_namespace", "controller_class", "controller_pod", "namespace", "name"}
+	mciNamespace     = []string{"controller_namespace", "controller_class", "controller_pod", "namespace"}
 	sslLabelHost     = []string{"namespace", "class", "host"}
 )
 
@@ -45,8 +48,26 @@ type Controller struct {
 	reloadOperationErrors       *prometheus.CounterVec
 	checkIngressOperation       *prometheus.CounterVec
 	checkIngressOperationErrors *prometheus.CounterVec
+	checkIngressTimeouts        *prometheus.CounterVec
+	canaryMergeFailures         *prometheus.CounterVec
+	mtlsConflicts               *prometheus.CounterVec
+	updatesCoalesced            prometheus.Counter
+	buildCoalescedUpdates       prometheus.Histogram
+	mciUpstreams                *prometheus.GaugeVec
+	mciServers                  *prometheus.GaugeVec
+	mciLocations                *prometheus.GaugeVec
+	configServers               prometheus.Gauge
+	configUpstreams             prometheus.Gauge
+	configLocations             prometheus.Gauge
 	sslExpireTime               *prometheus.GaugeVec
 
+	// mciLastChange is the time, in nanoseconds since the epoch, of the last
+	// change detected in the multiclusteringresses
+	mciLastChange             int64
+	mciSecondsSinceLastChange prometheus.GaugeFunc
+	// now returns the current time, replaced in tests
+	now func() time.Time
+
 	constLabels prometheus.Labels
 	labels      prometheus.Labels
 
@@ -67,6 +88,8 @@ func NewController(pod, namespace, class string) *Controller {
 	cm := &Controller{
 		constLabels: constLabels,
 
+		now: time.Now,
+
 		labels: prometheus.Labels{
 			"namespace": namespace,
 			"class":     class,
@@ -135,6 +158,14 @@ func NewController(pod, namespace, class string) *Controller {
 			},
 			ingressOperation,
 		),
+		checkIngressTimeouts: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: PrometheusNamespace,
+				Name:      "check_timeouts",
+				Help:      `Cumulative number of Ingress controller syntax check operations abandoned because they exceeded the admission timeout`,
+			},
+			ingressOperation,
+		),
 		checkIngressOperation: prometheus.NewCounterVec(
 			prometheus.CounterOpts{
 				Namespace: PrometheusNamespace,
@@ -143,6 +174,87 @@ func NewController(pod, namespace, class string) *Controller {
 			},
 			ingressOperation,
 		),
+		canaryMergeFailures: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: PrometheusNamespace,
+				Name:      "mci_canary_merge_failures_total",
+				Help:      `Cumulative number of canary MultiClusterIngress upstreams deleted because no matching primary backend was found`,
+			},
+			mciOperation,
+		),
+		mtlsConflicts: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: PrometheusNamespace,
+				Name:      "mci_mtls_conflict_total",
+				Help:      `Cumulative number of MultiClusterIngresses whose mutual TLS CA secret was ignored because the host already uses a different one`,
+			},
+			mciOperation,
+		),
+		updatesCoalesced: prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Namespace:   PrometheusNamespace,
+				Name:        "mci_updates_coalesced_total",
+				Help:        `Cumulative number of updates applied by the build of another update instead of their own build`,
+				ConstLabels: constLabels,
+			},
+		),
+		buildCoalescedUpdates: prometheus.NewHistogram(
+			prometheus.HistogramOpts{
+				Namespace:   PrometheusNamespace,
+				Name:        "mci_build_coalesced_updates",
+				Help:        `Number of pending updates consumed by each build of the configuration`,
+				Buckets:     prometheus.ExponentialBuckets(1, 2, 8),
+				ConstLabels: constLabels,
+			},
+		),
+		mciUpstreams: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: PrometheusNamespace,
+				Name:      "mci_upstreams",
+				Help:      `Number of upstreams of the running configuration by MultiClusterIngress namespace`,
+			},
+			mciNamespace,
+		),
+		mciServers: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: PrometheusNamespace,
+				Name:      "mci_servers",
+				Help:      `Number of servers with locations of a MultiClusterIngress namespace in the running configuration`,
+			},
+			mciNamespace,
+		),
+		mciLocations: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: PrometheusNamespace,
+				Name:      "mci_locations",
+				Help:      `Number of locations of the running configuration by MultiClusterIngress namespace`,
+			},
+			mciNamespace,
+		),
+		configServers: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace:   PrometheusNamespace,
+				Name:        "config_servers",
+				Help:        `Number of servers of the running configuration`,
+				ConstLabels: constLabels,
+			},
+		),
+		configUpstreams: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace:   PrometheusNamespace,
+				Name:        "config_upstreams",
+				Help:        `Number of upstreams of the running configuration`,
+				ConstLabels: constLabels,
+			},
+		),
+		configLocations: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace:   PrometheusNamespace,
+				Name:        "config_locations",
+				Help:        `Number of locations of the running configuration`,
+				ConstLabels: constLabels,
+			},
+		),
 		sslExpireTime: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Namespace: PrometheusNamespace,
@@ -163,6 +275,20 @@ func NewController(pod, namespace, class string) *Controller {
 		),
 	}
 
+	cm.mciLastChange = cm.now().UnixNano()
+	cm.mciSecondsSinceLastChange = prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Namespace:   PrometheusNamespace,
+			Name:        "mci_seconds_since_last_change",
+			Help:        "Number of seconds since the last change detected in the MultiClusterIngresses",
+			ConstLabels: constLabels,
+		},
+		func() float64 {
+			lastChange := time.Unix(0, atomic.LoadInt64(&cm.mciLastChange))
+			return cm.now().Sub(lastChange).Seconds()
+		},
+	)
+
 	return cm
 }
 
@@ -204,6 +330,70 @@ func (cm *Controller) IncCheckErrorCount(namespace, name string) {
 	cm.checkIngressOperationErrors.MustCurryWith(cm.constLabels).With(labels).Inc()
 }
 
+// IncCheckTimeoutCount increment the check timeout counter
+func (cm *Controller) IncCheckTimeoutCount(namespace, name string) {
+	labels := prometheus.Labels{
+		"namespace": namespace,
+		"ingress":   name,
+	}
+	cm.checkIngressTimeouts.MustCurryWith(cm.constLabels).With(labels).Inc()
+}
+
+// IncCanaryMergeFailureCount increment the canary merge failure counter
+func (cm *Controller) IncCanaryMergeFailureCount(namespace, name string) {
+	labels := prometheus.Labels{
+		"namespace": namespace,
+		"name":      name,
+	}
+	cm.canaryMergeFailures.MustCurryWith(cm.constLabels).With(labels).Inc()
+}
+
+// IncMTLSConflictCount increment the mutual TLS conflict counter
+func (cm *Controller) IncMTLSConflictCount(namespace, name string) {
+	labels := prometheus.Labels{
+		"namespace": namespace,
+		"name":      name,
+	}
+	cm.mtlsConflicts.MustCurryWith(cm.constLabels).With(labels).Inc()
+}
+
+// MCIChanged resets the time since the last change in the multiclusteringresses
+func (cm *Controller) MCIChanged() {
+	atomic.StoreInt64(&cm.mciLastChange, cm.now().UnixNano())
+}
+
+// ObserveCoalescedUpdates records the number of pending updates consumed by a
+// build, all but one of them being coalesced into the build
+func (cm *Controller) ObserveCoalescedUpdates(updates int) {
+	cm.buildCoalescedUpdates.Observe(float64(updates))
+	if updates > 1 {
+		cm.updatesCoalesced.Add(float64(updates - 1))
+	}
+}
+
+// SetMCIBuildMetrics sets the number of upstreams, servers and locations by
+// namespace, dropping the namespaces which are no longer present
+func (cm *Controller) SetMCIBuildMetrics(upstreams, servers, locations map[string]int) {
+	setNamespaceGauges(cm.mciUpstreams.MustCurryWith(cm.constLabels), upstreams)
+	setNamespaceGauges(cm.mciServers.MustCurryWith(cm.constLabels), servers)
+	setNamespaceGauges(cm.mciLocations.MustCurryWith(cm.constLabels), locations)
+}
+
+// SetConfigScale sets the number of servers, upstreams and locations of the
+// running configuration
+func (cm *Controller) SetConfigScale(servers, upstreams, locations int) {
+	cm.configServers.Set(float64(servers))
+	cm.configUpstreams.Set(float64(upstreams))
+	cm.configLocations.Set(float64(locations))
+}
+
+func setNamespaceGauges(gauges *prometheus.GaugeVec, counts map[string]int) {
+	gauges.Reset()
+	for namespace, count := range counts {
+		gauges.With(prometheus.Labels{"namespace": namespace}).Set(float64(count))
+	}
+}
+
 // ConfigSuccess set a boolean flag according to the output of the controller configuration reload
 func (cm *Controller) ConfigSuccess(hash uint64, success bool) {
 	if success {
@@ -228,6 +418,18 @@ func (cm Controller) Describe(ch chan<- *prometheus.Desc) {
 	cm.reloadOperationErrors.Describe(ch)
 	cm.checkIngressOperation.Describe(ch)
 	cm.checkIngressOperationErrors.Describe(ch)
+	cm.checkIngressTimeouts.Describe(ch)
+	cm.canaryMergeFailures.Describe(ch)
+	cm.mtlsConflicts.Describe(ch)
+	cm.updatesCoalesced.Describe(ch)
+	cm.buildCoalescedUpdates.Describe(ch)
+	cm.mciUpstreams.Describe(ch)
+	cm.mciServers.Describe(ch)
+	cm.mciLocations.Describe(ch)
+	cm.configServers.Describe(ch)
+	cm.configUpstreams.Describe(ch)
+	cm.configLocations.Describe(ch)
+	cm.mciSecondsSinceLastChange.Describe(ch)
 	cm.sslExpireTime.Describe(ch)
 	cm.leaderElection.Describe(ch)
 	cm.buildInfo.Describe(ch)
@@ -242,6 +444,18 @@ func (cm Controller) Collect(ch chan<- prometheus.Metric) {
 	cm.reloadOperationErrors.Collect(ch)
 	cm.checkIngressOperation.Collect(ch)
 	cm.checkIngressOperationErrors.Collect(ch)
+	cm.checkIngressTimeouts.Collect(ch)
+	cm.canaryMergeFailures.Collect(ch)
+	cm.mtlsConflicts.Collect(ch)
+	cm.updatesCoalesced.Collect(ch)
+	cm.buildCoalescedUpdates.Collect(ch)
+	cm.mciUpstreams.Collect(ch)
+	cm.mciServers.Collect(ch)
+	cm.mciLocations.Collect(ch)
+	cm.configServers.Collect(ch)
+	cm.configUpstreams.Collect(ch)
+	cm.configLocations.Collect(ch)
+	cm.mciSecondsSinceLastChange.Collect(ch)
 	cm.sslExpireTime.Collect(ch)
 	cm.leaderElection.Collect(ch)
 	cm.buildInfo.Collect(ch)
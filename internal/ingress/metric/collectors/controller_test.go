@@ -70,6 +70,116 @@ func TestControllerCounters(t *testing.T) {
 			`,
 			metrics: []string{"nginx_ingress_controller_errors"},
 		},
+		{
+			name: "single admission check timeout should return 1",
+			test: func(cm *Controller) {
+				cm.IncCheckTimeoutCount("example", "example-slow")
+			},
+			want: `
+				# HELP nginx_ingress_controller_check_timeouts Cumulative number of Ingress controller syntax check operations abandoned because they exceeded the admission timeout
+				# TYPE nginx_ingress_controller_check_timeouts counter
+				nginx_ingress_controller_check_timeouts{controller_class="nginx",controller_namespace="default",controller_pod="pod",ingress="example-slow",namespace="example"} 1
+			`,
+			metrics: []string{"nginx_ingress_controller_check_timeouts"},
+		},
+		{
+			name: "single canary merge failure should return 1",
+			test: func(cm *Controller) {
+				cm.IncCanaryMergeFailureCount("example", "example-canary")
+			},
+			want: `
+				# HELP nginx_ingress_controller_mci_canary_merge_failures_total Cumulative number of canary MultiClusterIngress upstreams deleted because no matching primary backend was found
+				# TYPE nginx_ingress_controller_mci_canary_merge_failures_total counter
+				nginx_ingress_controller_mci_canary_merge_failures_total{controller_class="nginx",controller_namespace="default",controller_pod="pod",name="example-canary",namespace="example"} 1
+			`,
+			metrics: []string{"nginx_ingress_controller_mci_canary_merge_failures_total"},
+		},
+		{
+			name: "single mutual TLS conflict should return 1",
+			test: func(cm *Controller) {
+				cm.IncMTLSConflictCount("example", "example-b")
+			},
+			want: `
+				# HELP nginx_ingress_controller_mci_mtls_conflict_total Cumulative number of MultiClusterIngresses whose mutual TLS CA secret was ignored because the host already uses a different one
+				# TYPE nginx_ingress_controller_mci_mtls_conflict_total counter
+				nginx_ingress_controller_mci_mtls_conflict_total{controller_class="nginx",controller_namespace="default",controller_pod="pod",name="example-b",namespace="example"} 1
+			`,
+			metrics: []string{"nginx_ingress_controller_mci_mtls_conflict_total"},
+		},
+		{
+			name: "coalesced updates should count all but one update of each build",
+			test: func(cm *Controller) {
+				cm.ObserveCoalescedUpdates(1)
+				cm.ObserveCoalescedUpdates(4)
+			},
+			want: `
+				# HELP nginx_ingress_controller_mci_updates_coalesced_total Cumulative number of updates applied by the build of another update instead of their own build
+				# TYPE nginx_ingress_controller_mci_updates_coalesced_total counter
+				nginx_ingress_controller_mci_updates_coalesced_total{controller_class="nginx",controller_namespace="default",controller_pod="pod"} 3
+				# HELP nginx_ingress_controller_mci_build_coalesced_updates Number of pending updates consumed by each build of the configuration
+				# TYPE nginx_ingress_controller_mci_build_coalesced_updates histogram
+				nginx_ingress_controller_mci_build_coalesced_updates_bucket{controller_class="nginx",controller_namespace="default",controller_pod="pod",le="1"} 1
+				nginx_ingress_controller_mci_build_coalesced_updates_bucket{controller_class="nginx",controller_namespace="default",controller_pod="pod",le="2"} 1
+				nginx_ingress_controller_mci_build_coalesced_updates_bucket{controller_class="nginx",controller_namespace="default",controller_pod="pod",le="4"} 2
+				nginx_ingress_controller_mci_build_coalesced_updates_bucket{controller_class="nginx",controller_namespace="default",controller_pod="pod",le="8"} 2
+				nginx_ingress_controller_mci_build_coalesced_updates_bucket{controller_class="nginx",controller_namespace="default",controller_pod="pod",le="16"} 2
+				nginx_ingress_controller_mci_build_coalesced_updates_bucket{controller_class="nginx",controller_namespace="default",controller_pod="pod",le="32"} 2
+				nginx_ingress_controller_mci_build_coalesced_updates_bucket{controller_class="nginx",controller_namespace="default",controller_pod="pod",le="64"} 2
+				nginx_ingress_controller_mci_build_coalesced_updates_bucket{controller_class="nginx",controller_namespace="default",controller_pod="pod",le="128"} 2
+				nginx_ingress_controller_mci_build_coalesced_updates_bucket{controller_class="nginx",controller_namespace="default",controller_pod="pod",le="+Inf"} 2
+				nginx_ingress_controller_mci_build_coalesced_updates_sum{controller_class="nginx",controller_namespace="default",controller_pod="pod"} 5
+				nginx_ingress_controller_mci_build_coalesced_updates_count{controller_class="nginx",controller_namespace="default",controller_pod="pod"} 2
+			`,
+			metrics: []string{"nginx_ingress_controller_mci_updates_coalesced_total", "nginx_ingress_controller_mci_build_coalesced_updates"},
+		},
+		{
+			name: "build metrics should be labeled by namespace",
+			test: func(cm *Controller) {
+				cm.SetMCIBuildMetrics(
+					map[string]int{"example": 3, "removed": 1},
+					map[string]int{"example": 2, "removed": 1},
+					map[string]int{"example": 4, "removed": 1},
+				)
+				cm.SetMCIBuildMetrics(
+					map[string]int{"example": 2, "team-a": 1},
+					map[string]int{"example": 1, "team-a": 1},
+					map[string]int{"example": 3, "team-a": 2},
+				)
+			},
+			want: `
+				# HELP nginx_ingress_controller_mci_locations Number of locations of the running configuration by MultiClusterIngress namespace
+				# TYPE nginx_ingress_controller_mci_locations gauge
+				nginx_ingress_controller_mci_locations{controller_class="nginx",controller_namespace="default",controller_pod="pod",namespace="example"} 3
+				nginx_ingress_controller_mci_locations{controller_class="nginx",controller_namespace="default",controller_pod="pod",namespace="team-a"} 2
+				# HELP nginx_ingress_controller_mci_servers Number of servers with locations of a MultiClusterIngress namespace in the running configuration
+				# TYPE nginx_ingress_controller_mci_servers gauge
+				nginx_ingress_controller_mci_servers{controller_class="nginx",controller_namespace="default",controller_pod="pod",namespace="example"} 1
+				nginx_ingress_controller_mci_servers{controller_class="nginx",controller_namespace="default",controller_pod="pod",namespace="team-a"} 1
+				# HELP nginx_ingress_controller_mci_upstreams Number of upstreams of the running configuration by MultiClusterIngress namespace
+				# TYPE nginx_ingress_controller_mci_upstreams gauge
+				nginx_ingress_controller_mci_upstreams{controller_class="nginx",controller_namespace="default",controller_pod="pod",namespace="example"} 2
+				nginx_ingress_controller_mci_upstreams{controller_class="nginx",controller_namespace="default",controller_pod="pod",namespace="team-a"} 1
+			`,
+			metrics: []string{"nginx_ingress_controller_mci_upstreams", "nginx_ingress_controller_mci_servers", "nginx_ingress_controller_mci_locations"},
+		},
+		{
+			name: "should set the scale of the running configuration",
+			test: func(cm *Controller) {
+				cm.SetConfigScale(3, 4, 7)
+			},
+			want: `
+				# HELP nginx_ingress_controller_config_locations Number of locations of the running configuration
+				# TYPE nginx_ingress_controller_config_locations gauge
+				nginx_ingress_controller_config_locations{controller_class="nginx",controller_namespace="default",controller_pod="pod"} 7
+				# HELP nginx_ingress_controller_config_servers Number of servers of the running configuration
+				# TYPE nginx_ingress_controller_config_servers gauge
+				nginx_ingress_controller_config_servers{controller_class="nginx",controller_namespace="default",controller_pod="pod"} 3
+				# HELP nginx_ingress_controller_config_upstreams Number of upstreams of the running configuration
+				# TYPE nginx_ingress_controller_config_upstreams gauge
+				nginx_ingress_controller_config_upstreams{controller_class="nginx",controller_namespace="default",controller_pod="pod"} 4
+			`,
+			metrics: []string{"nginx_ingress_controller_config_servers", "nginx_ingress_controller_config_upstreams", "nginx_ingress_controller_config_locations"},
+		},
 		{
 			name: "should set SSL certificates metrics",
 			test: func(cm *Controller) {
@@ -156,3 +266,47 @@ func TestRemoveMetrics(t *testing.T) {
 
 	reg.Unregister(cm)
 }
+
+func TestMCISecondsSinceLastChange(t *testing.T) {
+	cm := NewController("pod", "default", "nginx")
+	reg := prometheus.NewPedanticRegistry()
+	if err := reg.Register(cm); err != nil {
+		t.Errorf("registering collector failed: %s", err)
+	}
+
+	now := time.Unix(1600000000, 0)
+	cm.now = func() time.Time { return now }
+	cm.MCIChanged()
+
+	const metadata = `
+		# HELP nginx_ingress_controller_mci_seconds_since_last_change Number of seconds since the last change detected in the MultiClusterIngresses
+		# TYPE nginx_ingress_controller_mci_seconds_since_last_change gauge
+	`
+	metrics := []string{"nginx_ingress_controller_mci_seconds_since_last_change"}
+
+	steps := []struct {
+		advance time.Duration
+		changed bool
+		want    string
+	}{
+		{0, false, "0"},
+		{30 * time.Second, false, "30"},
+		{15 * time.Second, false, "45"},
+		{5 * time.Second, true, "0"},
+		{10 * time.Second, false, "10"},
+	}
+
+	for _, step := range steps {
+		now = now.Add(step.advance)
+		if step.changed {
+			cm.MCIChanged()
+		}
+
+		want := metadata + `nginx_ingress_controller_mci_seconds_since_last_change{controller_class="nginx",controller_namespace="default",controller_pod="pod"} ` + step.want
+		if err := GatherAndCompare(cm, want, metrics, reg); err != nil {
+			t.Errorf("unexpected collecting result after %v:\n%s", step.advance, err)
+		}
+	}
+
+	reg.Unregister(cm)
+}
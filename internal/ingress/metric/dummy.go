@@ -47,6 +47,27 @@ func (dc DummyCollector) IncCheckCount(string, string) {}
 // IncCheckErrorCount ...
 func (dc DummyCollector) IncCheckErrorCount(string, string) {}
 
+// IncCheckTimeoutCount ...
+func (dc DummyCollector) IncCheckTimeoutCount(string, string) {}
+
+// IncCanaryMergeFailureCount ...
+func (dc DummyCollector) IncCanaryMergeFailureCount(string, string) {}
+
+// IncMTLSConflictCount ...
+func (dc DummyCollector) IncMTLSConflictCount(string, string) {}
+
+// MCIChanged ...
+func (dc DummyCollector) MCIChanged() {}
+
+// ObserveCoalescedUpdates ...
+func (dc DummyCollector) ObserveCoalescedUpdates(int) {}
+
+// SetMCIBuildMetrics ...
+func (dc DummyCollector) SetMCIBuildMetrics(upstreams, servers, locations map[string]int) {}
+
+// SetConfigScale ...
+func (dc DummyCollector) SetConfigScale(servers, upstreams, locations int) {}
+
 // RemoveMetrics ...
 func (dc DummyCollector) RemoveMetrics(ingresses, endpoints []string) {}
 
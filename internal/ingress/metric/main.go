@@ -44,6 +44,34 @@ type Collector interface {
 	IncCheckCount(string, string)
 	IncCheckErrorCount(string, string)
 
+	// IncCheckTimeoutCount increments the number of admission checks
+	// abandoned because they exceeded the admission timeout
+	IncCheckTimeoutCount(string, string)
+
+	// IncCanaryMergeFailureCount increments the number of canary MultiClusterIngress
+	// upstreams deleted because no matching primary backend was found
+	IncCanaryMergeFailureCount(string, string)
+
+	// IncMTLSConflictCount increments the number of MultiClusterIngresses
+	// ignored because their host already uses a different mutual TLS CA
+	IncMTLSConflictCount(string, string)
+
+	// MCIChanged resets the time since the last change detected in the
+	// MultiClusterIngresses
+	MCIChanged()
+
+	// ObserveCoalescedUpdates records the number of pending updates
+	// consumed by a build of the configuration
+	ObserveCoalescedUpdates(int)
+
+	// SetMCIBuildMetrics sets the number of upstreams, servers and locations
+	// of the running configuration by MultiClusterIngress namespace
+	SetMCIBuildMetrics(upstreams, servers, locations map[string]int)
+
+	// SetConfigScale sets the number of servers, upstreams and locations
+	// of the running configuration
+	SetConfigScale(servers, upstreams, locations int)
+
 	RemoveMetrics(ingresses, endpoints []string)
 
 	SetSSLExpireTime([]*ingress.Server)
@@ -120,6 +148,34 @@ func (c *collector) IncCheckErrorCount(namespace string, name string) {
 	c.ingressController.IncCheckErrorCount(namespace, name)
 }
 
+func (c *collector) IncCheckTimeoutCount(namespace string, name string) {
+	c.ingressController.IncCheckTimeoutCount(namespace, name)
+}
+
+func (c *collector) IncCanaryMergeFailureCount(namespace string, name string) {
+	c.ingressController.IncCanaryMergeFailureCount(namespace, name)
+}
+
+func (c *collector) IncMTLSConflictCount(namespace string, name string) {
+	c.ingressController.IncMTLSConflictCount(namespace, name)
+}
+
+func (c *collector) MCIChanged() {
+	c.ingressController.MCIChanged()
+}
+
+func (c *collector) ObserveCoalescedUpdates(updates int) {
+	c.ingressController.ObserveCoalescedUpdates(updates)
+}
+
+func (c *collector) SetMCIBuildMetrics(upstreams, servers, locations map[string]int) {
+	c.ingressController.SetMCIBuildMetrics(upstreams, servers, locations)
+}
+
+func (c *collector) SetConfigScale(servers, upstreams, locations int) {
+	c.ingressController.SetConfigScale(servers, upstreams, locations)
+}
+
 func (c *collector) IncReloadCount() {
 	c.ingressController.IncReloadCount()
 }
@@ -23,24 +23,40 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 
 	"k8s.io/ingress-nginx/internal/ingress/annotations"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/absoluteredirect"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/auth"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/authreq"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/authtls"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/brotli"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/charset"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/clientcert"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/clientkeepalive"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/connection"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/cors"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/fastcgi"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/geoipaccess"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/globalratelimit"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/grpctimeout"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/gzip"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/healthcheck"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/influxdb"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/internalredirect"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/ipwhitelist"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/log"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/mirror"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/modsecurity"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/opentracing"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/passivehealthcheck"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/proxy"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/proxycache"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/proxysetheaders"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/proxyssl"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/ratelimit"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/redirect"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/requestid"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/rewrite"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/securityheaders"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/subfilter"
 )
 
 var (
@@ -98,6 +114,10 @@ type Backend struct {
 	UpstreamHashBy UpstreamHashByConfig `json:"upstreamHashByConfig,omitempty"`
 	// LB algorithm configuration per ingress
 	LoadBalancing string `json:"load-balance,omitempty"`
+	// EWMADecay is the decay time, in seconds, of the EWMA load balancer.
+	// Zero uses the default of the balancer.
+	// +optional
+	EWMADecay float64 `json:"ewmaDecay,omitempty"`
 	// Denotes if a backend has no server. The backend instead shares a server with another backend and acts as an
 	// alternative backend.
 	// This can be used to share multiple upstreams in the sam nginx server block.
@@ -108,6 +128,17 @@ type Backend struct {
 	// Contains a list of backends without servers that are associated with this backend.
 	// +optional
 	AlternativeBackends []string `json:"alternativeBackends,omitempty"`
+	// ProxyProtocol indicates the endpoints expect a PROXY protocol header
+	// on connections proxied to them.
+	// +optional
+	ProxyProtocol bool `json:"proxyProtocol,omitempty"`
+	// HealthCheck contains the configuration used to actively probe the endpoints.
+	// +optional
+	HealthCheck healthcheck.Config `json:"healthCheck,omitempty"`
+	// PassiveHealthCheck contains the maximum number of failures after which
+	// an endpoint is considered unavailable, applied to every endpoint.
+	// +optional
+	PassiveHealthCheck passivehealthcheck.Config `json:"passiveHealthCheck,omitempty"`
 }
 
 // TrafficShapingPolicy describes the policies to put in place when a backend has no server and is used as an
@@ -131,6 +162,9 @@ type TrafficShapingPolicy struct {
 	HeaderPattern string `json:"headerPattern"`
 	// Cookie on which to redirect requests to this backend
 	Cookie string `json:"cookie"`
+	// Priority defines whether the header and cookie ("header") or the
+	// weight ("weight") are evaluated first
+	Priority string `json:"priority,omitempty"`
 }
 
 // HashInclude defines if a field should be used or not to calculate the hash
@@ -186,6 +220,31 @@ type Endpoint struct {
 	Port string `json:"port"`
 	// Target returns a reference to the object providing the endpoint
 	Target *apiv1.ObjectReference `json:"target,omitempty"`
+	// MaxFails is the number of failed attempts to reach the endpoint after
+	// which it is considered unavailable. Zero disables the check
+	MaxFails int `json:"maxFails"`
+	// FailTimeout is the time in seconds the failures are counted in, and
+	// the endpoint is then considered unavailable for
+	FailTimeout int `json:"failTimeout"`
+	// Zone is the zone of the endpoint, only known from EndpointSlices
+	Zone string `json:"zone,omitempty"`
+	// ForZones are the zones the endpoint is hinted for by the
+	// topology aware hints of its EndpointSlice
+	ForZones []string `json:"forZones,omitempty"`
+	// Conditions are the conditions of the endpoint in its EndpointSlice,
+	// nil for the endpoints read from the Endpoints of the service
+	Conditions *EndpointConditions `json:"conditions,omitempty"`
+}
+
+// EndpointConditions describes the state of an endpoint of an EndpointSlice
+type EndpointConditions struct {
+	// Ready indicates the endpoint is ready to receive traffic
+	Ready bool `json:"ready"`
+	// Serving indicates the endpoint is able to serve traffic,
+	// like Ready but regardless of the termination
+	Serving bool `json:"serving"`
+	// Terminating indicates the endpoint is terminating
+	Terminating bool `json:"terminating"`
 }
 
 // Server describes a website
@@ -217,8 +276,32 @@ type Server struct {
 	// SSLPreferServerCiphers indicates that server ciphers should be preferred
 	// over client ciphers when using the SSLv3 and TLS protocols.
 	SSLPreferServerCiphers string `json:"sslPreferServerCiphers,omitempty"`
+	// SSLProtocols returns the list of TLS protocols enabled in the server.
+	// Empty means the global setting applies.
+	SSLProtocols string `json:"sslProtocols,omitempty"`
+	// ServerTokens enables or disables emitting the NGINX version in the server.
+	// Empty means the global setting applies.
+	ServerTokens string `json:"serverTokens,omitempty"`
+	// SecurityHeaders contains the security headers set in the responses of the server
+	// +optional
+	SecurityHeaders securityheaders.Config `json:"securityHeaders,omitempty"`
+	// ClientKeepalive configures the keep-alive of the client connections.
+	// Zero values mean the global settings apply.
+	ClientKeepalive clientkeepalive.Config `json:"clientKeepalive,omitempty"`
+	// InternalRedirect contains the internal locations of the server, only
+	// reachable through the X-Accel-Redirect header of the responses
+	InternalRedirect internalredirect.Config `json:"internalRedirect,omitempty"`
 	// AuthTLSError contains the reason why the access to a server should be denied
 	AuthTLSError string `json:"authTLSError,omitempty"`
+	// HTTPPort is the port the server listens on for HTTP traffic.
+	// Zero means the default HTTP port applies.
+	HTTPPort int `json:"httpPort,omitempty"`
+	// HTTPSPort is the port the server listens on for HTTPS traffic.
+	// Zero means the default HTTPS port applies.
+	HTTPSPort int `json:"httpsPort,omitempty"`
+	// MisdirectedRequestCheck returns 421 when the SNI of the TLS connection
+	// does not match the Host of the request
+	MisdirectedRequestCheck bool `json:"misdirectedRequestCheck,omitempty"`
 }
 
 // Location describes an URI inside a server.
@@ -284,6 +367,10 @@ type Location struct {
 	// original location.
 	// +optional
 	HTTP2PushPreload bool `json:"http2PushPreload,omitempty"`
+	// PreloadLinks contains the paths of the assets announced with
+	// Link rel=preload headers instead of HTTP/2 push
+	// +optional
+	PreloadLinks []string `json:"preloadLinks,omitempty"`
 	// RateLimit describes a limit in the number of connections per IP
 	// address or connections per second.
 	// The Redirect annotation precedes RateLimit
@@ -325,6 +412,10 @@ type Location struct {
 	// buffer size for a specific location.
 	// +optional
 	ClientBodyBufferSize string `json:"clientBodyBufferSize,omitempty"`
+	// ClientBodyInFileOnly defines whether the client request bodies
+	// are saved to files for a specific location: off, clean or on.
+	// +optional
+	ClientBodyInFileOnly string `json:"clientBodyInFileOnly,omitempty"`
 	// DefaultBackend allows the use of a custom default backend for this location.
 	// +optional
 	DefaultBackend *apiv1.Service `json:"-"`
@@ -344,6 +435,14 @@ type Location struct {
 	// BackendProtocol indicates which protocol should be used to communicate with the service
 	// By default this is HTTP
 	BackendProtocol string `json:"backend-protocol"`
+	// BackendScheme overrides the scheme of the upstream in proxy_pass or grpc_pass,
+	// taking precedence over the one derived from BackendProtocol
+	// +optional
+	BackendScheme string `json:"backend-scheme,omitempty"`
+	// WebSocket indicates the backend protocol is WS or WSS, so the connection
+	// must always be upgraded and kept open for a long time
+	// +optional
+	WebSocket bool `json:"webSocket,omitempty"`
 	// FastCGI allows the ingress to act as a FastCGI client for a given location.
 	// +optional
 	FastCGI fastcgi.Config `json:"fastcgi,omitempty"`
@@ -361,6 +460,77 @@ type Location struct {
 	// Opentracing allows the global opentracing setting to be overridden for a location
 	// +optional
 	Opentracing opentracing.Config `json:"opentracing"`
+	// RequestID configures the header used to propagate the request ID to the upstream
+	// +optional
+	RequestID requestid.Config `json:"requestID,omitempty"`
+	// ServiceUnavailableOnEmptyUpstream returns 503 when the upstream of this
+	// location has no endpoints instead of using a default backend
+	// +optional
+	ServiceUnavailableOnEmptyUpstream bool `json:"serviceUnavailableOnEmptyUpstream,omitempty"`
+	// MaintenanceMode returns 503, or uses the custom default backend,
+	// instead of the upstream of this location
+	// +optional
+	MaintenanceMode bool `json:"maintenanceMode,omitempty"`
+	// DisablePathRedirect adds an exact location without the trailing slash
+	// when the path of this location ends with one, so NGINX does not
+	// redirect requests for the path without the slash
+	// +optional
+	DisablePathRedirect bool `json:"disablePathRedirect,omitempty"`
+	// AbsoluteRedirect chooses between absolute and relative redirects
+	// issued by NGINX in this location
+	// +optional
+	AbsoluteRedirect absoluteredirect.Config `json:"absoluteRedirect,omitempty"`
+	// Gzip allows to enable and configure gzip compression for this location
+	// +optional
+	Gzip gzip.Config `json:"gzip,omitempty"`
+	// Brotli allows to enable and configure brotli compression for this location
+	// +optional
+	Brotli brotli.Config `json:"brotli,omitempty"`
+	// Charset adds the charset to the Content-Type header of the responses
+	// +optional
+	Charset charset.Config `json:"charset,omitempty"`
+	// SubFilter replaces a string in the bodies of the responses
+	// +optional
+	SubFilter subfilter.Config `json:"subFilter,omitempty"`
+	// GeoIPAccess allows or denies the access to the location by the
+	// country of the client
+	// +optional
+	GeoIPAccess geoipaccess.Config `json:"geoIPAccess,omitempty"`
+	// ClientCert selects the details of the client certificate sent to the
+	// upstream when the server verifies client certificates
+	// +optional
+	ClientCert clientcert.Config `json:"clientCert,omitempty"`
+	// ProxyCache caches the responses of the location
+	// +optional
+	ProxyCache proxycache.Config `json:"proxyCache,omitempty"`
+	// GRPCTimeout contains the timeouts of the location when its backend
+	// protocol is GRPC or GRPCS
+	// +optional
+	GRPCTimeout grpctimeout.Config `json:"grpcTimeout,omitempty"`
+	// ProxySetHeaders contains the headers set in the requests sent to the upstream
+	// +optional
+	ProxySetHeaders proxysetheaders.Config `json:"proxySetHeaders,omitempty"`
+	// ExternalUpstream is set when the location proxies to an ExternalName
+	// Service NGINX must resolve at request time using the upstream DNS resolvers
+	// +optional
+	ExternalUpstream *ExternalUpstream `json:"externalUpstream,omitempty"`
+	// ServedByMCI is the key, as namespace/name, of the multiclusteringress
+	// sent in the X-Served-By-MCI response header, for debugging
+	// +optional
+	ServedByMCI string `json:"servedByMCI,omitempty"`
+}
+
+// ExternalUpstream describes an upstream addressed by a DNS name, re-resolved
+// by NGINX using the configured resolvers instead of the Lua balancer
+type ExternalUpstream struct {
+	// Host is the DNS name of the upstream
+	Host string `json:"host"`
+	// Port is the port of the upstream
+	Port string `json:"port"`
+	// Resolvers are the addresses of the DNS servers
+	Resolvers []string `json:"resolvers"`
+	// Valid is the time NGINX caches the answers, in seconds
+	Valid int `json:"valid"`
 }
 
 // SSLPassthroughBackend describes a SSL upstream server configured
@@ -374,6 +544,8 @@ type SSLPassthroughBackend struct {
 	Backend string `json:"namespace,omitempty"`
 	// Hostname returns the FQDN of the server
 	Hostname string `json:"hostname"`
+	// ProxyProtocol indicates the PROXY protocol header must be sent to the endpoints
+	ProxyProtocol bool `json:"proxyProtocol,omitempty"`
 }
 
 // L4Service describes a L4 Ingress service.
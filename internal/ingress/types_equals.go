@@ -80,6 +80,12 @@ func (c1 *Configuration) Equal(c2 *Configuration) bool {
 		return false
 	}
 
+	if len(c1.StreamSnippets) != 0 || len(c2.StreamSnippets) != 0 {
+		if !sets.StringElementsMatch(c1.StreamSnippets, c2.StreamSnippets) {
+			return false
+		}
+	}
+
 	return true
 }
 
@@ -125,6 +131,18 @@ func (b1 *Backend) Equal(b2 *Backend) bool {
 	if b1.LoadBalancing != b2.LoadBalancing {
 		return false
 	}
+	if b1.EWMADecay != b2.EWMADecay {
+		return false
+	}
+	if b1.ProxyProtocol != b2.ProxyProtocol {
+		return false
+	}
+	if !(&b1.HealthCheck).Equal(&b2.HealthCheck) {
+		return false
+	}
+	if !(&b1.PassiveHealthCheck).Equal(&b2.PassiveHealthCheck) {
+		return false
+	}
 
 	match := compareEndpoints(b1.Endpoints, b2.Endpoints)
 	if !match {
@@ -227,6 +245,26 @@ func (e1 *Endpoint) Equal(e2 *Endpoint) bool {
 	if e1.Port != e2.Port {
 		return false
 	}
+	if e1.MaxFails != e2.MaxFails {
+		return false
+	}
+	if e1.FailTimeout != e2.FailTimeout {
+		return false
+	}
+	if e1.Zone != e2.Zone {
+		return false
+	}
+	if !sets.StringElementsMatch(e1.ForZones, e2.ForZones) {
+		return false
+	}
+	if e1.Conditions != e2.Conditions {
+		if e1.Conditions == nil || e2.Conditions == nil {
+			return false
+		}
+		if *e1.Conditions != *e2.Conditions {
+			return false
+		}
+	}
 
 	if e1.Target != e2.Target {
 		if e1.Target == nil || e2.Target == nil {
@@ -260,6 +298,9 @@ func (tsp1 TrafficShapingPolicy) Equal(tsp2 TrafficShapingPolicy) bool {
 	if tsp1.Cookie != tsp2.Cookie {
 		return false
 	}
+	if tsp1.Priority != tsp2.Priority {
+		return false
+	}
 
 	return true
 }
@@ -314,9 +355,33 @@ func (s1 *Server) Equal(s2 *Server) bool {
 	if s1.SSLPreferServerCiphers != s2.SSLPreferServerCiphers {
 		return false
 	}
+	if s1.SSLProtocols != s2.SSLProtocols {
+		return false
+	}
+	if s1.ServerTokens != s2.ServerTokens {
+		return false
+	}
+	if !(&s1.SecurityHeaders).Equal(&s2.SecurityHeaders) {
+		return false
+	}
+	if !(&s1.ClientKeepalive).Equal(&s2.ClientKeepalive) {
+		return false
+	}
+	if !(&s1.InternalRedirect).Equal(&s2.InternalRedirect) {
+		return false
+	}
 	if s1.AuthTLSError != s2.AuthTLSError {
 		return false
 	}
+	if s1.HTTPPort != s2.HTTPPort {
+		return false
+	}
+	if s1.HTTPSPort != s2.HTTPSPort {
+		return false
+	}
+	if s1.MisdirectedRequestCheck != s2.MisdirectedRequestCheck {
+		return false
+	}
 	if !(&s1.ProxySSL).Equal(&s2.ProxySSL) {
 		return false
 	}
@@ -386,6 +451,14 @@ func (l1 *Location) Equal(l2 *Location) bool {
 	if l1.HTTP2PushPreload != l2.HTTP2PushPreload {
 		return false
 	}
+	if len(l1.PreloadLinks) != len(l2.PreloadLinks) {
+		return false
+	}
+	for i := range l1.PreloadLinks {
+		if l1.PreloadLinks[i] != l2.PreloadLinks[i] {
+			return false
+		}
+	}
 	if !(&l1.RateLimit).Equal(&l2.RateLimit) {
 		return false
 	}
@@ -416,6 +489,9 @@ func (l1 *Location) Equal(l2 *Location) bool {
 	if l1.ClientBodyBufferSize != l2.ClientBodyBufferSize {
 		return false
 	}
+	if l1.ClientBodyInFileOnly != l2.ClientBodyInFileOnly {
+		return false
+	}
 	if l1.UpstreamVhost != l2.UpstreamVhost {
 		return false
 	}
@@ -436,6 +512,12 @@ func (l1 *Location) Equal(l2 *Location) bool {
 	if l1.BackendProtocol != l2.BackendProtocol {
 		return false
 	}
+	if l1.BackendScheme != l2.BackendScheme {
+		return false
+	}
+	if l1.WebSocket != l2.WebSocket {
+		return false
+	}
 
 	if !(&l1.FastCGI).Equal(&l2.FastCGI) {
 		return false
@@ -466,9 +548,79 @@ func (l1 *Location) Equal(l2 *Location) bool {
 		return false
 	}
 
+	if !l1.RequestID.Equal(&l2.RequestID) {
+		return false
+	}
+	if l1.ServiceUnavailableOnEmptyUpstream != l2.ServiceUnavailableOnEmptyUpstream {
+		return false
+	}
+	if l1.MaintenanceMode != l2.MaintenanceMode {
+		return false
+	}
+	if l1.DisablePathRedirect != l2.DisablePathRedirect {
+		return false
+	}
+	if !l1.AbsoluteRedirect.Equal(&l2.AbsoluteRedirect) {
+		return false
+	}
+	if !l1.Gzip.Equal(&l2.Gzip) {
+		return false
+	}
+	if !l1.Brotli.Equal(&l2.Brotli) {
+		return false
+	}
+	if !l1.Charset.Equal(&l2.Charset) {
+		return false
+	}
+	if !l1.SubFilter.Equal(&l2.SubFilter) {
+		return false
+	}
+	if !l1.GeoIPAccess.Equal(&l2.GeoIPAccess) {
+		return false
+	}
+	if !l1.ClientCert.Equal(&l2.ClientCert) {
+		return false
+	}
+	if !l1.ProxyCache.Equal(&l2.ProxyCache) {
+		return false
+	}
+	if !l1.GRPCTimeout.Equal(&l2.GRPCTimeout) {
+		return false
+	}
+	if !l1.ProxySetHeaders.Equal(&l2.ProxySetHeaders) {
+		return false
+	}
+	if !l1.ExternalUpstream.Equal(l2.ExternalUpstream) {
+		return false
+	}
+	if l1.ServedByMCI != l2.ServedByMCI {
+		return false
+	}
+
 	return true
 }
 
+// Equal tests for equality between two ExternalUpstream types
+func (eu1 *ExternalUpstream) Equal(eu2 *ExternalUpstream) bool {
+	if eu1 == eu2 {
+		return true
+	}
+	if eu1 == nil || eu2 == nil {
+		return false
+	}
+	if eu1.Host != eu2.Host {
+		return false
+	}
+	if eu1.Port != eu2.Port {
+		return false
+	}
+	if eu1.Valid != eu2.Valid {
+		return false
+	}
+
+	return sets.StringElementsMatch(eu1.Resolvers, eu2.Resolvers)
+}
+
 // Equal tests for equality between two SSLPassthroughBackend types
 func (ptb1 *SSLPassthroughBackend) Equal(ptb2 *SSLPassthroughBackend) bool {
 	if ptb1 == ptb2 {
@@ -486,6 +638,9 @@ func (ptb1 *SSLPassthroughBackend) Equal(ptb2 *SSLPassthroughBackend) bool {
 	if ptb1.Port != ptb2.Port {
 		return false
 	}
+	if ptb1.ProxyProtocol != ptb2.ProxyProtocol {
+		return false
+	}
 
 	if ptb1.Service != ptb2.Service {
 		if ptb1.Service == nil || ptb2.Service == nil {
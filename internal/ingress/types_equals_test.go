@@ -22,6 +22,10 @@ import (
 	"path/filepath"
 	"testing"
 	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 func TestEqualConfiguration(t *testing.T) {
@@ -56,6 +60,104 @@ func TestEqualConfiguration(t *testing.T) {
 	}
 }
 
+func newTestConfiguration() *Configuration {
+	pathType := networking.PathTypePrefix
+
+	return &Configuration{
+		Backends: []*Backend{
+			{
+				Name: "example-http-svc-80",
+				Endpoints: []Endpoint{
+					{Address: "10.0.0.1", Port: "8080"},
+				},
+			},
+		},
+		Servers: []*Server{
+			{
+				Hostname: "example.com",
+				Locations: []*Location{
+					{
+						Path:     "/",
+						PathType: &pathType,
+						Backend:  "example-http-svc-80",
+					},
+				},
+			},
+		},
+		TCPEndpoints: []L4Service{
+			{
+				Port:    5353,
+				Backend: L4Backend{Name: "dns-tcp", Namespace: "example", Port: intstr.FromInt(53), Protocol: apiv1.ProtocolTCP},
+			},
+		},
+		UDPEndpoints: []L4Service{
+			{
+				Port:    5353,
+				Backend: L4Backend{Name: "dns-udp", Namespace: "example", Port: intstr.FromInt(53), Protocol: apiv1.ProtocolUDP},
+			},
+		},
+		PassthroughBackends: []*SSLPassthroughBackend{
+			{
+				Backend:  "example-http-svc-80",
+				Hostname: "example.com",
+				Port:     intstr.FromInt(443),
+			},
+		},
+		BackendConfigChecksum: "1",
+		DefaultSSLCertificate: &SSLCert{PemSHA: "a"},
+		StreamSnippets:        []string{"server { listen 8000; }"},
+	}
+}
+
+func TestEqualConfigurationFields(t *testing.T) {
+	testCases := map[string]func(c *Configuration){
+		"backend endpoint port": func(c *Configuration) {
+			c.Backends[0].Endpoints[0].Port = "9090"
+		},
+		"server location path": func(c *Configuration) {
+			c.Servers[0].Locations[0].Path = "/api"
+		},
+		"tcp endpoint backend": func(c *Configuration) {
+			c.TCPEndpoints[0].Backend.Name = "dns"
+		},
+		"udp endpoint port": func(c *Configuration) {
+			c.UDPEndpoints[0].Port = 5354
+		},
+		"passthrough backend hostname": func(c *Configuration) {
+			c.PassthroughBackends[0].Hostname = "example.org"
+		},
+		"backend configuration checksum": func(c *Configuration) {
+			c.BackendConfigChecksum = "2"
+		},
+		"default SSL certificate": func(c *Configuration) {
+			c.DefaultSSLCertificate.PemSHA = "b"
+		},
+		"stream snippets": func(c *Configuration) {
+			c.StreamSnippets = append(c.StreamSnippets, "server { listen 8001; }")
+		},
+	}
+
+	if !newTestConfiguration().Equal(newTestConfiguration()) {
+		t.Fatalf("expected equal configurations")
+	}
+
+	for title, change := range testCases {
+		t.Run(title, func(t *testing.T) {
+			c1 := newTestConfiguration()
+			c2 := newTestConfiguration()
+			change(c2)
+
+			if c1.Equal(c2) {
+				t.Errorf("expected configurations to differ")
+			}
+
+			if c2.Equal(c1) {
+				t.Errorf("expected configurations to differ")
+			}
+		})
+	}
+}
+
 func readJSON(p string) (*Configuration, error) {
 	f, err := os.Open(p)
 	if err != nil {
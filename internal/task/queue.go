@@ -18,6 +18,7 @@ package task
 
 import (
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"k8s.io/klog/v2"
@@ -47,6 +48,13 @@ type Queue struct {
 	fn func(obj interface{}) (interface{}, error)
 	// lastSync is the Unix epoch time of the last execution of 'sync'
 	lastSync int64
+	// pending is the number of skippable elements waiting in the queue
+	pending int64
+	// covered is the number of pending elements enqueued before the last
+	// successful sync, which are skipped once dequeued
+	covered int64
+	// onSync is called after each successful execution of 'sync'
+	onSync func(updates int)
 }
 
 // Element represents one item of the queue
@@ -61,6 +69,13 @@ func (t *Queue) Run(period time.Duration, stopCh <-chan struct{}) {
 	wait.Until(t.worker, period, stopCh)
 }
 
+// OnSync sets the function called after each successful sync with the number of
+// updates it consumed: the element synced plus the skippable elements enqueued
+// before the sync started, skipped afterwards as the sync already covered them.
+func (t *Queue) OnSync(fn func(updates int)) {
+	t.onSync = fn
+}
+
 // EnqueueTask enqueues ns/name of the given api object in the task queue.
 func (t *Queue) EnqueueTask(obj interface{}) {
 	t.enqueue(obj, false)
@@ -90,9 +105,13 @@ func (t *Queue) enqueue(obj interface{}, skippable bool) {
 		klog.ErrorS(err, "creating object key", "item", obj)
 		return
 	}
+	if skippable {
+		atomic.AddInt64(&t.pending, 1)
+	}
 	t.queue.Add(Element{
-		Key:       key,
-		Timestamp: ts,
+		Key:         key,
+		Timestamp:   ts,
+		IsSkippable: skippable,
 	})
 }
 
@@ -115,9 +134,20 @@ func (t *Queue) worker() {
 			}
 			return
 		}
-		ts := time.Now().UnixNano()
 
 		item := key.(Element)
+		if item.IsSkippable {
+			atomic.AddInt64(&t.pending, -1)
+			if t.covered > 0 {
+				t.covered--
+			}
+		}
+
+		// the skippable elements waiting in the queue and not covered by
+		// a previous sync are older than ts, so this sync covers them
+		coalesced := atomic.LoadInt64(&t.pending) - t.covered
+		ts := time.Now().UnixNano()
+
 		if item.Timestamp != 0 && t.lastSync > item.Timestamp {
 			klog.V(3).InfoS("skipping sync", "key", item.Key, "last", t.lastSync, "now", item.Timestamp)
 			t.queue.Forget(key)
@@ -135,6 +165,11 @@ func (t *Queue) worker() {
 		} else {
 			t.queue.Forget(key)
 			t.lastSync = ts
+			t.covered += coalesced
+
+			if t.onSync != nil {
+				t.onSync(1 + int(coalesced))
+			}
 		}
 
 		t.queue.Done(key)
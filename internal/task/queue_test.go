@@ -157,3 +157,61 @@ func TestSkipEnqueue(t *testing.T) {
 	// shutdown queue before exit
 	q.Shutdown()
 }
+
+func TestCoalescedUpdates(t *testing.T) {
+	started := make(chan struct{}, 10)
+	release := make(chan struct{})
+	var syncs uint32
+	q := NewCustomTaskQueue(func(interface{}) error {
+		if atomic.AddUint32(&syncs, 1) == 1 {
+			started <- struct{}{}
+			<-release
+		}
+		return nil
+	}, mockKeyFn)
+
+	observed := make(chan int, 10)
+	q.OnSync(func(updates int) {
+		observed <- updates
+	})
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go q.Run(5*time.Second, stopCh)
+
+	q.EnqueueSkippableTask(mockEnqueueObj{k: "update", v: "0"})
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatalf("expected the first update to start a build")
+	}
+
+	// rapid updates while the first build is running
+	for i := 1; i <= 4; i++ {
+		q.EnqueueSkippableTask(mockEnqueueObj{k: "update", v: fmt.Sprint(i)})
+	}
+	close(release)
+
+	// the first build only consumes its own update, the second one the
+	// rest of them
+	for _, expected := range []int{1, 4} {
+		select {
+		case updates := <-observed:
+			if updates != expected {
+				t.Errorf("expected a build consuming %v updates but got %v", expected, updates)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("expected a build consuming %v updates", expected)
+		}
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	if s := atomic.LoadUint32(&syncs); s != 2 {
+		t.Errorf("expected 2 builds but got %v", s)
+	}
+	select {
+	case updates := <-observed:
+		t.Errorf("unexpected build consuming %v updates", updates)
+	default:
+	}
+}
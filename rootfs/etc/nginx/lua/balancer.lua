@@ -3,6 +3,7 @@ local cjson = require("cjson.safe")
 local util = require("util")
 local dns_lookup = require("util.dns").lookup
 local configuration = require("configuration")
+local healthcheck = require("healthcheck")
 local round_robin = require("balancer.round_robin")
 local chash = require("balancer.chash")
 local chashsubset = require("balancer.chashsubset")
@@ -23,6 +24,14 @@ local ngx = ngx
 -- it will take <the delay until controller POSTed the backend object to the
 -- Nginx endpoint> + BACKENDS_SYNC_INTERVAL
 local BACKENDS_SYNC_INTERVAL = 1
+-- measured in seconds
+-- how often the health checks of the backends are considered, every backend
+-- is then probed according to its own interval
+local HEALTH_CHECK_INTERVAL = 1
+
+-- FAILED_STATUSES are the statuses NGINX uses for the last attempt when the
+-- upstream could not be reached or did not answer in time
+local FAILED_STATUSES = { ["502"] = true, ["504"] = true }
 
 local DEFAULT_LB_ALG = "round_robin"
 local IMPLEMENTATIONS = {
@@ -106,6 +115,7 @@ end
 local function sync_backend(backend)
   if not backend.endpoints or #backend.endpoints == 0 then
     balancers[backend.name] = nil
+    healthcheck.remove(backend.name)
     return
   end
 
@@ -113,6 +123,9 @@ local function sync_backend(backend)
     backend = resolve_external_names(backend)
   end
 
+  healthcheck.sync(backend)
+  backend = healthcheck.filter(backend)
+
   backend.endpoints = format_ipv6_endpoints(backend.endpoints)
 
   local implementation = get_implementation(backend)
@@ -176,28 +189,101 @@ local function sync_backends()
     if not balancers_to_keep[backend_name] then
       balancers[backend_name] = nil
       backends_with_external_name[backend_name] = nil
+      healthcheck.remove(backend_name)
     end
   end
   backends_last_synced_at = raw_backends_last_synced_at
 end
 
-local function route_to_alternative_balancer(balancer)
-  if balancer.is_affinitized(balancer) then
-    -- If request is already affinitized to a primary balancer, keep the primary balancer.
-    return false
+local function run_health_checks(premature)
+  if premature then
+    return
   end
 
-  if not balancer.alternative_backends then
-    return false
+  for _, backend in ipairs(healthcheck.run()) do
+    sync_backend(backend)
   end
+end
 
-  -- TODO: support traffic shaping for n > 1 alternative backends
-  local backend_name = balancer.alternative_backends[1]
-  if not backend_name then
-    ngx.log(ngx.ERR, "empty alternative backend")
+-- returns true or false when the canary header decides the routing,
+-- or nil when it does not apply to the request
+local function route_by_header(traffic_shaping_policy)
+  local target_header = util.replace_special_char(traffic_shaping_policy.header,
+                                                  "-", "_")
+  local header = ngx.var["http_" .. target_header]
+  if not header then
+    return nil
+  end
+
+  if traffic_shaping_policy.headerValue
+     and #traffic_shaping_policy.headerValue > 0 then
+    if traffic_shaping_policy.headerValue == header then
+      return true
+    end
+  elseif traffic_shaping_policy.headerPattern
+     and #traffic_shaping_policy.headerPattern > 0 then
+    local m, err = ngx.re.match(header, traffic_shaping_policy.headerPattern)
+    if m then
+      return true
+    elseif  err then
+        ngx.log(ngx.ERR, "error when matching canary-by-header-pattern: '",
+                traffic_shaping_policy.headerPattern, "', error: ", err)
+        return false
+    end
+  elseif header == "always" then
+    return true
+  elseif header == "never" then
     return false
   end
 
+  return nil
+end
+
+-- returns true or false when the canary cookie decides the routing,
+-- or nil when it does not apply to the request
+local function route_by_cookie(traffic_shaping_policy)
+  local target_cookie = traffic_shaping_policy.cookie
+  local cookie = ngx.var["cookie_" .. target_cookie]
+  if cookie then
+    if cookie == "always" then
+      return true
+    elseif cookie == "never" then
+      return false
+    end
+  end
+
+  return nil
+end
+
+-- returns the weight total shared by the alternative backends, the largest
+-- one defined by their traffic shaping policies
+local function get_weight_total(alternative_backends)
+  local weight_total = 100
+  for _, backend_name in ipairs(alternative_backends) do
+    local alternative_balancer = balancers[backend_name]
+    local traffic_shaping_policy = alternative_balancer and alternative_balancer.traffic_shaping_policy
+    if traffic_shaping_policy and traffic_shaping_policy.weightTotal ~= nil
+       and traffic_shaping_policy.weightTotal > weight_total then
+      weight_total = traffic_shaping_policy.weightTotal
+    end
+  end
+
+  return weight_total
+end
+
+-- the alternative backends share a single draw per request, each one being
+-- selected by the range of the draw following the ranges of the previous
+-- ones, so the weights of several alternative backends split the traffic
+local function route_by_weight(traffic_shaping_policy, split)
+  local weight = traffic_shaping_policy.weight or 0
+  local routed = split.draw > split.offset and split.draw <= split.offset + weight
+  split.offset = split.offset + weight
+
+  return routed
+end
+
+-- returns true when the request must be sent to the alternative backend
+local function route_to_alternative_backend(backend_name, split)
   local alternative_balancer = balancers[backend_name]
   if not alternative_balancer then
     ngx.log(ngx.ERR, "no alternative balancer for backend: ",
@@ -219,48 +305,59 @@ local function route_to_alternative_balancer(balancer)
     return false
   end
 
-  local target_header = util.replace_special_char(traffic_shaping_policy.header,
-                                                  "-", "_")
-  local header = ngx.var["http_" .. target_header]
-  if header then
-    if traffic_shaping_policy.headerValue
-	   and #traffic_shaping_policy.headerValue > 0 then
-      if traffic_shaping_policy.headerValue == header then
-        return true
-      end
-    elseif traffic_shaping_policy.headerPattern
-       and #traffic_shaping_policy.headerPattern > 0 then
-      local m, err = ngx.re.match(header, traffic_shaping_policy.headerPattern)
-      if m then
-        return true
-      elseif  err then
-          ngx.log(ngx.ERR, "error when matching canary-by-header-pattern: '",
-                  traffic_shaping_policy.headerPattern, "', error: ", err)
-          return false
-      end
-    elseif header == "always" then
-      return true
-    elseif header == "never" then
-      return false
-    end
+  -- the weight is always evaluated so the next alternative backends get
+  -- their range of the draw
+  local routed_by_weight = route_by_weight(traffic_shaping_policy, split)
+
+  local weight_first = traffic_shaping_policy.priority == "weight"
+  if weight_first and routed_by_weight then
+    return true
   end
 
-  local target_cookie = traffic_shaping_policy.cookie
-  local cookie = ngx.var["cookie_" .. target_cookie]
-  if cookie then
-    if cookie == "always" then
-      return true
-    elseif cookie == "never" then
-      return false
-    end
+  local routed = route_by_header(traffic_shaping_policy)
+  if routed ~= nil then
+    return routed
   end
 
-  local weightTotal = 100
-  if traffic_shaping_policy.weightTotal ~= nil and traffic_shaping_policy.weightTotal > 100 then
-    weightTotal = traffic_shaping_policy.weightTotal
+  routed = route_by_cookie(traffic_shaping_policy)
+  if routed ~= nil then
+    return routed
   end
-  if math.random(weightTotal) <= traffic_shaping_policy.weight then
-    return true
+
+  if weight_first then
+    -- the weight was already evaluated
+    return false
+  end
+
+  return routed_by_weight
+end
+
+-- returns true and the name of the alternative backend the request must be
+-- sent to, the alternative backends being evaluated in order, or false
+local function route_to_alternative_balancer(balancer)
+  if balancer.is_affinitized(balancer) then
+    -- If request is already affinitized to a primary balancer, keep the primary balancer.
+    return false
+  end
+
+  if not balancer.alternative_backends then
+    return false
+  end
+
+  if not balancer.alternative_backends[1] then
+    ngx.log(ngx.ERR, "empty alternative backend")
+    return false
+  end
+
+  local split = {
+    draw = math.random(get_weight_total(balancer.alternative_backends)),
+    offset = 0,
+  }
+
+  for _, backend_name in ipairs(balancer.alternative_backends) do
+    if route_to_alternative_backend(backend_name, split) then
+      return true, backend_name
+    end
   end
 
   return false
@@ -282,8 +379,8 @@ local function get_balancer()
     return nil
   end
 
-  if route_to_alternative_balancer(balancer) then
-    local alternative_backend_name = balancer.alternative_backends[1]
+  local routed, alternative_backend_name = route_to_alternative_balancer(balancer)
+  if routed then
     ngx.var.proxy_alternative_upstream_name = alternative_backend_name
 
     balancer = balancers[alternative_backend_name]
@@ -314,6 +411,10 @@ function _M.init_worker()
     ngx.log(ngx.ERR, "error when setting up timer.every for sync_backends_with_external_name: ",
             err)
   end
+  ok, err = ngx.timer.every(HEALTH_CHECK_INTERVAL, run_health_checks)
+  if not ok then
+    ngx.log(ngx.ERR, "error when setting up timer.every for run_health_checks: ", err)
+  end
 end
 
 function _M.rewrite()
@@ -324,12 +425,28 @@ function _M.rewrite()
   end
 end
 
+-- report_failure records the failure of the previous attempt when the
+-- request is retried, for the backends using maxFails
+local function report_failure(balancer)
+  local previous_peer = ngx.ctx.balancer_peer
+  if not previous_peer then
+    return
+  end
+
+  local state = ngx_balancer.get_last_failure()
+  if state == "failed" or state == "next" then
+    healthcheck.report_failure(balancer.name, previous_peer)
+  end
+end
+
 function _M.balance()
   local balancer = get_balancer()
   if not balancer then
     return
   end
 
+  report_failure(balancer)
+
   local peer = balancer:balance()
   if not peer then
     ngx.log(ngx.WARN, "no peer was returned, balancer: " .. balancer.name)
@@ -343,6 +460,7 @@ function _M.balance()
 
   ngx_balancer.set_more_tries(1)
 
+  ngx.ctx.balancer_peer = peer
   local ok, err = ngx_balancer.set_current_peer(peer)
   if not ok then
     ngx.log(ngx.ERR, "error while setting current upstream peer ", peer,
@@ -356,6 +474,17 @@ function _M.log()
     return
   end
 
+  local peer = ngx.ctx.balancer_peer
+  local upstream_status = ngx.var.upstream_status
+  if peer and upstream_status then
+    -- only the status of the last attempt, the previous ones were reported
+    -- when the request was retried
+    local last_status = upstream_status:match("([^%s,:]+)$")
+    if FAILED_STATUSES[last_status] then
+      healthcheck.report_failure(balancer.name, peer)
+    end
+  end
+
   if not balancer.after_balance then
     return
   end
@@ -22,7 +22,7 @@ local table_insert = table.insert
 local ngx_log = ngx.log
 local INFO = ngx.INFO
 
-local DECAY_TIME = 10 -- this value is in seconds
+local DEFAULT_DECAY_TIME = 10 -- this value is in seconds
 local LOCK_KEY = ":ewma_key"
 local PICK_SET_SIZE = 2
 
@@ -53,10 +53,10 @@ local function unlock()
   return err
 end
 
-local function decay_ewma(ewma, last_touched_at, rtt, now)
+local function decay_ewma(ewma, last_touched_at, rtt, now, decay_time)
   local td = now - last_touched_at
   td = (td > 0) and td or 0
-  local weight = math.exp(-td/DECAY_TIME)
+  local weight = math.exp(-td/decay_time)
 
   ewma = ewma * weight + rtt * (1.0 - weight)
   return ewma
@@ -80,7 +80,7 @@ local function store_stats(upstream, ewma, now)
   end
 end
 
-local function get_or_update_ewma(upstream, rtt, update)
+local function get_or_update_ewma(upstream, rtt, update, decay_time)
   local lock_err = nil
   if update then
     lock_err = lock(upstream)
@@ -92,7 +92,7 @@ local function get_or_update_ewma(upstream, rtt, update)
 
   local now = ngx.now()
   local last_touched_at = ngx.shared.balancer_ewma_last_touched_at:get(upstream) or 0
-  ewma = decay_ewma(ewma, last_touched_at, rtt, now)
+  ewma = decay_ewma(ewma, last_touched_at, rtt, now, decay_time)
 
   if not update then
     return ewma, nil
@@ -111,11 +111,11 @@ local function get_upstream_name(upstream)
 end
 
 
-local function score(upstream)
+local function score(upstream, decay_time)
   -- Original implementation used names
   -- Endpoints don't have names, so passing in IP:Port as key instead
   local upstream_name = get_upstream_name(upstream)
-  return get_or_update_ewma(upstream_name, 0, false)
+  return get_or_update_ewma(upstream_name, 0, false, decay_time)
 end
 
 -- implementation similar to https://en.wikipedia.org/wiki/Fisher%E2%80%93Yates_shuffle
@@ -131,12 +131,12 @@ local function shuffle_peers(peers, k)
   -- peers[1 .. k] will now contain a randomly selected k from #peers
 end
 
-local function pick_and_score(peers, k)
+local function pick_and_score(peers, k, decay_time)
   shuffle_peers(peers, k)
   local lowest_score_index = 1
-  local lowest_score = score(peers[lowest_score_index])
+  local lowest_score = score(peers[lowest_score_index], decay_time)
   for i = 2, k do
-    local new_score = score(peers[i])
+    local new_score = score(peers[i], decay_time)
     if new_score < lowest_score then
       lowest_score_index, lowest_score = i, new_score
     end
@@ -205,9 +205,9 @@ function _M.balance(self)
     end
 
     if #filtered_peers > 1 then
-      endpoint, ewma_score = pick_and_score(filtered_peers, k)
+      endpoint, ewma_score = pick_and_score(filtered_peers, k, self.decay_time)
     else
-      endpoint, ewma_score = filtered_peers[1], score(filtered_peers[1])
+      endpoint, ewma_score = filtered_peers[1], score(filtered_peers[1], self.decay_time)
     end
 
     tried_endpoints[get_upstream_name(endpoint)] = true
@@ -219,7 +219,7 @@ function _M.balance(self)
   return get_upstream_name(endpoint)
 end
 
-function _M.after_balance(_)
+function _M.after_balance(self)
   local response_time = tonumber(split.get_last_value(ngx.var.upstream_response_time)) or 0
   local connect_time = tonumber(split.get_last_value(ngx.var.upstream_connect_time)) or 0
   local rtt = connect_time + response_time
@@ -229,12 +229,23 @@ function _M.after_balance(_)
     return
   end
 
-  get_or_update_ewma(upstream, rtt, true)
+  get_or_update_ewma(upstream, rtt, true, self.decay_time)
+end
+
+-- backend_decay_time returns the decay time of the backend, from the ewma-decay annotation
+local function backend_decay_time(backend)
+  local decay = tonumber(backend.ewmaDecay)
+  if decay and decay > 0 then
+    return decay
+  end
+
+  return DEFAULT_DECAY_TIME
 end
 
 function _M.sync(self, backend)
   self.traffic_shaping_policy = backend.trafficShapingPolicy
   self.alternative_backends = backend.alternativeBackends
+  self.decay_time = backend_decay_time(backend)
 
   local normalized_endpoints_added, normalized_endpoints_removed =
     util.diff_endpoints(self.peers, backend.endpoints)
@@ -267,6 +278,7 @@ function _M.new(self, backend)
     peers = backend.endpoints,
     traffic_shaping_policy = backend.trafficShapingPolicy,
     alternative_backends = backend.alternativeBackends,
+    decay_time = backend_decay_time(backend),
   }
   setmetatable(o, self)
   self.__index = self
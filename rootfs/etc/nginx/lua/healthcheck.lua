@@ -0,0 +1,274 @@
+local util = require("util")
+
+local ngx = ngx
+local ipairs = ipairs
+local pairs = pairs
+local next = next
+local string = string
+local table = table
+local tonumber = tonumber
+local tostring = tostring
+
+-- measured in milliseconds
+local PROBE_TIMEOUT = 1000
+-- measured in seconds, used when the backend does not define an interval
+local DEFAULT_INTERVAL = 10
+local DEFAULT_STATUS = 200
+-- measured in seconds, used when an endpoint defines maxFails but not failTimeout
+local DEFAULT_FAIL_TIMEOUT = 10
+
+local _M = {}
+
+-- backend name -> {
+--   backend, next_probe_at,
+--   unhealthy = { ["address:port"] = true },
+--   failures = { ["address:port"] = { fails, first_fail_at, down_until } },
+--   changed,
+-- }
+local checks = {}
+
+local function endpoint_key(endpoint)
+  return endpoint.address .. ":" .. endpoint.port
+end
+
+-- peer_key returns the key of the endpoint of a peer returned by a balancer,
+-- IPv6 addresses being enclosed in brackets
+local function peer_key(peer)
+  return (peer:gsub("^%[(.*)%]:", "%1:"))
+end
+
+local function is_enabled(backend)
+  return backend.healthCheck and backend.healthCheck.path
+           and #backend.healthCheck.path > 0
+end
+
+local function is_passive_enabled(backend)
+  for _, endpoint in ipairs(backend.endpoints) do
+    if endpoint.maxFails and endpoint.maxFails > 0 then
+      return true
+    end
+  end
+  return false
+end
+
+local function probe(endpoint, config)
+  local sock = ngx.socket.tcp()
+  sock:settimeout(PROBE_TIMEOUT)
+
+  local ok, err = sock:connect(endpoint.address, tonumber(endpoint.port))
+  if not ok then
+    return false, err
+  end
+
+  local request = string.format(
+    "GET %s HTTP/1.0\r\nHost: %s\r\nUser-Agent: ingress-nginx-healthcheck\r\n" ..
+    "Connection: close\r\n\r\n", config.path, endpoint.address)
+  local bytes
+  bytes, err = sock:send(request)
+  if not bytes then
+    sock:close()
+    return false, err
+  end
+
+  local line
+  line, err = sock:receive("*l")
+  sock:close()
+  if not line then
+    return false, err
+  end
+
+  local status = tonumber(line:match("^HTTP/%d+%.%d+%s+(%d+)"))
+  if status ~= (config.status or DEFAULT_STATUS) then
+    return false, "unexpected status line: " .. line
+  end
+
+  return true
+end
+
+-- sync records the endpoints of the backend to probe, or to track the
+-- failures of, or forgets them when the backend has no health check configured.
+function _M.sync(backend)
+  if not backend.endpoints or #backend.endpoints == 0
+     or not (is_enabled(backend) or is_passive_enabled(backend)) then
+    checks[backend.name] = nil
+    return
+  end
+
+  local check = checks[backend.name]
+  if not check then
+    check = { next_probe_at = 0, unhealthy = {}, failures = {} }
+    checks[backend.name] = check
+  end
+  check.backend = util.deepcopy(backend)
+
+  -- forget the state of endpoints that are not part of the backend anymore
+  check.endpoints = {}
+  for _, endpoint in ipairs(check.backend.endpoints) do
+    check.endpoints[endpoint_key(endpoint)] = endpoint
+  end
+  for key, _ in pairs(check.unhealthy) do
+    if not check.endpoints[key] then
+      check.unhealthy[key] = nil
+    end
+  end
+  for key, _ in pairs(check.failures) do
+    if not check.endpoints[key] then
+      check.failures[key] = nil
+    end
+  end
+end
+
+-- report_failure records a failed attempt to send a request to the peer of
+-- the backend. Like the max_fails and fail_timeout parameters of an NGINX
+-- upstream server, the endpoint is considered unavailable for failTimeout
+-- seconds after maxFails failures in failTimeout seconds. The backend is
+-- returned by the next run once the endpoint is unavailable.
+function _M.report_failure(backend_name, peer)
+  local check = checks[backend_name]
+  if not check then
+    return
+  end
+
+  local key = peer_key(peer)
+  local endpoint = check.endpoints[key]
+  if not endpoint or not endpoint.maxFails or endpoint.maxFails <= 0 then
+    return
+  end
+
+  local fail_timeout = endpoint.failTimeout
+  if not fail_timeout or fail_timeout <= 0 then
+    fail_timeout = DEFAULT_FAIL_TIMEOUT
+  end
+
+  local now = ngx.now()
+  local failure = check.failures[key]
+  if failure and failure.down_until then
+    return
+  end
+  if not failure or now - failure.first_fail_at > fail_timeout then
+    failure = { fails = 0, first_fail_at = now }
+    check.failures[key] = failure
+  end
+
+  failure.fails = failure.fails + 1
+  if failure.fails >= endpoint.maxFails then
+    ngx.log(ngx.WARN, "endpoint ", key, " of backend ", backend_name, " failed ",
+            failure.fails, " times, considering it unavailable for ",
+            fail_timeout, " seconds")
+    failure.down_until = now + fail_timeout
+    check.changed = true
+  end
+end
+
+local function is_available(check, key)
+  if check.unhealthy[key] then
+    return false
+  end
+
+  local failure = check.failures[key]
+  return not (failure and failure.down_until)
+end
+
+function _M.remove(backend_name)
+  checks[backend_name] = nil
+end
+
+-- filter returns the backend without the endpoints that failed their last probe
+-- or are unavailable after too many failures.
+-- When every endpoint is unhealthy the backend is returned untouched, as failing
+-- all requests would not be better than trying the endpoints.
+function _M.filter(backend)
+  local check = checks[backend.name]
+  if not check or (not next(check.unhealthy) and not next(check.failures)) then
+    return backend
+  end
+
+  local endpoints = {}
+  for _, endpoint in ipairs(backend.endpoints) do
+    if is_available(check, endpoint_key(endpoint)) then
+      table.insert(endpoints, endpoint)
+    end
+  end
+
+  if #endpoints == 0 then
+    ngx.log(ngx.WARN, "all endpoints of backend ", backend.name,
+            " failed their health check, using all of them")
+    return backend
+  end
+
+  local filtered = {}
+  for key, value in pairs(backend) do
+    filtered[key] = value
+  end
+  filtered.endpoints = endpoints
+
+  return filtered
+end
+
+-- run probes the endpoints of the backends whose interval elapsed and
+-- returns the backends where the health or availability of an endpoint changed.
+function _M.run()
+  local now = ngx.now()
+  local changed = {}
+  local changed_names = {}
+
+  for _, check in pairs(checks) do
+    for key, failure in pairs(check.failures) do
+      if failure.down_until and failure.down_until <= now then
+        ngx.log(ngx.NOTICE, "endpoint ", key, " of backend ", check.backend.name,
+                " is available again")
+        check.failures[key] = nil
+        check.changed = true
+      end
+    end
+
+    if check.changed then
+      check.changed = false
+      table.insert(changed, check.backend)
+      changed_names[check.backend.name] = true
+    end
+  end
+
+  -- probing yields, so the checks to run are collected first to not
+  -- traverse the table while it is modified by a sync
+  local due = {}
+  for _, check in pairs(checks) do
+    if is_enabled(check.backend) and check.next_probe_at <= now then
+      check.next_probe_at = now + (check.backend.healthCheck.interval or DEFAULT_INTERVAL)
+      table.insert(due, check)
+    end
+  end
+
+  for _, check in ipairs(due) do
+    local updated = false
+
+    for _, endpoint in ipairs(check.backend.endpoints) do
+      local key = endpoint_key(endpoint)
+      local ok, err = probe(endpoint, check.backend.healthCheck)
+
+      if ok and check.unhealthy[key] then
+        ngx.log(ngx.NOTICE, "endpoint ", key, " of backend ", check.backend.name,
+                " is healthy again")
+        check.unhealthy[key] = nil
+        updated = true
+      elseif not ok and not check.unhealthy[key] then
+        ngx.log(ngx.WARN, "endpoint ", key, " of backend ", check.backend.name,
+                " failed its health check: ", tostring(err))
+        check.unhealthy[key] = true
+        updated = true
+      end
+    end
+
+    if updated and not changed_names[check.backend.name] then
+      table.insert(changed, check.backend)
+    end
+  end
+
+  return changed
+end
+
+setmetatable(_M, {__index = {
+  probe = probe,
+}})
+
+return _M
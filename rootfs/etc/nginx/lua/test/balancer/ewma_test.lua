@@ -81,6 +81,22 @@ describe("Balancer ewma", function()
       assert.are.equals(expected_ewma, ngx.shared.balancer_ewma:get("10.10.10.2:8080"))
       assert.are.equals(ngx_now, ngx.shared.balancer_ewma_last_touched_at:get("10.10.10.2:8080"))
     end)
+
+    it("updates EWMA stats with the decay time of the backend", function()
+      local decay_backend = util.deepcopy(backend)
+      decay_backend.ewmaDecay = 20
+      local decay_instance = balancer_ewma:new(decay_backend)
+
+      ngx.var = { upstream_addr = "10.10.10.2:8080", upstream_connect_time = "0.02", upstream_response_time = "0.1" }
+
+      decay_instance:after_balance()
+
+      local weight = math.exp(-5 / 20)
+      local expected_ewma = 0.3 * weight + 0.12 * (1.0 - weight)
+
+      assert.are.equals(expected_ewma, ngx.shared.balancer_ewma:get(ngx.var.upstream_addr))
+      assert.are.equals(ngx_now, ngx.shared.balancer_ewma_last_touched_at:get(ngx.var.upstream_addr))
+    end)
   end)
 
   describe("balance()", function()
@@ -175,6 +191,23 @@ describe("Balancer ewma", function()
       assert_ewma_stats("10.10.10.3:8080", 1.2, ngx_now - 20)
     end)
 
+    it("updates the decay time even if endpoints do not change", function()
+      assert.are.equals(10, instance.decay_time)
+
+      local new_backend = util.deepcopy(backend)
+      new_backend.ewmaDecay = 0.5
+
+      instance:sync(new_backend)
+
+      assert.are.equals(0.5, instance.decay_time)
+
+      new_backend = util.deepcopy(backend)
+
+      instance:sync(new_backend)
+
+      assert.are.equals(10, instance.decay_time)
+    end)
+
     it("updates peers, deletes stats for old endpoints and sets average ewma score to new ones", function()
       local new_backend = util.deepcopy(backend)
 
@@ -191,6 +191,57 @@ describe("Balancer", function()
         assert.equal(false, balancer.route_to_alternative_balancer(_primaryBalancer))
       end)
 
+      it("evaluates the next alternative backend when one does not route the request", function()
+        backend.trafficShapingPolicy.weight = 100
+        balancer.sync_backend(backend)
+        _primaryBalancer.alternative_backends = { "nonExistingBackend", backend.name }
+
+        local routed, backend_name = balancer.route_to_alternative_balancer(_primaryBalancer)
+        assert.equal(true, routed)
+        assert.equal(backend.name, backend_name)
+      end)
+
+      describe("weighted split across several alternative backends", function()
+        local original_random = math.random
+
+        before_each(function()
+          -- the primary backend keeps 50% of the requests
+          for _, alternative in ipairs({ { "canary-30", 30 }, { "canary-20", 20 } }) do
+            balancer.sync_backend({
+              name = alternative[1], ["load-balance"] = "round_robin",
+              endpoints = { { address = "10.184.7.41", port = "8080", maxFails = 0, failTimeout = 0 } },
+              trafficShapingPolicy = { weight = alternative[2], header = "", headerValue = "", cookie = "" },
+            })
+          end
+          _primaryBalancer.alternative_backends = { "canary-30", "canary-20" }
+        end)
+
+        after_each(function()
+          math.random = original_random
+        end)
+
+        local test_patterns = {
+          { draw = 1, expected = "canary-30" },
+          { draw = 30, expected = "canary-30" },
+          { draw = 31, expected = "canary-20" },
+          { draw = 50, expected = "canary-20" },
+          { draw = 51, expected = nil },
+          { draw = 100, expected = nil },
+        }
+        for _, test_pattern in ipairs(test_patterns) do
+          it("routes the draw " .. test_pattern.draw .. " to " .. tostring(test_pattern.expected), function()
+            math.random = function(total)
+              assert.equal(100, total)
+              return test_pattern.draw
+            end
+
+            local routed, backend_name = balancer.route_to_alternative_balancer(_primaryBalancer)
+            assert.equal(test_pattern.expected ~= nil, routed)
+            assert.equal(test_pattern.expected, backend_name)
+          end)
+        end
+      end)
+
       describe("canary by weight", function()
         it("returns true when weight is 100", function()
           backend.trafficShapingPolicy.weight = 100
@@ -339,6 +390,46 @@ describe("Balancer", function()
         end)
       end)
 
+      describe("canary priority", function()
+        before_each(function()
+          mock_ngx({ var = { http_canaryHeader = "never", request_uri = "/" } })
+          backend.trafficShapingPolicy.header = "canaryHeader"
+          backend.trafficShapingPolicy.headerValue = ""
+          backend.trafficShapingPolicy.weight = 100
+        end)
+
+        after_each(function()
+          backend.trafficShapingPolicy.priority = nil
+          reset_ngx()
+        end)
+
+        it("evaluates the header before the weight by default", function()
+          backend.trafficShapingPolicy.priority = nil
+          balancer.sync_backend(backend)
+          assert.equal(false, balancer.route_to_alternative_balancer(_primaryBalancer))
+        end)
+
+        it("evaluates the header before the weight when priority is header", function()
+          backend.trafficShapingPolicy.priority = "header"
+          balancer.sync_backend(backend)
+          assert.equal(false, balancer.route_to_alternative_balancer(_primaryBalancer))
+        end)
+
+        it("evaluates the weight before the header when priority is weight", function()
+          backend.trafficShapingPolicy.priority = "weight"
+          balancer.sync_backend(backend)
+          assert.equal(true, balancer.route_to_alternative_balancer(_primaryBalancer))
+        end)
+
+        it("falls back to the header when the weight does not match", function()
+          mock_ngx({ var = { http_canaryHeader = "always", request_uri = "/" } })
+          backend.trafficShapingPolicy.priority = "weight"
+          backend.trafficShapingPolicy.weight = 0
+          balancer.sync_backend(backend)
+          assert.equal(true, balancer.route_to_alternative_balancer(_primaryBalancer))
+        end)
+      end)
+
     end)
 
     -- Affinitized request prefers backend it is affinitized to.
@@ -0,0 +1,225 @@
+local original_ngx = ngx
+local function reset_ngx()
+  _G.ngx = original_ngx
+end
+
+local function mock_ngx(mock)
+  local _ngx = mock
+  setmetatable(_ngx, { __index = ngx })
+  _G.ngx = _ngx
+end
+
+-- statuses maps "address:port" to the status line returned by the endpoint,
+-- endpoints missing from it refuse the connection
+local function mock_ngx_socket_tcp(statuses)
+  local socket_mock = {
+    tcp = function()
+      local target
+      return {
+        settimeout = function() end,
+        connect = function(_, address, port)
+          target = address .. ":" .. port
+          if not statuses[target] then
+            return nil, "connection refused"
+          end
+          return true
+        end,
+        send = function(_, request) return #request end,
+        receive = function() return statuses[target] end,
+        close = function() return true end,
+      }
+    end,
+  }
+  mock_ngx({ socket = socket_mock, now = function() return 100 end })
+end
+
+local function new_backend()
+  return {
+    name = "example-http-svc-80",
+    endpoints = {
+      { address = "10.0.0.1", port = "8080", maxFails = 0, failTimeout = 0 },
+      { address = "10.0.0.2", port = "8080", maxFails = 0, failTimeout = 0 },
+    },
+    healthCheck = { path = "/healthz", interval = 5, status = 200 },
+  }
+end
+
+describe("Health check", function()
+  local healthcheck
+
+  before_each(function()
+    package.loaded["healthcheck"] = nil
+  end)
+
+  after_each(function()
+    reset_ngx()
+    package.loaded["healthcheck"] = nil
+  end)
+
+  it("does not probe backends without health check", function()
+    mock_ngx_socket_tcp({})
+    healthcheck = require("healthcheck")
+
+    local backend = new_backend()
+    backend.healthCheck = nil
+    healthcheck.sync(backend)
+
+    assert.same({}, healthcheck.run())
+    assert.equal(backend, healthcheck.filter(backend))
+  end)
+
+  it("removes the endpoints failing their health check", function()
+    mock_ngx_socket_tcp({
+      ["10.0.0.1:8080"] = "HTTP/1.1 200 OK",
+      ["10.0.0.2:8080"] = "HTTP/1.1 503 Service Unavailable",
+    })
+    healthcheck = require("healthcheck")
+
+    local backend = new_backend()
+    healthcheck.sync(backend)
+
+    local changed = healthcheck.run()
+    assert.equal(1, #changed)
+    assert.equal("example-http-svc-80", changed[1].name)
+
+    local filtered = healthcheck.filter(backend)
+    assert.same({ backend.endpoints[1] }, filtered.endpoints)
+    assert.same(2, #backend.endpoints)
+  end)
+
+  it("treats refused connections as unhealthy", function()
+    mock_ngx_socket_tcp({ ["10.0.0.1:8080"] = "HTTP/1.1 200 OK" })
+    healthcheck = require("healthcheck")
+
+    local backend = new_backend()
+    healthcheck.sync(backend)
+    healthcheck.run()
+
+    assert.same({ backend.endpoints[1] }, healthcheck.filter(backend).endpoints)
+  end)
+
+  it("uses the expected status of the backend", function()
+    mock_ngx_socket_tcp({
+      ["10.0.0.1:8080"] = "HTTP/1.1 200 OK",
+      ["10.0.0.2:8080"] = "HTTP/1.1 204 No Content",
+    })
+    healthcheck = require("healthcheck")
+
+    local backend = new_backend()
+    backend.healthCheck.status = 204
+    healthcheck.sync(backend)
+    healthcheck.run()
+
+    assert.same({ backend.endpoints[2] }, healthcheck.filter(backend).endpoints)
+  end)
+
+  it("keeps every endpoint when all of them are unhealthy", function()
+    mock_ngx_socket_tcp({})
+    healthcheck = require("healthcheck")
+
+    local backend = new_backend()
+    healthcheck.sync(backend)
+    healthcheck.run()
+
+    assert.equal(backend, healthcheck.filter(backend))
+  end)
+
+  it("does not probe again before the interval elapsed", function()
+    mock_ngx_socket_tcp({ ["10.0.0.1:8080"] = "HTTP/1.1 200 OK" })
+    healthcheck = require("healthcheck")
+
+    local backend = new_backend()
+    healthcheck.sync(backend)
+
+    assert.equal(1, #healthcheck.run())
+    assert.equal(0, #healthcheck.run())
+  end)
+
+  it("forgets the state of removed endpoints", function()
+    mock_ngx_socket_tcp({ ["10.0.0.1:8080"] = "HTTP/1.1 200 OK" })
+    healthcheck = require("healthcheck")
+
+    local backend = new_backend()
+    healthcheck.sync(backend)
+    healthcheck.run()
+
+    backend.endpoints[2] = { address = "10.0.0.3", port = "8080", maxFails = 0, failTimeout = 0 }
+    healthcheck.sync(backend)
+
+    assert.equal(backend, healthcheck.filter(backend))
+  end)
+
+  describe("passive", function()
+    local now
+
+    local function new_passive_backend()
+      local backend = new_backend()
+      backend.healthCheck = nil
+      for _, endpoint in ipairs(backend.endpoints) do
+        endpoint.maxFails = 2
+        endpoint.failTimeout = 10
+      end
+      return backend
+    end
+
+    before_each(function()
+      now = 100
+      mock_ngx({ now = function() return now end })
+      healthcheck = require("healthcheck")
+    end)
+
+    it("ignores failures of backends without maxFails", function()
+      local backend = new_backend()
+      backend.healthCheck = nil
+      healthcheck.sync(backend)
+
+      healthcheck.report_failure(backend.name, "10.0.0.1:8080")
+      healthcheck.report_failure(backend.name, "10.0.0.1:8080")
+
+      assert.same({}, healthcheck.run())
+      assert.equal(backend, healthcheck.filter(backend))
+    end)
+
+    it("removes the endpoint for failTimeout after maxFails failures", function()
+      local backend = new_passive_backend()
+      healthcheck.sync(backend)
+
+      healthcheck.report_failure(backend.name, "10.0.0.1:8080")
+      assert.same({}, healthcheck.run())
+      assert.equal(backend, healthcheck.filter(backend))
+
+      healthcheck.report_failure(backend.name, "10.0.0.1:8080")
+      local changed = healthcheck.run()
+      assert.equal(1, #changed)
+      assert.same({ backend.endpoints[2] }, healthcheck.filter(backend).endpoints)
+
+      now = 111
+      changed = healthcheck.run()
+      assert.equal(1, #changed)
+      assert.equal(backend, healthcheck.filter(backend))
+    end)
+
+    it("does not count failures older than failTimeout", function()
+      local backend = new_passive_backend()
+      healthcheck.sync(backend)
+
+      healthcheck.report_failure(backend.name, "10.0.0.1:8080")
+      now = 111
+      healthcheck.report_failure(backend.name, "10.0.0.1:8080")
+
+      assert.same({}, healthcheck.run())
+      assert.equal(backend, healthcheck.filter(backend))
+    end)
+
+    it("matches the peers of IPv6 endpoints", function()
+      local backend = new_passive_backend()
+      backend.endpoints[1].address = "::1"
+      healthcheck.sync(backend)
+
+      healthcheck.report_failure(backend.name, "[::1]:8080")
+      healthcheck.report_failure(backend.name, "[::1]:8080")
+
+      assert.same({ backend.endpoints[2] }, healthcheck.filter(backend).endpoints)
+    end)
+  end)
+end)